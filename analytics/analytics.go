@@ -0,0 +1,446 @@
+// Package analytics computes conversation statistics from messages: counts
+// per sender, media by attachment type, reaction leaderboards, response
+// times, active hours and trends over time. Each report covers every message
+// in total, each account and each chat.
+//
+// Run fetches messages with Messages.Search; Analyze works on messages the
+// caller already has.
+package analytics
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"time"
+
+	beeperdesktop "github.com/cameronaaron/beeper-go-sdk"
+	"github.com/cameronaaron/beeper-go-sdk/resources"
+)
+
+const (
+	defaultPageSize        = 100
+	defaultMaxResponseTime = 24 * time.Hour
+)
+
+// Interval is the width of a trend bucket
+type Interval string
+
+const (
+	Day   Interval = "day"
+	Week  Interval = "week" // starting on Monday
+	Month Interval = "month"
+)
+
+// Options configures a report
+type Options struct {
+	// Params filters the messages analyzed; search.Resolver.MessageParams
+	// builds them from a query. Cursor, Direction and Limit are set by Run.
+	Params resources.MessageSearchParams
+	// Interval is the trend bucket width. Defaults to Day.
+	Interval Interval
+	// Location is used for active hours and trend buckets. Defaults to
+	// time.Local.
+	Location *time.Location
+	// MaxResponseTime is the longest gap counted as a response; a slower
+	// reply starts a new conversation. Defaults to 24 hours.
+	MaxResponseTime time.Duration
+	// MaxMessages stops Run after this many messages, the most recent ones.
+	// Zero means no limit.
+	MaxMessages int
+	// PageSize is the number of messages fetched per request. Defaults to 100.
+	PageSize int
+}
+
+func (o Options) withDefaults() Options {
+	if o.Interval == "" {
+		o.Interval = Day
+	}
+	if o.Location == nil {
+		o.Location = time.Local
+	}
+	if o.MaxResponseTime <= 0 {
+		o.MaxResponseTime = defaultMaxResponseTime
+	}
+	if o.PageSize <= 0 {
+		o.PageSize = defaultPageSize
+	}
+	return o
+}
+
+// Report holds statistics for all messages, each account and each chat
+type Report struct {
+	Interval Interval `json:"interval"`
+	Total    Stats    `json:"total"`
+	Accounts []Stats  `json:"accounts"` // by message count
+	Chats    []Stats  `json:"chats"`    // by message count
+}
+
+// Stats are the statistics of one scope. AccountID and ChatID are empty for
+// the total, and ChatID is empty for an account.
+type Stats struct {
+	AccountID string `json:"accountID,omitempty"`
+	ChatID    string `json:"chatID,omitempty"`
+	Title     string `json:"title,omitempty"`
+
+	Messages  int        `json:"messages"`
+	Reactions int        `json:"reactions"`
+	First     *time.Time `json:"first,omitempty"`
+	Last      *time.Time `json:"last,omitempty"`
+
+	Senders       []SenderStats `json:"senders"`      // by messages sent
+	Media         []Count       `json:"media"`        // by Attachment.Type
+	ReactionKeys  []Count       `json:"reactionKeys"` // the most used reactions
+	ResponseTimes Distribution  `json:"responseTimes"`
+	ActiveHours   Heatmap       `json:"activeHours"`
+	Trend         []Point       `json:"trend"` // with empty buckets filled in
+}
+
+// SenderStats are one participant's statistics in a scope. Participants who
+// only reacted are included.
+type SenderStats struct {
+	ID                string  `json:"id"`
+	Name              string  `json:"name,omitempty"`
+	Messages          int     `json:"messages"`
+	ReactionsReceived int     `json:"reactionsReceived"`
+	ReactionsGiven    int     `json:"reactionsGiven"`
+	Replies           int     `json:"replies"` // messages that answered someone else
+	MedianResponse    float64 `json:"medianResponseSeconds"`
+}
+
+// Count is a number of occurrences of a key
+type Count struct {
+	Key   string `json:"key"`
+	Count int    `json:"count"`
+}
+
+// Distribution summarizes response times in seconds
+type Distribution struct {
+	Count   int      `json:"count"`
+	Mean    float64  `json:"meanSeconds"`
+	Median  float64  `json:"medianSeconds"`
+	P90     float64  `json:"p90Seconds"`
+	Buckets []Bucket `json:"buckets"`
+}
+
+// Bucket is a range of response times
+type Bucket struct {
+	Label string `json:"label"`
+	Count int    `json:"count"`
+}
+
+// responseBuckets are the upper bounds of the distribution buckets; the
+// last one catches the rest
+var responseBuckets = []struct {
+	label string
+	upTo  time.Duration
+}{
+	{"<1m", time.Minute},
+	{"1-5m", 5 * time.Minute},
+	{"5-15m", 15 * time.Minute},
+	{"15-60m", time.Hour},
+	{"1-6h", 6 * time.Hour},
+	{">6h", 0},
+}
+
+// Heatmap counts messages by weekday (Sunday first) and hour
+type Heatmap [7][24]int
+
+// Point is the number of messages in a trend bucket
+type Point struct {
+	Start    time.Time `json:"start"`
+	Messages int       `json:"messages"`
+}
+
+// Run fetches the messages matching opts.Params, newest first, and analyzes
+// them. Chat titles are filled in with Chats.Retrieve.
+func Run(ctx context.Context, client beeperdesktop.Client, opts Options) (*Report, error) {
+	opts = opts.withDefaults()
+	params := opts.Params
+	params.Cursor = nil
+	params.Direction = nil
+	params.Limit = beeperdesktop.IntPtr(opts.PageSize)
+
+	var messages []resources.Message
+	for {
+		page, err := client.MessagesAPI().Search(ctx, params)
+		if err != nil {
+			return nil, fmt.Errorf("search messages: %w", err)
+		}
+		messages = append(messages, page.Items...)
+		if opts.MaxMessages > 0 && len(messages) >= opts.MaxMessages {
+			messages = messages[:opts.MaxMessages]
+			break
+		}
+		if page.Pagination == nil || !page.Pagination.HasMore || page.Pagination.Cursor == nil {
+			break
+		}
+		params.Cursor = page.Pagination.Cursor
+	}
+
+	report := Analyze(messages, opts)
+	for i := range report.Chats {
+		chat, err := client.ChatsAPI().Retrieve(ctx, resources.ChatRetrieveParams{ChatID: report.Chats[i].ChatID})
+		var notFound *beeperdesktop.NotFoundError
+		if errors.As(err, &notFound) {
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("retrieve chat %s: %w", report.Chats[i].ChatID, err)
+		}
+		report.Chats[i].Title = chat.Title
+	}
+	return report, nil
+}
+
+// Analyze computes a report from messages in any order
+func Analyze(messages []resources.Message, opts Options) *Report {
+	opts = opts.withDefaults()
+
+	byChat := make(map[string][]resources.Message)
+	var chatIDs []string
+	for _, msg := range messages {
+		if _, ok := byChat[msg.ChatID]; !ok {
+			chatIDs = append(chatIDs, msg.ChatID)
+		}
+		byChat[msg.ChatID] = append(byChat[msg.ChatID], msg)
+	}
+
+	total := newAccumulator(Stats{})
+	accounts := make(map[string]*accumulator)
+	var chats []*accumulator
+	for _, chatID := range chatIDs {
+		list := byChat[chatID]
+		sort.SliceStable(list, func(i, j int) bool {
+			if c := list[i].Timestamp.Compare(list[j].Timestamp); c != 0 {
+				return c < 0
+			}
+			return list[i].SortKey.Less(list[j].SortKey)
+		})
+
+		accountID := list[0].AccountID
+		account := accounts[accountID]
+		if account == nil {
+			account = newAccumulator(Stats{AccountID: accountID})
+			accounts[accountID] = account
+		}
+		chat := newAccumulator(Stats{AccountID: accountID, ChatID: chatID})
+		chats = append(chats, chat)
+		scopes := []*accumulator{total, account, chat}
+
+		for i, msg := range list {
+			for _, scope := range scopes {
+				scope.add(msg, opts)
+			}
+			if i == 0 || list[i-1].SenderID == msg.SenderID {
+				continue
+			}
+			gap := msg.Timestamp.Sub(list[i-1].Timestamp.Time)
+			if gap < 0 || gap > opts.MaxResponseTime {
+				continue
+			}
+			for _, scope := range scopes {
+				scope.addResponse(msg.SenderID, gap)
+			}
+		}
+	}
+
+	report := &Report{Interval: opts.Interval, Total: total.finish(opts), Accounts: []Stats{}, Chats: []Stats{}}
+	for _, account := range accounts {
+		report.Accounts = append(report.Accounts, account.finish(opts))
+	}
+	for _, chat := range chats {
+		report.Chats = append(report.Chats, chat.finish(opts))
+	}
+	byMessages := func(list []Stats) {
+		sort.SliceStable(list, func(i, j int) bool {
+			if list[i].Messages != list[j].Messages {
+				return list[i].Messages > list[j].Messages
+			}
+			if list[i].AccountID != list[j].AccountID {
+				return list[i].AccountID < list[j].AccountID
+			}
+			return list[i].ChatID < list[j].ChatID
+		})
+	}
+	byMessages(report.Accounts)
+	byMessages(report.Chats)
+	return report
+}
+
+// accumulator collects the statistics of one scope
+type accumulator struct {
+	stats     Stats
+	senders   map[string]*SenderStats
+	media     map[string]int
+	reactions map[string]int
+	gaps      []time.Duration
+	replies   map[string][]time.Duration // by sender
+	trend     map[time.Time]int
+}
+
+func newAccumulator(stats Stats) *accumulator {
+	return &accumulator{
+		stats:     stats,
+		senders:   make(map[string]*SenderStats),
+		media:     make(map[string]int),
+		reactions: make(map[string]int),
+		replies:   make(map[string][]time.Duration),
+		trend:     make(map[time.Time]int),
+	}
+}
+
+func (a *accumulator) sender(id string) *SenderStats {
+	s := a.senders[id]
+	if s == nil {
+		s = &SenderStats{ID: id}
+		a.senders[id] = s
+	}
+	return s
+}
+
+func (a *accumulator) add(msg resources.Message, opts Options) {
+	a.stats.Messages++
+	at := msg.Timestamp.Time
+	if a.stats.First == nil || at.Before(*a.stats.First) {
+		a.stats.First = &at
+	}
+	if a.stats.Last == nil || at.After(*a.stats.Last) {
+		a.stats.Last = &at
+	}
+
+	sender := a.sender(msg.SenderID)
+	sender.Messages++
+	if msg.SenderName != nil && *msg.SenderName != "" {
+		sender.Name = *msg.SenderName
+	}
+	for _, attachment := range msg.Attachments {
+		a.media[attachment.Type]++
+	}
+	for _, reaction := range msg.Reactions {
+		a.stats.Reactions++
+		a.reactions[reaction.ReactionKey]++
+		sender.ReactionsReceived++
+		a.sender(reaction.ParticipantID).ReactionsGiven++
+	}
+
+	local := at.In(opts.Location)
+	a.stats.ActiveHours[local.Weekday()][local.Hour()]++
+	a.trend[bucketStart(local, opts.Interval)]++
+}
+
+func (a *accumulator) addResponse(senderID string, gap time.Duration) {
+	a.gaps = append(a.gaps, gap)
+	a.replies[senderID] = append(a.replies[senderID], gap)
+	a.sender(senderID).Replies++
+}
+
+func (a *accumulator) finish(opts Options) Stats {
+	stats := a.stats
+
+	stats.Senders = make([]SenderStats, 0, len(a.senders))
+	for id, sender := range a.senders {
+		if gaps := a.replies[id]; len(gaps) > 0 {
+			sender.MedianResponse = percentile(sortedCopy(gaps), 0.5).Seconds()
+		}
+		stats.Senders = append(stats.Senders, *sender)
+	}
+	sort.Slice(stats.Senders, func(i, j int) bool {
+		a, b := stats.Senders[i], stats.Senders[j]
+		if a.Messages != b.Messages {
+			return a.Messages > b.Messages
+		}
+		return a.ID < b.ID
+	})
+
+	stats.Media = counts(a.media)
+	stats.ReactionKeys = counts(a.reactions)
+	stats.ResponseTimes = distribution(a.gaps)
+
+	stats.Trend = []Point{}
+	if stats.First != nil {
+		end := bucketStart(stats.Last.In(opts.Location), opts.Interval)
+		for start := bucketStart(stats.First.In(opts.Location), opts.Interval); !start.After(end); start = nextBucket(start, opts.Interval) {
+			stats.Trend = append(stats.Trend, Point{Start: start, Messages: a.trend[start]})
+		}
+	}
+	return stats
+}
+
+// counts sorts a tally by count, then key
+func counts(tally map[string]int) []Count {
+	list := make([]Count, 0, len(tally))
+	for key, n := range tally {
+		list = append(list, Count{Key: key, Count: n})
+	}
+	sort.Slice(list, func(i, j int) bool {
+		if list[i].Count != list[j].Count {
+			return list[i].Count > list[j].Count
+		}
+		return list[i].Key < list[j].Key
+	})
+	return list
+}
+
+func distribution(gaps []time.Duration) Distribution {
+	d := Distribution{Count: len(gaps), Buckets: make([]Bucket, len(responseBuckets))}
+	for i, b := range responseBuckets {
+		d.Buckets[i].Label = b.label
+	}
+	if len(gaps) == 0 {
+		return d
+	}
+
+	sorted := sortedCopy(gaps)
+	var sum time.Duration
+	for _, gap := range sorted {
+		sum += gap
+		i := 0
+		for i < len(responseBuckets)-1 && gap >= responseBuckets[i].upTo {
+			i++
+		}
+		d.Buckets[i].Count++
+	}
+	d.Mean = (sum / time.Duration(len(sorted))).Seconds()
+	d.Median = percentile(sorted, 0.5).Seconds()
+	d.P90 = percentile(sorted, 0.9).Seconds()
+	return d
+}
+
+// percentile returns the nearest-rank percentile of sorted durations
+func percentile(sorted []time.Duration, p float64) time.Duration {
+	rank := int(math.Ceil(p*float64(len(sorted)))) - 1
+	return sorted[max(0, min(rank, len(sorted)-1))]
+}
+
+func sortedCopy(gaps []time.Duration) []time.Duration {
+	sorted := append([]time.Duration(nil), gaps...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+	return sorted
+}
+
+// bucketStart returns the start of the trend bucket containing t, in t's
+// location
+func bucketStart(t time.Time, interval Interval) time.Time {
+	day := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+	switch interval {
+	case Week:
+		return day.AddDate(0, 0, -(int(day.Weekday())+6)%7)
+	case Month:
+		return day.AddDate(0, 0, 1-day.Day())
+	default:
+		return day
+	}
+}
+
+func nextBucket(start time.Time, interval Interval) time.Time {
+	switch interval {
+	case Week:
+		return start.AddDate(0, 0, 7)
+	case Month:
+		return start.AddDate(0, 1, 0)
+	default:
+		return start.AddDate(0, 0, 1)
+	}
+}
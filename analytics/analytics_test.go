@@ -0,0 +1,176 @@
+package analytics_test
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	beeperdesktop "github.com/cameronaaron/beeper-go-sdk"
+	"github.com/cameronaaron/beeper-go-sdk/analytics"
+	"github.com/cameronaaron/beeper-go-sdk/beepertest"
+	"github.com/cameronaaron/beeper-go-sdk/resources"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// monday is 2024-06-03 09:00 UTC
+var monday = time.Date(2024, 6, 3, 9, 0, 0, 0, time.UTC)
+
+func message(id, accountID, chatID, senderID string, at time.Duration) resources.Message {
+	return resources.Message{
+		ID:         id,
+		AccountID:  accountID,
+		ChatID:     chatID,
+		SenderID:   senderID,
+		SenderName: beeperdesktop.StringPtr(strings.ToUpper(senderID[:1]) + senderID[1:]),
+		Timestamp:  resources.NewTimestamp(monday.Add(at)),
+	}
+}
+
+// messages returns two chats, out of order
+func messages() []resources.Message {
+	m2 := message("m2", "a", "c1", "bob", 2*time.Minute)
+	m2.Attachments = []resources.Attachment{{Type: "img"}}
+	m2.Reactions = []resources.Reaction{{ParticipantID: "alice", ReactionKey: "👍"}, {ParticipantID: "carol", ReactionKey: "👍"}}
+	m6 := message("m6", "b", "c2", "dave", 51*time.Hour)
+	m6.Attachments = []resources.Attachment{{Type: "video"}}
+	m7 := message("m7", "b", "c2", "erin", 51*time.Hour+30*time.Minute)
+	m7.Reactions = []resources.Reaction{{ParticipantID: "dave", ReactionKey: "❤️"}}
+
+	return []resources.Message{
+		message("m4", "a", "c1", "alice", 49*time.Hour),
+		m7,
+		message("m1", "a", "c1", "alice", 0),
+		m2,
+		message("m3", "a", "c1", "bob", 3*time.Minute),
+		m6,
+		message("m5", "a", "c1", "alice", 49*time.Hour+30*time.Minute),
+	}
+}
+
+func senderIDs(senders []analytics.SenderStats) []string {
+	var ids []string
+	for _, s := range senders {
+		ids = append(ids, s.ID)
+	}
+	return ids
+}
+
+func TestAnalyze(t *testing.T) {
+	report := analytics.Analyze(messages(), analytics.Options{Location: time.UTC})
+	total := report.Total
+
+	assert.Equal(t, 7, total.Messages)
+	assert.Equal(t, 3, total.Reactions)
+	assert.Equal(t, monday, *total.First)
+	assert.Equal(t, []string{"alice", "bob", "dave", "erin", "carol"}, senderIDs(total.Senders), "by messages, then ID")
+	assert.Equal(t, analytics.SenderStats{ID: "bob", Name: "Bob", Messages: 2, ReactionsReceived: 2, Replies: 1, MedianResponse: 120}, total.Senders[1])
+	assert.Equal(t, analytics.SenderStats{ID: "carol", ReactionsGiven: 1}, total.Senders[4])
+
+	assert.Equal(t, []analytics.Count{{Key: "img", Count: 1}, {Key: "video", Count: 1}}, total.Media)
+	assert.Equal(t, []analytics.Count{{Key: "👍", Count: 2}, {Key: "❤️", Count: 1}}, total.ReactionKeys)
+
+	// A reply after more than a day starts a new conversation
+	responses := total.ResponseTimes
+	assert.Equal(t, 2, responses.Count)
+	assert.Equal(t, 960.0, responses.Mean)
+	assert.Equal(t, 120.0, responses.Median)
+	assert.Equal(t, 1800.0, responses.P90)
+	assert.Equal(t, []analytics.Bucket{
+		{Label: "<1m"}, {Label: "1-5m", Count: 1}, {Label: "5-15m"}, {Label: "15-60m", Count: 1}, {Label: "1-6h"}, {Label: ">6h"},
+	}, responses.Buckets)
+
+	assert.Equal(t, 3, total.ActiveHours[time.Monday][9])
+	assert.Equal(t, 2, total.ActiveHours[time.Wednesday][10])
+	assert.Equal(t, 2, total.ActiveHours[time.Wednesday][12])
+
+	assert.Equal(t, []analytics.Point{
+		{Start: time.Date(2024, 6, 3, 0, 0, 0, 0, time.UTC), Messages: 3},
+		{Start: time.Date(2024, 6, 4, 0, 0, 0, 0, time.UTC), Messages: 0},
+		{Start: time.Date(2024, 6, 5, 0, 0, 0, 0, time.UTC), Messages: 4},
+	}, total.Trend)
+
+	require.Len(t, report.Accounts, 2)
+	assert.Equal(t, "a", report.Accounts[0].AccountID)
+	assert.Equal(t, 5, report.Accounts[0].Messages)
+	require.Len(t, report.Chats, 2)
+	chat := report.Chats[1]
+	assert.Equal(t, "b", chat.AccountID)
+	assert.Equal(t, "c2", chat.ChatID)
+	assert.Equal(t, 1800.0, chat.ResponseTimes.Median)
+	assert.Len(t, chat.Trend, 1)
+}
+
+func TestAnalyzeIntervals(t *testing.T) {
+	tokyo := time.FixedZone("JST", 9*3600)
+	report := analytics.Analyze(messages(), analytics.Options{Interval: analytics.Week, Location: tokyo})
+	assert.Equal(t, []analytics.Point{{Start: time.Date(2024, 6, 3, 0, 0, 0, 0, tokyo), Messages: 7}}, report.Total.Trend)
+	assert.Equal(t, 3, report.Total.ActiveHours[time.Monday][18])
+
+	report = analytics.Analyze(messages(), analytics.Options{Interval: analytics.Month, Location: time.UTC, MaxResponseTime: time.Minute})
+	assert.Equal(t, []analytics.Point{{Start: time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC), Messages: 7}}, report.Total.Trend)
+	assert.Equal(t, 0, report.Total.ResponseTimes.Count)
+
+	report = analytics.Analyze(nil, analytics.Options{})
+	assert.Equal(t, 0, report.Total.Messages)
+	assert.Empty(t, report.Total.Trend)
+	assert.Empty(t, report.Chats)
+}
+
+func TestRun(t *testing.T) {
+	server := beepertest.NewServer(beepertest.DefaultFixtures())
+	defer server.Close()
+	client, err := server.Client()
+	require.NoError(t, err)
+	ctx := context.Background()
+
+	report, err := analytics.Run(ctx, client, analytics.Options{PageSize: 2, Location: time.UTC})
+	require.NoError(t, err)
+	assert.Equal(t, 5, report.Total.Messages)
+	require.Len(t, report.Chats, 3)
+	assert.Equal(t, "Alice Smith", report.Chats[0].Title)
+	assert.Equal(t, "Project Updates", report.Chats[1].Title)
+	assert.Equal(t, []analytics.Count{{Key: "img", Count: 1}}, report.Total.Media)
+	assert.Equal(t, []analytics.Count{{Key: "🎉", Count: 1}}, report.Total.ReactionKeys)
+
+	report, err = analytics.Run(ctx, client, analytics.Options{
+		Params:      resources.MessageSearchParams{AccountIDs: []string{"matrix"}},
+		MaxMessages: 3,
+		PageSize:    2,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, 3, report.Total.Messages, "stops at MaxMessages")
+	assert.Equal(t, "2024-06-01T09:05:00Z", report.Total.First.UTC().Format(time.RFC3339), "keeps the most recent")
+	require.Len(t, report.Accounts, 1)
+}
+
+func TestWriteCSV(t *testing.T) {
+	report := analytics.Analyze(messages(), analytics.Options{Location: time.UTC})
+	report.Chats[0].Title = "Team, core"
+
+	var buf bytes.Buffer
+	require.NoError(t, report.WriteCSV(&buf, analytics.TableSummary))
+	assert.Equal(t, strings.Join([]string{
+		"scope,account_id,chat_id,title,messages,reactions,senders,first,last,responses,median_response_seconds,p90_response_seconds",
+		"total,,,,7,3,5,2024-06-03T09:00:00Z,2024-06-05T12:30:00Z,2,120,1800",
+		"account,a,,,5,2,3,2024-06-03T09:00:00Z,2024-06-05T10:30:00Z,1,120,120",
+		"account,b,,,2,1,2,2024-06-05T12:00:00Z,2024-06-05T12:30:00Z,1,1800,1800",
+		`chat,a,c1,"Team, core",5,2,3,2024-06-03T09:00:00Z,2024-06-05T10:30:00Z,1,120,120`,
+		"chat,b,c2,,2,1,2,2024-06-05T12:00:00Z,2024-06-05T12:30:00Z,1,1800,1800",
+		"",
+	}, "\n"), buf.String())
+
+	buf.Reset()
+	require.NoError(t, report.WriteCSV(&buf, analytics.TableHours))
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	assert.Equal(t, "scope,account_id,chat_id,title,weekday,hour,messages", lines[0])
+	assert.Equal(t, "total,,,,Monday,9,3", lines[1])
+	assert.Len(t, lines, 1+3+3+3, "only hours with messages")
+
+	for _, table := range analytics.Tables {
+		assert.NoError(t, report.WriteCSV(&bytes.Buffer{}, table))
+	}
+	assert.EqualError(t, report.WriteCSV(&buf, "words"), `unknown table "words"`)
+}
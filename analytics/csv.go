@@ -0,0 +1,149 @@
+package analytics
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"strconv"
+	"time"
+)
+
+// Table names one CSV view of a report
+type Table string
+
+const (
+	TableSummary   Table = "summary"
+	TableSenders   Table = "senders"
+	TableMedia     Table = "media"
+	TableReactions Table = "reactions"
+	TableResponses Table = "responses"
+	TableHours     Table = "hours"
+	TableTrend     Table = "trend"
+)
+
+// Tables lists every table WriteCSV accepts
+var Tables = []Table{TableSummary, TableSenders, TableMedia, TableReactions, TableResponses, TableHours, TableTrend}
+
+// scopeColumns start every row: which scope the row belongs to
+var scopeColumns = []string{"scope", "account_id", "chat_id", "title"}
+
+// WriteCSV writes one table of the report, with rows for the total, then
+// each account, then each chat. The hours table only has rows for hours with
+// messages.
+func (r *Report) WriteCSV(w io.Writer, table Table) error {
+	columns, rows, ok := tableRows(table)
+	if !ok {
+		return fmt.Errorf("unknown table %q", table)
+	}
+
+	cw := csv.NewWriter(w)
+	cw.Write(append(append([]string(nil), scopeColumns...), columns...))
+	for _, stats := range r.scopes() {
+		scope := scopeRow(stats)
+		for _, row := range rows(stats) {
+			cw.Write(append(append([]string(nil), scope...), row...))
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+func (r *Report) scopes() []Stats {
+	scopes := []Stats{r.Total}
+	scopes = append(scopes, r.Accounts...)
+	return append(scopes, r.Chats...)
+}
+
+func scopeRow(stats Stats) []string {
+	scope := "total"
+	switch {
+	case stats.ChatID != "":
+		scope = "chat"
+	case stats.AccountID != "":
+		scope = "account"
+	}
+	return []string{scope, stats.AccountID, stats.ChatID, stats.Title}
+}
+
+// tableRows returns a table's columns after the scope columns, and a function
+// producing its rows for one scope
+func tableRows(table Table) ([]string, func(Stats) [][]string, bool) {
+	switch table {
+	case TableSummary:
+		return []string{"messages", "reactions", "senders", "first", "last", "responses", "median_response_seconds", "p90_response_seconds"},
+			func(s Stats) [][]string {
+				return [][]string{{
+					itoa(s.Messages), itoa(s.Reactions), itoa(len(s.Senders)), timeString(s.First), timeString(s.Last),
+					itoa(s.ResponseTimes.Count), ftoa(s.ResponseTimes.Median), ftoa(s.ResponseTimes.P90),
+				}}
+			}, true
+	case TableSenders:
+		return []string{"sender_id", "sender_name", "messages", "reactions_received", "reactions_given", "replies", "median_response_seconds"},
+			func(s Stats) [][]string {
+				var rows [][]string
+				for _, sender := range s.Senders {
+					rows = append(rows, []string{
+						sender.ID, sender.Name, itoa(sender.Messages), itoa(sender.ReactionsReceived),
+						itoa(sender.ReactionsGiven), itoa(sender.Replies), ftoa(sender.MedianResponse),
+					})
+				}
+				return rows
+			}, true
+	case TableMedia:
+		return []string{"type", "count"}, func(s Stats) [][]string { return countRows(s.Media) }, true
+	case TableReactions:
+		return []string{"reaction", "count"}, func(s Stats) [][]string { return countRows(s.ReactionKeys) }, true
+	case TableResponses:
+		return []string{"bucket", "count"}, func(s Stats) [][]string {
+			var rows [][]string
+			for _, b := range s.ResponseTimes.Buckets {
+				rows = append(rows, []string{b.Label, itoa(b.Count)})
+			}
+			return rows
+		}, true
+	case TableHours:
+		return []string{"weekday", "hour", "messages"}, func(s Stats) [][]string {
+			var rows [][]string
+			for day, hours := range s.ActiveHours {
+				for hour, n := range hours {
+					if n > 0 {
+						rows = append(rows, []string{time.Weekday(day).String(), itoa(hour), itoa(n)})
+					}
+				}
+			}
+			return rows
+		}, true
+	case TableTrend:
+		return []string{"start", "messages"}, func(s Stats) [][]string {
+			var rows [][]string
+			for _, p := range s.Trend {
+				rows = append(rows, []string{p.Start.Format(time.RFC3339), itoa(p.Messages)})
+			}
+			return rows
+		}, true
+	}
+	return nil, nil, false
+}
+
+func countRows(counts []Count) [][]string {
+	var rows [][]string
+	for _, c := range counts {
+		rows = append(rows, []string{c.Key, itoa(c.Count)})
+	}
+	return rows
+}
+
+func itoa(n int) string {
+	return strconv.Itoa(n)
+}
+
+func ftoa(f float64) string {
+	return strconv.FormatFloat(f, 'f', -1, 64)
+}
+
+func timeString(t *time.Time) string {
+	if t == nil {
+		return ""
+	}
+	return t.Format(time.RFC3339)
+}
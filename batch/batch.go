@@ -0,0 +1,396 @@
+// Package batch runs an operation over many items concurrently, with a
+// bounded worker pool, per-item retries, an optional rate limit and progress
+// callbacks. The report tells successes apart from failures worth retrying
+// and permanent ones.
+//
+//	report := batch.Archive(ctx, client, chatIDs, true, batch.Options{Workers: 4, Rate: 10})
+//	fmt.Println(report.Succeeded, "archived;", report.Retriable, "can be retried")
+package batch
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	beeperdesktop "github.com/cameronaaron/beeper-go-sdk"
+	"github.com/cameronaaron/beeper-go-sdk/resources"
+)
+
+const (
+	defaultWorkers     = 4
+	defaultMaxAttempts = 3
+	defaultBackoff     = 500 * time.Millisecond
+	maxBackoff         = 10 * time.Second
+)
+
+// Status is the outcome of one item
+type Status string
+
+const (
+	// Succeeded means the operation completed
+	Succeeded Status = "succeeded"
+	// Retriable means every attempt failed with an error that may go away,
+	// such as a rate limit or a server error, or the batch was canceled
+	// while the item ran
+	Retriable Status = "retriable"
+	// Permanent means the operation failed with an error retrying will not
+	// fix, such as a missing chat or a bad request
+	Permanent Status = "permanent"
+	// Skipped means the batch was canceled before the item started
+	Skipped Status = "skipped"
+)
+
+// Options configures a batch
+type Options struct {
+	// Workers is the number of items processed at once. Defaults to 4.
+	Workers int
+	// MaxAttempts is the number of tries per item, including the first.
+	// Defaults to 3.
+	MaxAttempts int
+	// Backoff is the wait before the first retry; it doubles for each later
+	// retry, up to 10 seconds. Defaults to 500ms.
+	Backoff time.Duration
+	// Rate caps attempts, retries included, per second across all workers.
+	// Zero means no limit.
+	Rate float64
+	// Retryable decides which errors are retriable rather than permanent.
+	// Defaults to IsRetryable.
+	Retryable func(error) bool
+	// Retry decides which retriable errors are retried within the batch.
+	// Defaults to all of them.
+	Retry func(error) bool
+	// Progress is called after each item finishes. Calls are serialized.
+	Progress func(Progress)
+}
+
+func (o Options) withDefaults() Options {
+	if o.Workers <= 0 {
+		o.Workers = defaultWorkers
+	}
+	if o.MaxAttempts <= 0 {
+		o.MaxAttempts = defaultMaxAttempts
+	}
+	if o.Backoff <= 0 {
+		o.Backoff = defaultBackoff
+	}
+	if o.Retryable == nil {
+		o.Retryable = IsRetryable
+	}
+	if o.Retry == nil {
+		o.Retry = func(error) bool { return true }
+	}
+	return o
+}
+
+// Progress counts the items finished so far
+type Progress struct {
+	Total     int
+	Done      int
+	Succeeded int
+	Failed    int // retriable and permanent failures
+	Skipped   int
+}
+
+// Result is the outcome of one item
+type Result[T any] struct {
+	Index    int // in the input
+	Item     T
+	Status   Status
+	Attempts int
+	Err      error // the last error; nil on success
+}
+
+// Report is the outcome of a batch. Results are in input order.
+type Report[T any] struct {
+	Results   []Result[T]
+	Succeeded int
+	Retriable int
+	Permanent int
+	Skipped   int
+}
+
+// Failed returns the results that did not succeed, skipped ones included
+func (r *Report[T]) Failed() []Result[T] {
+	var failed []Result[T]
+	for _, result := range r.Results {
+		if result.Status != Succeeded {
+			failed = append(failed, result)
+		}
+	}
+	return failed
+}
+
+// RetryItems returns the items worth running again: retriable and skipped
+// ones
+func (r *Report[T]) RetryItems() []T {
+	var items []T
+	for _, result := range r.Results {
+		if result.Status == Retriable || result.Status == Skipped {
+			items = append(items, result.Item)
+		}
+	}
+	return items
+}
+
+// Err joins the errors of the items that failed, or returns nil if every
+// item succeeded. Skipped items are reported by the context error.
+func (r *Report[T]) Err() error {
+	var errs []error
+	var skipped error
+	for _, result := range r.Results {
+		switch result.Status {
+		case Succeeded:
+		case Skipped:
+			if skipped == nil {
+				skipped = result.Err
+			}
+		default:
+			errs = append(errs, fmt.Errorf("item %d: %w", result.Index, result.Err))
+		}
+	}
+	if r.Skipped > 0 {
+		errs = append(errs, fmt.Errorf("%d items skipped: %w", r.Skipped, skipped))
+	}
+	return errors.Join(errs...)
+}
+
+// IsRetryable reports whether an error, or any error it wraps, is one the
+// SDK considers retryable: connection failures, timeouts, conflicts, rate
+// limits and server errors
+func IsRetryable(err error) bool {
+	if err == nil {
+		return false
+	}
+	if beeperdesktop.IsRetryableError(err) {
+		return true
+	}
+	switch wrapped := err.(type) {
+	case interface{ Unwrap() error }:
+		return IsRetryable(wrapped.Unwrap())
+	case interface{ Unwrap() []error }:
+		for _, err := range wrapped.Unwrap() {
+			if IsRetryable(err) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// IsRateLimited reports whether an error is a rate limit. Requests that were
+// rate limited were not carried out, so they are safe to retry even when the
+// operation is not idempotent.
+func IsRateLimited(err error) bool {
+	var rateLimit *beeperdesktop.RateLimitError
+	return errors.As(err, &rateLimit)
+}
+
+// Run calls op for every item and waits for all of them. It stops starting
+// items when ctx is done; the rest are reported as skipped.
+func Run[T any](ctx context.Context, items []T, op func(ctx context.Context, item T) error, opts Options) *Report[T] {
+	opts = opts.withDefaults()
+	r := &runner[T]{
+		opts:    opts,
+		op:      op,
+		limiter: newLimiter(opts.Rate),
+		report:  &Report[T]{Results: make([]Result[T], len(items))},
+	}
+	r.progress.Total = len(items)
+
+	indexes := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < min(opts.Workers, len(items)); w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range indexes {
+				r.finish(r.run(ctx, i, items[i]))
+			}
+		}()
+	}
+
+	next := 0
+feed:
+	for ; next < len(items); next++ {
+		select {
+		case indexes <- next:
+		case <-ctx.Done():
+			break feed
+		}
+	}
+	close(indexes)
+	wg.Wait()
+
+	for i := next; i < len(items); i++ {
+		r.finish(Result[T]{Index: i, Item: items[i], Status: Skipped, Err: ctx.Err()})
+	}
+	return r.report
+}
+
+// Archive archives or unarchives chats. Archiving is idempotent, so every
+// retryable error is retried.
+func Archive(ctx context.Context, client beeperdesktop.Client, chatIDs []string, archived bool, opts Options) *Report[string] {
+	return Run(ctx, chatIDs, func(ctx context.Context, chatID string) error {
+		_, err := client.ChatsAPI().Archive(ctx, resources.ChatArchiveParams{ChatID: chatID, Archived: archived})
+		return err
+	}, opts)
+}
+
+// Send sends messages. Unless opts.Retry is set, only rate-limited sends are
+// retried: after a timeout or server error the message may already have been
+// delivered, and retrying could send it twice. Such sends are still reported
+// as retriable; check the chat before sending them again.
+// A send Desktop answers with success set to false fails with a
+// *beeperdesktop.SendError, which is permanent unless opts.Retryable says
+// otherwise.
+func Send(ctx context.Context, client beeperdesktop.Client, messages []resources.MessageSendParams, opts Options) *Report[resources.MessageSendParams] {
+	if opts.Retry == nil {
+		opts.Retry = IsRateLimited
+	}
+	return Run(ctx, messages, func(ctx context.Context, params resources.MessageSendParams) error {
+		resp, err := client.MessagesAPI().Send(ctx, params)
+		if err != nil {
+			return err
+		}
+		return beeperdesktop.CheckSent(resp)
+	}, opts)
+}
+
+// runner is the state of one Run call
+type runner[T any] struct {
+	opts    Options
+	op      func(ctx context.Context, item T) error
+	limiter *limiter
+
+	mu       sync.Mutex
+	report   *Report[T]
+	progress Progress
+}
+
+// run tries one item until it succeeds, fails permanently or runs out of
+// attempts
+func (r *runner[T]) run(ctx context.Context, index int, item T) Result[T] {
+	result := Result[T]{Index: index, Item: item}
+	backoff := r.opts.Backoff
+	for {
+		if err := r.limiter.wait(ctx); err != nil {
+			return r.interrupted(ctx, result, err)
+		}
+		result.Attempts++
+		result.Err = r.op(ctx, item)
+
+		switch {
+		case result.Err == nil:
+			result.Status = Succeeded
+			return result
+		case ctx.Err() != nil:
+			return r.interrupted(ctx, result, result.Err)
+		case !r.opts.Retryable(result.Err):
+			result.Status = Permanent
+			return result
+		case result.Attempts >= r.opts.MaxAttempts || !r.opts.Retry(result.Err):
+			result.Status = Retriable
+			return result
+		}
+
+		select {
+		case <-time.After(backoff):
+		case <-ctx.Done():
+			return r.interrupted(ctx, result, result.Err)
+		}
+		backoff = min(backoff*2, maxBackoff)
+	}
+}
+
+// interrupted records an item cut short by cancellation. An item that was
+// never attempted counts as skipped.
+func (r *runner[T]) interrupted(ctx context.Context, result Result[T], err error) Result[T] {
+	if result.Attempts == 0 {
+		result.Status = Skipped
+		result.Err = ctx.Err()
+		return result
+	}
+	result.Status = Retriable
+	if err == nil {
+		err = ctx.Err()
+	}
+	result.Err = err
+	return result
+}
+
+// finish records a result and reports progress
+func (r *runner[T]) finish(result Result[T]) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.report.Results[result.Index] = result
+	r.progress.Done++
+	switch result.Status {
+	case Succeeded:
+		r.report.Succeeded++
+		r.progress.Succeeded++
+	case Retriable:
+		r.report.Retriable++
+		r.progress.Failed++
+	case Permanent:
+		r.report.Permanent++
+		r.progress.Failed++
+	case Skipped:
+		r.report.Skipped++
+		r.progress.Skipped++
+	}
+	if r.opts.Progress != nil {
+		r.opts.Progress(r.progress)
+	}
+}
+
+// limiter spaces attempts evenly to stay under a rate
+type limiter struct {
+	interval time.Duration
+
+	mu   sync.Mutex
+	next time.Time
+}
+
+// newLimiter returns a limiter for rate attempts per second, or nil for no
+// limit
+func newLimiter(rate float64) *limiter {
+	if rate <= 0 {
+		return nil
+	}
+	return &limiter{interval: time.Duration(float64(time.Second) / rate)}
+}
+
+// wait blocks until the caller's turn or until ctx is done
+func (l *limiter) wait(ctx context.Context) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	if l == nil {
+		return nil
+	}
+
+	l.mu.Lock()
+	now := time.Now()
+	at := l.next
+	if at.Before(now) {
+		at = now
+	}
+	l.next = at.Add(l.interval)
+	l.mu.Unlock()
+
+	delay := time.Until(at)
+	if delay <= 0 {
+		return nil
+	}
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,186 @@
+package batch_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	beeperdesktop "github.com/cameronaaron/beeper-go-sdk"
+	"github.com/cameronaaron/beeper-go-sdk/batch"
+	"github.com/cameronaaron/beeper-go-sdk/beepermock"
+	"github.com/cameronaaron/beeper-go-sdk/beepertest"
+	"github.com/cameronaaron/beeper-go-sdk/resources"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var (
+	errFlaky = &beeperdesktop.InternalServerError{}
+	errBad   = &beeperdesktop.BadRequestError{}
+)
+
+func statuses[T any](report *batch.Report[T]) []batch.Status {
+	var list []batch.Status
+	for _, result := range report.Results {
+		list = append(list, result.Status)
+	}
+	return list
+}
+
+func TestRun(t *testing.T) {
+	var mu sync.Mutex
+	calls := map[int]int{}
+	var running, peak int32
+
+	var progress []batch.Progress
+	report := batch.Run(context.Background(), []int{0, 1, 2, 3, 4, 5}, func(ctx context.Context, item int) error {
+		n := atomic.AddInt32(&running, 1)
+		defer atomic.AddInt32(&running, -1)
+		for {
+			p := atomic.LoadInt32(&peak)
+			if n <= p || atomic.CompareAndSwapInt32(&peak, p, n) {
+				break
+			}
+		}
+		time.Sleep(time.Millisecond)
+
+		mu.Lock()
+		calls[item]++
+		attempt := calls[item]
+		mu.Unlock()
+		switch {
+		case item == 1:
+			return errBad
+		case item == 2:
+			return errFlaky
+		case item%2 == 0 && attempt == 1:
+			return errFlaky
+		}
+		return nil
+	}, batch.Options{
+		Workers:     2,
+		Backoff:     time.Millisecond,
+		MaxAttempts: 3,
+		Progress:    func(p batch.Progress) { progress = append(progress, p) },
+	})
+
+	assert.Equal(t, []batch.Status{batch.Succeeded, batch.Permanent, batch.Retriable, batch.Succeeded, batch.Succeeded, batch.Succeeded}, statuses(report))
+	assert.Equal(t, 4, report.Succeeded)
+	assert.Equal(t, 1, report.Retriable)
+	assert.Equal(t, 1, report.Permanent)
+	assert.Equal(t, 2, report.Results[0].Attempts, "retried once")
+	assert.Equal(t, 1, report.Results[1].Attempts, "permanent errors are not retried")
+	assert.Equal(t, 3, report.Results[2].Attempts)
+	assert.Same(t, errFlaky, report.Results[2].Err)
+	assert.Nil(t, report.Results[3].Err)
+	assert.LessOrEqual(t, peak, int32(2), "at most Workers at once")
+
+	require.Len(t, progress, 6)
+	assert.Equal(t, batch.Progress{Total: 6, Done: 6, Succeeded: 4, Failed: 2}, progress[5])
+
+	assert.Equal(t, []int{2}, report.RetryItems())
+	assert.Len(t, report.Failed(), 2)
+	err := report.Err()
+	assert.ErrorIs(t, err, errBad)
+	assert.Contains(t, err.Error(), "item 2: ")
+}
+
+func TestRunCanceled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	report := batch.Run(ctx, []string{"a", "b", "c", "d"}, func(ctx context.Context, item string) error {
+		if item == "b" {
+			cancel()
+			<-ctx.Done()
+			return ctx.Err()
+		}
+		return nil
+	}, batch.Options{Workers: 1})
+
+	assert.Equal(t, []batch.Status{batch.Succeeded, batch.Retriable, batch.Skipped, batch.Skipped}, statuses(report))
+	assert.Equal(t, []string{"b", "c", "d"}, report.RetryItems())
+	err := report.Err()
+	assert.ErrorIs(t, err, context.Canceled)
+	assert.Contains(t, err.Error(), "2 items skipped")
+}
+
+func TestRunRate(t *testing.T) {
+	start := time.Now()
+	report := batch.Run(context.Background(), make([]int, 5), func(ctx context.Context, item int) error {
+		return nil
+	}, batch.Options{Workers: 5, Rate: 50})
+	assert.Equal(t, 5, report.Succeeded)
+	assert.GreaterOrEqual(t, time.Since(start), 80*time.Millisecond, "five attempts at 50 per second take 80ms")
+
+	assert.Nil(t, report.Err())
+	assert.Empty(t, batch.Run(context.Background(), nil, func(context.Context, int) error { return nil }, batch.Options{}).Results)
+}
+
+func TestIsRetryable(t *testing.T) {
+	assert.True(t, batch.IsRetryable(errFlaky))
+	assert.True(t, batch.IsRetryable(errors.Join(errors.New("context"), &beeperdesktop.RateLimitError{})))
+	assert.False(t, batch.IsRetryable(errBad))
+	assert.False(t, batch.IsRetryable(nil))
+	assert.True(t, batch.IsRateLimited(&beeperdesktop.RateLimitError{}))
+	assert.False(t, batch.IsRateLimited(errFlaky))
+}
+
+func TestArchiveAndSend(t *testing.T) {
+	server := beepertest.NewServer(beepertest.DefaultFixtures())
+	defer server.Close()
+	injector := beepertest.NewInjector(1,
+		beepertest.Fault{Kind: beepertest.FaultServerError, Path: "/v0/archive-chat", Limit: 1},
+		beepertest.Fault{Kind: beepertest.FaultRateLimit, Path: "/v0/send-message", Limit: 1},
+	)
+	client, err := server.Client(beeperdesktop.WithHTTPClient(&http.Client{Transport: injector.Transport(nil)}))
+	require.NoError(t, err)
+	ctx := context.Background()
+	opts := batch.Options{Workers: 1, Backoff: time.Millisecond}
+
+	report := batch.Archive(ctx, client, []string{"chat-alice", "chat-missing", "chat-team"}, true, opts)
+	assert.Equal(t, []batch.Status{batch.Succeeded, batch.Permanent, batch.Succeeded}, statuses(report))
+	assert.Equal(t, 2, report.Results[0].Attempts, "server errors are retried")
+	var notFound *beeperdesktop.NotFoundError
+	assert.ErrorAs(t, report.Results[1].Err, &notFound)
+	chat, err := client.Chats.Retrieve(ctx, resources.ChatRetrieveParams{ChatID: "chat-team"})
+	require.NoError(t, err)
+	assert.True(t, *chat.IsArchived)
+
+	sends := batch.Send(ctx, client, []resources.MessageSendParams{
+		{ChatID: "chat-alice", Text: "one"},
+		{ChatID: "chat-alice", Text: "two"},
+	}, opts)
+	assert.Equal(t, []batch.Status{batch.Succeeded, batch.Succeeded}, statuses(sends))
+	assert.Equal(t, 2, sends.Results[0].Attempts, "rate-limited sends are retried")
+
+	injector = beepertest.NewInjector(1, beepertest.Fault{Kind: beepertest.FaultServerError, Path: "/v0/send-message", Limit: 1})
+	client, err = server.Client(beeperdesktop.WithHTTPClient(&http.Client{Transport: injector.Transport(nil)}))
+	require.NoError(t, err)
+	sends = batch.Send(ctx, client, []resources.MessageSendParams{{ChatID: "chat-alice", Text: "three"}}, opts)
+	assert.Equal(t, []batch.Status{batch.Retriable}, statuses(sends))
+	assert.Equal(t, 1, sends.Results[0].Attempts, "server errors on send are not retried")
+}
+
+func TestSendChecksSuccess(t *testing.T) {
+	client := beepermock.NewClient()
+	client.Messages.SendFunc = func(ctx context.Context, params resources.MessageSendParams) (*resources.MessageSendResponse, error) {
+		if params.ChatID == "chat-readonly" {
+			return &resources.MessageSendResponse{Error: "chat is read-only"}, nil
+		}
+		return &resources.MessageSendResponse{MessageID: "m1", Success: true}, nil
+	}
+
+	report := batch.Send(context.Background(), client, []resources.MessageSendParams{
+		{ChatID: "chat-alice", Text: "hi"},
+		{ChatID: "chat-readonly", Text: "hi"},
+	}, batch.Options{Workers: 1})
+	assert.Equal(t, []batch.Status{batch.Succeeded, batch.Permanent}, statuses(report))
+	var sendErr *beeperdesktop.SendError
+	assert.ErrorAs(t, report.Results[1].Err, &sendErr)
+	assert.Equal(t, 1, report.Results[1].Attempts)
+}
@@ -0,0 +1,111 @@
+package beepermock
+
+import (
+	"context"
+	"fmt"
+	"io"
+
+	beeperdesktop "github.com/cameronaaron/beeper-go-sdk"
+	"github.com/cameronaaron/beeper-go-sdk/resources"
+)
+
+// Client is a mock implementation of beeperdesktop.Client. The resource
+// accessors return the resource fields and are not recorded; the raw request
+// methods are recorded and delegate to their Func fields.
+type Client struct {
+	recorder
+
+	Accounts *AccountsAPI
+	App      *AppAPI
+	Chats    *ChatsAPI
+	Contacts *ContactsAPI
+	Messages *MessagesAPI
+	Token    *TokenAPI
+
+	// Participants and Reminders back the default Chats mock's sub-resource
+	// accessors
+	Participants *ParticipantsAPI
+	Reminders    *RemindersAPI
+
+	DoRequestFunc          func(ctx context.Context, method, path string, body interface{}, result interface{}) error
+	DoRequestWithQueryFunc func(ctx context.Context, method, path string, query map[string]interface{}, result interface{}) error
+	OpenEventStreamFunc    func(ctx context.Context, path, lastEventID string) (io.ReadCloser, error)
+}
+
+var _ beeperdesktop.Client = (*Client)(nil)
+
+// NewClient returns a Client whose resource fields hold fresh mocks. The
+// fields may be replaced before use. The Chats mock's ParticipantsAPI and
+// RemindersAPI return the client's Participants and Reminders fields.
+func NewClient() *Client {
+	c := &Client{
+		Accounts:     &AccountsAPI{},
+		App:          &AppAPI{},
+		Chats:        &ChatsAPI{},
+		Contacts:     &ContactsAPI{},
+		Messages:     &MessagesAPI{},
+		Token:        &TokenAPI{},
+		Participants: &ParticipantsAPI{},
+		Reminders:    &RemindersAPI{},
+	}
+	c.Chats.ParticipantsAPIFunc = func() resources.ParticipantsAPI { return c.Participants }
+	c.Chats.RemindersAPIFunc = func() resources.RemindersAPI { return c.Reminders }
+	return c
+}
+
+// AccountsAPI returns the Accounts mock
+func (c *Client) AccountsAPI() resources.AccountsAPI {
+	return c.Accounts
+}
+
+// AppAPI returns the App mock
+func (c *Client) AppAPI() resources.AppAPI {
+	return c.App
+}
+
+// ChatsAPI returns the Chats mock
+func (c *Client) ChatsAPI() resources.ChatsAPI {
+	return c.Chats
+}
+
+// ContactsAPI returns the Contacts mock
+func (c *Client) ContactsAPI() resources.ContactsAPI {
+	return c.Contacts
+}
+
+// MessagesAPI returns the Messages mock
+func (c *Client) MessagesAPI() resources.MessagesAPI {
+	return c.Messages
+}
+
+// TokenAPI returns the Token mock
+func (c *Client) TokenAPI() resources.TokenAPI {
+	return c.Token
+}
+
+// DoRequest records the call and invokes DoRequestFunc
+func (c *Client) DoRequest(ctx context.Context, method, path string, body interface{}, result interface{}) error {
+	c.record("DoRequest", ctx, method, path, body, result)
+	if c.DoRequestFunc == nil {
+		return fmt.Errorf("%w: Client.DoRequest", ErrNotStubbed)
+	}
+	return c.DoRequestFunc(ctx, method, path, body, result)
+}
+
+// DoRequestWithQuery records the call and invokes DoRequestWithQueryFunc
+func (c *Client) DoRequestWithQuery(ctx context.Context, method, path string, query map[string]interface{}, result interface{}) error {
+	c.record("DoRequestWithQuery", ctx, method, path, query, result)
+	if c.DoRequestWithQueryFunc == nil {
+		return fmt.Errorf("%w: Client.DoRequestWithQuery", ErrNotStubbed)
+	}
+	return c.DoRequestWithQueryFunc(ctx, method, path, query, result)
+}
+
+// OpenEventStream records the call and invokes OpenEventStreamFunc
+func (c *Client) OpenEventStream(ctx context.Context, path, lastEventID string) (io.ReadCloser, error) {
+	c.record("OpenEventStream", ctx, path, lastEventID)
+	if c.OpenEventStreamFunc == nil {
+		return nil, fmt.Errorf("%w: Client.OpenEventStream", ErrNotStubbed)
+	}
+	return c.OpenEventStreamFunc(ctx, path, lastEventID)
+}
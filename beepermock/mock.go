@@ -0,0 +1,72 @@
+// Package beepermock provides mock implementations of the SDK interfaces for
+// unit tests. Every mock records its calls; behavior is set per method through
+// the exported Func fields.
+//
+//	chats := &beepermock.ChatsAPI{
+//		RetrieveFunc: func(ctx context.Context, params resources.ChatRetrieveParams) (*resources.Chat, error) {
+//			return &resources.Chat{ID: params.ChatID, Title: "Test"}, nil
+//		},
+//	}
+//	client := beepermock.NewClient()
+//	client.Chats = chats
+//
+//	// ... exercise code that takes a beeperdesktop.Client ...
+//
+//	calls := chats.CallsTo("Retrieve")
+package beepermock
+
+//go:generate go run ../internal/cmd/mockgen -out mocks_gen.go
+
+import (
+	"errors"
+	"sync"
+)
+
+// ErrNotStubbed is returned by mock methods whose Func field is nil
+var ErrNotStubbed = errors.New("beepermock: method not stubbed")
+
+// Call is one recorded invocation of a mock method
+type Call struct {
+	Method string
+	Args   []interface{}
+}
+
+// recorder keeps the calls made to a mock. It is embedded in every mock.
+type recorder struct {
+	mu    sync.Mutex
+	calls []Call
+}
+
+func (r *recorder) record(method string, args ...interface{}) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.calls = append(r.calls, Call{Method: method, Args: args})
+}
+
+// Calls returns every recorded call in order
+func (r *recorder) Calls() []Call {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]Call(nil), r.calls...)
+}
+
+// CallsTo returns the recorded calls to one method in order
+func (r *recorder) CallsTo(method string) []Call {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var calls []Call
+	for _, call := range r.calls {
+		if call.Method == method {
+			calls = append(calls, call)
+		}
+	}
+	return calls
+}
+
+// Reset forgets all recorded calls
+func (r *recorder) Reset() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.calls = nil
+}
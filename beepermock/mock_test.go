@@ -0,0 +1,64 @@
+package beepermock_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	beeperdesktop "github.com/cameronaaron/beeper-go-sdk"
+	"github.com/cameronaaron/beeper-go-sdk/beepermock"
+	"github.com/cameronaaron/beeper-go-sdk/resources"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// greetNewMembers is application code written against the Client interface
+func greetNewMembers(ctx context.Context, client beeperdesktop.Client, chatID string) (int, error) {
+	members, err := client.ChatsAPI().ParticipantsAPI().List(ctx, resources.ParticipantListParams{ChatID: chatID})
+	if err != nil {
+		return 0, err
+	}
+
+	sent := 0
+	for _, member := range members.Items {
+		if member.IsSelf != nil && *member.IsSelf {
+			continue
+		}
+		text := "Welcome, " + member.ID
+		if _, err := client.MessagesAPI().Send(ctx, resources.MessageSendParams{ChatID: chatID, Text: text}); err != nil {
+			return sent, err
+		}
+		sent++
+	}
+	return sent, nil
+}
+
+func TestClientMock(t *testing.T) {
+	ctx := context.Background()
+	client := beepermock.NewClient()
+	client.Participants.ListFunc = func(ctx context.Context, params resources.ParticipantListParams) (*resources.ParticipantsCursor, error) {
+		return &resources.ParticipantsCursor{Items: []resources.User{
+			{ID: "@me:beeper.com", IsSelf: beeperdesktop.BoolPtr(true)},
+			{ID: "@alice:beeper.com"},
+		}}, nil
+	}
+
+	// Unstubbed methods fail with ErrNotStubbed
+	_, err := greetNewMembers(ctx, client, "chat-1")
+	assert.True(t, errors.Is(err, beepermock.ErrNotStubbed))
+
+	client.Messages.Reset()
+	client.Messages.SendFunc = func(ctx context.Context, params resources.MessageSendParams) (*resources.MessageSendResponse, error) {
+		return &resources.MessageSendResponse{Success: true}, nil
+	}
+
+	sent, err := greetNewMembers(ctx, client, "chat-1")
+	require.NoError(t, err)
+	assert.Equal(t, 1, sent)
+
+	calls := client.Messages.CallsTo("Send")
+	require.Len(t, calls, 1)
+	params := calls[0].Args[1].(resources.MessageSendParams)
+	assert.Equal(t, "Welcome, @alice:beeper.com", params.Text)
+	assert.Len(t, client.Participants.Calls(), 2)
+}
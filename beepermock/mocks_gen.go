@@ -0,0 +1,284 @@
+// Code generated by internal/cmd/mockgen; DO NOT EDIT.
+
+package beepermock
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/cameronaaron/beeper-go-sdk/resources"
+)
+
+// AccountsAPI is a mock implementation of resources.AccountsAPI
+type AccountsAPI struct {
+	recorder
+
+	ListFunc func(context.Context) (*resources.AccountListResponse, error)
+}
+
+var _ resources.AccountsAPI = (*AccountsAPI)(nil)
+
+// List records the call and invokes ListFunc
+func (m *AccountsAPI) List(ctx context.Context) (*resources.AccountListResponse, error) {
+	m.record("List", ctx)
+	if m.ListFunc == nil {
+		return nil, fmt.Errorf("%w: AccountsAPI.List", ErrNotStubbed)
+	}
+	return m.ListFunc(ctx)
+}
+
+// AppAPI is a mock implementation of resources.AppAPI
+type AppAPI struct {
+	recorder
+
+	DownloadAssetFunc func(context.Context, resources.AppDownloadAssetParams) (*resources.AppDownloadAssetResponse, error)
+	OpenFunc          func(context.Context, resources.AppOpenParams) (*resources.AppOpenResponse, error)
+	SearchFunc        func(context.Context, resources.AppSearchParams) (*resources.AppSearchResponse, error)
+}
+
+var _ resources.AppAPI = (*AppAPI)(nil)
+
+// DownloadAsset records the call and invokes DownloadAssetFunc
+func (m *AppAPI) DownloadAsset(ctx context.Context, params resources.AppDownloadAssetParams) (*resources.AppDownloadAssetResponse, error) {
+	m.record("DownloadAsset", ctx, params)
+	if m.DownloadAssetFunc == nil {
+		return nil, fmt.Errorf("%w: AppAPI.DownloadAsset", ErrNotStubbed)
+	}
+	return m.DownloadAssetFunc(ctx, params)
+}
+
+// Open records the call and invokes OpenFunc
+func (m *AppAPI) Open(ctx context.Context, params resources.AppOpenParams) (*resources.AppOpenResponse, error) {
+	m.record("Open", ctx, params)
+	if m.OpenFunc == nil {
+		return nil, fmt.Errorf("%w: AppAPI.Open", ErrNotStubbed)
+	}
+	return m.OpenFunc(ctx, params)
+}
+
+// Search records the call and invokes SearchFunc
+func (m *AppAPI) Search(ctx context.Context, params resources.AppSearchParams) (*resources.AppSearchResponse, error) {
+	m.record("Search", ctx, params)
+	if m.SearchFunc == nil {
+		return nil, fmt.Errorf("%w: AppAPI.Search", ErrNotStubbed)
+	}
+	return m.SearchFunc(ctx, params)
+}
+
+// ChatsAPI is a mock implementation of resources.ChatsAPI
+type ChatsAPI struct {
+	recorder
+
+	ArchiveFunc         func(context.Context, resources.ChatArchiveParams) (*resources.BaseResponse, error)
+	CreateFunc          func(context.Context, resources.ChatCreateParams) (*resources.ChatCreateResponse, error)
+	ParticipantsAPIFunc func() resources.ParticipantsAPI
+	RemindersAPIFunc    func() resources.RemindersAPI
+	RetrieveFunc        func(context.Context, resources.ChatRetrieveParams) (*resources.Chat, error)
+	SearchFunc          func(context.Context, resources.ChatSearchParams) (*resources.ChatsCursor, error)
+}
+
+var _ resources.ChatsAPI = (*ChatsAPI)(nil)
+
+// Archive records the call and invokes ArchiveFunc
+func (m *ChatsAPI) Archive(ctx context.Context, params resources.ChatArchiveParams) (*resources.BaseResponse, error) {
+	m.record("Archive", ctx, params)
+	if m.ArchiveFunc == nil {
+		return nil, fmt.Errorf("%w: ChatsAPI.Archive", ErrNotStubbed)
+	}
+	return m.ArchiveFunc(ctx, params)
+}
+
+// Create records the call and invokes CreateFunc
+func (m *ChatsAPI) Create(ctx context.Context, params resources.ChatCreateParams) (*resources.ChatCreateResponse, error) {
+	m.record("Create", ctx, params)
+	if m.CreateFunc == nil {
+		return nil, fmt.Errorf("%w: ChatsAPI.Create", ErrNotStubbed)
+	}
+	return m.CreateFunc(ctx, params)
+}
+
+// ParticipantsAPI records the call and invokes ParticipantsAPIFunc
+func (m *ChatsAPI) ParticipantsAPI() resources.ParticipantsAPI {
+	m.record("ParticipantsAPI")
+	if m.ParticipantsAPIFunc == nil {
+		return nil
+	}
+	return m.ParticipantsAPIFunc()
+}
+
+// RemindersAPI records the call and invokes RemindersAPIFunc
+func (m *ChatsAPI) RemindersAPI() resources.RemindersAPI {
+	m.record("RemindersAPI")
+	if m.RemindersAPIFunc == nil {
+		return nil
+	}
+	return m.RemindersAPIFunc()
+}
+
+// Retrieve records the call and invokes RetrieveFunc
+func (m *ChatsAPI) Retrieve(ctx context.Context, params resources.ChatRetrieveParams) (*resources.Chat, error) {
+	m.record("Retrieve", ctx, params)
+	if m.RetrieveFunc == nil {
+		return nil, fmt.Errorf("%w: ChatsAPI.Retrieve", ErrNotStubbed)
+	}
+	return m.RetrieveFunc(ctx, params)
+}
+
+// Search records the call and invokes SearchFunc
+func (m *ChatsAPI) Search(ctx context.Context, params resources.ChatSearchParams) (*resources.ChatsCursor, error) {
+	m.record("Search", ctx, params)
+	if m.SearchFunc == nil {
+		return nil, fmt.Errorf("%w: ChatsAPI.Search", ErrNotStubbed)
+	}
+	return m.SearchFunc(ctx, params)
+}
+
+// ContactsAPI is a mock implementation of resources.ContactsAPI
+type ContactsAPI struct {
+	recorder
+
+	SearchFunc func(context.Context, resources.ContactSearchParams) (*resources.ContactSearchResponse, error)
+}
+
+var _ resources.ContactsAPI = (*ContactsAPI)(nil)
+
+// Search records the call and invokes SearchFunc
+func (m *ContactsAPI) Search(ctx context.Context, params resources.ContactSearchParams) (*resources.ContactSearchResponse, error) {
+	m.record("Search", ctx, params)
+	if m.SearchFunc == nil {
+		return nil, fmt.Errorf("%w: ContactsAPI.Search", ErrNotStubbed)
+	}
+	return m.SearchFunc(ctx, params)
+}
+
+// MessagesAPI is a mock implementation of resources.MessagesAPI
+type MessagesAPI struct {
+	recorder
+
+	SearchFunc func(context.Context, resources.MessageSearchParams) (*resources.MessagesCursor, error)
+	SendFunc   func(context.Context, resources.MessageSendParams) (*resources.MessageSendResponse, error)
+}
+
+var _ resources.MessagesAPI = (*MessagesAPI)(nil)
+
+// Search records the call and invokes SearchFunc
+func (m *MessagesAPI) Search(ctx context.Context, params resources.MessageSearchParams) (*resources.MessagesCursor, error) {
+	m.record("Search", ctx, params)
+	if m.SearchFunc == nil {
+		return nil, fmt.Errorf("%w: MessagesAPI.Search", ErrNotStubbed)
+	}
+	return m.SearchFunc(ctx, params)
+}
+
+// Send records the call and invokes SendFunc
+func (m *MessagesAPI) Send(ctx context.Context, params resources.MessageSendParams) (*resources.MessageSendResponse, error) {
+	m.record("Send", ctx, params)
+	if m.SendFunc == nil {
+		return nil, fmt.Errorf("%w: MessagesAPI.Send", ErrNotStubbed)
+	}
+	return m.SendFunc(ctx, params)
+}
+
+// ParticipantsAPI is a mock implementation of resources.ParticipantsAPI
+type ParticipantsAPI struct {
+	recorder
+
+	AddFunc    func(context.Context, resources.ParticipantUpdateParams) (*resources.BaseResponse, error)
+	ListFunc   func(context.Context, resources.ParticipantListParams) (*resources.ParticipantsCursor, error)
+	RemoveFunc func(context.Context, resources.ParticipantUpdateParams) (*resources.BaseResponse, error)
+}
+
+var _ resources.ParticipantsAPI = (*ParticipantsAPI)(nil)
+
+// Add records the call and invokes AddFunc
+func (m *ParticipantsAPI) Add(ctx context.Context, params resources.ParticipantUpdateParams) (*resources.BaseResponse, error) {
+	m.record("Add", ctx, params)
+	if m.AddFunc == nil {
+		return nil, fmt.Errorf("%w: ParticipantsAPI.Add", ErrNotStubbed)
+	}
+	return m.AddFunc(ctx, params)
+}
+
+// List records the call and invokes ListFunc
+func (m *ParticipantsAPI) List(ctx context.Context, params resources.ParticipantListParams) (*resources.ParticipantsCursor, error) {
+	m.record("List", ctx, params)
+	if m.ListFunc == nil {
+		return nil, fmt.Errorf("%w: ParticipantsAPI.List", ErrNotStubbed)
+	}
+	return m.ListFunc(ctx, params)
+}
+
+// Remove records the call and invokes RemoveFunc
+func (m *ParticipantsAPI) Remove(ctx context.Context, params resources.ParticipantUpdateParams) (*resources.BaseResponse, error) {
+	m.record("Remove", ctx, params)
+	if m.RemoveFunc == nil {
+		return nil, fmt.Errorf("%w: ParticipantsAPI.Remove", ErrNotStubbed)
+	}
+	return m.RemoveFunc(ctx, params)
+}
+
+// RemindersAPI is a mock implementation of resources.RemindersAPI
+type RemindersAPI struct {
+	recorder
+
+	CreateFunc func(context.Context, resources.ReminderCreateParams) (*resources.BaseResponse, error)
+	DeleteFunc func(context.Context, resources.ReminderDeleteParams) (*resources.BaseResponse, error)
+	GetFunc    func(context.Context, resources.ReminderGetParams) (*resources.Reminder, error)
+	ListFunc   func(context.Context, resources.ReminderListParams) (*resources.ReminderListResponse, error)
+}
+
+var _ resources.RemindersAPI = (*RemindersAPI)(nil)
+
+// Create records the call and invokes CreateFunc
+func (m *RemindersAPI) Create(ctx context.Context, params resources.ReminderCreateParams) (*resources.BaseResponse, error) {
+	m.record("Create", ctx, params)
+	if m.CreateFunc == nil {
+		return nil, fmt.Errorf("%w: RemindersAPI.Create", ErrNotStubbed)
+	}
+	return m.CreateFunc(ctx, params)
+}
+
+// Delete records the call and invokes DeleteFunc
+func (m *RemindersAPI) Delete(ctx context.Context, params resources.ReminderDeleteParams) (*resources.BaseResponse, error) {
+	m.record("Delete", ctx, params)
+	if m.DeleteFunc == nil {
+		return nil, fmt.Errorf("%w: RemindersAPI.Delete", ErrNotStubbed)
+	}
+	return m.DeleteFunc(ctx, params)
+}
+
+// Get records the call and invokes GetFunc
+func (m *RemindersAPI) Get(ctx context.Context, params resources.ReminderGetParams) (*resources.Reminder, error) {
+	m.record("Get", ctx, params)
+	if m.GetFunc == nil {
+		return nil, fmt.Errorf("%w: RemindersAPI.Get", ErrNotStubbed)
+	}
+	return m.GetFunc(ctx, params)
+}
+
+// List records the call and invokes ListFunc
+func (m *RemindersAPI) List(ctx context.Context, params resources.ReminderListParams) (*resources.ReminderListResponse, error) {
+	m.record("List", ctx, params)
+	if m.ListFunc == nil {
+		return nil, fmt.Errorf("%w: RemindersAPI.List", ErrNotStubbed)
+	}
+	return m.ListFunc(ctx, params)
+}
+
+// TokenAPI is a mock implementation of resources.TokenAPI
+type TokenAPI struct {
+	recorder
+
+	InfoFunc func(context.Context) (*resources.UserInfo, error)
+}
+
+var _ resources.TokenAPI = (*TokenAPI)(nil)
+
+// Info records the call and invokes InfoFunc
+func (m *TokenAPI) Info(ctx context.Context) (*resources.UserInfo, error) {
+	m.record("Info", ctx)
+	if m.InfoFunc == nil {
+		return nil, fmt.Errorf("%w: TokenAPI.Info", ErrNotStubbed)
+	}
+	return m.InfoFunc(ctx)
+}
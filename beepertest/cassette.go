@@ -0,0 +1,399 @@
+package beepertest
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sync"
+)
+
+// Redacted replaces sensitive values in recorded cassettes
+const Redacted = "[REDACTED]"
+
+// cassetteVersion is the format version written to cassette files
+const cassetteVersion = 1
+
+// DefaultRedactedFields are the JSON fields whose string values are replaced
+// before a cassette is written: message text, reminder notes and tokens
+var DefaultRedactedFields = []string{
+	"text", "draftText", "message",
+	"token", "access_token", "refresh_token", "sub", "client_id",
+}
+
+// DefaultRedactedParams are the query parameters whose values are replaced
+// before a cassette is written: the search text
+var DefaultRedactedParams = []string{"query"}
+
+// Cassette is a recorded sequence of HTTP interactions
+type Cassette struct {
+	Version      int           `json:"version"`
+	Interactions []Interaction `json:"interactions"`
+}
+
+// Interaction is one recorded request and its response
+type Interaction struct {
+	Request  RecordedRequest  `json:"request"`
+	Response RecordedResponse `json:"response"`
+}
+
+// RecordedRequest is the part of a request that replay matches on. Headers,
+// including Authorization, are never recorded.
+type RecordedRequest struct {
+	Method string          `json:"method"`
+	Path   string          `json:"path"`
+	Query  url.Values      `json:"query,omitempty"`
+	Body   json.RawMessage `json:"body,omitempty"`
+}
+
+// RecordedResponse is a sanitized response
+type RecordedResponse struct {
+	Status  int               `json:"status"`
+	Headers map[string]string `json:"headers,omitempty"`
+	Body    json.RawMessage   `json:"body,omitempty"`
+	RawBody string            `json:"rawBody,omitempty"` // Set instead of Body when the response is not JSON
+}
+
+// RecorderMode selects whether a Recorder records or replays
+type RecorderMode int
+
+const (
+	// ModeReplay serves responses from the cassette and fails unmatched requests
+	ModeReplay RecorderMode = iota
+	// ModeRecord forwards requests and records the interactions
+	ModeRecord
+	// ModeAuto records when the cassette file does not exist and replays otherwise
+	ModeAuto
+)
+
+// recordedHeaders are the response headers kept in cassettes
+var recordedHeaders = []string{"Content-Type", "Retry-After"}
+
+// Recorder is an http.RoundTripper that records interactions into a cassette
+// file or replays them from one
+type Recorder struct {
+	path      string
+	mode      RecorderMode
+	transport http.RoundTripper
+	redact    map[string]bool
+	params    map[string]bool
+
+	mu       sync.Mutex
+	cassette Cassette
+	used     []bool
+}
+
+// RecorderOption configures a Recorder
+type RecorderOption func(*Recorder)
+
+// WithRecorderTransport sets the transport used to reach the real server while
+// recording. Defaults to http.DefaultTransport.
+func WithRecorderTransport(transport http.RoundTripper) RecorderOption {
+	return func(r *Recorder) {
+		r.transport = transport
+	}
+}
+
+// WithRedactedFields replaces the default set of redacted JSON fields
+func WithRedactedFields(fields ...string) RecorderOption {
+	return func(r *Recorder) {
+		r.redact = make(map[string]bool, len(fields))
+		for _, field := range fields {
+			r.redact[field] = true
+		}
+	}
+}
+
+// WithRedactedParams replaces the default set of redacted query parameters.
+// Replay redacts the same parameters before matching, so requests that differ
+// only in a redacted value match the same interaction.
+func WithRedactedParams(params ...string) RecorderOption {
+	return func(r *Recorder) {
+		r.params = make(map[string]bool, len(params))
+		for _, param := range params {
+			r.params[param] = true
+		}
+	}
+}
+
+// ReplayMismatchError reports a request with no matching recorded interaction
+type ReplayMismatchError struct {
+	Method string
+	URL    string
+	// Closest is the first unused interaction with the same method and path, if any
+	Closest *RecordedRequest
+}
+
+func (e *ReplayMismatchError) Error() string {
+	msg := fmt.Sprintf("beepertest: no recorded interaction for %s %s", e.Method, e.URL)
+	if e.Closest != nil {
+		msg += fmt.Sprintf(" (recorded query was %q)", e.Closest.Query.Encode())
+	}
+	return msg
+}
+
+// NewRecorder creates a recorder for the cassette at path. In replay mode the
+// cassette must exist.
+func NewRecorder(path string, mode RecorderMode, opts ...RecorderOption) (*Recorder, error) {
+	r := &Recorder{
+		path:      path,
+		mode:      mode,
+		transport: http.DefaultTransport,
+		cassette:  Cassette{Version: cassetteVersion},
+	}
+	WithRedactedFields(DefaultRedactedFields...)(r)
+	WithRedactedParams(DefaultRedactedParams...)(r)
+	for _, opt := range opts {
+		opt(r)
+	}
+
+	if r.mode == ModeAuto {
+		r.mode = ModeReplay
+		if _, err := os.Stat(path); errors.Is(err, os.ErrNotExist) {
+			r.mode = ModeRecord
+		}
+	}
+
+	if r.mode == ModeReplay {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read cassette: %w", err)
+		}
+		if err := json.Unmarshal(data, &r.cassette); err != nil {
+			return nil, fmt.Errorf("failed to parse cassette %s: %w", path, err)
+		}
+		r.used = make([]bool, len(r.cassette.Interactions))
+	}
+	return r, nil
+}
+
+// Recording reports whether the recorder is capturing new interactions
+func (r *Recorder) Recording() bool {
+	return r.mode == ModeRecord
+}
+
+// Client returns an HTTP client using the recorder, for WithHTTPClient
+func (r *Recorder) Client() *http.Client {
+	return &http.Client{Transport: r}
+}
+
+// RoundTrip records or replays a single request
+func (r *Recorder) RoundTrip(req *http.Request) (*http.Response, error) {
+	var body []byte
+	if req.Body != nil {
+		var err error
+		body, err = io.ReadAll(req.Body)
+		req.Body.Close()
+		if err != nil {
+			return nil, fmt.Errorf("failed to read request body: %w", err)
+		}
+		req.Body = io.NopCloser(bytes.NewReader(body))
+	}
+	recorded := RecordedRequest{
+		Method: req.Method,
+		Path:   req.URL.Path,
+		Query:  r.sanitizeQuery(req.URL.Query()),
+		Body:   r.sanitizeJSON(body),
+	}
+
+	if r.mode == ModeReplay {
+		return r.replay(req, recorded)
+	}
+	return r.record(req, recorded)
+}
+
+func (r *Recorder) record(req *http.Request, recorded RecordedRequest) (*http.Response, error) {
+	resp, err := r.transport.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response body: %w", err)
+	}
+	resp.Body = io.NopCloser(bytes.NewReader(body))
+
+	response := RecordedResponse{Status: resp.StatusCode}
+	for _, name := range recordedHeaders {
+		if value := resp.Header.Get(name); value != "" {
+			if response.Headers == nil {
+				response.Headers = make(map[string]string)
+			}
+			response.Headers[name] = value
+		}
+	}
+	if sanitized := r.sanitizeJSON(body); sanitized != nil {
+		response.Body = sanitized
+	} else if len(body) > 0 {
+		response.RawBody = string(body)
+	}
+
+	r.mu.Lock()
+	r.cassette.Interactions = append(r.cassette.Interactions, Interaction{Request: recorded, Response: response})
+	r.mu.Unlock()
+	return resp, nil
+}
+
+// replay serves the first unused interaction that matches the method, path
+// and query exactly
+func (r *Recorder) replay(req *http.Request, recorded RecordedRequest) (*http.Response, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var closest *RecordedRequest
+	for idx, interaction := range r.cassette.Interactions {
+		if r.used[idx] || interaction.Request.Method != recorded.Method || interaction.Request.Path != recorded.Path {
+			continue
+		}
+		if !sameQuery(interaction.Request.Query, recorded.Query) {
+			if closest == nil {
+				closest = &r.cassette.Interactions[idx].Request
+			}
+			continue
+		}
+
+		r.used[idx] = true
+		return buildResponse(req, interaction.Response), nil
+	}
+
+	return nil, &ReplayMismatchError{Method: req.Method, URL: req.URL.RequestURI(), Closest: closest}
+}
+
+// Unused returns the recorded interactions that replay has not served, so
+// tests can assert every expected request was made
+func (r *Recorder) Unused() []Interaction {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var unused []Interaction
+	for idx, interaction := range r.cassette.Interactions {
+		if !r.used[idx] {
+			unused = append(unused, interaction)
+		}
+	}
+	return unused
+}
+
+// Stop writes the cassette when recording. It is a no-op when replaying.
+func (r *Recorder) Stop() error {
+	if r.mode != ModeRecord {
+		return nil
+	}
+
+	r.mu.Lock()
+	data, err := json.MarshalIndent(r.cassette, "", "  ")
+	r.mu.Unlock()
+	if err != nil {
+		return fmt.Errorf("failed to encode cassette: %w", err)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(r.path), 0o755); err != nil {
+		return fmt.Errorf("failed to create cassette directory: %w", err)
+	}
+	return os.WriteFile(r.path, append(data, '\n'), 0o644)
+}
+
+func buildResponse(req *http.Request, recorded RecordedResponse) *http.Response {
+	body := []byte(recorded.Body)
+	if recorded.RawBody != "" {
+		body = []byte(recorded.RawBody)
+	}
+
+	header := make(http.Header)
+	for name, value := range recorded.Headers {
+		header.Set(name, value)
+	}
+
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", recorded.Status, http.StatusText(recorded.Status)),
+		StatusCode:    recorded.Status,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        header,
+		Body:          io.NopCloser(bytes.NewReader(body)),
+		ContentLength: int64(len(body)),
+		Request:       req,
+	}
+}
+
+// sameQuery compares queries key by key, keeping value order significant
+func sameQuery(a, b url.Values) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for key, values := range a {
+		if !reflect.DeepEqual(values, b[key]) {
+			return false
+		}
+	}
+	return true
+}
+
+// sanitizeQuery replaces the values of redacted parameters, returning nil for
+// an empty query
+func (r *Recorder) sanitizeQuery(query url.Values) url.Values {
+	if len(query) == 0 {
+		return nil
+	}
+	for name, values := range query {
+		if !r.params[name] {
+			continue
+		}
+		for i, value := range values {
+			if value != "" {
+				values[i] = Redacted
+			}
+		}
+	}
+	return query
+}
+
+// sanitizeJSON returns a compact copy of a JSON document with redacted fields
+// replaced, or nil when data is empty or not JSON
+func (r *Recorder) sanitizeJSON(data []byte) json.RawMessage {
+	if len(bytes.TrimSpace(data)) == 0 {
+		return nil
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	var value interface{}
+	if err := decoder.Decode(&value); err != nil {
+		return nil
+	}
+
+	out, err := json.Marshal(r.redactValue(value))
+	if err != nil {
+		return nil
+	}
+	return out
+}
+
+func (r *Recorder) redactValue(value interface{}) interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for key, field := range v {
+			if s, ok := field.(string); ok && s != "" && r.redact[key] {
+				v[key] = Redacted
+				continue
+			}
+			v[key] = r.redactValue(field)
+		}
+		return v
+	case []interface{}:
+		for i := range v {
+			v[i] = r.redactValue(v[i])
+		}
+		return v
+	}
+	return value
+}
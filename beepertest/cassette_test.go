@@ -0,0 +1,84 @@
+package beepertest_test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	beeperdesktop "github.com/cameronaaron/beeper-go-sdk"
+	"github.com/cameronaaron/beeper-go-sdk/beepertest"
+	"github.com/cameronaaron/beeper-go-sdk/resources"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRecorderRecordAndReplay(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "cassettes", "search.json")
+	ctx := context.Background()
+	search := resources.MessageSearchParams{ChatIDs: []string{"chat-alice"}, Query: beeperdesktop.StringPtr("after"), Limit: beeperdesktop.IntPtr(1)}
+
+	server := beepertest.NewServer(beepertest.DefaultFixtures())
+	recorder, err := beepertest.NewRecorder(path, beepertest.ModeAuto)
+	require.NoError(t, err)
+	require.True(t, recorder.Recording())
+
+	client, err := server.Client(beeperdesktop.WithHTTPClient(recorder.Client()))
+	require.NoError(t, err)
+	live, err := client.Messages.Search(ctx, search)
+	require.NoError(t, err)
+	require.Equal(t, "Sure, after 5", *live.Items[0].Text, "callers see unredacted responses while recording")
+	_, err = client.Messages.Send(ctx, resources.MessageSendParams{ChatID: "chat-alice", Text: "secret plans"})
+	require.NoError(t, err)
+	require.NoError(t, recorder.Stop())
+	server.Close()
+
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	cassette := string(data)
+	assert.NotContains(t, cassette, server.Token)
+	assert.NotContains(t, cassette, "Sure, after 5")
+	assert.NotContains(t, cassette, "secret plans")
+	assert.NotContains(t, cassette, `"after"`)
+	assert.Contains(t, cassette, beepertest.Redacted)
+	assert.Contains(t, cassette, "chatIDs[0]")
+
+	// Replay works with the server gone
+	replayer, err := beepertest.NewRecorder(path, beepertest.ModeAuto)
+	require.NoError(t, err)
+	require.False(t, replayer.Recording())
+
+	client, err = beeperdesktop.New(
+		beeperdesktop.WithAccessToken("any"),
+		beeperdesktop.WithBaseURL("http://desktop.invalid"),
+		beeperdesktop.WithMaxRetries(0),
+		beeperdesktop.WithHTTPClient(replayer.Client()),
+	)
+	require.NoError(t, err)
+
+	replayed, err := client.Messages.Search(ctx, search)
+	require.NoError(t, err)
+	assert.Equal(t, live.Items[0].ID, replayed.Items[0].ID)
+	assert.Equal(t, beepertest.Redacted, *replayed.Items[0].Text)
+	assert.Equal(t, live.Pagination.Cursor, replayed.Pagination.Cursor)
+	assert.Len(t, replayer.Unused(), 1)
+
+	// Only the redacted search text may differ from the recording
+	replayer, err = beepertest.NewRecorder(path, beepertest.ModeReplay)
+	require.NoError(t, err)
+	client, err = beeperdesktop.New(
+		beeperdesktop.WithAccessToken("any"),
+		beeperdesktop.WithBaseURL("http://desktop.invalid"),
+		beeperdesktop.WithMaxRetries(0),
+		beeperdesktop.WithHTTPClient(replayer.Client()),
+	)
+	require.NoError(t, err)
+	other := search
+	other.Query = beeperdesktop.StringPtr("before")
+	_, err = client.Messages.Search(ctx, other)
+	require.NoError(t, err)
+	other.ChatIDs = []string{"chat-team"}
+	_, err = client.Messages.Search(ctx, other)
+	var mismatch *beepertest.ReplayMismatchError
+	require.ErrorAs(t, err, &mismatch)
+}
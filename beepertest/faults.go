@@ -0,0 +1,370 @@
+package beepertest
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math/rand"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync"
+	"syscall"
+	"time"
+)
+
+// FaultKind selects the failure a Fault injects
+type FaultKind string
+
+const (
+	// FaultLatency delays the request by Fault.Latency and then lets it through
+	FaultLatency FaultKind = "latency"
+	// FaultReset drops the connection without a response
+	FaultReset FaultKind = "reset"
+	// FaultRateLimit answers 429 with a Retry-After header
+	FaultRateLimit FaultKind = "rate_limit"
+	// FaultServerError answers with a 5xx status, optionally for a burst of requests
+	FaultServerError FaultKind = "server_error"
+	// FaultTruncate cuts the JSON response body in half
+	FaultTruncate FaultKind = "truncate"
+	// FaultRepeatCursor returns the request's own cursor as the next cursor, so
+	// the client fetches the same page again
+	FaultRepeatCursor FaultKind = "repeat_cursor"
+	// FaultEmptyPage drops the items of a page while still reporting has_more
+	FaultEmptyPage FaultKind = "empty_page"
+)
+
+// Fault describes a failure to inject into matching requests
+type Fault struct {
+	Kind FaultKind
+	// Method and Path restrict the fault to one endpoint. Empty values match any.
+	Method string
+	Path   string
+	// Probability is the chance a matching request is affected. Zero or less
+	// means always.
+	Probability float64
+	// Limit caps how many times the fault fires. Zero means unlimited.
+	Limit int
+
+	Latency    time.Duration // FaultLatency delay
+	RetryAfter time.Duration // FaultRateLimit Retry-After, defaults to 1 second
+	Status     int           // FaultServerError status, defaults to 503
+	Burst      int           // FaultServerError consecutive failures once triggered
+}
+
+// Injector decides which faults apply to each request. It can wrap a client
+// transport with Transport or a server handler with Handler; the same
+// injector may be shared by both.
+type Injector struct {
+	mu     sync.Mutex
+	faults []Fault
+	rand   *rand.Rand
+	fired  []int
+	burst  []int
+	counts map[FaultKind]int
+}
+
+// NewInjector creates an injector. The seed makes probabilistic faults
+// reproducible across runs.
+func NewInjector(seed int64, faults ...Fault) *Injector {
+	return &Injector{
+		faults: faults,
+		rand:   rand.New(rand.NewSource(seed)),
+		fired:  make([]int, len(faults)),
+		burst:  make([]int, len(faults)),
+		counts: make(map[FaultKind]int),
+	}
+}
+
+// Count returns how many times faults of the given kind were injected
+func (i *Injector) Count(kind FaultKind) int {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	return i.counts[kind]
+}
+
+// plan is the set of faults chosen for one request
+type plan struct {
+	latency time.Duration
+	fault   *Fault
+}
+
+// choose rolls every matching fault. Latency faults accumulate; the first
+// other fault that fires decides the outcome.
+func (i *Injector) choose(r *http.Request) plan {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	var p plan
+	for idx := range i.faults {
+		fault := &i.faults[idx]
+		if fault.Method != "" && fault.Method != r.Method {
+			continue
+		}
+		if fault.Path != "" && fault.Path != r.URL.Path {
+			continue
+		}
+		if fault.Kind != FaultLatency && p.fault != nil {
+			continue
+		}
+		if !i.fires(idx) {
+			continue
+		}
+
+		if fault.Kind == FaultLatency {
+			p.latency += fault.Latency
+			i.counts[fault.Kind]++
+			continue
+		}
+		p.fault = fault
+	}
+	return p
+}
+
+// fires reports whether fault idx applies to the current request. Callers
+// must hold i.mu.
+func (i *Injector) fires(idx int) bool {
+	fault := i.faults[idx]
+	if fault.Limit > 0 && i.fired[idx] >= fault.Limit {
+		return false
+	}
+
+	if i.burst[idx] > 0 {
+		i.burst[idx]--
+	} else {
+		if fault.Probability > 0 && i.rand.Float64() >= fault.Probability {
+			return false
+		}
+		if fault.Kind == FaultServerError && fault.Burst > 1 {
+			i.burst[idx] = fault.Burst - 1
+		}
+	}
+
+	i.fired[idx]++
+	return true
+}
+
+// record counts an injected fault
+func (i *Injector) record(kind FaultKind) {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	i.counts[kind]++
+}
+
+// Transport returns a RoundTripper that injects faults in front of base, or
+// http.DefaultTransport when base is nil
+func (i *Injector) Transport(base http.RoundTripper) http.RoundTripper {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	return &faultTransport{injector: i, base: base}
+}
+
+type faultTransport struct {
+	injector *Injector
+	base     http.RoundTripper
+}
+
+func (t *faultTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	p := t.injector.choose(req)
+	if err := wait(req.Context(), p.latency); err != nil {
+		return nil, err
+	}
+
+	if p.fault != nil {
+		switch p.fault.Kind {
+		case FaultReset:
+			t.injector.record(FaultReset)
+			return nil, &net.OpError{Op: "read", Net: "tcp", Err: fmt.Errorf("beepertest: injected reset: %w", syscall.ECONNRESET)}
+		case FaultRateLimit, FaultServerError:
+			t.injector.record(p.fault.Kind)
+			rec := httptest.NewRecorder()
+			writeFault(rec, p.fault)
+			resp := rec.Result()
+			resp.Request = req
+			return resp, nil
+		}
+	}
+
+	resp, err := t.base.RoundTrip(req)
+	if err != nil || p.fault == nil {
+		return resp, err
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	if rewritten, ok := rewriteBody(p.fault, req, resp.StatusCode, body); ok {
+		t.injector.record(p.fault.Kind)
+		body = rewritten
+	}
+	resp.Body = io.NopCloser(bytes.NewReader(body))
+	resp.ContentLength = int64(len(body))
+	resp.Header.Set("Content-Length", strconv.Itoa(len(body)))
+	return resp, nil
+}
+
+// Handler returns a handler that injects faults in front of next. Response
+// rewriting faults are not applied to event streams.
+func (i *Injector) Handler(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		p := i.choose(r)
+		if err := wait(r.Context(), p.latency); err != nil {
+			return
+		}
+
+		if p.fault == nil {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		switch p.fault.Kind {
+		case FaultReset:
+			i.record(FaultReset)
+			resetConnection(w)
+			return
+		case FaultRateLimit, FaultServerError:
+			i.record(p.fault.Kind)
+			writeFault(w, p.fault)
+			return
+		}
+
+		if r.Header.Get("Accept") == "text/event-stream" {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		rec := httptest.NewRecorder()
+		next.ServeHTTP(rec, r)
+		body := rec.Body.Bytes()
+		if rewritten, ok := rewriteBody(p.fault, r, rec.Code, body); ok {
+			i.record(p.fault.Kind)
+			body = rewritten
+		}
+
+		for key, values := range rec.Header() {
+			w.Header()[key] = values
+		}
+		w.Header().Set("Content-Length", strconv.Itoa(len(body)))
+		w.WriteHeader(rec.Code)
+		_, _ = w.Write(body)
+	})
+}
+
+// WithFaults runs the server's handler behind the injector
+func WithFaults(injector *Injector) Option {
+	return func(s *Server) {
+		s.faults = injector
+	}
+}
+
+// writeFault writes the error response of a rate limit or server error fault
+func writeFault(w http.ResponseWriter, fault *Fault) {
+	if fault.Kind == FaultRateLimit {
+		retryAfter := fault.RetryAfter
+		if retryAfter <= 0 {
+			retryAfter = time.Second
+		}
+		seconds := int((retryAfter + time.Second - 1) / time.Second)
+		w.Header().Set("Retry-After", strconv.Itoa(seconds))
+		writeError(w, http.StatusTooManyRequests, "rate limited")
+		return
+	}
+
+	status := fault.Status
+	if status == 0 {
+		status = http.StatusServiceUnavailable
+	}
+	writeError(w, status, http.StatusText(status))
+}
+
+// resetConnection closes the underlying connection without a response, with
+// SO_LINGER set to zero so the client sees a reset rather than EOF
+func resetConnection(w http.ResponseWriter) {
+	hijacker, ok := w.(http.Hijacker)
+	if !ok {
+		panic(http.ErrAbortHandler)
+	}
+	conn, _, err := hijacker.Hijack()
+	if err != nil {
+		panic(http.ErrAbortHandler)
+	}
+	if tcp, ok := conn.(*net.TCPConn); ok {
+		_ = tcp.SetLinger(0)
+	}
+	conn.Close()
+}
+
+// rewriteBody applies a body-level fault to a successful response. It reports
+// false when the fault does not apply, such as a page anomaly on an endpoint
+// that is not paginated.
+func rewriteBody(fault *Fault, r *http.Request, status int, body []byte) ([]byte, bool) {
+	if status >= 300 || len(body) == 0 {
+		return nil, false
+	}
+
+	switch fault.Kind {
+	case FaultTruncate:
+		return body[:len(body)/2], true
+	case FaultRepeatCursor, FaultEmptyPage:
+		return rewritePage(fault.Kind, body, r.URL.Query().Get("cursor"))
+	}
+	return nil, false
+}
+
+// rewritePage points the next cursor back at the requested page and, for
+// FaultEmptyPage, drops the items
+func rewritePage(kind FaultKind, body []byte, requestCursor string) ([]byte, bool) {
+	var page map[string]json.RawMessage
+	if err := json.Unmarshal(body, &page); err != nil {
+		return nil, false
+	}
+	if _, ok := page["items"]; !ok {
+		return nil, false
+	}
+
+	pagination := map[string]interface{}{}
+	if raw, ok := page["pagination"]; ok {
+		_ = json.Unmarshal(raw, &pagination)
+	}
+	pagination["has_more"] = true
+	delete(pagination, "cursor")
+	if requestCursor != "" {
+		pagination["cursor"] = requestCursor
+	}
+
+	if kind == FaultEmptyPage {
+		page["items"] = json.RawMessage("[]")
+	}
+	rawPagination, err := json.Marshal(pagination)
+	if err != nil {
+		return nil, false
+	}
+	page["pagination"] = rawPagination
+
+	out, err := json.Marshal(page)
+	if err != nil {
+		return nil, false
+	}
+	return append(out, '\n'), true
+}
+
+// wait sleeps for d unless the context ends first
+func wait(ctx context.Context, d time.Duration) error {
+	if d <= 0 {
+		return nil
+	}
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,141 @@
+package beepertest_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	beeperdesktop "github.com/cameronaaron/beeper-go-sdk"
+	"github.com/cameronaaron/beeper-go-sdk/beepertest"
+	"github.com/cameronaaron/beeper-go-sdk/resources"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTransportFaults(t *testing.T) {
+	server := beepertest.NewServer(beepertest.DefaultFixtures())
+	defer server.Close()
+
+	injector := beepertest.NewInjector(1,
+		beepertest.Fault{Kind: beepertest.FaultServerError, Path: "/v0/get-accounts", Burst: 2, Limit: 2},
+		beepertest.Fault{Kind: beepertest.FaultRateLimit, Path: "/v0/search-users", RetryAfter: 3 * time.Second, Limit: 1},
+		beepertest.Fault{Kind: beepertest.FaultReset, Path: "/oauth/userinfo", Limit: 1},
+		beepertest.Fault{Kind: beepertest.FaultTruncate, Path: "/v0/get-chat", Limit: 1},
+	)
+	httpClient := &http.Client{Transport: injector.Transport(nil)}
+	client, err := server.Client(beeperdesktop.WithHTTPClient(httpClient))
+	require.NoError(t, err)
+	ctx := context.Background()
+
+	var serverErr *beeperdesktop.InternalServerError
+	for i := 0; i < 2; i++ {
+		_, err = client.Accounts.List(ctx)
+		require.ErrorAs(t, err, &serverErr)
+		assert.Equal(t, http.StatusServiceUnavailable, serverErr.Status)
+	}
+	_, err = client.Accounts.List(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 2, injector.Count(beepertest.FaultServerError))
+
+	req, err := http.NewRequest("GET", server.URL+"/v0/search-users?accountID=matrix", nil)
+	require.NoError(t, err)
+	req.Header.Set("Authorization", "Bearer "+server.Token)
+	resp, err := httpClient.Do(req)
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusTooManyRequests, resp.StatusCode)
+	assert.Equal(t, "3", resp.Header.Get("Retry-After"))
+
+	_, err = client.Token.Info(ctx)
+	var connErr *beeperdesktop.APIConnectionError
+	require.ErrorAs(t, err, &connErr)
+
+	_, err = client.Chats.Retrieve(ctx, resources.ChatRetrieveParams{ChatID: "chat-team"})
+	assert.ErrorContains(t, err, "failed to unmarshal response")
+	_, err = client.Chats.Retrieve(ctx, resources.ChatRetrieveParams{ChatID: "chat-team"})
+	assert.NoError(t, err)
+}
+
+func TestServerFaultMode(t *testing.T) {
+	injector := beepertest.NewInjector(1,
+		beepertest.Fault{Kind: beepertest.FaultEmptyPage, Path: "/v0/get-chat-participants", Limit: 1},
+		beepertest.Fault{Kind: beepertest.FaultRepeatCursor, Path: "/v0/search-messages", Limit: 1},
+		beepertest.Fault{Kind: beepertest.FaultLatency, Path: "/v0/get-accounts", Latency: 200 * time.Millisecond},
+		beepertest.Fault{Kind: beepertest.FaultReset, Path: "/v0/search-users", Limit: 1},
+	)
+	server := beepertest.NewServer(beepertest.DefaultFixtures(), beepertest.WithFaults(injector))
+	defer server.Close()
+
+	client, err := server.Client()
+	require.NoError(t, err)
+	ctx := context.Background()
+
+	// An empty page still reports has_more
+	users, err := client.Chats.Participants.List(ctx, resources.ParticipantListParams{ChatID: "chat-team"})
+	require.NoError(t, err)
+	assert.Empty(t, users.Items)
+	require.NotNil(t, users.Pagination)
+	assert.True(t, users.Pagination.HasMore)
+	assert.Equal(t, 1, injector.Count(beepertest.FaultEmptyPage))
+
+	// A repeated cursor points back at the page just served, here the first
+	messages, err := client.Messages.Search(ctx, resources.MessageSearchParams{Limit: beeperdesktop.IntPtr(3)})
+	require.NoError(t, err)
+	assert.Len(t, messages.Items, 3)
+	require.NotNil(t, messages.Pagination)
+	assert.True(t, messages.Pagination.HasMore)
+	assert.Nil(t, messages.Pagination.Cursor)
+	assert.Equal(t, 1, injector.Count(beepertest.FaultRepeatCursor))
+
+	timeoutCtx, cancel := context.WithTimeout(ctx, 50*time.Millisecond)
+	defer cancel()
+	_, err = client.Accounts.List(timeoutCtx)
+	assert.True(t, errors.Is(err, context.DeadlineExceeded), "got %v", err)
+
+	_, err = client.Contacts.Search(ctx, resources.ContactSearchParams{AccountID: "matrix"})
+	var connErr *beeperdesktop.APIConnectionError
+	require.ErrorAs(t, err, &connErr)
+	_, err = client.Contacts.Search(ctx, resources.ContactSearchParams{AccountID: "matrix"})
+	assert.NoError(t, err)
+}
+
+func TestIteratorFaults(t *testing.T) {
+	ctx := context.Background()
+	iterate := func(fault beepertest.Fault, next func(client *beeperdesktop.BeeperDesktop) error) error {
+		server := beepertest.NewServer(beepertest.DefaultFixtures(), beepertest.WithFaults(beepertest.NewInjector(1, fault)))
+		defer server.Close()
+		client, err := server.Client()
+		require.NoError(t, err)
+		return next(client)
+	}
+
+	// Empty pages that still report has_more are skipped, up to a bound
+	var users []resources.User
+	err := iterate(beepertest.Fault{Kind: beepertest.FaultEmptyPage, Path: "/v0/get-chat-participants", Limit: 2}, func(client *beeperdesktop.BeeperDesktop) (err error) {
+		users, err = client.NewParticipantIterator(resources.ParticipantListParams{ChatID: "chat-team"}).ToSlice(ctx)
+		return err
+	})
+	require.NoError(t, err)
+	assert.Len(t, users, 3)
+
+	err = iterate(beepertest.Fault{Kind: beepertest.FaultEmptyPage, Path: "/v0/get-chat-participants"}, func(client *beeperdesktop.BeeperDesktop) error {
+		_, err := client.NewParticipantIterator(resources.ParticipantListParams{ChatID: "chat-team"}).ToSlice(ctx)
+		return err
+	})
+	assert.EqualError(t, err, "pagination stalled after 5 empty pages")
+
+	// A page that points back at a fetched cursor would loop
+	err = iterate(beepertest.Fault{Kind: beepertest.FaultRepeatCursor, Path: "/v0/search-chats"}, func(client *beeperdesktop.BeeperDesktop) error {
+		_, err := client.NewChatIterator(resources.ChatSearchParams{Cursor: beeperdesktop.StringPtr("1"), Limit: beeperdesktop.IntPtr(1)}).ToSlice(ctx)
+		return err
+	})
+	assert.EqualError(t, err, `pagination cursor "1" repeated`)
+
+	err = iterate(beepertest.Fault{Kind: beepertest.FaultRepeatCursor, Path: "/v0/search-messages"}, func(client *beeperdesktop.BeeperDesktop) error {
+		_, err := client.NewMessageIterator(resources.MessageSearchParams{Limit: beeperdesktop.IntPtr(3)}).ToSlice(ctx)
+		return err
+	})
+	assert.EqualError(t, err, `pagination cursor "" repeated`)
+}
@@ -0,0 +1,95 @@
+package beepertest
+
+import (
+	"time"
+
+	"github.com/cameronaaron/beeper-go-sdk/resources"
+)
+
+// Fixtures seeds the state of a fake server
+type Fixtures struct {
+	Accounts  []resources.Account
+	Chats     []resources.Chat
+	Messages  []resources.Message
+	Reminders []resources.Reminder
+
+	// Contacts lists the users searchable through Contacts.Search, by account ID
+	Contacts map[string][]resources.User
+
+	// UserInfo is returned by Token.Info. A default is generated when nil.
+	UserInfo *resources.UserInfo
+
+	// RestrictedNetworks lists networks that reject participant changes
+	RestrictedNetworks []string
+}
+
+// DefaultFixtures returns a small, realistic data set with two accounts, a
+// direct chat, a group chat and a muted chat
+func DefaultFixtures() Fixtures {
+	base := time.Date(2024, 6, 1, 9, 0, 0, 0, time.UTC)
+
+	self := func(id, name string) resources.User {
+		return resources.User{ID: id, FullName: strPtr(name), IsSelf: boolPtr(true)}
+	}
+	alice := resources.User{ID: "@alice:beeper.com", FullName: strPtr("Alice Smith"), Username: strPtr("alice"), Email: strPtr("alice@example.com")}
+	bob := resources.User{ID: "@bob:beeper.com", FullName: strPtr("Bob Jones"), Username: strPtr("bob"), PhoneNumber: strPtr("+15555550100")}
+	carol := resources.User{ID: "+15555550123", FullName: strPtr("Carol White"), PhoneNumber: strPtr("+15555550123")}
+	me := self("@me:beeper.com", "Test User")
+	meWhatsApp := self("+15555550199", "Test User")
+
+	text := func(id, chatID, accountID, senderID, senderName, body string, minutes int) resources.Message {
+		return resources.Message{
+			ID:         id,
+			MessageID:  id,
+			ChatID:     chatID,
+			AccountID:  accountID,
+			SenderID:   senderID,
+			SenderName: strPtr(senderName),
+			Text:       strPtr(body),
+			Timestamp:  resources.NewTimestamp(base.Add(time.Duration(minutes) * time.Minute)),
+		}
+	}
+
+	photo := text("msg-4", "chat-team", "matrix", alice.ID, "Alice Smith", "Launch screenshot", 30)
+	photo.Attachments = []resources.Attachment{{Type: "img", FileName: strPtr("launch.png"), MimeType: strPtr("image/png")}}
+	photo.Reactions = []resources.Reaction{{ID: "react-1", ParticipantID: bob.ID, ReactionKey: "🎉", Emoji: boolPtr(true)}}
+
+	return Fixtures{
+		Accounts: []resources.Account{
+			{AccountID: "matrix", Network: "Beeper (Matrix)", User: me},
+			{AccountID: "whatsapp", Network: "WhatsApp", User: meWhatsApp},
+		},
+		Chats: []resources.Chat{
+			{ID: "chat-alice", AccountID: "matrix", Network: "Beeper (Matrix)", Title: "Alice Smith", Type: "single",
+				Participants: participants(me, alice)},
+			{ID: "chat-team", AccountID: "matrix", Network: "Beeper (Matrix)", Title: "Project Updates", Type: "group", UnreadCount: 2,
+				Participants: participants(me, alice, bob), IsPinned: boolPtr(true)},
+			{ID: "chat-family", AccountID: "whatsapp", Network: "WhatsApp", Title: "Family", Type: "group",
+				Participants: participants(meWhatsApp, carol), IsMuted: boolPtr(true)},
+		},
+		Messages: []resources.Message{
+			text("msg-1", "chat-alice", "matrix", alice.ID, "Alice Smith", "Hey, are you free later?", 0),
+			text("msg-2", "chat-alice", "matrix", me.ID, "Test User", "Sure, after 5", 5),
+			text("msg-3", "chat-team", "matrix", bob.ID, "Bob Jones", "Release is on track", 20),
+			photo,
+			text("msg-5", "chat-family", "whatsapp", carol.ID, "Carol White", "Dinner on Sunday?", 45),
+		},
+		Contacts: map[string][]resources.User{
+			"matrix":   {alice, bob},
+			"whatsapp": {carol},
+		},
+		RestrictedNetworks: []string{"WhatsApp"},
+	}
+}
+
+func participants(users ...resources.User) resources.ChatParticipants {
+	return resources.ChatParticipants{Items: users, Total: len(users)}
+}
+
+func strPtr(s string) *string {
+	return &s
+}
+
+func boolPtr(b bool) *bool {
+	return &b
+}
@@ -0,0 +1,773 @@
+package beepertest
+
+import (
+	"net/http"
+	"path"
+	"sort"
+	"strings"
+
+	beeperdesktop "github.com/cameronaaron/beeper-go-sdk"
+	"github.com/cameronaaron/beeper-go-sdk/resources"
+)
+
+const (
+	// defaultLimit is the page size used when a request does not set one
+	defaultLimit = 20
+	// maxLimit caps the page size a request may ask for
+	maxLimit = 200
+)
+
+// routeFeatures maps the routes of features older Desktop builds lack to
+// the feature
+var routeFeatures = map[string]string{
+	"GET /v0/get-chat-participants":     beeperdesktop.FeatureParticipants,
+	"POST /v0/add-chat-participants":    beeperdesktop.FeatureParticipants,
+	"POST /v0/remove-chat-participants": beeperdesktop.FeatureParticipants,
+	"GET /v0/get-chat-reminders":        beeperdesktop.FeatureReminderLookup,
+	"GET /v0/get-chat-reminder":         beeperdesktop.FeatureReminderLookup,
+	"GET " + streamPath:                 beeperdesktop.FeatureEvents,
+}
+
+func (s *Server) buildRoutes() map[string]handlerFunc {
+	routes := map[string]handlerFunc{
+		"GET /v0/get-info":                  s.handleGetInfo,
+		"GET /oauth/userinfo":               s.handleUserInfo,
+		"GET /v0/get-accounts":              s.handleGetAccounts,
+		"POST /v0/create-chat":              s.handleCreateChat,
+		"GET /v0/get-chat":                  s.handleGetChat,
+		"POST /v0/archive-chat":             s.handleArchiveChat,
+		"GET /v0/search-chats":              s.handleSearchChats,
+		"GET /v0/get-chat-participants":     s.handleGetParticipants,
+		"POST /v0/add-chat-participants":    s.handleUpdateParticipants(true),
+		"POST /v0/remove-chat-participants": s.handleUpdateParticipants(false),
+		"POST /v0/set-chat-reminder":        s.handleSetReminder,
+		"POST /v0/clear-chat-reminder":      s.handleClearReminder,
+		"GET /v0/get-chat-reminders":        s.handleGetReminders,
+		"GET /v0/get-chat-reminder":         s.handleGetReminder,
+		"GET /v0/search-messages":           s.handleSearchMessages,
+		"POST /v0/send-message":             s.handleSendMessage,
+		"GET /v0/search-users":              s.handleSearchUsers,
+		"GET /v0/search":                    s.handleSearch,
+		"POST /v0/download-asset":           s.handleDownloadAsset,
+		"POST /v0/open-app":                 s.handleOpenApp,
+		"GET " + streamPath:                 s.handleEvents,
+	}
+	for route, feature := range routeFeatures {
+		if s.disabled[feature] {
+			delete(routes, route)
+		}
+	}
+	if s.noInfo {
+		delete(routes, "GET /v0/get-info")
+	}
+	return routes
+}
+
+func (s *Server) handleGetInfo(w http.ResponseWriter, r *request) {
+	info := beeperdesktop.Capabilities{Version: s.version, Features: []string{}}
+	for _, feature := range []string{beeperdesktop.FeatureParticipants, beeperdesktop.FeatureReminderLookup, beeperdesktop.FeatureEvents} {
+		if !s.disabled[feature] {
+			info.Features = append(info.Features, feature)
+		}
+	}
+	writeJSON(w, http.StatusOK, info)
+}
+
+func (s *Server) handleUserInfo(w http.ResponseWriter, r *request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	writeJSON(w, http.StatusOK, s.userInfo)
+}
+
+func (s *Server) handleGetAccounts(w http.ResponseWriter, r *request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	writeJSON(w, http.StatusOK, resources.AccountListResponse(s.accounts))
+}
+
+func (s *Server) handleCreateChat(w http.ResponseWriter, r *request) {
+	var params resources.ChatCreateParams
+	if err := r.decode(&params); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	account := s.findAccount(params.AccountID)
+	switch {
+	case account == nil:
+		writeError(w, http.StatusNotFound, "account not found")
+		return
+	case len(params.ParticipantIDs) == 0:
+		writeError(w, http.StatusBadRequest, "participantIDs is required")
+		return
+	case params.Type != "single" && params.Type != "group":
+		writeError(w, http.StatusBadRequest, "type must be single or group")
+		return
+	case params.Type == "single" && len(params.ParticipantIDs) != 1:
+		writeError(w, http.StatusBadRequest, "single chats take exactly one participant")
+		return
+	}
+
+	users := []resources.User{account.User}
+	var names []string
+	for _, id := range params.ParticipantIDs {
+		user := s.resolveUser(account.AccountID, id)
+		users = append(users, user)
+		names = append(names, displayName(user))
+	}
+
+	title := strings.Join(names, ", ")
+	if params.Title != nil && *params.Title != "" {
+		title = *params.Title
+	}
+
+	now := resources.NewTimestamp(s.now())
+	chat := &resources.Chat{
+		ID:           s.newID("chat"),
+		AccountID:    account.AccountID,
+		Network:      account.Network,
+		Title:        title,
+		Type:         params.Type,
+		Participants: participants(users...),
+		LastActivity: &now,
+	}
+	s.chats = append(s.chats, chat)
+	s.publishChat(chat)
+
+	writeJSON(w, http.StatusOK, resources.ChatCreateResponse{Chat: copyChat(chat), Success: true})
+}
+
+func (s *Server) handleGetChat(w http.ResponseWriter, r *request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	chat := s.findChat(r.str("chatID"))
+	if chat == nil {
+		writeError(w, http.StatusNotFound, "chat not found")
+		return
+	}
+	writeJSON(w, http.StatusOK, copyChat(chat))
+}
+
+func (s *Server) handleArchiveChat(w http.ResponseWriter, r *request) {
+	var params resources.ChatArchiveParams
+	if err := r.decode(&params); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	chat := s.findChat(params.ChatID)
+	if chat == nil {
+		writeError(w, http.StatusNotFound, "chat not found")
+		return
+	}
+	archived := params.Archived
+	chat.IsArchived = &archived
+	s.publishChat(chat)
+
+	writeJSON(w, http.StatusOK, resources.BaseResponse{Success: true})
+}
+
+func (s *Server) handleSearchChats(w http.ResponseWriter, r *request) {
+	limit, err := pageLimit(r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	chats := s.filterChats(r.strs("accountIDs"), r.str("chatType"), r.boolean("includeMuted"))
+	if query := r.str("query"); query != "" {
+		scope := r.str("scope")
+		matched := chats[:0]
+		for _, chat := range chats {
+			if chatMatches(chat, query, scope) {
+				matched = append(matched, chat)
+			}
+		}
+		chats = matched
+	}
+
+	page, pagination, err := paginate(chats, r.str("cursor"), limit)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	writeJSON(w, http.StatusOK, resources.ChatsCursor{Items: page, Pagination: pagination})
+}
+
+// filterChats returns copies of the matching chats, most recently active first
+func (s *Server) filterChats(accountIDs []string, chatType string, includeMuted *bool) []resources.Chat {
+	var chats []resources.Chat
+	for _, chat := range s.chats {
+		if len(accountIDs) > 0 && !containsString(accountIDs, chat.AccountID) {
+			continue
+		}
+		if chatType != "" && chatType != "any" && chat.Type != chatType {
+			continue
+		}
+		if includeMuted != nil && !*includeMuted && chat.IsMuted != nil && *chat.IsMuted {
+			continue
+		}
+		chats = append(chats, copyChat(chat))
+	}
+
+	sort.SliceStable(chats, func(i, j int) bool {
+		a, b := chats[i].LastActivity, chats[j].LastActivity
+		if a == nil || b == nil {
+			return a != nil
+		}
+		return a.After(b.Time)
+	})
+	return chats
+}
+
+// chatMatches applies a chat search query to the title, the participants or
+// both, depending on scope
+func chatMatches(chat resources.Chat, query, scope string) bool {
+	if scope != "participants" && containsFold(query, &chat.Title) {
+		return true
+	}
+	if scope == "titles" {
+		return false
+	}
+	for _, user := range chat.Participants.Items {
+		if containsFold(query, user.FullName, user.Username, &user.ID) {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *Server) handleGetParticipants(w http.ResponseWriter, r *request) {
+	limit, err := pageLimit(r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	chat := s.findChat(r.str("chatID"))
+	if chat == nil {
+		writeError(w, http.StatusNotFound, "chat not found")
+		return
+	}
+
+	users := append([]resources.User(nil), chat.Participants.Items...)
+	page, pagination, err := paginate(users, r.str("cursor"), limit)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	writeJSON(w, http.StatusOK, resources.ParticipantsCursor{Items: page, Pagination: pagination})
+}
+
+// handleUpdateParticipants adds or removes participants. Direct chats and
+// restricted networks reject changes with 403, as the real bridges do.
+func (s *Server) handleUpdateParticipants(add bool) handlerFunc {
+	return func(w http.ResponseWriter, r *request) {
+		var params resources.ParticipantUpdateParams
+		if err := r.decode(&params); err != nil {
+			writeError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+
+		s.mu.Lock()
+		defer s.mu.Unlock()
+
+		chat := s.findChat(params.ChatID)
+		switch {
+		case chat == nil:
+			writeError(w, http.StatusNotFound, "chat not found")
+			return
+		case len(params.ParticipantIDs) == 0:
+			writeError(w, http.StatusBadRequest, "participantIDs is required")
+			return
+		case chat.Type != "group" || s.restricted[chat.Network]:
+			writeError(w, http.StatusForbidden, chat.Network+" does not allow changing participants of this chat")
+			return
+		}
+
+		items := chat.Participants.Items
+		for _, id := range params.ParticipantIDs {
+			idx := -1
+			for i, user := range items {
+				if user.ID == id {
+					idx = i
+					break
+				}
+			}
+			switch {
+			case add && idx < 0:
+				items = append(items, s.resolveUser(chat.AccountID, id))
+			case !add && idx >= 0:
+				items = append(items[:idx:idx], items[idx+1:]...)
+			}
+		}
+		chat.Participants = participants(items...)
+		s.publishChat(chat)
+
+		writeJSON(w, http.StatusOK, resources.BaseResponse{Success: true})
+	}
+}
+
+func (s *Server) handleSetReminder(w http.ResponseWriter, r *request) {
+	var params resources.ReminderCreateParams
+	if err := r.decode(&params); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if params.Timestamp.IsZero() {
+		writeError(w, http.StatusBadRequest, "timestamp is required")
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	chat := s.findChat(params.ChatID)
+	if chat == nil {
+		writeError(w, http.StatusNotFound, "chat not found")
+		return
+	}
+	s.reminders[chat.ID] = resources.Reminder{
+		ChatID:    chat.ID,
+		AccountID: chat.AccountID,
+		Timestamp: resources.NewTimestamp(params.Timestamp),
+		Message:   params.Message,
+	}
+
+	writeJSON(w, http.StatusOK, resources.BaseResponse{Success: true})
+}
+
+func (s *Server) handleClearReminder(w http.ResponseWriter, r *request) {
+	var params resources.ReminderDeleteParams
+	if err := r.decode(&params); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.findChat(params.ChatID) == nil {
+		writeError(w, http.StatusNotFound, "chat not found")
+		return
+	}
+	delete(s.reminders, params.ChatID)
+
+	writeJSON(w, http.StatusOK, resources.BaseResponse{Success: true})
+}
+
+func (s *Server) handleGetReminders(w http.ResponseWriter, r *request) {
+	accountIDs := r.strs("accountIDs")
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	items := []resources.Reminder{}
+	for _, reminder := range s.reminders {
+		if len(accountIDs) == 0 || containsString(accountIDs, reminder.AccountID) {
+			items = append(items, reminder)
+		}
+	}
+	sort.Slice(items, func(i, j int) bool {
+		if c := items[i].Timestamp.Compare(items[j].Timestamp); c != 0 {
+			return c < 0
+		}
+		return items[i].ChatID < items[j].ChatID
+	})
+
+	writeJSON(w, http.StatusOK, resources.ReminderListResponse{Items: items})
+}
+
+func (s *Server) handleGetReminder(w http.ResponseWriter, r *request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	reminder, ok := s.reminders[r.str("chatID")]
+	if !ok {
+		writeError(w, http.StatusNotFound, "reminder not found")
+		return
+	}
+	writeJSON(w, http.StatusOK, reminder)
+}
+
+// handleSearchMessages returns matching messages newest first, or oldest
+// first with direction=after. The cursor is the sort key of the last message
+// returned, so pages stay stable while new messages arrive.
+func (s *Server) handleSearchMessages(w http.ResponseWriter, r *request) {
+	limit, err := pageLimit(r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	filter, err := parseMessageFilter(r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	ascending := r.str("direction") == "after"
+	cursor := r.str("cursor")
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var matched []resources.Message
+	for _, msg := range s.messages {
+		if !filter.matches(s, msg) {
+			continue
+		}
+		if cursor != "" {
+			c := msg.SortKey.Compare(resources.NewSortKey(cursor))
+			if (ascending && c <= 0) || (!ascending && c >= 0) {
+				continue
+			}
+		}
+		matched = append(matched, copyMessage(msg))
+	}
+
+	sort.SliceStable(matched, func(i, j int) bool {
+		if ascending {
+			return matched[i].SortKey.Less(matched[j].SortKey)
+		}
+		return matched[j].SortKey.Less(matched[i].SortKey)
+	})
+
+	page := matched
+	pagination := &resources.PaginationInfo{Limit: &limit}
+	if len(matched) > limit {
+		page = matched[:limit]
+		next := page[len(page)-1].SortKey.String()
+		pagination.Cursor = &next
+		pagination.HasMore = true
+	}
+	if ascending {
+		direction := "after"
+		pagination.Direction = &direction
+	}
+	if page == nil {
+		page = []resources.Message{}
+	}
+
+	writeJSON(w, http.StatusOK, resources.MessagesCursor{Items: page, Pagination: pagination})
+}
+
+func (s *Server) handleSendMessage(w http.ResponseWriter, r *request) {
+	var params resources.MessageSendParams
+	if err := r.decode(&params); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if params.Text == "" && params.Attachment == nil {
+		writeError(w, http.StatusBadRequest, "text or attachment is required")
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	chat := s.findChat(params.ChatID)
+	if chat == nil {
+		writeError(w, http.StatusNotFound, "chat not found")
+		return
+	}
+
+	isSender := true
+	msg := &resources.Message{
+		ChatID:   chat.ID,
+		IsSender: &isSender,
+	}
+	if account := s.findAccount(chat.AccountID); account != nil {
+		msg.SenderID = account.User.ID
+		msg.SenderName = account.User.FullName
+	}
+	if params.Text != "" {
+		text := params.Text
+		msg.Text = &text
+	}
+	if params.Attachment != nil {
+		name := path.Base(*params.Attachment)
+		msg.Attachments = []resources.Attachment{{Type: "unknown", FileName: &name}}
+	}
+
+	s.fillMessage(msg)
+	s.messages = append(s.messages, msg)
+	s.touchChat(chat.ID, msg.Timestamp)
+	s.publishMessage(eventMessageCreated, msg)
+	s.publishChat(chat)
+
+	writeJSON(w, http.StatusOK, resources.MessageSendResponse{
+		MessageID: msg.ID,
+		Deeplink:  "beeper://chat/" + chat.ID + "/message/" + msg.ID,
+		Success:   true,
+	})
+}
+
+func (s *Server) handleSearchUsers(w http.ResponseWriter, r *request) {
+	accountID := r.str("accountID")
+	if accountID == "" {
+		writeError(w, http.StatusBadRequest, "accountID is required")
+		return
+	}
+	query := r.str("query")
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.findAccount(accountID) == nil {
+		writeError(w, http.StatusNotFound, "account not found")
+		return
+	}
+
+	items := []resources.User{}
+	for _, user := range s.contacts[accountID] {
+		if query == "" || containsFold(query, user.FullName, user.Username, user.Email, user.PhoneNumber, &user.ID) {
+			items = append(items, user)
+		}
+	}
+	writeJSON(w, http.StatusOK, resources.ContactSearchResponse{Items: items})
+}
+
+func (s *Server) handleSearch(w http.ResponseWriter, r *request) {
+	query := r.str("query")
+	if query == "" {
+		writeError(w, http.StatusBadRequest, "query is required")
+		return
+	}
+	chatLimit, err := r.integer("limit", defaultLimit)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	messageLimit, err := r.integer("messageLimit", defaultLimit)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	participantLimit, err := r.integer("participantLimit", defaultLimit)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	accountIDs := r.strs("accountIDs")
+	chats := s.filterChats(accountIDs, r.str("chatType"), r.boolean("includeMuted"))
+
+	response := resources.AppSearchResponse{
+		Chats:    []resources.ChatSearchResult{},
+		Messages: []resources.MessageSearchResult{},
+	}
+	for _, chat := range chats {
+		if len(response.Chats) >= chatLimit {
+			break
+		}
+		if !chatMatches(chat, query, "") {
+			continue
+		}
+		users := chat.Participants.Items
+		if len(users) > participantLimit {
+			users = users[:participantLimit]
+		}
+		response.Chats = append(response.Chats, resources.ChatSearchResult{Chat: chat, Participants: users, Messages: []resources.Message{}})
+	}
+
+	for i := len(s.messages) - 1; i >= 0 && len(response.Messages) < messageLimit; i-- {
+		msg := s.messages[i]
+		if !containsFold(query, msg.Text) {
+			continue
+		}
+		chat := s.findChat(msg.ChatID)
+		if chat == nil || (len(accountIDs) > 0 && !containsString(accountIDs, chat.AccountID)) {
+			continue
+		}
+		response.Messages = append(response.Messages, resources.MessageSearchResult{Message: copyMessage(msg), Chat: copyChat(chat)})
+	}
+
+	writeJSON(w, http.StatusOK, response)
+}
+
+func (s *Server) handleDownloadAsset(w http.ResponseWriter, r *request) {
+	var params resources.AppDownloadAssetParams
+	if err := r.decode(&params); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if params.AssetURL == "" {
+		writeError(w, http.StatusBadRequest, "assetUrl is required")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, resources.AppDownloadAssetResponse{
+		LocalPath: path.Join("/tmp/beepertest/assets", path.Base(params.AssetURL)),
+		Success:   true,
+	})
+}
+
+func (s *Server) handleOpenApp(w http.ResponseWriter, r *request) {
+	var params resources.AppOpenParams
+	if err := r.decode(&params); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	if params.ChatID != nil {
+		s.mu.Lock()
+		chat := s.findChat(*params.ChatID)
+		s.mu.Unlock()
+		if chat == nil {
+			writeError(w, http.StatusNotFound, "chat not found")
+			return
+		}
+	}
+
+	writeJSON(w, http.StatusOK, resources.AppOpenResponse{Success: true})
+}
+
+// messageFilter holds the message search filters of a request
+type messageFilter struct {
+	accountIDs   []string
+	chatIDs      []string
+	senderIDs    []string
+	mediaTypes   []string
+	chatType     string
+	query        string
+	includeMuted *bool
+	after        *resources.Timestamp
+	before       *resources.Timestamp
+}
+
+func parseMessageFilter(r *request) (*messageFilter, error) {
+	filter := &messageFilter{
+		accountIDs:   r.strs("accountIDs"),
+		chatIDs:      r.strs("chatIDs"),
+		senderIDs:    r.strs("senderIDs"),
+		mediaTypes:   r.strs("mediaTypes"),
+		chatType:     r.str("chatType"),
+		query:        r.str("query"),
+		includeMuted: r.boolean("includeMuted"),
+	}
+
+	after, err := r.timeParam("dateAfter")
+	if err != nil {
+		return nil, err
+	}
+	if after != nil {
+		ts := resources.NewTimestamp(*after)
+		filter.after = &ts
+	}
+
+	before, err := r.timeParam("dateBefore")
+	if err != nil {
+		return nil, err
+	}
+	if before != nil {
+		ts := resources.NewTimestamp(*before)
+		filter.before = &ts
+	}
+	return filter, nil
+}
+
+// matches applies the filter to a stored message. Callers must hold s.mu.
+func (f *messageFilter) matches(s *Server, msg *resources.Message) bool {
+	if len(f.accountIDs) > 0 && !containsString(f.accountIDs, msg.AccountID) {
+		return false
+	}
+	if len(f.chatIDs) > 0 && !containsString(f.chatIDs, msg.ChatID) {
+		return false
+	}
+	if len(f.senderIDs) > 0 && !containsString(f.senderIDs, msg.SenderID) {
+		return false
+	}
+	if f.query != "" && !containsFold(f.query, msg.Text) {
+		return false
+	}
+	if f.after != nil && msg.Timestamp.Compare(*f.after) <= 0 {
+		return false
+	}
+	if f.before != nil && msg.Timestamp.Compare(*f.before) >= 0 {
+		return false
+	}
+	if len(f.mediaTypes) > 0 && !hasMediaType(msg, f.mediaTypes) {
+		return false
+	}
+
+	if f.chatType != "" && f.chatType != "any" || f.includeMuted != nil {
+		chat := s.findChat(msg.ChatID)
+		if chat == nil {
+			return false
+		}
+		if f.chatType != "" && f.chatType != "any" && chat.Type != f.chatType {
+			return false
+		}
+		if f.includeMuted != nil && !*f.includeMuted && chat.IsMuted != nil && *chat.IsMuted {
+			return false
+		}
+	}
+	return true
+}
+
+// hasMediaType reports whether the message has an attachment of one of the
+// given types. "any" matches every message with an attachment.
+func hasMediaType(msg *resources.Message, mediaTypes []string) bool {
+	for _, attachment := range msg.Attachments {
+		if containsString(mediaTypes, "any") || containsString(mediaTypes, attachment.Type) {
+			return true
+		}
+	}
+	return false
+}
+
+// resolveUser looks a user up in the account's contacts, falling back to a
+// bare user with only the ID set
+func (s *Server) resolveUser(accountID, id string) resources.User {
+	for _, user := range s.contacts[accountID] {
+		if user.ID == id {
+			return user
+		}
+	}
+	return resources.User{ID: id}
+}
+
+func displayName(user resources.User) string {
+	if user.FullName != nil && *user.FullName != "" {
+		return *user.FullName
+	}
+	return user.ID
+}
+
+func pageLimit(r *request) (int, error) {
+	limit, err := r.integer("limit", defaultLimit)
+	if err != nil {
+		return 0, err
+	}
+	if limit <= 0 {
+		limit = defaultLimit
+	}
+	if limit > maxLimit {
+		limit = maxLimit
+	}
+	return limit, nil
+}
+
+func containsString(list []string, value string) bool {
+	for _, item := range list {
+		if item == value {
+			return true
+		}
+	}
+	return false
+}
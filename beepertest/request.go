@@ -0,0 +1,240 @@
+package beepertest
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/cameronaaron/beeper-go-sdk/resources"
+)
+
+// handlerFunc serves one fake endpoint
+type handlerFunc func(w http.ResponseWriter, r *request)
+
+// request gives handlers uniform access to parameters, which the SDK sends
+// either in the query string or as a JSON body depending on the endpoint
+type request struct {
+	*http.Request
+	query url.Values
+	raw   []byte
+	body  map[string]json.RawMessage
+}
+
+// listParams are the query parameters the API reads as arrays, which must be
+// sent indexed as name[0]=a&name[1]=b
+var listParams = []string{"accountIDs", "chatIDs", "senderIDs", "mediaTypes"}
+
+func parseRequest(r *http.Request) (*request, error) {
+	req := &request{Request: r, query: r.URL.Query()}
+	if err := checkLists(req.query); err != nil {
+		return nil, err
+	}
+	if r.Body == nil {
+		return req, nil
+	}
+
+	raw, err := io.ReadAll(r.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read request body: %w", err)
+	}
+	req.raw = raw
+	if len(strings.TrimSpace(string(raw))) == 0 {
+		return req, nil
+	}
+	if err := json.Unmarshal(raw, &req.body); err != nil {
+		return nil, fmt.Errorf("invalid JSON body: %w", err)
+	}
+	return req, nil
+}
+
+// checkLists rejects array parameters in any encoding but the indexed one
+func checkLists(query url.Values) error {
+	for key := range query {
+		for _, name := range listParams {
+			if key == name {
+				return fmt.Errorf("invalid %s: arrays must be sent as %s[0]", name, name)
+			}
+			inner, ok := strings.CutPrefix(key, name+"[")
+			if !ok {
+				continue
+			}
+			if idx, err := strconv.Atoi(strings.TrimSuffix(inner, "]")); err != nil || idx < 0 || !strings.HasSuffix(inner, "]") {
+				return fmt.Errorf("invalid %s parameter %q", name, key)
+			}
+		}
+	}
+	return nil
+}
+
+// decode unmarshals the JSON body into v
+func (r *request) decode(v interface{}) error {
+	if len(r.body) == 0 {
+		return nil
+	}
+	if err := json.Unmarshal(r.raw, v); err != nil {
+		return fmt.Errorf("invalid request body: %w", err)
+	}
+	return nil
+}
+
+// str returns a string parameter, or "" when it is absent
+func (r *request) str(name string) string {
+	if value := r.query.Get(name); value != "" {
+		return value
+	}
+	var value string
+	if raw, ok := r.body[name]; ok {
+		_ = json.Unmarshal(raw, &value)
+	}
+	return value
+}
+
+// strs returns a list parameter, sent indexed in the query
+// (name[0]=a&name[1]=b) or as a JSON array in the body
+func (r *request) strs(name string) []string {
+	type indexed struct {
+		idx   int
+		value string
+	}
+	var items []indexed
+	for key, vals := range r.query {
+		inner, ok := strings.CutPrefix(key, name+"[")
+		if !ok || !strings.HasSuffix(inner, "]") {
+			continue
+		}
+		idx, err := strconv.Atoi(strings.TrimSuffix(inner, "]"))
+		if err != nil {
+			continue
+		}
+		for _, value := range vals {
+			items = append(items, indexed{idx, value})
+		}
+	}
+	sort.SliceStable(items, func(i, j int) bool { return items[i].idx < items[j].idx })
+
+	var values []string
+	for _, item := range items {
+		values = append(values, item.value)
+	}
+
+	if raw, ok := r.body[name]; ok {
+		var list []string
+		_ = json.Unmarshal(raw, &list)
+		values = append(values, list...)
+	}
+	return values
+}
+
+// integer returns an integer parameter, or fallback when it is absent
+func (r *request) integer(name string, fallback int) (int, error) {
+	if value := r.query.Get(name); value != "" {
+		n, err := strconv.Atoi(value)
+		if err != nil {
+			return 0, fmt.Errorf("invalid %s: %q", name, value)
+		}
+		return n, nil
+	}
+	if raw, ok := r.body[name]; ok {
+		var n int
+		if err := json.Unmarshal(raw, &n); err != nil {
+			return 0, fmt.Errorf("invalid %s: %s", name, raw)
+		}
+		return n, nil
+	}
+	return fallback, nil
+}
+
+// boolean returns a boolean parameter, or nil when it is absent
+func (r *request) boolean(name string) *bool {
+	if value := r.query.Get(name); value != "" {
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			return nil
+		}
+		return &b
+	}
+	if raw, ok := r.body[name]; ok {
+		var b bool
+		if json.Unmarshal(raw, &b) == nil {
+			return &b
+		}
+	}
+	return nil
+}
+
+// timeParam returns an RFC 3339 time parameter, or nil when it is absent
+func (r *request) timeParam(name string) (*time.Time, error) {
+	value := r.str(name)
+	if value == "" {
+		return nil, nil
+	}
+
+	t, err := time.Parse(time.RFC3339Nano, value)
+	if err != nil {
+		return nil, fmt.Errorf("invalid %s: %q", name, value)
+	}
+	return &t, nil
+}
+
+// paginate returns the page of items starting at the offset encoded in cursor
+func paginate[T any](items []T, cursor string, limit int) ([]T, *resources.PaginationInfo, error) {
+	offset := 0
+	if cursor != "" {
+		n, err := strconv.Atoi(cursor)
+		if err != nil || n < 0 {
+			return nil, nil, fmt.Errorf("invalid cursor: %q", cursor)
+		}
+		offset = n
+	}
+	if offset > len(items) {
+		offset = len(items)
+	}
+
+	end := offset + limit
+	if end > len(items) {
+		end = len(items)
+	}
+
+	pagination := &resources.PaginationInfo{Limit: &limit, HasMore: end < len(items)}
+	if pagination.HasMore {
+		next := strconv.Itoa(end)
+		pagination.Cursor = &next
+	}
+	page := items[offset:end]
+	if page == nil {
+		page = []T{}
+	}
+	return page, pagination, nil
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+// errorCodes are the codes the API sends with its own errors. A missing route
+// has none and names the route instead, which is how clients tell it from a
+// missing chat or reminder.
+var errorCodes = map[int]string{
+	http.StatusBadRequest:      "BAD_REQUEST",
+	http.StatusUnauthorized:    "UNAUTHORIZED",
+	http.StatusForbidden:       "FORBIDDEN",
+	http.StatusNotFound:        "NOT_FOUND",
+	http.StatusTooManyRequests: "RATE_LIMITED",
+	http.StatusNotImplemented:  "NOT_IMPLEMENTED",
+}
+
+func writeError(w http.ResponseWriter, status int, message string) {
+	resp := resources.ErrorResponse{Error: message}
+	if code, ok := errorCodes[status]; ok {
+		resp.Code = &code
+	}
+	writeJSON(w, status, resp)
+}
@@ -0,0 +1,452 @@
+// Package beepertest provides an in-memory fake of the Beeper Desktop API for
+// integration tests that run offline.
+//
+// A Server implements every /v0 endpoint used by the resources package, the
+// /oauth/userinfo endpoint and the /v0/events stream, backed by state seeded
+// from Fixtures. Writes made through the SDK are visible to later reads, and
+// helpers such as ReceiveMessage simulate activity from other participants.
+//
+//	server := beepertest.NewServer(beepertest.DefaultFixtures())
+//	defer server.Close()
+//
+//	client, err := server.Client()
+package beepertest
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	beeperdesktop "github.com/cameronaaron/beeper-go-sdk"
+	"github.com/cameronaaron/beeper-go-sdk/resources"
+)
+
+// DefaultToken is the access token a Server accepts unless WithToken is used
+const DefaultToken = "beepertest-token"
+
+// DefaultVersion is the Desktop version a Server reports unless WithVersion is used
+const DefaultVersion = "4.1.0"
+
+// Server is a stateful fake Beeper Desktop API server
+type Server struct {
+	// URL is the base URL of the running server
+	URL string
+	// Token is the access token the server accepts
+	Token string
+
+	httpServer *httptest.Server
+	now        func() time.Time
+	routes     map[string]handlerFunc
+	faults     *Injector
+	done       chan struct{}
+	closeOnce  sync.Once
+
+	mu          sync.Mutex
+	accounts    []resources.Account
+	chats       []*resources.Chat
+	messages    []*resources.Message
+	reminders   map[string]resources.Reminder
+	contacts    map[string][]resources.User
+	userInfo    resources.UserInfo
+	restricted  map[string]bool
+	ids         map[string]bool
+	nextSortKey int64
+	version     string
+	disabled    map[string]bool
+	noInfo      bool
+
+	stream streamLog
+}
+
+// Option configures a Server
+type Option func(*Server)
+
+// WithToken sets the access token the server accepts
+func WithToken(token string) Option {
+	return func(s *Server) {
+		s.Token = token
+	}
+}
+
+// WithClock sets the time source used for new messages and chats
+func WithClock(now func() time.Time) Option {
+	return func(s *Server) {
+		s.now = now
+	}
+}
+
+// WithVersion sets the Desktop version the info endpoint reports
+func WithVersion(version string) Option {
+	return func(s *Server) {
+		s.version = version
+	}
+}
+
+// WithoutFeatures simulates an older Desktop build: the endpoints of the given
+// beeperdesktop.Feature* values are missing and the info endpoint does not
+// list them
+func WithoutFeatures(features ...string) Option {
+	return func(s *Server) {
+		for _, feature := range features {
+			s.disabled[feature] = true
+		}
+	}
+}
+
+// WithoutInfo removes the info endpoint, as on Desktop builds that predate it
+func WithoutInfo() Option {
+	return func(s *Server) {
+		s.noInfo = true
+	}
+}
+
+// NewServer starts a fake server seeded with the given fixtures. Fixtures are
+// copied, so later changes to them do not affect the server.
+func NewServer(fixtures Fixtures, opts ...Option) *Server {
+	s := &Server{
+		Token:      DefaultToken,
+		now:        time.Now,
+		done:       make(chan struct{}),
+		reminders:  make(map[string]resources.Reminder),
+		contacts:   make(map[string][]resources.User),
+		restricted: make(map[string]bool),
+		ids:        make(map[string]bool),
+		version:    DefaultVersion,
+		disabled:   make(map[string]bool),
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+
+	s.seed(fixtures)
+	s.routes = s.buildRoutes()
+	s.stream.notify = make(chan struct{})
+
+	var handler http.Handler = s
+	if s.faults != nil {
+		handler = s.faults.Handler(handler)
+	}
+	s.httpServer = httptest.NewServer(handler)
+	s.URL = s.httpServer.URL
+	return s
+}
+
+// Close ends open event streams and shuts the server down
+func (s *Server) Close() {
+	s.closeOnce.Do(func() {
+		close(s.done)
+		s.httpServer.Close()
+	})
+}
+
+// Client returns an SDK client configured for this server. Retries are
+// disabled so tests see errors as the server returns them; opts may override
+// any setting.
+func (s *Server) Client(opts ...beeperdesktop.ClientOption) (*beeperdesktop.BeeperDesktop, error) {
+	defaults := []beeperdesktop.ClientOption{
+		beeperdesktop.WithAccessToken(s.Token),
+		beeperdesktop.WithBaseURL(s.URL),
+		beeperdesktop.WithMaxRetries(0),
+	}
+	return beeperdesktop.New(append(defaults, opts...)...)
+}
+
+// ServeHTTP routes a request to the matching fake endpoint
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Header.Get("Authorization") != "Bearer "+s.Token {
+		writeError(w, http.StatusUnauthorized, "invalid or missing access token")
+		return
+	}
+
+	handler, ok := s.routes[r.Method+" "+r.URL.Path]
+	if !ok {
+		// Worded as Express words it, which is how clients recognize a missing route
+		writeJSON(w, http.StatusNotFound, resources.ErrorResponse{Error: fmt.Sprintf("Cannot %s %s", r.Method, r.URL.Path)})
+		return
+	}
+
+	req, err := parseRequest(r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	handler(w, req)
+}
+
+// seed loads fixtures into the server state, filling in the fields the real
+// API always populates
+func (s *Server) seed(fixtures Fixtures) {
+	s.accounts = append([]resources.Account(nil), fixtures.Accounts...)
+
+	for _, chat := range fixtures.Chats {
+		chat := chat
+		chat.Participants.Items = append([]resources.User(nil), chat.Participants.Items...)
+		chat.Participants.Total = len(chat.Participants.Items)
+		s.ids[chat.ID] = true
+		s.chats = append(s.chats, &chat)
+	}
+
+	messages := append([]resources.Message(nil), fixtures.Messages...)
+	sort.SliceStable(messages, func(i, j int) bool {
+		return messages[i].Timestamp.Before(messages[j].Timestamp.Time)
+	})
+	for _, msg := range messages {
+		msg := msg
+		s.fillMessage(&msg)
+		s.messages = append(s.messages, &msg)
+		s.touchChat(msg.ChatID, msg.Timestamp)
+	}
+
+	for _, reminder := range fixtures.Reminders {
+		s.reminders[reminder.ChatID] = reminder
+	}
+	for accountID, users := range fixtures.Contacts {
+		s.contacts[accountID] = append([]resources.User(nil), users...)
+	}
+	for _, network := range fixtures.RestrictedNetworks {
+		s.restricted[network] = true
+	}
+
+	if fixtures.UserInfo != nil {
+		s.userInfo = *fixtures.UserInfo
+	} else {
+		s.userInfo = resources.UserInfo{
+			Iat:      s.now().Unix(),
+			Scope:    "read write",
+			Sub:      "beepertest",
+			TokenUse: "access",
+		}
+	}
+}
+
+// fillMessage assigns the IDs, sort key and timestamp of a message that
+// omits them. Callers must hold s.mu or be seeding.
+func (s *Server) fillMessage(msg *resources.Message) {
+	if msg.ID == "" {
+		msg.ID = s.newID("msg")
+	}
+	s.ids[msg.ID] = true
+	if msg.MessageID == "" {
+		msg.MessageID = msg.ID
+	}
+	if msg.AccountID == "" {
+		if chat := s.findChat(msg.ChatID); chat != nil {
+			msg.AccountID = chat.AccountID
+		}
+	}
+	if msg.Timestamp.IsZero() {
+		msg.Timestamp = resources.NewTimestamp(s.now())
+	}
+
+	if msg.SortKey.IsZero() {
+		s.nextSortKey++
+		msg.SortKey = resources.NewNumericSortKey(s.nextSortKey)
+	} else if n, err := strconv.ParseInt(msg.SortKey.String(), 10, 64); err == nil && n > s.nextSortKey {
+		s.nextSortKey = n
+	}
+}
+
+// newID returns an unused identifier with the given prefix
+func (s *Server) newID(prefix string) string {
+	for n := len(s.ids) + 1; ; n++ {
+		id := fmt.Sprintf("%s-%d", prefix, n)
+		if !s.ids[id] {
+			s.ids[id] = true
+			return id
+		}
+	}
+}
+
+// touchChat moves a chat's last activity forward to at
+func (s *Server) touchChat(chatID string, at resources.Timestamp) {
+	chat := s.findChat(chatID)
+	if chat == nil {
+		return
+	}
+	if chat.LastActivity == nil || chat.LastActivity.Before(at.Time) {
+		chat.LastActivity = &at
+	}
+}
+
+func (s *Server) findChat(id string) *resources.Chat {
+	for _, chat := range s.chats {
+		if chat.ID == id {
+			return chat
+		}
+	}
+	return nil
+}
+
+func (s *Server) findAccount(id string) *resources.Account {
+	for i := range s.accounts {
+		if s.accounts[i].AccountID == id {
+			return &s.accounts[i]
+		}
+	}
+	return nil
+}
+
+func (s *Server) findMessage(id string) *resources.Message {
+	for _, msg := range s.messages {
+		if msg.ID == id {
+			return msg
+		}
+	}
+	return nil
+}
+
+// Chat returns a copy of the chat with the given ID
+func (s *Server) Chat(id string) (resources.Chat, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	chat := s.findChat(id)
+	if chat == nil {
+		return resources.Chat{}, false
+	}
+	return copyChat(chat), true
+}
+
+// Chats returns a copy of every chat in creation order
+func (s *Server) Chats() []resources.Chat {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	chats := make([]resources.Chat, 0, len(s.chats))
+	for _, chat := range s.chats {
+		chats = append(chats, copyChat(chat))
+	}
+	return chats
+}
+
+// Messages returns a copy of the messages in a chat, oldest first
+func (s *Server) Messages(chatID string) []resources.Message {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var messages []resources.Message
+	for _, msg := range s.messages {
+		if msg.ChatID == chatID {
+			messages = append(messages, copyMessage(msg))
+		}
+	}
+	return messages
+}
+
+// Reminders returns the reminders currently set, ordered by chat ID
+func (s *Server) Reminders() []resources.Reminder {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	reminders := make([]resources.Reminder, 0, len(s.reminders))
+	for _, reminder := range s.reminders {
+		reminders = append(reminders, reminder)
+	}
+	sort.Slice(reminders, func(i, j int) bool {
+		return reminders[i].ChatID < reminders[j].ChatID
+	})
+	return reminders
+}
+
+// ReceiveMessage stores a message as if it arrived from another participant
+// and publishes a message.created event. Missing IDs, the sort key and the
+// timestamp are filled in; the stored message is returned.
+func (s *Server) ReceiveMessage(msg resources.Message) (resources.Message, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	chat := s.findChat(msg.ChatID)
+	if chat == nil {
+		return resources.Message{}, fmt.Errorf("beepertest: unknown chat %q", msg.ChatID)
+	}
+
+	stored := copyMessage(&msg)
+	s.fillMessage(&stored)
+	s.messages = append(s.messages, &stored)
+	s.touchChat(chat.ID, stored.Timestamp)
+	if stored.IsSender == nil || !*stored.IsSender {
+		chat.UnreadCount++
+	}
+
+	s.publishMessage(eventMessageCreated, &stored)
+	s.publishChat(chat)
+	return copyMessage(&stored), nil
+}
+
+// EditMessage replaces the text of a stored message and publishes a
+// message.updated event
+func (s *Server) EditMessage(messageID, text string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	msg := s.findMessage(messageID)
+	if msg == nil {
+		return fmt.Errorf("beepertest: unknown message %q", messageID)
+	}
+	msg.Text = &text
+	s.publishMessage(eventMessageUpdated, msg)
+	return nil
+}
+
+// AddReaction adds a reaction to a stored message and publishes a
+// reaction.added event
+func (s *Server) AddReaction(messageID string, reaction resources.Reaction) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	msg := s.findMessage(messageID)
+	if msg == nil {
+		return fmt.Errorf("beepertest: unknown message %q", messageID)
+	}
+	if reaction.ID == "" {
+		reaction.ID = s.newID("reaction")
+	}
+	msg.Reactions = append(msg.Reactions, reaction)
+	s.publishReaction(msg, reaction)
+	return nil
+}
+
+// MarkRead clears a chat's unread count, as reading it in Desktop would, and
+// publishes a chat.updated event
+func (s *Server) MarkRead(chatID string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	chat := s.findChat(chatID)
+	if chat == nil {
+		return fmt.Errorf("beepertest: unknown chat %q", chatID)
+	}
+	chat.UnreadCount = 0
+	s.publishChat(chat)
+	return nil
+}
+
+// copyChat returns a deep copy of the slices a caller could mutate
+func copyChat(chat *resources.Chat) resources.Chat {
+	out := *chat
+	out.Participants.Items = append([]resources.User(nil), chat.Participants.Items...)
+	return out
+}
+
+func copyMessage(msg *resources.Message) resources.Message {
+	out := *msg
+	out.Attachments = append([]resources.Attachment(nil), msg.Attachments...)
+	out.Reactions = append([]resources.Reaction(nil), msg.Reactions...)
+	return out
+}
+
+// containsFold reports whether substr is within any of the values, ignoring case
+func containsFold(substr string, values ...*string) bool {
+	substr = strings.ToLower(substr)
+	for _, value := range values {
+		if value != nil && strings.Contains(strings.ToLower(*value), substr) {
+			return true
+		}
+	}
+	return false
+}
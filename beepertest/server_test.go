@@ -0,0 +1,215 @@
+package beepertest_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	beeperdesktop "github.com/cameronaaron/beeper-go-sdk"
+	"github.com/cameronaaron/beeper-go-sdk/beepertest"
+	"github.com/cameronaaron/beeper-go-sdk/events"
+	"github.com/cameronaaron/beeper-go-sdk/resources"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newServer(t *testing.T) (*beepertest.Server, *beeperdesktop.BeeperDesktop) {
+	server := beepertest.NewServer(beepertest.DefaultFixtures())
+	t.Cleanup(server.Close)
+
+	client, err := server.Client()
+	require.NoError(t, err)
+	return server, client
+}
+
+func TestServerChatsAndMessages(t *testing.T) {
+	server, client := newServer(t)
+	ctx := context.Background()
+
+	accounts, err := client.Accounts.List(ctx)
+	require.NoError(t, err)
+	assert.Len(t, *accounts, 2)
+
+	chats, err := client.NewChatIterator(resources.ChatSearchParams{Limit: beeperdesktop.IntPtr(1)}).ToSlice(ctx)
+	require.NoError(t, err)
+	require.Len(t, chats, 3)
+	assert.Equal(t, "chat-family", chats[0].ID, "most recently active first")
+
+	unmuted, err := client.Chats.Search(ctx, resources.ChatSearchParams{IncludeMuted: beeperdesktop.BoolPtr(false), Query: beeperdesktop.StringPtr("bob")})
+	require.NoError(t, err)
+	require.Len(t, unmuted.Items, 1)
+	assert.Equal(t, "chat-team", unmuted.Items[0].ID)
+
+	sent, err := client.Messages.Send(ctx, resources.MessageSendParams{ChatID: "chat-alice", Text: "See you at 5"})
+	require.NoError(t, err)
+	assert.True(t, sent.Success)
+
+	page, err := client.Messages.Search(ctx, resources.MessageSearchParams{ChatIDs: []string{"chat-alice"}, Limit: beeperdesktop.IntPtr(2)})
+	require.NoError(t, err)
+	require.Len(t, page.Items, 2)
+	assert.Equal(t, sent.MessageID, page.Items[0].ID)
+	assert.True(t, *page.Items[0].IsSender)
+	require.True(t, page.Pagination.HasMore)
+
+	// A message arriving between pages does not shift the next page
+	_, err = server.ReceiveMessage(resources.Message{ChatID: "chat-alice", SenderID: "@alice:beeper.com", Text: beeperdesktop.StringPtr("Great")})
+	require.NoError(t, err)
+
+	rest, err := client.Messages.Search(ctx, resources.MessageSearchParams{ChatIDs: []string{"chat-alice"}, Limit: beeperdesktop.IntPtr(2), Cursor: page.Pagination.Cursor})
+	require.NoError(t, err)
+	require.Len(t, rest.Items, 1)
+	assert.Equal(t, "msg-1", rest.Items[0].ID)
+	assert.False(t, rest.Pagination.HasMore)
+
+	media, err := client.NewMessageIterator(resources.MessageSearchParams{MediaTypes: []string{"img"}}).ToSlice(ctx)
+	require.NoError(t, err)
+	require.Len(t, media, 1)
+	assert.Equal(t, "msg-4", media[0].ID)
+
+	chat, err := client.Chats.Retrieve(ctx, resources.ChatRetrieveParams{ChatID: "chat-alice"})
+	require.NoError(t, err)
+	assert.Equal(t, 1, chat.UnreadCount)
+	assert.Len(t, server.Messages("chat-alice"), 4)
+
+	_, err = client.Chats.Retrieve(ctx, resources.ChatRetrieveParams{ChatID: "missing"})
+	var notFound *beeperdesktop.NotFoundError
+	assert.ErrorAs(t, err, &notFound)
+}
+
+func TestServerParticipantsRemindersAndContacts(t *testing.T) {
+	server, client := newServer(t)
+	ctx := context.Background()
+
+	_, err := client.Chats.Participants.Add(ctx, resources.ParticipantUpdateParams{ChatID: "chat-team", ParticipantIDs: []string{"@dave:beeper.com"}})
+	require.NoError(t, err)
+	users, err := client.NewParticipantIterator(resources.ParticipantListParams{ChatID: "chat-team", Limit: beeperdesktop.IntPtr(2)}).ToSlice(ctx)
+	require.NoError(t, err)
+	assert.Len(t, users, 4)
+
+	_, err = client.Chats.Participants.Remove(ctx, resources.ParticipantUpdateParams{ChatID: "chat-family", ParticipantIDs: []string{"+15555550123"}})
+	assert.True(t, errors.Is(err, resources.ErrParticipantsForbidden))
+
+	at := time.Date(2030, 1, 1, 9, 0, 0, 0, time.UTC)
+	_, err = client.Chats.Reminders.Create(ctx, resources.ReminderCreateParams{ChatID: "chat-team", Timestamp: at})
+	require.NoError(t, err)
+
+	reminders, err := client.Chats.Reminders.List(ctx, resources.ReminderListParams{AccountIDs: []string{"matrix"}})
+	require.NoError(t, err)
+	require.Len(t, reminders.Items, 1)
+	assert.True(t, at.Equal(reminders.Items[0].Timestamp.Time))
+
+	_, err = client.Chats.Reminders.Delete(ctx, resources.ReminderDeleteParams{ChatID: "chat-team"})
+	require.NoError(t, err)
+	_, err = client.Chats.Reminders.Get(ctx, resources.ReminderGetParams{ChatID: "chat-team"})
+	var notFound *beeperdesktop.NotFoundError
+	assert.ErrorAs(t, err, &notFound)
+	assert.Empty(t, server.Reminders())
+
+	contacts, err := client.Contacts.Search(ctx, resources.ContactSearchParams{AccountID: "matrix", Query: "ALICE"})
+	require.NoError(t, err)
+	require.Len(t, contacts.Items, 1)
+
+	created, err := client.Chats.Create(ctx, resources.ChatCreateParams{AccountID: "matrix", ParticipantIDs: []string{"@bob:beeper.com"}, Type: "single"})
+	require.NoError(t, err)
+	assert.Equal(t, "Bob Jones", created.Chat.Title)
+	_, ok := server.Chat(created.Chat.ID)
+	assert.True(t, ok)
+
+	info, err := client.Token.Info(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, "beepertest", info.Sub)
+
+	unauthorized, err := server.Client(beeperdesktop.WithAccessToken("wrong"))
+	require.NoError(t, err)
+	_, err = unauthorized.Accounts.List(ctx)
+	var authErr *beeperdesktop.AuthenticationError
+	assert.ErrorAs(t, err, &authErr)
+}
+
+func TestServerEventStream(t *testing.T) {
+	server, client := newServer(t)
+	ctx := context.Background()
+
+	sub := events.Subscribe(ctx, client, events.Options{Mode: events.ModePush, ChatIDs: []string{"chat-team"}})
+	defer sub.Close()
+	require.Eventually(t, func() bool { return server.Subscribers() == 1 }, 2*time.Second, 10*time.Millisecond)
+
+	_, err := server.ReceiveMessage(resources.Message{ChatID: "chat-alice", Text: beeperdesktop.StringPtr("filtered out")})
+	require.NoError(t, err)
+	received, err := server.ReceiveMessage(resources.Message{ChatID: "chat-team", SenderID: "@bob:beeper.com", Text: beeperdesktop.StringPtr("Shipped")})
+	require.NoError(t, err)
+	require.NoError(t, server.AddReaction(received.ID, resources.Reaction{ParticipantID: "@alice:beeper.com", ReactionKey: "👍"}))
+
+	var got []events.Event
+	for len(got) < 3 {
+		select {
+		case event := <-sub.Events():
+			got = append(got, event)
+		case <-time.After(2 * time.Second):
+			t.Fatalf("timed out after %d events: %v", len(got), sub.Err())
+		}
+	}
+
+	created, ok := got[0].(events.MessageCreated)
+	require.True(t, ok, "got %T", got[0])
+	assert.Equal(t, received.ID, created.Message.ID)
+	assert.IsType(t, events.ChatUpdated{}, got[1])
+	assert.IsType(t, events.ReactionAdded{}, got[2])
+}
+
+func TestServerOlderVersion(t *testing.T) {
+	server := beepertest.NewServer(beepertest.DefaultFixtures(),
+		beepertest.WithVersion("4.0.0"),
+		beepertest.WithoutFeatures(beeperdesktop.FeatureParticipants),
+	)
+	defer server.Close()
+	client, err := server.Client()
+	require.NoError(t, err)
+	ctx := context.Background()
+
+	chats, err := client.Chats.Search(ctx, resources.ChatSearchParams{})
+	require.NoError(t, err)
+	require.NotEmpty(t, chats.Items)
+
+	// Without probing first, the missing route is detected from the 404
+	_, err = client.Chats.Participants.List(ctx, resources.ParticipantListParams{ChatID: chats.Items[0].ID})
+	require.ErrorIs(t, err, beeperdesktop.ErrUnsupported)
+
+	caps, err := client.Capabilities(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, "4.0.0", caps.Version)
+	assert.Equal(t, []string{beeperdesktop.FeatureReminderLookup, beeperdesktop.FeatureEvents}, caps.Features)
+
+	_, err = client.Chats.Participants.Add(ctx, resources.ParticipantUpdateParams{ChatID: chats.Items[0].ID, ParticipantIDs: []string{"x"}})
+	require.ErrorIs(t, err, beeperdesktop.ErrUnsupported)
+
+	// Missing chats are still reported as such
+	_, err = client.Chats.Reminders.Get(ctx, resources.ReminderGetParams{ChatID: "missing"})
+	var notFound *beeperdesktop.NotFoundError
+	require.True(t, errors.As(err, &notFound))
+	assert.False(t, errors.Is(err, beeperdesktop.ErrUnsupported))
+}
+
+func TestServerRejectsOtherEncodings(t *testing.T) {
+	_, client := newServer(t)
+	ctx := context.Background()
+
+	for _, path := range []string{
+		"/v0/search-messages?chatIDs=chat-alice,chat-team",
+		"/v0/search-messages?chatIDs=chat-alice",
+		"/v0/search-messages?chatIDs[first]=chat-alice",
+		"/v0/search-chats?accountIDs=matrix",
+		"/v0/search-messages?dateAfter=2024-06-05+09:00:00+%2B0000+UTC",
+		"/v0/search-messages?dateBefore=1717578000",
+	} {
+		var result resources.MessagesCursor
+		err := client.DoRequest(ctx, "GET", path, nil, &result)
+		var badRequest *beeperdesktop.BadRequestError
+		assert.ErrorAs(t, err, &badRequest, path)
+	}
+
+	var result resources.MessagesCursor
+	err := client.DoRequest(ctx, "GET", "/v0/search-messages?chatIDs[0]=chat-alice&dateAfter=2024-06-05T09:00:00Z", nil, &result)
+	assert.NoError(t, err)
+}
@@ -0,0 +1,142 @@
+package beepertest
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/cameronaaron/beeper-go-sdk/events"
+	"github.com/cameronaaron/beeper-go-sdk/resources"
+)
+
+// streamPath is the endpoint serving server-sent events
+const streamPath = "/v0/events"
+
+const (
+	eventMessageCreated = events.TypeMessageCreated
+	eventMessageUpdated = events.TypeMessageUpdated
+)
+
+// streamEvent is a published event kept so reconnecting clients can resume
+type streamEvent struct {
+	id        int
+	typ       events.Type
+	accountID string
+	chatID    string
+	data      []byte
+}
+
+// streamLog records every published event. notify is closed and replaced on
+// each publish to wake the open streams.
+type streamLog struct {
+	events      []streamEvent
+	notify      chan struct{}
+	subscribers int
+}
+
+// publish appends an event to the log. Callers must hold s.mu.
+func (s *Server) publish(typ events.Type, accountID, chatID string, payload interface{}) {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		panic(fmt.Sprintf("beepertest: marshal %s event: %v", typ, err))
+	}
+
+	s.stream.events = append(s.stream.events, streamEvent{
+		id:        len(s.stream.events) + 1,
+		typ:       typ,
+		accountID: accountID,
+		chatID:    chatID,
+		data:      data,
+	})
+	close(s.stream.notify)
+	s.stream.notify = make(chan struct{})
+}
+
+func (s *Server) publishMessage(typ events.Type, msg *resources.Message) {
+	var payload events.Event = events.MessageCreated{Message: copyMessage(msg)}
+	if typ == eventMessageUpdated {
+		payload = events.MessageUpdated{Message: copyMessage(msg)}
+	}
+	s.publish(typ, msg.AccountID, msg.ChatID, payload)
+}
+
+func (s *Server) publishChat(chat *resources.Chat) {
+	s.publish(events.TypeChatUpdated, chat.AccountID, chat.ID, events.ChatUpdated{Chat: copyChat(chat)})
+}
+
+func (s *Server) publishReaction(msg *resources.Message, reaction resources.Reaction) {
+	payload := events.ReactionAdded{ChatID: msg.ChatID, MessageID: msg.ID, Reaction: reaction}
+	s.publish(events.TypeReactionAdded, msg.AccountID, msg.ChatID, payload)
+}
+
+// Subscribers returns the number of open event streams
+func (s *Server) Subscribers() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.stream.subscribers
+}
+
+// handleEvents streams events published after Last-Event-ID, or after the
+// connection opened when it is absent, until the client disconnects or the
+// server is closed
+func (s *Server) handleEvents(w http.ResponseWriter, r *request) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		writeError(w, http.StatusNotImplemented, "streaming unsupported")
+		return
+	}
+
+	s.mu.Lock()
+	lastID := len(s.stream.events)
+	s.stream.subscribers++
+	s.mu.Unlock()
+	defer func() {
+		s.mu.Lock()
+		s.stream.subscribers--
+		s.mu.Unlock()
+	}()
+
+	if header := r.Header.Get("Last-Event-ID"); header != "" {
+		n, err := strconv.Atoi(header)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, "invalid Last-Event-ID")
+			return
+		}
+		lastID = n
+	}
+	accountIDs := r.strs("accountIDs")
+	chatIDs := r.strs("chatIDs")
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	for {
+		s.mu.Lock()
+		pending := s.stream.events[min(lastID, len(s.stream.events)):]
+		notify := s.stream.notify
+		s.mu.Unlock()
+
+		for _, event := range pending {
+			lastID = event.id
+			if len(accountIDs) > 0 && !containsString(accountIDs, event.accountID) {
+				continue
+			}
+			if len(chatIDs) > 0 && !containsString(chatIDs, event.chatID) {
+				continue
+			}
+			fmt.Fprintf(w, "id: %d\nevent: %s\ndata: %s\n\n", event.id, event.typ, event.data)
+		}
+		flusher.Flush()
+
+		select {
+		case <-notify:
+		case <-r.Context().Done():
+			return
+		case <-s.done:
+			return
+		}
+	}
+}
@@ -0,0 +1,173 @@
+package beeperdesktop
+
+import (
+	"context"
+	"errors"
+	"strings"
+)
+
+// infoPath is the Desktop endpoint describing the server build
+const infoPath = "/v0/get-info"
+
+// Features that only some Beeper Desktop builds offer
+const (
+	// FeatureParticipants covers listing, adding and removing chat participants
+	FeatureParticipants = "participants"
+	// FeatureReminderLookup covers listing reminders and getting a chat's reminder
+	FeatureReminderLookup = "reminder-lookup"
+	// FeatureEvents covers the server-sent event stream
+	FeatureEvents = "events"
+)
+
+// featurePaths maps the endpoints of newer features to the feature
+var featurePaths = map[string]string{
+	"/v0/get-chat-participants":    FeatureParticipants,
+	"/v0/add-chat-participants":    FeatureParticipants,
+	"/v0/remove-chat-participants": FeatureParticipants,
+	"/v0/get-chat-reminders":       FeatureReminderLookup,
+	"/v0/get-chat-reminder":        FeatureReminderLookup,
+	"/v0/events":                   FeatureEvents,
+}
+
+// knownFeatures lists every feature the SDK can negotiate
+var knownFeatures = []string{FeatureParticipants, FeatureReminderLookup, FeatureEvents}
+
+// Capabilities describes what the connected Beeper Desktop build supports
+type Capabilities struct {
+	Version  string   `json:"version"`
+	Features []string `json:"features"`
+	// Inferred is set when the server has no info endpoint. Features then
+	// lists every feature not yet seen failing with a missing route.
+	Inferred bool `json:"-"`
+}
+
+// Supports reports whether the server offers a feature
+func (c *Capabilities) Supports(feature string) bool {
+	for _, f := range c.Features {
+		if f == feature {
+			return true
+		}
+	}
+	return false
+}
+
+// Capabilities returns the server's version and features. The first call asks
+// the server and the result is cached on the client; servers without an info
+// endpoint get inferred capabilities that shrink as newer endpoints turn out
+// to be missing.
+func (c *BeeperDesktop) Capabilities(ctx context.Context) (*Capabilities, error) {
+	c.capsMu.Lock()
+	cached := c.caps
+	c.capsMu.Unlock()
+	if cached != nil {
+		return c.capabilitiesSnapshot(), nil
+	}
+
+	var caps Capabilities
+	err := c.DoRequest(ctx, "GET", infoPath, nil, &caps)
+	var notFound *NotFoundError
+	switch {
+	case errors.As(err, &notFound):
+		caps = Capabilities{Inferred: true}
+	case err != nil:
+		return nil, err
+	}
+
+	c.capsMu.Lock()
+	if c.caps == nil {
+		c.caps = &caps
+	}
+	c.capsMu.Unlock()
+	return c.capabilitiesSnapshot(), nil
+}
+
+// capabilitiesSnapshot copies the cached capabilities, filling in the
+// features of inferred ones
+func (c *BeeperDesktop) capabilitiesSnapshot() *Capabilities {
+	c.capsMu.Lock()
+	defer c.capsMu.Unlock()
+
+	caps := *c.caps
+	if caps.Inferred {
+		caps.Features = nil
+		for _, feature := range knownFeatures {
+			if !c.missing[feature] {
+				caps.Features = append(caps.Features, feature)
+			}
+		}
+	} else {
+		caps.Features = append([]string(nil), caps.Features...)
+	}
+	return &caps
+}
+
+// checkSupported fails fast when the server is known to lack a feature
+func (c *BeeperDesktop) checkSupported(feature string) error {
+	c.capsMu.Lock()
+	defer c.capsMu.Unlock()
+
+	if c.missing[feature] {
+		return &UnsupportedError{Feature: feature, Version: c.serverVersion()}
+	}
+	if c.caps != nil && !c.caps.Inferred && !c.caps.Supports(feature) {
+		return &UnsupportedError{Feature: feature, Version: c.caps.Version}
+	}
+	return nil
+}
+
+// unsupported turns a missing route for a newer feature into an
+// UnsupportedError and remembers it. Other 404s, such as a missing chat, are
+// returned unchanged and leave the feature usable.
+func (c *BeeperDesktop) unsupported(feature, method, path string, err error) error {
+	var notFound *NotFoundError
+	if !errors.As(err, &notFound) || !missingRoute(method, path, notFound) {
+		return err
+	}
+
+	c.capsMu.Lock()
+	defer c.capsMu.Unlock()
+	if c.caps != nil && !c.caps.Inferred && c.caps.Supports(feature) {
+		return err
+	}
+	if c.missing == nil {
+		c.missing = make(map[string]bool)
+	}
+	c.missing[feature] = true
+	return &UnsupportedError{Feature: feature, Version: c.serverVersion(), Cause: err}
+}
+
+// missingRoute reports whether a 404 is the web framework's answer for a path
+// the server has no route for, which Express words "Cannot GET /v0/x" and
+// Fastify "Route GET:/v0/x not found". The API's own not-found errors carry a
+// code and describe the missing chat or reminder instead.
+func missingRoute(method, path string, notFound *NotFoundError) bool {
+	if notFound.Code != "" {
+		return false
+	}
+	path, _, _ = strings.Cut(path, "?")
+	path = "/" + strings.TrimPrefix(path, "/")
+
+	message := notFound.Message
+	switch {
+	case strings.Contains(message, "Cannot "+method+" "):
+		return strings.Contains(message, path)
+	case strings.Contains(message, "Route "+method+":"):
+		return strings.Contains(message, path+" not found")
+	}
+	return false
+}
+
+// serverVersion returns the cached server version, if known. Callers must
+// hold c.capsMu.
+func (c *BeeperDesktop) serverVersion() string {
+	if c.caps == nil {
+		return ""
+	}
+	return c.caps.Version
+}
+
+// featureForPath returns the newer feature an API path belongs to, if any
+func featureForPath(path string) string {
+	path, _, _ = strings.Cut(path, "?")
+	return featurePaths["/"+strings.TrimPrefix(path, "/")]
+}
@@ -0,0 +1,139 @@
+package beeperdesktop
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+
+	"github.com/cameronaaron/beeper-go-sdk/resources"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCapabilities(t *testing.T) {
+	var infoCalls, participantCalls int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Path {
+		case "/v0/get-info":
+			atomic.AddInt32(&infoCalls, 1)
+			w.Write([]byte(`{"version": "4.2.0", "features": ["reminder-lookup"]}`))
+		case "/v0/get-chat-participants":
+			atomic.AddInt32(&participantCalls, 1)
+			w.Write([]byte(`{"items": []}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(`{"error": "No reminder set", "code": "NOT_FOUND"}`))
+		}
+	}))
+	defer server.Close()
+
+	client, err := New(WithAccessToken("token"), WithBaseURL(server.URL))
+	require.NoError(t, err)
+	ctx := context.Background()
+
+	caps, err := client.Capabilities(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, "4.2.0", caps.Version)
+	assert.False(t, caps.Inferred)
+	assert.True(t, caps.Supports(FeatureReminderLookup))
+	assert.False(t, caps.Supports(FeatureParticipants))
+
+	_, err = client.Capabilities(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, int32(1), atomic.LoadInt32(&infoCalls), "capabilities are cached")
+
+	_, err = client.Chats.Participants.List(ctx, resources.ParticipantListParams{ChatID: "c"})
+	require.ErrorIs(t, err, ErrUnsupported)
+	var unsupported *UnsupportedError
+	require.True(t, errors.As(err, &unsupported))
+	assert.Equal(t, FeatureParticipants, unsupported.Feature)
+	assert.Equal(t, "participants is not supported by Beeper Desktop 4.2.0", err.Error())
+	assert.Zero(t, atomic.LoadInt32(&participantCalls), "the request is not sent")
+
+	// A supported feature's own not-found errors pass through
+	_, err = client.Chats.Reminders.Get(ctx, resources.ReminderGetParams{ChatID: "c"})
+	var notFound *NotFoundError
+	require.True(t, errors.As(err, &notFound))
+	assert.False(t, errors.Is(err, ErrUnsupported))
+}
+
+func TestCapabilitiesWithoutInfoEndpoint(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Path {
+		case "/v0/get-chat-reminder":
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(`{"error": "No reminder set", "code": "NOT_FOUND"}`))
+		default:
+			// The server has no such route
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(`Cannot GET ` + r.URL.Path))
+		}
+	}))
+	defer server.Close()
+
+	client, err := New(WithAccessToken("token"), WithBaseURL(server.URL))
+	require.NoError(t, err)
+	ctx := context.Background()
+
+	caps, err := client.Capabilities(ctx)
+	require.NoError(t, err)
+	assert.True(t, caps.Inferred)
+	assert.Empty(t, caps.Version)
+	assert.True(t, caps.Supports(FeatureParticipants))
+
+	_, err = client.Chats.Participants.List(ctx, resources.ParticipantListParams{ChatID: "c"})
+	require.ErrorIs(t, err, ErrUnsupported)
+	var notFound *NotFoundError
+	assert.True(t, errors.As(err, &notFound), "the 404 stays reachable")
+	assert.Equal(t, "Cannot GET /v0/get-chat-participants", notFound.Message)
+
+	_, err = client.Chats.Reminders.Get(ctx, resources.ReminderGetParams{ChatID: "c"})
+	require.True(t, errors.As(err, &notFound))
+	assert.False(t, errors.Is(err, ErrUnsupported), "coded 404s are the API's own")
+
+	caps, err = client.Capabilities(ctx)
+	require.NoError(t, err)
+	assert.False(t, caps.Supports(FeatureParticipants))
+	assert.True(t, caps.Supports(FeatureReminderLookup))
+}
+
+func TestMissingRoute(t *testing.T) {
+	notFound := func(message, code string) *NotFoundError {
+		return &NotFoundError{APIError: APIError{Status: http.StatusNotFound, Message: message, Code: code}}
+	}
+
+	assert.True(t, missingRoute("GET", "/v0/events?chatIDs[0]=a", notFound("Cannot GET /v0/events", "")))
+	assert.True(t, missingRoute("POST", "v0/add-chat-participants", notFound(`{"message":"Route POST:/v0/add-chat-participants not found"}`, "")))
+	assert.False(t, missingRoute("GET", "/v0/get-chat-participants", notFound("Chat not found", "")))
+	assert.False(t, missingRoute("GET", "/v0/get-chat-participants", notFound("Cannot GET /v0/get-chat-participants", "NOT_FOUND")))
+	assert.False(t, missingRoute("POST", "/v0/get-chat-participants", notFound("Cannot GET /v0/get-chat-participants", "")))
+}
+
+func TestCapabilitiesIgnoreOtherNotFound(t *testing.T) {
+	var participantCalls int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		atomic.AddInt32(&participantCalls, 1)
+		// A server whose errors carry no code
+		w.WriteHeader(http.StatusNotFound)
+		w.Write([]byte(`{"error": "Chat missing not found"}`))
+	}))
+	defer server.Close()
+
+	client, err := New(WithAccessToken("token"), WithBaseURL(server.URL), WithMaxRetries(0))
+	require.NoError(t, err)
+	ctx := context.Background()
+
+	for i := 0; i < 2; i++ {
+		_, err = client.Chats.Participants.List(ctx, resources.ParticipantListParams{ChatID: "missing"})
+		var notFound *NotFoundError
+		require.True(t, errors.As(err, &notFound))
+		assert.False(t, errors.Is(err, ErrUnsupported))
+	}
+	assert.Equal(t, int32(2), atomic.LoadInt32(&participantCalls), "a missing chat does not hide the feature")
+}
@@ -6,8 +6,10 @@ import (
 	"fmt"
 	"io"
 	"net/http"
+	"net/url"
 	"os"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/cameronaaron/beeper-go-sdk/internal"
@@ -27,6 +29,15 @@ type BeeperDesktop struct {
 	httpClient *http.Client
 	retryLogic *internal.RetryLogic
 
+	// Schema drift detection
+	driftHandler   DriftHandler
+	strictDecoding bool
+
+	// Server capabilities, probed on demand
+	capsMu  sync.Mutex
+	caps    *Capabilities
+	missing map[string]bool // features seen failing with a missing route
+
 	// Resource clients
 	Accounts *resources.Accounts
 	App      *resources.App
@@ -38,18 +49,33 @@ type BeeperDesktop struct {
 
 // New creates a new BeeperDesktop client with the given options
 func New(opts ...ClientOption) (*BeeperDesktop, error) {
-	config := &ClientConfig{
-		AccessToken: os.Getenv("BEEPER_ACCESS_TOKEN"),
-		BaseURL:     getEnvWithDefault("BEEPER_DESKTOP_BASE_URL", "http://localhost:23373"),
-		Timeout:     30 * time.Second,
-		MaxRetries:  2,
-		UserAgent:   fmt.Sprintf("beeper-desktop-api-go/%s", Version),
-	}
-
+	config := defaultConfig()
 	for _, opt := range opts {
 		opt(config)
 	}
 
+	// A profile sits between the defaults and the options
+	if config.UseProfile || os.Getenv("BEEPER_PROFILE") != "" {
+		profiled := defaultConfig()
+		profiled.Profile = config.Profile
+		profiled.ConfigPath = config.ConfigPath
+		if err := applyProfile(profiled); err != nil {
+			return nil, err
+		}
+		for _, opt := range opts {
+			opt(profiled)
+		}
+		config = profiled
+	}
+
+	if config.AccessToken == "" && config.TokenCommand != "" {
+		token, err := runTokenCommand(config.TokenCommand)
+		if err != nil {
+			return nil, err
+		}
+		config.AccessToken = token
+	}
+
 	if config.AccessToken == "" {
 		return nil, &AuthenticationError{
 			APIError: APIError{
@@ -59,9 +85,15 @@ func New(opts ...ClientOption) (*BeeperDesktop, error) {
 		}
 	}
 
-	// Ensure base URL ends with /
-	if !strings.HasSuffix(config.BaseURL, "/") {
-		config.BaseURL += "/"
+	if config.BaseURL == "" {
+		config.BaseURL = DefaultBaseURL
+		if config.AutoDiscover {
+			found, err := discoverFor(config)
+			if err != nil {
+				return nil, err
+			}
+			config.BaseURL = found.BaseURL
+		}
 	}
 
 	httpClient := config.HTTPClient
@@ -71,14 +103,35 @@ func New(opts ...ClientOption) (*BeeperDesktop, error) {
 		}
 	}
 
+	var proxy *url.URL
+	if config.Proxy != "" {
+		var err error
+		if proxy, err = url.Parse(config.Proxy); err != nil {
+			return nil, fmt.Errorf("invalid proxy URL: %w", err)
+		}
+	}
+
+	baseURL, httpClient, err := resolveTransport(config.BaseURL, httpClient, config.Dialer, proxy)
+	if err != nil {
+		return nil, err
+	}
+
+	// Ensure base URL ends with /
+	if !strings.HasSuffix(baseURL, "/") {
+		baseURL += "/"
+	}
+
 	client := &BeeperDesktop{
 		accessToken: config.AccessToken,
-		baseURL:     config.BaseURL,
+		baseURL:     baseURL,
 		timeout:     config.Timeout,
 		maxRetries:  config.MaxRetries,
 		userAgent:   config.UserAgent,
 		httpClient:  httpClient,
 		retryLogic:  internal.NewRetryLogic(config.MaxRetries),
+
+		driftHandler:   config.DriftHandler,
+		strictDecoding: config.StrictDecoding,
 	}
 
 	// Initialize resource clients
@@ -92,11 +145,45 @@ func New(opts ...ClientOption) (*BeeperDesktop, error) {
 	return client, nil
 }
 
-// DoRequest performs an HTTP request with retry logic and error handling
+// defaultConfig returns the settings used when no option or profile overrides them
+func defaultConfig() *ClientConfig {
+	return &ClientConfig{
+		AccessToken: os.Getenv("BEEPER_ACCESS_TOKEN"),
+		BaseURL:     os.Getenv("BEEPER_DESKTOP_BASE_URL"),
+		Timeout:     30 * time.Second,
+		MaxRetries:  2,
+		UserAgent:   fmt.Sprintf("beeper-desktop-api-go/%s", Version),
+	}
+}
+
+// idempotencyKey is the context key of WithIdempotencyKey
+type idempotencyKey struct{}
+
+// WithIdempotencyKey returns a context whose requests carry an
+// Idempotency-Key header, so a server that supports it can recognize a
+// retried request it already carried out. Desktop versions that ignore the
+// header carry out the request again.
+func WithIdempotencyKey(ctx context.Context, key string) context.Context {
+	return context.WithValue(ctx, idempotencyKey{}, key)
+}
+
+// DoRequest performs an HTTP request with retry logic and error handling.
+// Calls to features the server lacks fail with an *UnsupportedError.
 func (c *BeeperDesktop) DoRequest(ctx context.Context, method, path string, body interface{}, result interface{}) error {
-	return c.retryLogic.Do(ctx, func() error {
+	feature := featureForPath(path)
+	if feature != "" {
+		if err := c.checkSupported(feature); err != nil {
+			return err
+		}
+	}
+
+	err := c.retryLogic.Do(ctx, func() error {
 		return c.doRequestOnce(ctx, method, path, body, result)
 	})
+	if err != nil && feature != "" {
+		return c.unsupported(feature, method, path, err)
+	}
+	return err
 }
 
 // doRequestOnce performs a single HTTP request without retry
@@ -124,6 +211,9 @@ func (c *BeeperDesktop) doRequestOnce(ctx context.Context, method, path string,
 		req.Header.Set("Content-Type", "application/json")
 	}
 	req.Header.Set("Accept", "application/json")
+	if key, ok := ctx.Value(idempotencyKey{}).(string); ok && key != "" {
+		req.Header.Set("Idempotency-Key", key)
+	}
 
 	resp, err := c.httpClient.Do(req)
 	if err != nil {
@@ -149,11 +239,95 @@ func (c *BeeperDesktop) doRequestOnce(ctx context.Context, method, path string,
 		if err := json.Unmarshal(respBody, result); err != nil {
 			return fmt.Errorf("failed to unmarshal response: %w", err)
 		}
+		if c.driftHandler != nil || c.strictDecoding {
+			return c.checkDrift(method, path, respBody, result)
+		}
 	}
 
 	return nil
 }
 
+// checkDrift reports schema drift in a decoded response and, in strict mode,
+// turns unknown fields into an error
+func (c *BeeperDesktop) checkDrift(method, path string, body []byte, result interface{}) error {
+	drifts := detectDrift(method, path, body, result)
+	if c.driftHandler != nil {
+		for _, d := range drifts {
+			c.driftHandler(d)
+		}
+	}
+	if !c.strictDecoding {
+		return nil
+	}
+
+	var unknown []Drift
+	for _, d := range drifts {
+		if d.Kind == DriftUnknownField {
+			unknown = append(unknown, d)
+		}
+	}
+	if len(unknown) == 0 {
+		return nil
+	}
+	return &SchemaDriftError{Method: unknown[0].Method, Path: unknown[0].Path, Drifts: unknown}
+}
+
+// OpenEventStream opens a long-lived server-sent events stream at the given path.
+// The caller must close the returned body. lastEventID is sent as Last-Event-ID
+// so the server can resume after a reconnect.
+func (c *BeeperDesktop) OpenEventStream(ctx context.Context, path, lastEventID string) (io.ReadCloser, error) {
+	feature := featureForPath(path)
+	if feature != "" {
+		if err := c.checkSupported(feature); err != nil {
+			return nil, err
+		}
+	}
+
+	url := c.baseURL + strings.TrimPrefix(path, "/")
+
+	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	req.Header.Set("Authorization", "Bearer "+c.accessToken)
+	req.Header.Set("User-Agent", c.userAgent)
+	req.Header.Set("Accept", "text/event-stream")
+	req.Header.Set("Cache-Control", "no-cache")
+	if lastEventID != "" {
+		req.Header.Set("Last-Event-ID", lastEventID)
+	}
+
+	// The client timeout covers reading the body, which would cut the stream short
+	streamClient := *c.httpClient
+	streamClient.Timeout = 0
+
+	resp, err := streamClient.Do(req)
+	if err != nil {
+		return nil, &APIConnectionError{
+			BeeperDesktopError: BeeperDesktopError{
+				Message: fmt.Sprintf("stream failed: %v", err),
+			},
+			Cause: err,
+		}
+	}
+
+	if resp.StatusCode >= 400 {
+		defer resp.Body.Close()
+		respBody, err := io.ReadAll(resp.Body)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read response body: %w", err)
+		}
+		err = c.handleErrorResponse(resp.StatusCode, respBody)
+		if feature != "" {
+			err = c.unsupported(feature, "GET", path, err)
+		}
+		return nil, err
+	}
+
+	return resp.Body, nil
+}
+
 // DoRequestWithQuery makes an HTTP request with query parameters
 func (c *BeeperDesktop) DoRequestWithQuery(ctx context.Context, method, path string, query map[string]interface{}, result interface{}) error {
 	// Convert map to url.Values
@@ -263,11 +437,18 @@ func (c *BeeperDesktop) handleErrorResponse(statusCode int, body []byte) error {
 	}
 }
 
-// getEnvWithDefault returns environment variable value or default
-func getEnvWithDefault(key, defaultValue string) string {
-	value := os.Getenv(key)
-	if value == "" {
-		return defaultValue
+// discoverFor runs Discover with the client's token and HTTP client, bounded
+// by its timeout
+func discoverFor(config *ClientConfig) (*Discovery, error) {
+	ctx := context.Background()
+	if config.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, config.Timeout)
+		defer cancel()
 	}
-	return value
+	return Discover(ctx, DiscoverOptions{
+		AccessToken: config.AccessToken,
+		HTTPClient:  config.HTTPClient,
+		Dialer:      config.Dialer,
+	})
 }
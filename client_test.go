@@ -7,6 +7,7 @@ import (
 	"testing"
 	"time"
 
+	"github.com/cameronaaron/beeper-go-sdk/resources"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -69,6 +70,28 @@ func TestBeeperDesktop_DoRequest(t *testing.T) {
 		assert.Equal(t, true, result["success"])
 	})
 
+	t.Run("idempotency key", func(t *testing.T) {
+		var keys []string
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			keys = append(keys, r.Header.Get("Idempotency-Key"))
+			w.Header().Set("Content-Type", "application/json")
+			w.Write([]byte(`{}`))
+		}))
+		defer server.Close()
+
+		client, err := New(
+			WithAccessToken("test-token"),
+			WithBaseURL(server.URL),
+			WithMaxRetries(0),
+		)
+		require.NoError(t, err)
+
+		ctx := context.Background()
+		require.NoError(t, client.DoRequest(WithIdempotencyKey(ctx, "key-1"), "POST", "/test", nil, nil))
+		require.NoError(t, client.DoRequest(ctx, "POST", "/test", nil, nil))
+		assert.Equal(t, []string{"key-1", ""}, keys)
+	})
+
 	t.Run("error response", func(t *testing.T) {
 		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 			w.Header().Set("Content-Type", "application/json")
@@ -158,3 +181,14 @@ func TestErrorTypes(t *testing.T) {
 		})
 	}
 }
+
+func TestCheckSent(t *testing.T) {
+	assert.NoError(t, CheckSent(&resources.MessageSendResponse{MessageID: "m1", Success: true}))
+
+	err := CheckSent(&resources.MessageSendResponse{Error: "chat is read-only"})
+	var sendErr *SendError
+	require.ErrorAs(t, err, &sendErr)
+	assert.EqualError(t, err, "message not sent: chat is read-only")
+	assert.EqualError(t, CheckSent(&resources.MessageSendResponse{}), "message not sent")
+	assert.False(t, IsRetryableError(err))
+}
@@ -5,6 +5,7 @@ import (
 	"crypto/sha1"
 	"encoding/hex"
 	"encoding/json"
+	"flag"
 	"fmt"
 	"html"
 	"log"
@@ -15,7 +16,9 @@ import (
 	"time"
 
 	beeperdesktop "github.com/cameronaaron/beeper-go-sdk"
+	"github.com/cameronaaron/beeper-go-sdk/internal/cli"
 	"github.com/cameronaaron/beeper-go-sdk/resources"
+	"github.com/cameronaaron/beeper-go-sdk/search"
 )
 
 const (
@@ -24,24 +27,35 @@ const (
 )
 
 func main() {
+	clientFlags := cli.RegisterClientFlags(flag.CommandLine)
+	chatQuery := flag.String("chats", "", `only list chats matching this search, e.g. "is:group account:whatsapp"`)
+	flag.Parse()
+
+	query, err := search.Parse(*chatQuery)
+	if err != nil {
+		log.Fatal("❌ Invalid -chats search: ", err)
+	}
+
 	fmt.Println("📦 Beeper Chat Archive Tool")
 	fmt.Println("============================")
 	fmt.Println()
 
 	// Create client
-	client, err := beeperdesktop.New(
-		beeperdesktop.WithTimeout(30*time.Second),
-		beeperdesktop.WithMaxRetries(3),
-	)
+	client, err := beeperdesktop.New(clientFlags.Options()...)
 	if err != nil {
 		log.Fatal("❌ Failed to create client:", err)
 	}
 
 	ctx := context.Background()
 
+	params, err := search.NewResolver(client).ChatParams(ctx, query)
+	if err != nil {
+		log.Fatal("❌ Invalid -chats search: ", err)
+	}
+
 	// Get all chats
 	fmt.Println("🔍 Fetching chats...")
-	chats, err := fetchAllChats(ctx, client)
+	chats, err := fetchAllChats(ctx, client, params)
 	if err != nil {
 		log.Fatal("❌ Failed to fetch chats:", err)
 	}
@@ -83,12 +97,10 @@ func main() {
 	fmt.Printf("📁 Archives saved to: %s/\n", archiveDir)
 }
 
-func fetchAllChats(ctx context.Context, client *beeperdesktop.BeeperDesktop) ([]resources.Chat, error) {
+func fetchAllChats(ctx context.Context, client *beeperdesktop.BeeperDesktop, params resources.ChatSearchParams) ([]resources.Chat, error) {
 	var allChats []resources.Chat
 
-	params := resources.ChatSearchParams{
-		Limit: beeperdesktop.IntPtr(100),
-	}
+	params.Limit = beeperdesktop.IntPtr(100)
 
 	result, err := client.Chats.Search(ctx, params)
 	if err != nil {
@@ -168,9 +180,12 @@ func archiveChat(ctx context.Context, client *beeperdesktop.BeeperDesktop, chat
 		return "", fmt.Errorf("failed to fetch messages: %w", err)
 	}
 
-	// Sort messages by timestamp
+	// Sort messages by sort key, falling back to timestamp when keys tie
 	sort.Slice(messages, func(i, j int) bool {
-		return messages[i].Timestamp.Before(messages[j].Timestamp)
+		if cmp := messages[i].SortKey.Compare(messages[j].SortKey); cmp != 0 {
+			return cmp < 0
+		}
+		return messages[i].Timestamp.Before(messages[j].Timestamp.Time)
 	})
 
 	archivedAt := time.Now()
@@ -251,7 +266,7 @@ func generateMarkdown(chat resources.Chat, messages []resources.Message, archive
 	md.WriteString(fmt.Sprintf("**Participants:** %d\n\n", chat.Participants.Total))
 
 	if chat.LastActivity != nil {
-		md.WriteString(fmt.Sprintf("**Last Activity:** %s\n\n", *chat.LastActivity))
+		md.WriteString(fmt.Sprintf("**Last Activity:** %s\n\n", chat.LastActivity.Format(time.RFC3339)))
 	}
 
 	md.WriteString(fmt.Sprintf("**Total Messages:** %d\n\n", len(messages)))
@@ -373,7 +388,7 @@ func generateHTML(chat resources.Chat, messages []resources.Message, archivedAt
 	htmlBuilder.WriteString(fmt.Sprintf("<li><strong>Chat ID:</strong> <code>%s</code></li>\n", html.EscapeString(chat.ID)))
 	htmlBuilder.WriteString(fmt.Sprintf("<li><strong>Participants:</strong> %d</li>\n", chat.Participants.Total))
 	if chat.LastActivity != nil {
-		htmlBuilder.WriteString(fmt.Sprintf("<li><strong>Last Activity:</strong> %s</li>\n", html.EscapeString(*chat.LastActivity)))
+		htmlBuilder.WriteString(fmt.Sprintf("<li><strong>Last Activity:</strong> %s</li>\n", html.EscapeString(chat.LastActivity.Format(time.RFC3339))))
 	}
 	htmlBuilder.WriteString(fmt.Sprintf("<li><strong>Total Messages:</strong> %d</li>\n", len(messages)))
 	htmlBuilder.WriteString("</ul>\n</section>\n")
@@ -465,7 +480,7 @@ func generatePlainText(chat resources.Chat, messages []resources.Message, archiv
 	textBuilder.WriteString(fmt.Sprintf("Chat ID: %s\n", chat.ID))
 	textBuilder.WriteString(fmt.Sprintf("Participants: %d\n", chat.Participants.Total))
 	if chat.LastActivity != nil {
-		textBuilder.WriteString(fmt.Sprintf("Last Activity: %s\n", *chat.LastActivity))
+		textBuilder.WriteString(fmt.Sprintf("Last Activity: %s\n", chat.LastActivity.Format(time.RFC3339)))
 	}
 	textBuilder.WriteString(fmt.Sprintf("Total Messages: %d\n", len(messages)))
 	textBuilder.WriteString("----------------------------------------\n\n")
@@ -537,7 +552,7 @@ func writeJSONArchive(chatDir, baseName string, chat resources.Chat, messages []
 		Network      string                     `json:"network"`
 		ChatID       string                     `json:"chat_id"`
 		Participants resources.ChatParticipants `json:"participants"`
-		LastActivity *string                    `json:"last_activity,omitempty"`
+		LastActivity *resources.Timestamp       `json:"last_activity,omitempty"`
 		ArchivedAt   string                     `json:"archived_at"`
 		Messages     []resources.Message        `json:"messages"`
 	}{
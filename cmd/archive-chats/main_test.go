@@ -88,7 +88,7 @@ func TestArchiveFolderAndBaseName(t *testing.T) {
 }
 
 func TestGenerateMarkdown(t *testing.T) {
-	lastActivity := "2025-10-07T12:34:56Z"
+	lastActivity := resources.NewTimestamp(time.Date(2025, 10, 7, 12, 34, 56, 0, time.UTC))
 	chat := resources.Chat{
 		ID:        "!updates:beeper.local",
 		AccountID: "acc_123",
@@ -115,7 +115,7 @@ func TestGenerateMarkdown(t *testing.T) {
 	messages := []resources.Message{
 		{
 			MessageID:  "msg_1",
-			Timestamp:  ts1,
+			Timestamp:  resources.NewTimestamp(ts1),
 			SenderName: ptr("Alice"),
 			Text:       ptr("Hello\nWorld"),
 			Attachments: []resources.Attachment{
@@ -130,7 +130,7 @@ func TestGenerateMarkdown(t *testing.T) {
 		},
 		{
 			MessageID:  "msg_2",
-			Timestamp:  ts2,
+			Timestamp:  resources.NewTimestamp(ts2),
 			SenderName: ptr("Bob"),
 		},
 	}
@@ -141,6 +141,7 @@ func TestGenerateMarkdown(t *testing.T) {
 	assert.Contains(t, markdown, "# Product Updates")
 	assert.Contains(t, markdown, "**Network:** Beeper (Matrix)")
 	assert.Contains(t, markdown, "**Chat ID:** `!updates:beeper.local`")
+	assert.Contains(t, markdown, "**Last Activity:** 2025-10-07T12:34:56Z")
 	assert.Contains(t, markdown, "## Participants")
 	assert.Contains(t, markdown, "1. **Alice** (`@alice:beeper.com`)")
 	assert.Contains(t, markdown, "2. **Unknown** (`@bob:beeper.com`)")
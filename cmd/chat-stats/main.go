@@ -0,0 +1,237 @@
+// Command chat-stats reports conversation statistics computed from message
+// search: messages per sender, media, reactions, response times, active
+// hours and trends, in total, per account and per chat.
+//
+//	BEEPER_ACCESS_TOKEN=... go run ./cmd/chat-stats [-q search] [-json | -csv table] [-interval day|week|month]
+//
+// Without -json or -csv a short text summary is printed.
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"strings"
+	"time"
+
+	beeperdesktop "github.com/cameronaaron/beeper-go-sdk"
+	"github.com/cameronaaron/beeper-go-sdk/analytics"
+	"github.com/cameronaaron/beeper-go-sdk/internal/cli"
+	"github.com/cameronaaron/beeper-go-sdk/search"
+)
+
+const dateFormat = "2006-01-02 15:04"
+
+// options builds the analytics options for a search query
+func options(ctx context.Context, client beeperdesktop.Client, q string, interval string, loc *time.Location, maxMessages int) (analytics.Options, error) {
+	opts := analytics.Options{
+		Interval:    analytics.Interval(interval),
+		Location:    loc,
+		MaxMessages: maxMessages,
+	}
+	switch opts.Interval {
+	case analytics.Day, analytics.Week, analytics.Month:
+	default:
+		return opts, fmt.Errorf("unknown interval %q: want day, week or month", interval)
+	}
+
+	query, err := search.Parse(q)
+	if err != nil {
+		return opts, err
+	}
+	params, err := search.NewResolver(client).MessageParams(ctx, query)
+	if err != nil {
+		return opts, err
+	}
+	opts.Params = params
+	return opts, nil
+}
+
+// printReport writes a text summary with at most top rows per list
+func printReport(w io.Writer, r *analytics.Report, top int) {
+	total := r.Total
+	if total.Messages == 0 {
+		fmt.Fprintln(w, "No messages found")
+		return
+	}
+	fmt.Fprintf(w, "%d messages in %d chats, %s to %s\n",
+		total.Messages, len(r.Chats), total.First.Format(dateFormat), total.Last.Format(dateFormat))
+
+	fmt.Fprintln(w, "\nTop senders")
+	for i, s := range total.Senders {
+		if i == top || s.Messages == 0 {
+			break
+		}
+		name := s.Name
+		if name == "" {
+			name = s.ID
+		}
+		fmt.Fprintf(w, "  %-24s %6d", truncate(name, 24), s.Messages)
+		if s.Replies > 0 {
+			fmt.Fprintf(w, "  median reply %s", seconds(s.MedianResponse))
+		}
+		fmt.Fprintln(w)
+	}
+
+	fmt.Fprintln(w, "\nBusiest chats")
+	for i, c := range r.Chats {
+		if i == top {
+			break
+		}
+		title := c.Title
+		if title == "" {
+			title = c.ChatID
+		}
+		fmt.Fprintf(w, "  %-24s %6d  %s\n", truncate(title, 24), c.Messages, c.AccountID)
+	}
+
+	if len(total.Media) > 0 {
+		fmt.Fprintf(w, "\nMedia: %s\n", joinCounts(total.Media, top))
+	}
+	if len(total.ReactionKeys) > 0 {
+		fmt.Fprintf(w, "Reactions: %s\n", joinCounts(total.ReactionKeys, top))
+	}
+
+	if rt := total.ResponseTimes; rt.Count > 0 {
+		fmt.Fprintf(w, "\nResponse times: %d replies, median %s, 90th percentile %s\n", rt.Count, seconds(rt.Median), seconds(rt.P90))
+		for _, b := range rt.Buckets {
+			fmt.Fprintln(w, strings.TrimRight(fmt.Sprintf("  %-7s %6d %s", b.Label, b.Count, bar(b.Count, rt.Count)), " "))
+		}
+	}
+
+	day, hour, busiest := 0, 0, 0
+	for d, hours := range total.ActiveHours {
+		for h, n := range hours {
+			if n > busiest {
+				day, hour, busiest = d, h, n
+			}
+		}
+	}
+	fmt.Fprintf(w, "\nBusiest hour: %s %02d:00 (%d messages)\n", time.Weekday(day).String()[:3], hour, busiest)
+
+	fmt.Fprintf(w, "\nMessages per %s\n", r.Interval)
+	peak := 0
+	for _, p := range total.Trend {
+		peak = max(peak, p.Messages)
+	}
+	for _, p := range total.Trend {
+		fmt.Fprintln(w, strings.TrimRight(fmt.Sprintf("  %s %6d %s", p.Start.Format("2006-01-02"), p.Messages, bar(p.Messages, peak)), " "))
+	}
+}
+
+func validTable(name string) bool {
+	for _, table := range analytics.Tables {
+		if string(table) == name {
+			return true
+		}
+	}
+	return false
+}
+
+func joinCounts(counts []analytics.Count, top int) string {
+	var parts []string
+	for i, c := range counts {
+		if i == top {
+			break
+		}
+		parts = append(parts, fmt.Sprintf("%s %d", c.Key, c.Count))
+	}
+	return strings.Join(parts, ", ")
+}
+
+// seconds formats a number of seconds as a rounded duration
+func seconds(s float64) string {
+	d := time.Duration(s * float64(time.Second))
+	switch {
+	case d < time.Minute:
+		d = d.Round(time.Second)
+	case d < time.Hour:
+		d = d.Round(time.Minute)
+	default:
+		d = d.Round(10 * time.Minute)
+	}
+	// 1h0m0s reads better as 1h
+	text := d.String()
+	if strings.HasSuffix(text, "m0s") {
+		text = strings.TrimSuffix(text, "0s")
+	}
+	if strings.HasSuffix(text, "h0m") {
+		text = strings.TrimSuffix(text, "0m")
+	}
+	return text
+}
+
+// bar draws n out of total as up to 30 blocks
+func bar(n, total int) string {
+	if total == 0 {
+		return ""
+	}
+	return strings.Repeat("█", n*30/total)
+}
+
+func truncate(s string, n int) string {
+	if r := []rune(s); len(r) > n {
+		return string(r[:n-1]) + "…"
+	}
+	return s
+}
+
+func main() {
+	q := flag.String("q", "", `messages to analyze, as a search, e.g. "account:whatsapp after:2024-01-01"`)
+	jsonOutput := flag.Bool("json", false, "print the full report as JSON")
+	csvTable := flag.String("csv", "", "print one table as CSV: summary, senders, media, reactions, responses, hours or trend")
+	interval := flag.String("interval", "day", "trend bucket: day, week or month")
+	tz := flag.String("tz", "", "time zone for active hours and trends (default local)")
+	maxMessages := flag.Int("max", 0, "analyze at most this many of the most recent messages (0 for all)")
+	top := flag.Int("top", 10, "rows per list in the text summary")
+	clientFlags := cli.RegisterClientFlags(flag.CommandLine)
+	flag.Parse()
+
+	if *jsonOutput && *csvTable != "" {
+		log.Fatal("Use -json or -csv, not both")
+	}
+	if *csvTable != "" && !validTable(*csvTable) {
+		log.Fatalf("Unknown -csv table %q", *csvTable)
+	}
+
+	loc := time.Local
+	if *tz != "" {
+		var err error
+		if loc, err = time.LoadLocation(*tz); err != nil {
+			log.Fatal("Invalid -tz: ", err)
+		}
+	}
+
+	client, err := beeperdesktop.New(clientFlags.Options()...)
+	if err != nil {
+		log.Fatal("Failed to create client: ", err)
+	}
+
+	ctx := context.Background()
+	opts, err := options(ctx, client, *q, *interval, loc, *maxMessages)
+	if err != nil {
+		log.Fatal(err)
+	}
+	report, err := analytics.Run(ctx, client, opts)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	switch {
+	case *jsonOutput:
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		err = encoder.Encode(report)
+	case *csvTable != "":
+		err = report.WriteCSV(os.Stdout, analytics.Table(*csvTable))
+	default:
+		printReport(os.Stdout, report, *top)
+	}
+	if err != nil {
+		log.Fatal(err)
+	}
+}
@@ -0,0 +1,51 @@
+package main
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/cameronaaron/beeper-go-sdk/analytics"
+	"github.com/cameronaaron/beeper-go-sdk/beepertest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReport(t *testing.T) {
+	server := beepertest.NewServer(beepertest.DefaultFixtures())
+	defer server.Close()
+	client, err := server.Client()
+	require.NoError(t, err)
+	ctx := context.Background()
+
+	opts, err := options(ctx, client, "account:matrix", "week", time.UTC, 0)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"matrix"}, opts.Params.AccountIDs)
+
+	report, err := analytics.Run(ctx, client, opts)
+	require.NoError(t, err)
+
+	var out strings.Builder
+	printReport(&out, report, 1)
+	text := out.String()
+	assert.Contains(t, text, "4 messages in 2 chats, 2024-06-01 09:00 to 2024-06-01 09:30\n")
+	assert.Contains(t, text, "\nTop senders\n  Alice Smith                   2  median reply 10m\n\nBusiest chats\n  Alice Smith ")
+	assert.Contains(t, text, "Media: img 1\nReactions: 🎉 1\n")
+	assert.Contains(t, text, "Response times: 2 replies, median 5m, 90th percentile 10m\n  <1m          0\n")
+	assert.Contains(t, text, "Busiest hour: Sat 09:00 (4 messages)\n")
+	assert.Contains(t, text, "Messages per week\n  2024-05-27      4 ")
+
+	_, err = options(ctx, client, "", "year", time.UTC, 0)
+	assert.EqualError(t, err, `unknown interval "year": want day, week or month`)
+	_, err = options(ctx, client, "from:", "day", time.UTC, 0)
+	assert.Error(t, err)
+
+	assert.Equal(t, "45s", seconds(44.6))
+	assert.Equal(t, "1h", seconds(3590))
+	assert.Equal(t, "2h10m", seconds(7800))
+
+	out.Reset()
+	printReport(&out, analytics.Analyze(nil, analytics.Options{}), 10)
+	assert.Equal(t, "No messages found\n", out.String())
+}
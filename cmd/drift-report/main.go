@@ -0,0 +1,283 @@
+// Command drift-report calls every read-only Desktop API endpoint and reports
+// where the responses no longer match the SDK's types: fields Desktop sends
+// that the SDK would drop, and required fields it stopped sending.
+//
+//	BEEPER_ACCESS_TOKEN=... go run ./cmd/drift-report [-json] [-query text] [-profile name]
+//
+// Endpoints that change data are listed as skipped. The exit status is 1 when
+// any drift is found or an endpoint fails.
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"sync"
+
+	beeperdesktop "github.com/cameronaaron/beeper-go-sdk"
+	"github.com/cameronaaron/beeper-go-sdk/internal/cli"
+	"github.com/cameronaaron/beeper-go-sdk/resources"
+)
+
+// Endpoint statuses
+const (
+	statusOK      = "ok"
+	statusDrift   = "drift"
+	statusError   = "error"
+	statusSkipped = "skipped"
+)
+
+type endpointResult struct {
+	Endpoint string                `json:"endpoint"`
+	Status   string                `json:"status"`
+	Detail   string                `json:"detail,omitempty"`
+	Drifts   []beeperdesktop.Drift `json:"drifts,omitempty"`
+}
+
+type report struct {
+	SDKVersion string           `json:"sdkVersion"`
+	Endpoints  []endpointResult `json:"endpoints"`
+}
+
+// failed reports whether any endpoint drifted or could not be checked
+func (r report) failed() bool {
+	for _, e := range r.Endpoints {
+		if e.Status == statusDrift || e.Status == statusError {
+			return true
+		}
+	}
+	return false
+}
+
+// collector gathers the drift reported by the client for the current endpoint
+type collector struct {
+	mu     sync.Mutex
+	drifts []beeperdesktop.Drift
+}
+
+func (c *collector) add(d beeperdesktop.Drift) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.drifts = append(c.drifts, d)
+}
+
+func (c *collector) take() []beeperdesktop.Drift {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	drifts := c.drifts
+	c.drifts = nil
+	return drifts
+}
+
+// session carries IDs discovered by earlier endpoints to later ones
+type session struct {
+	client    *beeperdesktop.BeeperDesktop
+	query     string
+	accountID string
+	chatID    string
+	assetURL  string
+}
+
+// errSkip marks an endpoint that could not be exercised; its text is the reason
+type errSkip string
+
+func (e errSkip) Error() string { return string(e) }
+
+type step struct {
+	endpoint string
+	run      func(ctx context.Context, s *session) error
+}
+
+var steps = []step{
+	{"token.info", func(ctx context.Context, s *session) error {
+		_, err := s.client.Token.Info(ctx)
+		return err
+	}},
+	{"accounts.list", func(ctx context.Context, s *session) error {
+		accounts, err := s.client.Accounts.List(ctx)
+		if err == nil && len(*accounts) > 0 {
+			s.accountID = (*accounts)[0].AccountID
+		}
+		return err
+	}},
+	{"chats.search", func(ctx context.Context, s *session) error {
+		chats, err := s.client.Chats.Search(ctx, resources.ChatSearchParams{Limit: beeperdesktop.IntPtr(20)})
+		if err == nil && len(chats.Items) > 0 {
+			s.chatID = chats.Items[0].ID
+		}
+		return err
+	}},
+	{"chats.retrieve", func(ctx context.Context, s *session) error {
+		if s.chatID == "" {
+			return errSkip("no chat found")
+		}
+		_, err := s.client.Chats.Retrieve(ctx, resources.ChatRetrieveParams{ChatID: s.chatID})
+		return err
+	}},
+	{"chats.participants.list", func(ctx context.Context, s *session) error {
+		if s.chatID == "" {
+			return errSkip("no chat found")
+		}
+		_, err := s.client.Chats.Participants.List(ctx, resources.ParticipantListParams{ChatID: s.chatID})
+		return err
+	}},
+	{"chats.reminders.list", func(ctx context.Context, s *session) error {
+		_, err := s.client.Chats.Reminders.List(ctx, resources.ReminderListParams{})
+		return err
+	}},
+	{"chats.reminders.get", func(ctx context.Context, s *session) error {
+		if s.chatID == "" {
+			return errSkip("no chat found")
+		}
+		_, err := s.client.Chats.Reminders.Get(ctx, resources.ReminderGetParams{ChatID: s.chatID})
+		var notFound *beeperdesktop.NotFoundError
+		if errors.As(err, &notFound) {
+			return errSkip("no reminder set on " + s.chatID)
+		}
+		return err
+	}},
+	{"contacts.search", func(ctx context.Context, s *session) error {
+		if s.accountID == "" {
+			return errSkip("no account found")
+		}
+		_, err := s.client.Contacts.Search(ctx, resources.ContactSearchParams{AccountID: s.accountID, Query: s.query})
+		return err
+	}},
+	{"messages.search", func(ctx context.Context, s *session) error {
+		messages, err := s.client.Messages.Search(ctx, resources.MessageSearchParams{
+			Limit:      beeperdesktop.IntPtr(50),
+			MediaTypes: []string{"any"},
+		})
+		if err != nil {
+			return err
+		}
+		for _, message := range messages.Items {
+			for _, attachment := range message.Attachments {
+				if attachment.SrcURL != nil && s.assetURL == "" {
+					s.assetURL = *attachment.SrcURL
+				}
+			}
+		}
+		return nil
+	}},
+	{"app.search", func(ctx context.Context, s *session) error {
+		_, err := s.client.App.Search(ctx, resources.AppSearchParams{Query: s.query, Limit: beeperdesktop.IntPtr(5)})
+		return err
+	}},
+	{"app.downloadAsset", func(ctx context.Context, s *session) error {
+		if s.assetURL == "" {
+			return errSkip("no attachment found")
+		}
+		_, err := s.client.App.DownloadAsset(ctx, resources.AppDownloadAssetParams{AssetURL: s.assetURL})
+		return err
+	}},
+}
+
+// skipped lists the endpoints the report never calls and why
+var skipped = []endpointResult{
+	{Endpoint: "app.open", Detail: "brings Desktop to the front"},
+	{Endpoint: "chats.create", Detail: "changes data"},
+	{Endpoint: "chats.archive", Detail: "changes data"},
+	{Endpoint: "chats.participants.add", Detail: "changes data"},
+	{Endpoint: "chats.participants.remove", Detail: "changes data"},
+	{Endpoint: "chats.reminders.create", Detail: "changes data"},
+	{Endpoint: "chats.reminders.delete", Detail: "changes data"},
+	{Endpoint: "messages.send", Detail: "changes data"},
+}
+
+// runReport calls every step in order. The client must report drift to c.
+func runReport(ctx context.Context, client *beeperdesktop.BeeperDesktop, c *collector, query string) report {
+	s := &session{client: client, query: query}
+	r := report{SDKVersion: beeperdesktop.Version}
+
+	for _, st := range steps {
+		c.take()
+		err := st.run(ctx, s)
+		result := endpointResult{Endpoint: st.endpoint, Drifts: c.take()}
+
+		var skip errSkip
+		switch {
+		case errors.As(err, &skip):
+			result.Status = statusSkipped
+			result.Detail = string(skip)
+		case err != nil:
+			result.Status = statusError
+			result.Detail = err.Error()
+		default:
+			result.Status = statusOK
+		}
+		// Drift seen before a failure is still worth reporting
+		if len(result.Drifts) > 0 {
+			result.Status = statusDrift
+		}
+		r.Endpoints = append(r.Endpoints, result)
+	}
+
+	for _, e := range skipped {
+		e.Status = statusSkipped
+		r.Endpoints = append(r.Endpoints, e)
+	}
+	return r
+}
+
+func printReport(w io.Writer, r report) {
+	fmt.Fprintf(w, "Schema drift report (SDK %s)\n\n", r.SDKVersion)
+	for _, e := range r.Endpoints {
+		fmt.Fprintf(w, "%-27s %s", e.Endpoint, e.Status)
+		if e.Detail != "" {
+			fmt.Fprintf(w, " (%s)", e.Detail)
+		}
+		fmt.Fprintln(w)
+
+		for _, d := range e.Drifts {
+			switch d.Kind {
+			case beeperdesktop.DriftUnknownField:
+				fmt.Fprintf(w, "    + %s in %s", d.Field, d.Type)
+			case beeperdesktop.DriftMissingField:
+				fmt.Fprintf(w, "    - %s of %s", d.Field, d.Type)
+			default:
+				fmt.Fprintf(w, "    ? %s %s in %s", d.Kind, d.Field, d.Type)
+			}
+			if d.Sample != "" {
+				fmt.Fprintf(w, "  e.g. %s", d.Sample)
+			}
+			fmt.Fprintln(w)
+		}
+	}
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "+ field sent by Desktop but unknown to the SDK, - required field Desktop did not send")
+}
+
+func main() {
+	jsonOutput := flag.Bool("json", false, "print the report as JSON")
+	query := flag.String("query", "a", "search text for the contact and app search endpoints")
+	clientFlags := cli.RegisterClientFlags(flag.CommandLine)
+	flag.Parse()
+
+	c := &collector{}
+	client, err := beeperdesktop.New(append(clientFlags.Options(), beeperdesktop.WithDriftHandler(c.add))...)
+	if err != nil {
+		log.Fatal("Failed to create client: ", err)
+	}
+
+	r := runReport(context.Background(), client, c, *query)
+
+	if *jsonOutput {
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		if err := encoder.Encode(r); err != nil {
+			log.Fatal(err)
+		}
+	} else {
+		printReport(os.Stdout, r)
+	}
+
+	if r.failed() {
+		os.Exit(1)
+	}
+}
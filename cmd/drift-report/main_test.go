@@ -0,0 +1,98 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/http/httputil"
+	"net/url"
+	"strconv"
+	"strings"
+	"testing"
+
+	beeperdesktop "github.com/cameronaaron/beeper-go-sdk"
+	"github.com/cameronaaron/beeper-go-sdk/beepertest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// driftingProxy serves the fake server's responses with an extra top-level
+// field added to every JSON object
+func driftingProxy(t *testing.T, target string) *httptest.Server {
+	u, err := url.Parse(target)
+	require.NoError(t, err)
+
+	proxy := httputil.NewSingleHostReverseProxy(u)
+	proxy.ModifyResponse = func(resp *http.Response) error {
+		body, err := io.ReadAll(resp.Body)
+		if err != nil {
+			return err
+		}
+		resp.Body.Close()
+		if bytes.HasPrefix(body, []byte("{")) && resp.StatusCode < 400 {
+			extra := []byte(`{"futureField":true,`)
+			if bytes.HasPrefix(bytes.TrimSpace(body[1:]), []byte("}")) {
+				extra = []byte(`{"futureField":true`)
+			}
+			body = append(extra, body[1:]...)
+		}
+		resp.Body = io.NopCloser(bytes.NewReader(body))
+		resp.ContentLength = int64(len(body))
+		resp.Header.Set("Content-Length", strconv.Itoa(len(body)))
+		return nil
+	}
+	return httptest.NewServer(proxy)
+}
+
+func TestRunReport(t *testing.T) {
+	server := beepertest.NewServer(beepertest.DefaultFixtures())
+	defer server.Close()
+	proxy := driftingProxy(t, server.URL)
+	defer proxy.Close()
+
+	c := &collector{}
+	client, err := beeperdesktop.New(
+		beeperdesktop.WithAccessToken(server.Token),
+		beeperdesktop.WithBaseURL(proxy.URL),
+		beeperdesktop.WithMaxRetries(0),
+		beeperdesktop.WithDriftHandler(c.add),
+	)
+	require.NoError(t, err)
+
+	r := runReport(context.Background(), client, c, "a")
+	require.True(t, r.failed())
+
+	results := map[string]endpointResult{}
+	for _, e := range r.Endpoints {
+		results[e.Endpoint] = e
+	}
+
+	// The accounts list is a JSON array, so the proxy leaves it alone
+	assert.Equal(t, statusOK, results["accounts.list"].Status)
+	assert.Equal(t, statusSkipped, results["messages.send"].Status)
+
+	chats := results["chats.search"]
+	assert.Equal(t, statusDrift, chats.Status)
+	require.Len(t, chats.Drifts, 1)
+	assert.Equal(t, "futureField", chats.Drifts[0].Field)
+	assert.Equal(t, beeperdesktop.DriftUnknownField, chats.Drifts[0].Kind)
+	assert.Equal(t, "true", chats.Drifts[0].Sample)
+
+	for _, e := range r.Endpoints {
+		assert.NotEqual(t, statusError, e.Status, "%s: %s", e.Endpoint, e.Detail)
+	}
+
+	var out strings.Builder
+	printReport(&out, r)
+	assert.Contains(t, out.String(), "    + futureField in resources.Cursor[resources.Chat]")
+}
+
+func TestReportFailed(t *testing.T) {
+	r := report{Endpoints: []endpointResult{{Endpoint: "accounts.list", Status: statusOK}, {Endpoint: "messages.send", Status: statusSkipped}}}
+	assert.False(t, r.failed())
+
+	r.Endpoints = append(r.Endpoints, endpointResult{Endpoint: "chats.search", Status: statusError})
+	assert.True(t, r.failed(), "an endpoint that could not be checked fails the report")
+}
@@ -0,0 +1,423 @@
+// Command send-later schedules messages to send later, once or on a cron
+// schedule. Jobs are kept in a local directory; the run command sends them
+// as they come due and records each result.
+//
+//	send-later add -chat CHAT_ID -text "Happy birthday!" -at "2024-06-03 09:00"
+//	send-later add -chat CHAT_ID -text "Standup in 5" -cron "55 9 * * MON-FRI"
+//	send-later list
+//	send-later edit -text "Standup in 10" JOB_ID
+//	send-later cancel JOB_ID
+//	BEEPER_ACCESS_TOKEN=... send-later run
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"time"
+
+	beeperdesktop "github.com/cameronaaron/beeper-go-sdk"
+	"github.com/cameronaaron/beeper-go-sdk/internal/cli"
+	"github.com/cameronaaron/beeper-go-sdk/outbox"
+	"github.com/cameronaaron/beeper-go-sdk/scheduler"
+)
+
+const (
+	dateFormat = "2006-01-02 15:04"
+	usage      = `Usage: send-later [-dir DIR] [-profile NAME] [-timeout D] [-retries N] COMMAND [flags] [JOB_ID]
+
+Commands:
+  add      schedule a message
+  edit     change a job's message or schedule
+  list     list jobs
+  show     show a job and its results
+  cancel   stop a job from running again
+  remove   delete a job and its results
+  run      send jobs as they come due, until interrupted
+
+Run "send-later COMMAND -h" for a command's flags.
+`
+)
+
+// defaultDir returns the directory jobs are kept in: send-later next to the
+// Beeper config file
+func defaultDir() string {
+	return filepath.Join(filepath.Dir(beeperdesktop.DefaultConfigPath()), "send-later")
+}
+
+// open opens the scheduler kept in dir
+func open(dir string, client beeperdesktop.Client, opts scheduler.Options) (*scheduler.Scheduler, error) {
+	opts.Store = scheduler.NewFileStore(filepath.Join(dir, "jobs.json"))
+	opts.Outbox.Store = outbox.NewFileStore(filepath.Join(dir, "outbox.json"))
+	return scheduler.Open(client, opts)
+}
+
+// parseTime reads a time given on the command line: RFC 3339, a date with
+// an optional time of day, or a time of day alone, meaning its next
+// occurrence
+func parseTime(s string, now time.Time, loc *time.Location) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, s); err == nil {
+		return t, nil
+	}
+	for _, layout := range []string{dateFormat, "2006-01-02T15:04", "2006-01-02"} {
+		if t, err := time.ParseInLocation(layout, s, loc); err == nil {
+			return t, nil
+		}
+	}
+	if clock, err := time.Parse("15:04", s); err == nil {
+		now = now.In(loc)
+		t := time.Date(now.Year(), now.Month(), now.Day(), clock.Hour(), clock.Minute(), 0, 0, loc)
+		if !t.After(now) {
+			t = t.AddDate(0, 0, 1)
+		}
+		return t, nil
+	}
+	return time.Time{}, fmt.Errorf("invalid time %q: want 2006-01-02 15:04, 15:04 or RFC 3339", s)
+}
+
+// jobFlags are the flags of add and edit
+type jobFlags struct {
+	fs                                            *flag.FlagSet
+	id, chat, text, attach, replyTo, at, cron, tz *string
+	in                                            *time.Duration
+}
+
+func newJobFlags(name string, w io.Writer) *jobFlags {
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	fs.SetOutput(w)
+	f := &jobFlags{
+		fs:      fs,
+		chat:    fs.String("chat", "", "chat ID to send to"),
+		text:    fs.String("text", "", "message text"),
+		attach:  fs.String("attach", "", "path of a file to attach"),
+		replyTo: fs.String("reply-to", "", "ID of the message to reply to"),
+		at:      fs.String("at", "", `when to send, e.g. "2024-06-03 09:00" or "09:00"; with -cron, the earliest first run`),
+		in:      fs.Duration("in", 0, "send after this long, e.g. 90m"),
+		cron:    fs.String("cron", "", `send on a cron schedule, e.g. "30 9 * * MON-FRI" or @daily`),
+		tz:      fs.String("tz", "", "time zone for -at and -cron (default local)"),
+	}
+	if name == "add" {
+		f.id = fs.String("id", "", "job ID (default random)")
+	}
+	return f
+}
+
+// apply sets the fields of job given on the command line
+func (f *jobFlags) apply(job *scheduler.Job, now time.Time) error {
+	set := map[string]bool{}
+	f.fs.Visit(func(fl *flag.Flag) { set[fl.Name] = true })
+	if set["at"] && set["in"] {
+		return errors.New("use -at or -in, not both")
+	}
+
+	if set["id"] {
+		job.ID = *f.id
+	}
+	if set["chat"] {
+		job.Params.ChatID = *f.chat
+	}
+	if set["text"] {
+		job.Params.Text = *f.text
+	}
+	if set["attach"] {
+		job.Params.Attachment = optional(*f.attach)
+	}
+	if set["reply-to"] {
+		job.Params.ReplyToID = optional(*f.replyTo)
+	}
+	if set["cron"] {
+		job.Cron = *f.cron
+	}
+	if set["tz"] {
+		job.TimeZone = *f.tz
+	}
+
+	loc := time.Local
+	if job.TimeZone != "" {
+		var err error
+		if loc, err = time.LoadLocation(job.TimeZone); err != nil {
+			return fmt.Errorf("invalid -tz: %w", err)
+		}
+	}
+	switch {
+	case set["at"] && *f.at == "":
+		job.At = time.Time{}
+	case set["at"]:
+		at, err := parseTime(*f.at, now, loc)
+		if err != nil {
+			return err
+		}
+		job.At = at
+	case set["in"]:
+		job.At = now.Add(*f.in)
+	}
+	return nil
+}
+
+func optional(s string) *string {
+	if s == "" {
+		return nil
+	}
+	return &s
+}
+
+// command runs every command but run, showing times in loc
+func command(s *scheduler.Scheduler, args []string, now time.Time, loc *time.Location, w io.Writer) error {
+	if len(args) == 0 {
+		return errors.New("missing command")
+	}
+	name, args := args[0], args[1:]
+
+	switch name {
+	case "add", "edit":
+		f := newJobFlags(name, w)
+		if err := f.fs.Parse(args); err != nil {
+			return err
+		}
+		var job scheduler.Job
+		if name == "edit" {
+			id, err := jobID(f.fs)
+			if err != nil {
+				return err
+			}
+			var ok bool
+			if job, ok = s.Job(id); !ok {
+				return fmt.Errorf("%w %q", scheduler.ErrUnknownJob, id)
+			}
+		} else if f.fs.NArg() > 0 {
+			return fmt.Errorf("unexpected argument %q", f.fs.Arg(0))
+		}
+		if err := f.apply(&job, now); err != nil {
+			return err
+		}
+
+		var err error
+		if name == "add" {
+			job, err = s.Add(job)
+		} else {
+			job, err = s.Edit(job.ID, job)
+		}
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(w, "%s: next run %s\n", job.ID, job.Next.In(loc).Format(dateFormat))
+		return nil
+
+	case "list":
+		fs := flag.NewFlagSet(name, flag.ContinueOnError)
+		fs.SetOutput(w)
+		all := fs.Bool("all", false, "include completed and canceled jobs")
+		jsonOutput := fs.Bool("json", false, "print the jobs as JSON")
+		if err := fs.Parse(args); err != nil {
+			return err
+		}
+		var jobs []scheduler.Job
+		for _, job := range s.List() {
+			if *all || job.Status == scheduler.Scheduled {
+				jobs = append(jobs, job)
+			}
+		}
+		if *jsonOutput {
+			encoder := json.NewEncoder(w)
+			encoder.SetIndent("", "  ")
+			return encoder.Encode(jobs)
+		}
+		printJobs(w, jobs, loc)
+		return nil
+
+	case "show", "cancel", "remove":
+		fs := flag.NewFlagSet(name, flag.ContinueOnError)
+		fs.SetOutput(w)
+		if err := fs.Parse(args); err != nil {
+			return err
+		}
+		id, err := jobID(fs)
+		if err != nil {
+			return err
+		}
+		switch name {
+		case "cancel":
+			return s.Cancel(id)
+		case "remove":
+			return s.Remove(id)
+		}
+		job, ok := s.Job(id)
+		if !ok {
+			return fmt.Errorf("%w %q", scheduler.ErrUnknownJob, id)
+		}
+		printJob(w, job, loc)
+		return nil
+	}
+	return fmt.Errorf("unknown command %q", name)
+}
+
+// jobID returns the single job ID argument of a command
+func jobID(fs *flag.FlagSet) (string, error) {
+	if fs.NArg() != 1 {
+		return "", fmt.Errorf("%s takes one job ID, after its flags", fs.Name())
+	}
+	return fs.Arg(0), nil
+}
+
+// printJobs writes one line per job
+func printJobs(w io.Writer, jobs []scheduler.Job, loc *time.Location) {
+	if len(jobs) == 0 {
+		fmt.Fprintln(w, "No jobs")
+		return
+	}
+	fmt.Fprintf(w, "%-12s %-9s %-16s %-20s %-16s %s\n", "ID", "STATUS", "NEXT", "SCHEDULE", "CHAT", "MESSAGE")
+	for _, job := range jobs {
+		next := "-"
+		if !job.Next.IsZero() {
+			next = job.Next.In(loc).Format(dateFormat)
+		}
+		line := fmt.Sprintf("%-12s %-9s %-16s %-20s %-16s %s", truncate(job.ID, 12), job.Status, next,
+			truncate(schedule(job), 20), truncate(job.Params.ChatID, 16), truncate(message(job), 40))
+		fmt.Fprintln(w, strings.TrimRight(line, " "))
+	}
+}
+
+// printJob writes a job and its results
+func printJob(w io.Writer, job scheduler.Job, loc *time.Location) {
+	fmt.Fprintf(w, "ID:        %s\n", job.ID)
+	fmt.Fprintf(w, "Status:    %s\n", job.Status)
+	fmt.Fprintf(w, "Chat:      %s\n", job.Params.ChatID)
+	fmt.Fprintf(w, "Message:   %s\n", message(job))
+	if job.Params.ReplyToID != nil {
+		fmt.Fprintf(w, "Reply to:  %s\n", *job.Params.ReplyToID)
+	}
+	if job.Recurring() {
+		fmt.Fprintf(w, "Schedule:  %s\n", schedule(job))
+		if !job.At.IsZero() {
+			fmt.Fprintf(w, "Starts:    %s\n", job.At.In(loc).Format(dateFormat))
+		}
+	} else {
+		fmt.Fprintf(w, "At:        %s\n", job.At.In(loc).Format(dateFormat))
+	}
+	if !job.Next.IsZero() {
+		fmt.Fprintf(w, "Next:      %s\n", job.Next.In(loc).Format(dateFormat))
+	}
+
+	if len(job.Runs) == 0 {
+		return
+	}
+	fmt.Fprintln(w, "Runs:")
+	for _, run := range job.Runs {
+		detail := run.MessageID
+		if run.Error != "" {
+			detail = run.Error
+		}
+		fmt.Fprintln(w, strings.TrimRight(fmt.Sprintf("  %s  %-7s  %s", run.At.In(loc).Format(dateFormat), run.Status, detail), " "))
+	}
+}
+
+// schedule describes when a job runs
+func schedule(job scheduler.Job) string {
+	if !job.Recurring() {
+		return "once"
+	}
+	if job.TimeZone != "" {
+		return job.Cron + " " + job.TimeZone
+	}
+	return job.Cron
+}
+
+// message describes what a job sends
+func message(job scheduler.Job) string {
+	text := strings.Join(strings.Fields(job.Params.Text), " ")
+	if job.Params.Attachment != nil {
+		attachment := "[" + filepath.Base(*job.Params.Attachment) + "]"
+		if text == "" {
+			return attachment
+		}
+		return attachment + " " + text
+	}
+	return text
+}
+
+func truncate(s string, n int) string {
+	if r := []rune(s); len(r) > n {
+		return string(r[:n-1]) + "…"
+	}
+	return s
+}
+
+// run sends jobs as they come due until interrupted
+func run(dir string, clientFlags *cli.ClientFlags, args []string) error {
+	fs := flag.NewFlagSet("run", flag.ContinueOnError)
+	interval := fs.Duration("interval", 30*time.Second, "how often to retry failed messages and pick up jobs changed by other commands")
+	maxDelay := fs.Duration("max-delay", 0, "skip runs more than this late, such as after downtime (0 sends them late)")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	client, err := beeperdesktop.New(clientFlags.Options()...)
+	if err != nil {
+		return fmt.Errorf("failed to create client: %w", err)
+	}
+
+	s, err := open(dir, client, scheduler.Options{
+		Interval: *interval,
+		MaxDelay: *maxDelay,
+		OnResult: func(job scheduler.Job, run scheduler.Run) {
+			if run.Error != "" {
+				log.Printf("%s: %s: %s", job.ID, run.Status, run.Error)
+				return
+			}
+			log.Printf("%s: %s", job.ID, run.Status)
+		},
+	})
+	if err != nil {
+		return err
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	log.Printf("Sending scheduled messages from %s", dir)
+	err = s.Run(ctx, func(err error) { log.Print(err) })
+	if errors.Is(err, context.Canceled) {
+		return nil
+	}
+	return err
+}
+
+func main() {
+	dir := flag.String("dir", defaultDir(), "directory jobs are kept in")
+	clientFlags := cli.RegisterClientFlags(flag.CommandLine)
+	flag.Usage = func() {
+		fmt.Fprint(flag.CommandLine.Output(), usage)
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+	if flag.NArg() == 0 {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	if flag.Arg(0) == "run" {
+		if err := run(*dir, clientFlags, flag.Args()[1:]); err != nil {
+			log.Fatal(err)
+		}
+		return
+	}
+
+	s, err := open(*dir, nil, scheduler.Options{})
+	if err != nil {
+		log.Fatal(err)
+	}
+	if err := command(s, flag.Args(), time.Now(), time.Local, os.Stdout); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return
+		}
+		log.Fatal(err)
+	}
+}
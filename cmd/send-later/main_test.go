@@ -0,0 +1,100 @@
+package main
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/cameronaaron/beeper-go-sdk/scheduler"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseTime(t *testing.T) {
+	now := time.Date(2024, 6, 3, 10, 0, 0, 0, time.UTC)
+	tests := map[string]time.Time{
+		"2024-06-05 09:30":          time.Date(2024, 6, 5, 9, 30, 0, 0, time.UTC),
+		"2024-06-05T09:30":          time.Date(2024, 6, 5, 9, 30, 0, 0, time.UTC),
+		"2024-06-05":                time.Date(2024, 6, 5, 0, 0, 0, 0, time.UTC),
+		"2024-06-05T09:30:00+02:00": time.Date(2024, 6, 5, 7, 30, 0, 0, time.UTC),
+		"11:15":                     time.Date(2024, 6, 3, 11, 15, 0, 0, time.UTC),
+		"09:00":                     time.Date(2024, 6, 4, 9, 0, 0, 0, time.UTC),
+	}
+	for input, want := range tests {
+		got, err := parseTime(input, now, time.UTC)
+		require.NoError(t, err, input)
+		assert.True(t, want.Equal(got), "%s: got %s", input, got)
+	}
+	_, err := parseTime("tomorrow", now, time.UTC)
+	assert.Error(t, err)
+}
+
+func TestCommand(t *testing.T) {
+	s, err := scheduler.Open(nil, scheduler.Options{})
+	require.NoError(t, err)
+	now := time.Now().UTC()
+	at := now.Add(48 * time.Hour).Truncate(time.Minute)
+
+	run := func(args ...string) (string, error) {
+		var out strings.Builder
+		err := command(s, args, now, time.UTC, &out)
+		return out.String(), err
+	}
+
+	out, err := run("add", "-id", "bday", "-chat", "chat-alice", "-text", "Happy\nbirthday!", "-at", at.Format(dateFormat))
+	require.NoError(t, err)
+	assert.Equal(t, "bday: next run "+at.Format(dateFormat)+"\n", out)
+	_, err = run("add", "-id", "standup", "-chat", "chat-team", "-attach", "/tmp/agenda.pdf", "-cron", "0 9 * * MON-FRI", "-tz", "Europe/Berlin")
+	require.NoError(t, err)
+
+	out, err = run("list")
+	require.NoError(t, err)
+	lines := strings.Split(strings.TrimSpace(out), "\n")
+	require.Len(t, lines, 3)
+	assert.Equal(t, "ID           STATUS    NEXT             SCHEDULE             CHAT             MESSAGE", lines[0])
+	assert.Contains(t, out, "bday         scheduled "+at.Format(dateFormat)+" once                 chat-alice       Happy birthday!\n")
+	assert.Contains(t, out, "0 9 * * MON-FRI Eur… chat-team        [agenda.pdf]\n")
+
+	_, err = run("edit", "-text", "Happy birthday 🎂", "-in", "1h", "bday")
+	require.NoError(t, err)
+	job, _ := s.Job("bday")
+	assert.Equal(t, "Happy birthday 🎂", job.Params.Text)
+	assert.Equal(t, "chat-alice", job.Params.ChatID, "unchanged")
+	assert.Equal(t, now.Add(time.Hour), job.At)
+
+	out, err = run("show", "bday")
+	require.NoError(t, err)
+	assert.Equal(t, strings.Join([]string{
+		"ID:        bday",
+		"Status:    scheduled",
+		"Chat:      chat-alice",
+		"Message:   Happy birthday 🎂",
+		"At:        " + now.Add(time.Hour).Format(dateFormat),
+		"Next:      " + now.Add(time.Hour).Format(dateFormat),
+		"",
+	}, "\n"), out)
+
+	require.NoError(t, s.Cancel("standup"))
+	_, err = run("cancel", "bday")
+	require.NoError(t, err)
+	out, err = run("list")
+	require.NoError(t, err)
+	assert.Equal(t, "No jobs\n", out)
+	out, err = run("list", "-all")
+	require.NoError(t, err)
+	assert.Contains(t, out, "bday         canceled  -")
+
+	_, err = run("remove", "bday")
+	require.NoError(t, err)
+	_, err = run("show", "bday")
+	assert.ErrorIs(t, err, scheduler.ErrUnknownJob)
+
+	_, err = run("edit", "-text", "x")
+	assert.EqualError(t, err, "edit takes one job ID, after its flags")
+	_, err = run("add", "-chat", "chat-alice", "-text", "x", "-at", "10:00", "-in", "1h")
+	assert.EqualError(t, err, "use -at or -in, not both")
+	_, err = run("add", "-chat", "chat-alice", "-text", "x")
+	assert.EqualError(t, err, "job needs a time or a cron schedule")
+	_, err = run("snooze")
+	assert.EqualError(t, err, `unknown command "snooze"`)
+}
@@ -2,12 +2,13 @@ package main
 
 import (
 	"context"
+	"errors"
+	"flag"
 	"fmt"
 	"log"
-	"os"
-	"time"
 
 	beeperdesktop "github.com/cameronaaron/beeper-go-sdk"
+	"github.com/cameronaaron/beeper-go-sdk/internal/cli"
 	"github.com/cameronaaron/beeper-go-sdk/resources"
 )
 
@@ -16,22 +17,19 @@ func main() {
 	fmt.Println("================================================")
 	fmt.Println()
 
-	// Check for access token
-	accessToken := os.Getenv("BEEPER_ACCESS_TOKEN")
-	if accessToken == "" {
-		fmt.Println("⚠️  BEEPER_ACCESS_TOKEN environment variable not set")
+	clientFlags := cli.RegisterClientFlags(flag.CommandLine)
+	flag.Parse()
+
+	// Create client
+	client, err := beeperdesktop.New(clientFlags.Options()...)
+	var authErr *beeperdesktop.AuthenticationError
+	if errors.As(err, &authErr) {
+		fmt.Println("⚠️  No access token in BEEPER_ACCESS_TOKEN or a config profile")
 		fmt.Println("ℹ️  Running in demo mode - will show API structure only")
 		fmt.Println()
 		runDemoMode()
 		return
 	}
-
-	// Create client
-	fmt.Println("✅ Creating Beeper Desktop client...")
-	client, err := beeperdesktop.New(
-		beeperdesktop.WithTimeout(10*time.Second),
-		beeperdesktop.WithMaxRetries(2),
-	)
 	if err != nil {
 		log.Fatal("❌ Failed to create client:", err)
 	}
@@ -84,6 +82,7 @@ func runDemoMode() {
 	fmt.Println("💡 To test with real API:")
 	fmt.Println("   export BEEPER_ACCESS_TOKEN=your_token_here")
 	fmt.Println("   go run cmd/testapp/main.go")
+	fmt.Println("   or add a profile to ~/.config/beeper/config.yaml and pass -profile name")
 }
 
 func runTokenTest(ctx context.Context, client *beeperdesktop.BeeperDesktop) {
@@ -7,6 +7,7 @@ import (
 	"encoding/hex"
 	"encoding/json"
 	"errors"
+	"flag"
 	"fmt"
 	"io/fs"
 	"log"
@@ -18,6 +19,7 @@ import (
 
 	beeperdesktop "github.com/cameronaaron/beeper-go-sdk"
 	"github.com/cameronaaron/beeper-go-sdk/resources"
+	"github.com/cameronaaron/beeper-go-sdk/search"
 )
 
 //go:embed ui/*
@@ -58,6 +60,9 @@ func (s *sessionStore) delete(id string) {
 }
 
 func main() {
+	profile := flag.String("profile", "", "connection profile from the Beeper config file, for the base URL and other settings")
+	flag.Parse()
+
 	addr := readEnv("PORT", "8080")
 	if !strings.HasPrefix(addr, ":") {
 		addr = ":" + addr
@@ -111,6 +116,7 @@ func main() {
 		}
 
 		clientOpts := []beeperdesktop.ClientOption{
+			beeperdesktop.WithProfile(*profile),
 			beeperdesktop.WithAccessToken(token),
 		}
 
@@ -254,6 +260,52 @@ func main() {
 		writeJSON(w, http.StatusOK, map[string]any{"messages": messages})
 	}))
 
+	mux.HandleFunc("/api/search", withJSON(func(w http.ResponseWriter, r *http.Request) {
+		sess, ok := mustSession(store, w, r)
+		if !ok {
+			return
+		}
+
+		query, err := search.Parse(r.URL.Query().Get("q"))
+		if err != nil {
+			writeError(w, http.StatusBadRequest, fmt.Sprintf("invalid search: %v", err))
+			return
+		}
+
+		ctx, cancel := context.WithTimeout(r.Context(), 10*time.Second)
+		defer cancel()
+
+		params, err := search.NewResolver(sess.Client).MessageParams(ctx, query)
+		var resolveErr *search.ResolveError
+		if errors.As(err, &resolveErr) {
+			writeError(w, http.StatusBadRequest, resolveErr.Error())
+			return
+		}
+		if err != nil {
+			writeError(w, http.StatusBadGateway, fmt.Sprintf("failed to search: %v", err))
+			return
+		}
+		params.Limit = beeperdesktop.IntPtr(100)
+
+		resp, err := sess.Client.Messages.Search(ctx, params)
+		if err != nil {
+			writeError(w, http.StatusBadGateway, fmt.Sprintf("failed to search: %v", err))
+			return
+		}
+
+		// The chats with matching messages, most recent match first
+		chatIDs := []string{}
+		seen := make(map[string]bool)
+		for _, msg := range resp.Items {
+			if !seen[msg.ChatID] {
+				seen[msg.ChatID] = true
+				chatIDs = append(chatIDs, msg.ChatID)
+			}
+		}
+
+		writeJSON(w, http.StatusOK, map[string]any{"chatIds": chatIDs, "query": query.String()})
+	}))
+
 	mux.HandleFunc("/api/messages/send", withJSON(func(w http.ResponseWriter, r *http.Request) {
 		sess, ok := mustSession(store, w, r)
 		if !ok {
@@ -25,6 +25,7 @@ const state = {
     messages: [],
     sending: false,
     chatFilter: '',
+    searchChatIds: null,
     loadingMessages: false,
     toast: null,
     pinnedChats: preferences.pinned,
@@ -72,6 +73,11 @@ const api = {
         return this.request('/api/chats');
     },
 
+    search(q) {
+        const params = new URLSearchParams({ q });
+        return this.request(`/api/search?${params.toString()}`);
+    },
+
     messages(chatId) {
         const params = new URLSearchParams({ chat_id: chatId });
         return this.request(`/api/messages?${params.toString()}`);
@@ -183,7 +189,7 @@ function renderChatShell() {
             ${renderSidebarHeader()}
             <div class="sidebar-controls">
                 <div class="chat-search">
-                    <input id="chat-filter" class="form-control" type="search" placeholder="Search chats" value="${escapeHtml(state.chatFilter)}" autocomplete="off" />
+                    <input id="chat-filter" class="form-control" type="search" placeholder="Search chats, or press Enter to search messages" value="${escapeHtml(state.chatFilter)}" autocomplete="off" />
                 </div>
                 <nav class="view-toggle" role="tablist">
                     ${renderSidebarViewToggle()}
@@ -654,7 +660,23 @@ function attachGlobalHandlers() {
     document.addEventListener('input', (event) => {
         if (event.target.matches('#chat-filter')) {
             state.chatFilter = event.target.value;
+            state.searchChatIds = null;
+            renderApp();
+        }
+    });
+
+    // Enter runs the filter as a message search, e.g. from:alice has:image
+    document.addEventListener('keydown', async (event) => {
+        if (!event.target.matches('#chat-filter') || event.key !== 'Enter') return;
+        event.preventDefault();
+        const q = event.target.value.trim();
+        if (!q) return;
+        try {
+            const { chatIds } = await api.search(q);
+            state.searchChatIds = new Set(chatIds.map(normalizeChatId));
             renderApp();
+        } catch (error) {
+            showToast(error.message);
         }
     });
 }
@@ -691,6 +713,9 @@ function getFilteredChats() {
         chats = chats.filter((chat) => chat.isArchived);
     }
 
+    if (state.searchChatIds) {
+        return chats.filter((chat) => state.searchChatIds.has(normalizeChatId(chat.id)));
+    }
     if (!state.chatFilter) {
         return chats;
     }
@@ -716,6 +741,7 @@ async function handleLogout() {
             messages: [],
             activeChat: null,
             chatFilter: '',
+            searchChatIds: null,
         });
         renderApp();
     } catch (error) {
@@ -13,6 +13,24 @@ type ClientConfig struct {
 	MaxRetries  int
 	UserAgent   string
 	HTTPClient  *http.Client
+	Dialer      DialFunc
+
+	// Schema drift detection
+	DriftHandler   DriftHandler
+	StrictDecoding bool
+
+	// AutoDiscover finds the running Desktop when no base URL is configured
+	AutoDiscover bool
+
+	// Proxy is the URL of an HTTP proxy for API requests
+	Proxy string
+	// TokenCommand prints the access token; it runs when no token is set
+	TokenCommand string
+
+	// Profiles from a config file, see ConfigFile
+	UseProfile bool
+	Profile    string
+	ConfigPath string
 }
 
 // ClientOption is a function that modifies ClientConfig
@@ -25,7 +43,8 @@ func WithAccessToken(token string) ClientOption {
 	}
 }
 
-// WithBaseURL sets the base URL for the API
+// WithBaseURL sets the base URL for the API. A unix:///path/to/socket URL
+// connects to a unix domain socket.
 func WithBaseURL(baseURL string) ClientOption {
 	return func(c *ClientConfig) {
 		c.BaseURL = baseURL
@@ -59,3 +78,75 @@ func WithHTTPClient(httpClient *http.Client) ClientOption {
 		c.HTTPClient = httpClient
 	}
 }
+
+// WithDialer sets how connections to the Desktop API are opened, for example
+// through an SSH tunnel or a named pipe. The HTTP client's transport, which
+// must be an *http.Transport if set, is cloned with this dialer. With a
+// unix:// base URL the dialer is asked for network "unix" and the socket path.
+func WithDialer(dial DialFunc) ClientOption {
+	return func(c *ClientConfig) {
+		c.Dialer = dial
+	}
+}
+
+// WithDriftHandler checks every response against the SDK's types and reports
+// unknown and missing fields to handler. Requests still succeed.
+func WithDriftHandler(handler DriftHandler) ClientOption {
+	return func(c *ClientConfig) {
+		c.DriftHandler = handler
+	}
+}
+
+// WithStrictDecoding fails requests whose responses contain fields the SDK's
+// types do not know about, returning a *SchemaDriftError. Drift is also passed
+// to the handler set with WithDriftHandler, if any.
+func WithStrictDecoding() ClientOption {
+	return func(c *ClientConfig) {
+		c.StrictDecoding = true
+	}
+}
+
+// WithAutoDiscover makes New locate the running Beeper Desktop with Discover
+// instead of assuming DefaultBaseURL. A base URL set with WithBaseURL or
+// BEEPER_DESKTOP_BASE_URL takes precedence. New fails with a
+// *DiscoveryError listing what was tried when nothing is found.
+func WithAutoDiscover() ClientOption {
+	return func(c *ClientConfig) {
+		c.AutoDiscover = true
+	}
+}
+
+// WithProxy sends API requests through an HTTP proxy
+func WithProxy(proxyURL string) ClientOption {
+	return func(c *ClientConfig) {
+		c.Proxy = proxyURL
+	}
+}
+
+// WithTokenCommand runs command with the shell to obtain the access token,
+// for example to read it from a password manager. It only runs when no token
+// is set otherwise.
+func WithTokenCommand(command string) ClientOption {
+	return func(c *ClientConfig) {
+		c.TokenCommand = command
+	}
+}
+
+// WithProfile loads a named profile from the config file (see
+// DefaultConfigPath). An empty name selects BEEPER_PROFILE or the file's
+// default, and is ignored when there is no config file, so tools can always
+// pass their -profile flag. Other options override the profile's settings
+// whatever their order.
+func WithProfile(name string) ClientOption {
+	return func(c *ClientConfig) {
+		c.UseProfile = true
+		c.Profile = name
+	}
+}
+
+// WithConfigFile reads profiles from path instead of DefaultConfigPath
+func WithConfigFile(path string) ClientOption {
+	return func(c *ClientConfig) {
+		c.ConfigPath = path
+	}
+}
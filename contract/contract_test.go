@@ -0,0 +1,369 @@
+package contract_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+
+	beeperdesktop "github.com/cameronaaron/beeper-go-sdk"
+	"github.com/cameronaaron/beeper-go-sdk/resources"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fixture is one file in fixtures/; see README.md for the format
+type fixture struct {
+	Endpoint string        `json:"endpoint"`
+	Cases    []fixtureCase `json:"cases"`
+}
+
+type fixtureCase struct {
+	Name     string            `json:"name"`
+	Params   json.RawMessage   `json:"params"`
+	Request  expectedRequest   `json:"request"`
+	Response cannedResponse    `json:"response"`
+	Result   json.RawMessage   `json:"result"`
+	Error    *expectedError    `json:"error"`
+	Pending  map[string]string `json:"pending"`
+}
+
+type expectedRequest struct {
+	Method string            `json:"method"`
+	Path   string            `json:"path"`
+	Query  map[string]string `json:"query"`
+	Body   json.RawMessage   `json:"body"`
+}
+
+type cannedResponse struct {
+	Status int             `json:"status"`
+	Body   json.RawMessage `json:"body"`
+}
+
+type expectedError struct {
+	Status  int    `json:"status"`
+	Message string `json:"message"`
+}
+
+// call invokes one SDK method with the case's params and returns its result
+type call func(ctx context.Context, client *beeperdesktop.BeeperDesktop, params json.RawMessage) (interface{}, error)
+
+// withParams adapts a resource method taking a params struct
+func withParams[P, R any](method func(*beeperdesktop.BeeperDesktop) func(context.Context, P) (*R, error)) call {
+	return func(ctx context.Context, client *beeperdesktop.BeeperDesktop, raw json.RawMessage) (interface{}, error) {
+		var params P
+		if len(raw) > 0 {
+			decoder := json.NewDecoder(bytes.NewReader(raw))
+			decoder.DisallowUnknownFields()
+			if err := decoder.Decode(&params); err != nil {
+				return nil, fmt.Errorf("decode params into %T: %w", params, err)
+			}
+		}
+		return method(client)(ctx, params)
+	}
+}
+
+// withoutParams adapts a resource method taking only a context
+func withoutParams[R any](method func(*beeperdesktop.BeeperDesktop) func(context.Context) (*R, error)) call {
+	return func(ctx context.Context, client *beeperdesktop.BeeperDesktop, _ json.RawMessage) (interface{}, error) {
+		return method(client)(ctx)
+	}
+}
+
+var endpoints = map[string]call{
+	"accounts.list": withoutParams(func(c *beeperdesktop.BeeperDesktop) func(context.Context) (*resources.AccountListResponse, error) {
+		return c.Accounts.List
+	}),
+	"token.info": withoutParams(func(c *beeperdesktop.BeeperDesktop) func(context.Context) (*resources.UserInfo, error) {
+		return c.Token.Info
+	}),
+	"app.downloadAsset": withParams(func(c *beeperdesktop.BeeperDesktop) func(context.Context, resources.AppDownloadAssetParams) (*resources.AppDownloadAssetResponse, error) {
+		return c.App.DownloadAsset
+	}),
+	"app.open": withParams(func(c *beeperdesktop.BeeperDesktop) func(context.Context, resources.AppOpenParams) (*resources.AppOpenResponse, error) {
+		return c.App.Open
+	}),
+	"app.search": withParams(func(c *beeperdesktop.BeeperDesktop) func(context.Context, resources.AppSearchParams) (*resources.AppSearchResponse, error) {
+		return c.App.Search
+	}),
+	"chats.create": withParams(func(c *beeperdesktop.BeeperDesktop) func(context.Context, resources.ChatCreateParams) (*resources.ChatCreateResponse, error) {
+		return c.Chats.Create
+	}),
+	"chats.retrieve": withParams(func(c *beeperdesktop.BeeperDesktop) func(context.Context, resources.ChatRetrieveParams) (*resources.Chat, error) {
+		return c.Chats.Retrieve
+	}),
+	"chats.archive": withParams(func(c *beeperdesktop.BeeperDesktop) func(context.Context, resources.ChatArchiveParams) (*resources.BaseResponse, error) {
+		return c.Chats.Archive
+	}),
+	"chats.search": withParams(func(c *beeperdesktop.BeeperDesktop) func(context.Context, resources.ChatSearchParams) (*resources.ChatsCursor, error) {
+		return c.Chats.Search
+	}),
+	"chats.participants.list": withParams(func(c *beeperdesktop.BeeperDesktop) func(context.Context, resources.ParticipantListParams) (*resources.ParticipantsCursor, error) {
+		return c.Chats.Participants.List
+	}),
+	"chats.participants.add": withParams(func(c *beeperdesktop.BeeperDesktop) func(context.Context, resources.ParticipantUpdateParams) (*resources.BaseResponse, error) {
+		return c.Chats.Participants.Add
+	}),
+	"chats.participants.remove": withParams(func(c *beeperdesktop.BeeperDesktop) func(context.Context, resources.ParticipantUpdateParams) (*resources.BaseResponse, error) {
+		return c.Chats.Participants.Remove
+	}),
+	"chats.reminders.create": withParams(func(c *beeperdesktop.BeeperDesktop) func(context.Context, resources.ReminderCreateParams) (*resources.BaseResponse, error) {
+		return c.Chats.Reminders.Create
+	}),
+	"chats.reminders.delete": withParams(func(c *beeperdesktop.BeeperDesktop) func(context.Context, resources.ReminderDeleteParams) (*resources.BaseResponse, error) {
+		return c.Chats.Reminders.Delete
+	}),
+	"chats.reminders.list": withParams(func(c *beeperdesktop.BeeperDesktop) func(context.Context, resources.ReminderListParams) (*resources.ReminderListResponse, error) {
+		return c.Chats.Reminders.List
+	}),
+	"chats.reminders.get": withParams(func(c *beeperdesktop.BeeperDesktop) func(context.Context, resources.ReminderGetParams) (*resources.Reminder, error) {
+		return c.Chats.Reminders.Get
+	}),
+	"contacts.search": withParams(func(c *beeperdesktop.BeeperDesktop) func(context.Context, resources.ContactSearchParams) (*resources.ContactSearchResponse, error) {
+		return c.Contacts.Search
+	}),
+	"messages.search": withParams(func(c *beeperdesktop.BeeperDesktop) func(context.Context, resources.MessageSearchParams) (*resources.MessagesCursor, error) {
+		return c.Messages.Search
+	}),
+	"messages.send": withParams(func(c *beeperdesktop.BeeperDesktop) func(context.Context, resources.MessageSendParams) (*resources.MessageSendResponse, error) {
+		return c.Messages.Send
+	}),
+}
+
+func loadFixtures(t *testing.T) []fixture {
+	t.Helper()
+
+	paths, err := filepath.Glob(filepath.Join("fixtures", "*.json"))
+	require.NoError(t, err)
+	require.NotEmpty(t, paths)
+
+	fixtures := make([]fixture, 0, len(paths))
+	for _, path := range paths {
+		data, err := os.ReadFile(path)
+		require.NoError(t, err)
+
+		var f fixture
+		decoder := json.NewDecoder(bytes.NewReader(data))
+		decoder.DisallowUnknownFields()
+		require.NoError(t, decoder.Decode(&f), path)
+		require.NotEmpty(t, f.Endpoint, path)
+		fixtures = append(fixtures, f)
+	}
+	return fixtures
+}
+
+// TestFixturesCoverEveryEndpoint keeps the suite and the runner in step with
+// the resource methods
+func TestFixturesCoverEveryEndpoint(t *testing.T) {
+	covered := map[string]bool{}
+	for _, f := range loadFixtures(t) {
+		_, ok := endpoints[f.Endpoint]
+		assert.True(t, ok, "no runner for endpoint %s", f.Endpoint)
+		assert.NotEmpty(t, f.Cases, f.Endpoint)
+		covered[f.Endpoint] = true
+	}
+
+	var missing []string
+	for name := range endpoints {
+		if !covered[name] {
+			missing = append(missing, name)
+		}
+	}
+	sort.Strings(missing)
+	assert.Empty(t, missing, "endpoints without fixtures")
+}
+
+func TestContract(t *testing.T) {
+	for _, f := range loadFixtures(t) {
+		run := endpoints[f.Endpoint]
+		if run == nil {
+			continue
+		}
+		for _, tc := range f.Cases {
+			tc := tc
+			t.Run(f.Endpoint+"/"+tc.Name, func(t *testing.T) {
+				if reason, ok := tc.Pending["go"]; ok {
+					t.Skip(reason)
+				}
+				runCase(t, run, tc)
+			})
+		}
+	}
+}
+
+func runCase(t *testing.T, run call, tc fixtureCase) {
+	var (
+		mu         sync.Mutex
+		requests   int
+		mismatches []string
+	)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		requests++
+		mismatches = append(mismatches, checkRequest(r, tc.Request)...)
+		mu.Unlock()
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(tc.Response.Status)
+		w.Write(tc.Response.Body)
+	}))
+	defer server.Close()
+
+	client, err := beeperdesktop.New(
+		beeperdesktop.WithAccessToken("contract-token"),
+		beeperdesktop.WithBaseURL(server.URL),
+		beeperdesktop.WithMaxRetries(0),
+	)
+	require.NoError(t, err)
+
+	result, err := run(context.Background(), client, tc.Params)
+	mu.Lock()
+	assert.Equal(t, 1, requests, "requests sent")
+	assert.Empty(t, mismatches, "request does not match the contract")
+	mu.Unlock()
+
+	if tc.Error != nil {
+		require.Error(t, err)
+		var statusErr interface{ StatusCode() int }
+		require.True(t, errors.As(err, &statusErr), "error %v carries no status", err)
+		assert.Equal(t, tc.Error.Status, statusErr.StatusCode())
+		assert.Contains(t, err.Error(), tc.Error.Message)
+		return
+	}
+	require.NoError(t, err)
+
+	expected := tc.Result
+	if len(expected) == 0 {
+		expected = tc.Response.Body
+	}
+	encoded, err := json.Marshal(result)
+	require.NoError(t, err)
+	for _, problem := range subset("$", decode(t, expected), decode(t, encoded)) {
+		t.Error(problem)
+	}
+}
+
+// checkRequest compares a received request with the expectation and returns
+// every difference
+func checkRequest(r *http.Request, want expectedRequest) []string {
+	var problems []string
+	if r.Method != want.Method {
+		problems = append(problems, fmt.Sprintf("method: got %s, want %s", r.Method, want.Method))
+	}
+	if r.URL.Path != want.Path {
+		problems = append(problems, fmt.Sprintf("path: got %s, want %s", r.URL.Path, want.Path))
+	}
+
+	got := r.URL.Query()
+	for name, values := range got {
+		wantValue, ok := want.Query[name]
+		switch {
+		case !ok:
+			problems = append(problems, fmt.Sprintf("query: unexpected %s=%s", name, strings.Join(values, ",")))
+		case len(values) != 1 || values[0] != wantValue:
+			problems = append(problems, fmt.Sprintf("query: got %s=%s, want %s", name, strings.Join(values, ","), wantValue))
+		}
+	}
+	for name := range want.Query {
+		if _, ok := got[name]; !ok {
+			problems = append(problems, fmt.Sprintf("query: missing %s", name))
+		}
+	}
+
+	body, _ := io.ReadAll(r.Body)
+	switch {
+	case len(want.Body) == 0 && len(bytes.TrimSpace(body)) > 0:
+		problems = append(problems, fmt.Sprintf("body: unexpected %s", body))
+	case len(want.Body) > 0:
+		var gotBody, wantBody interface{}
+		if err := json.Unmarshal(body, &gotBody); err != nil {
+			problems = append(problems, fmt.Sprintf("body: invalid JSON %q", body))
+			break
+		}
+		_ = json.Unmarshal(want.Body, &wantBody)
+		if !reflect.DeepEqual(dropNulls(gotBody), dropNulls(wantBody)) {
+			problems = append(problems, fmt.Sprintf("body: got %s, want %s", body, want.Body))
+		}
+	}
+	return problems
+}
+
+// dropNulls removes null object members, which the contract treats the same
+// as absent ones
+func dropNulls(v interface{}) interface{} {
+	switch value := v.(type) {
+	case map[string]interface{}:
+		out := make(map[string]interface{}, len(value))
+		for key, member := range value {
+			if member != nil {
+				out[key] = dropNulls(member)
+			}
+		}
+		return out
+	case []interface{}:
+		out := make([]interface{}, len(value))
+		for i, item := range value {
+			out[i] = dropNulls(item)
+		}
+		return out
+	}
+	return v
+}
+
+func decode(t *testing.T, data []byte) interface{} {
+	t.Helper()
+	var v interface{}
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	require.NoError(t, decoder.Decode(&v))
+	return v
+}
+
+// subset reports every value in want that the decoded result lost or changed.
+// Objects in the result may carry extra members; arrays must match in length.
+func subset(path string, want, got interface{}) []string {
+	switch w := want.(type) {
+	case map[string]interface{}:
+		g, ok := got.(map[string]interface{})
+		if !ok {
+			return []string{fmt.Sprintf("%s: got %v, want an object", path, got)}
+		}
+		var problems []string
+		for key, member := range w {
+			gotMember, present := g[key]
+			if !present {
+				if member != nil {
+					problems = append(problems, fmt.Sprintf("%s.%s: dropped by decoding", path, key))
+				}
+				continue
+			}
+			problems = append(problems, subset(path+"."+key, member, gotMember)...)
+		}
+		return problems
+	case []interface{}:
+		g, ok := got.([]interface{})
+		if !ok || len(g) != len(w) {
+			return []string{fmt.Sprintf("%s: got %v, want %d items", path, got, len(w))}
+		}
+		var problems []string
+		for i := range w {
+			problems = append(problems, subset(fmt.Sprintf("%s[%d]", path, i), w[i], g[i])...)
+		}
+		return problems
+	}
+	if !reflect.DeepEqual(want, got) {
+		return []string{fmt.Sprintf("%s: got %v, want %v", path, got, want)}
+	}
+	return nil
+}
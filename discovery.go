@@ -0,0 +1,158 @@
+package beeperdesktop
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"mime"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// DefaultBaseURL is where Beeper Desktop serves its API unless configured otherwise
+const DefaultBaseURL = "http://localhost:23373"
+
+// DefaultDiscoveryPorts are the localhost ports Discover probes. Desktop
+// moves to the next port when 23373 is taken.
+var DefaultDiscoveryPorts = []int{23373, 23374, 23375}
+
+// DiscoverOptions configures Discover. Zero values use the defaults.
+type DiscoverOptions struct {
+	// Sockets are unix socket paths to probe. Desktop has no documented
+	// socket path, so none are probed by default.
+	Sockets []string
+	// Ports are localhost ports to probe after the sockets
+	Ports []int
+	// ProbeTimeout bounds each probe. Defaults to 500ms.
+	ProbeTimeout time.Duration
+	// AccessToken, if set, is sent with probes
+	AccessToken string
+	// HTTPClient sends the probes
+	HTTPClient *http.Client
+	// Dialer, if set, opens the probes' connections
+	Dialer DialFunc
+}
+
+// Discovery is the result of a successful Discover
+type Discovery struct {
+	BaseURL string
+	Source  string // the socket or "port 23373" the URL came from
+}
+
+// DiscoveryAttempt records one candidate Discover tried
+type DiscoveryAttempt struct {
+	Source    string
+	Candidate string // the URL probed
+	Err       error
+}
+
+// DiscoveryError is returned when Discover finds no running Desktop. It lists
+// every candidate and why it was rejected.
+type DiscoveryError struct {
+	Attempts []DiscoveryAttempt
+}
+
+func (e *DiscoveryError) Error() string {
+	var b strings.Builder
+	b.WriteString("could not find a running Beeper Desktop API; tried:")
+	for _, a := range e.Attempts {
+		b.WriteString("\n  ")
+		b.WriteString(a.Source)
+		if a.Candidate != a.Source {
+			fmt.Fprintf(&b, " (%s)", a.Candidate)
+		}
+		fmt.Fprintf(&b, ": %v", a.Err)
+	}
+	b.WriteString("\nMake sure Beeper Desktop is running with its API enabled, or set BEEPER_DESKTOP_BASE_URL")
+	return b.String()
+}
+
+// errNotDesktop marks a server that answered but is not the Desktop API
+var errNotDesktop = errors.New("responded, but not as the Beeper Desktop API")
+
+// Discover finds the running Beeper Desktop API. It probes the unix sockets,
+// then the localhost ports, and returns the first candidate that answers like
+// the Desktop API. Desktop does not document where it records its address, so
+// no config or state file is read.
+func Discover(ctx context.Context, opts DiscoverOptions) (*Discovery, error) {
+	if opts.Ports == nil {
+		opts.Ports = DefaultDiscoveryPorts
+	}
+	if opts.ProbeTimeout == 0 {
+		opts.ProbeTimeout = 500 * time.Millisecond
+	}
+	if opts.HTTPClient == nil {
+		opts.HTTPClient = &http.Client{}
+	}
+
+	var attempts []DiscoveryAttempt
+	try := func(source, baseURL string) *Discovery {
+		err := probe(ctx, opts, baseURL)
+		attempts = append(attempts, DiscoveryAttempt{Source: source, Candidate: baseURL, Err: err})
+		if err != nil {
+			return nil
+		}
+		return &Discovery{BaseURL: baseURL, Source: source}
+	}
+
+	for _, socket := range opts.Sockets {
+		if found := try(socket, "unix://"+socket); found != nil {
+			return found, nil
+		}
+	}
+
+	for _, port := range opts.Ports {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		if found := try("port "+strconv.Itoa(port), "http://localhost:"+strconv.Itoa(port)); found != nil {
+			return found, nil
+		}
+	}
+
+	return nil, &DiscoveryError{Attempts: attempts}
+}
+
+// probe checks that baseURL serves the Desktop API. The token endpoint exists
+// on every build and answers with JSON, or a 401 without a valid token.
+func probe(ctx context.Context, opts DiscoverOptions, baseURL string) error {
+	ctx, cancel := context.WithTimeout(ctx, opts.ProbeTimeout)
+	defer cancel()
+
+	baseURL, client, err := resolveTransport(baseURL, opts.HTTPClient, opts.Dialer, nil)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "GET", strings.TrimSuffix(baseURL, "/")+"/oauth/userinfo", nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/json")
+	if opts.AccessToken != "" {
+		req.Header.Set("Authorization", "Bearer "+opts.AccessToken)
+	}
+
+	resp, err := client.Do(req)
+	var urlErr *url.Error
+	if errors.As(err, &urlErr) {
+		// The URL is already in the attempt
+		return urlErr.Err
+	}
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+
+	if resp.StatusCode == http.StatusUnauthorized {
+		return nil
+	}
+	mediaType, _, _ := mime.ParseMediaType(resp.Header.Get("Content-Type"))
+	if resp.StatusCode == http.StatusOK && mediaType == "application/json" {
+		return nil
+	}
+	return fmt.Errorf("%w (status %d)", errNotDesktop, resp.StatusCode)
+}
@@ -0,0 +1,98 @@
+package beeperdesktop
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"path/filepath"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// desktopServer answers the token endpoint like the Desktop API
+func desktopServer(t *testing.T) *httptest.Server {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		if r.Header.Get("Authorization") != "Bearer token" {
+			w.WriteHeader(http.StatusUnauthorized)
+		}
+		w.Write([]byte(`{"error": "unauthorized"}`))
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func serverPort(t *testing.T, server *httptest.Server) int {
+	u, err := url.Parse(server.URL)
+	require.NoError(t, err)
+	port, err := strconv.Atoi(u.Port())
+	require.NoError(t, err)
+	return port
+}
+
+func TestDiscover(t *testing.T) {
+	ctx := context.Background()
+	desktop := desktopServer(t)
+	port := serverPort(t, desktop)
+
+	other := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("<html>not beeper</html>"))
+	}))
+	defer other.Close()
+
+	t.Run("skips what is not Desktop", func(t *testing.T) {
+		found, err := Discover(ctx, DiscoverOptions{
+			Ports:       []int{serverPort(t, other), port},
+			AccessToken: "token",
+		})
+		require.NoError(t, err)
+		assert.Equal(t, "http://localhost:"+strconv.Itoa(port), found.BaseURL)
+		assert.Equal(t, "port "+strconv.Itoa(port), found.Source)
+	})
+
+	t.Run("explains what was tried", func(t *testing.T) {
+		missing := filepath.Join(t.TempDir(), "missing.sock")
+		_, err := Discover(ctx, DiscoverOptions{
+			Sockets: []string{missing},
+			Ports:   []int{serverPort(t, other)},
+		})
+		var discoveryErr *DiscoveryError
+		require.True(t, errors.As(err, &discoveryErr), "got %v", err)
+		require.Len(t, discoveryErr.Attempts, 2)
+		assert.True(t, errors.Is(discoveryErr.Attempts[1].Err, errNotDesktop))
+
+		message := err.Error()
+		assert.Contains(t, message, missing+" (unix://"+missing+"): ")
+		assert.Contains(t, message, "port "+strconv.Itoa(serverPort(t, other))+" (http://localhost:")
+		assert.Contains(t, message, "BEEPER_DESKTOP_BASE_URL")
+	})
+}
+
+func TestNewWithAutoDiscover(t *testing.T) {
+	desktop := desktopServer(t)
+	t.Setenv("BEEPER_DESKTOP_BASE_URL", "")
+
+	// Route the default port to the test server
+	var addrs []string
+	dial := func(ctx context.Context, network, addr string) (net.Conn, error) {
+		addrs = append(addrs, addr)
+		return (&net.Dialer{}).DialContext(ctx, "tcp", desktop.Listener.Addr().String())
+	}
+
+	client, err := New(WithAccessToken("token"), WithAutoDiscover(), WithDialer(dial))
+	require.NoError(t, err)
+	assert.Equal(t, "http://localhost:23373/", client.baseURL)
+	assert.Equal(t, []string{"localhost:23373"}, addrs)
+
+	t.Run("explicit base URL wins", func(t *testing.T) {
+		client, err := New(WithAccessToken("token"), WithAutoDiscover(), WithBaseURL("http://example.test:1"))
+		require.NoError(t, err)
+		assert.Equal(t, "http://example.test:1/", client.baseURL)
+	})
+}
@@ -0,0 +1,233 @@
+package beeperdesktop
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// DriftKind classifies a difference between a response and the SDK's types
+type DriftKind string
+
+const (
+	// DriftUnknownField is a response field the SDK's type has no place for.
+	// Its value is dropped by decoding.
+	DriftUnknownField DriftKind = "unknown_field"
+	// DriftMissingField is a required field of the SDK's type that the
+	// response did not include
+	DriftMissingField DriftKind = "missing_field"
+)
+
+// maxDriftSample bounds the length of Drift.Sample
+const maxDriftSample = 80
+
+// Drift describes one field of a response that does not match the SDK's types
+type Drift struct {
+	Method string    `json:"method"`
+	Path   string    `json:"path"` // Request path without the query
+	Kind   DriftKind `json:"kind"`
+	Field  string    `json:"field"`            // JSON path of the field, e.g. items[].reactions[].emoji
+	Type   string    `json:"type"`             // Go type that declares or lacks the field
+	Sample string    `json:"sample,omitempty"` // Compact JSON of an unknown field's value, truncated
+}
+
+func (d Drift) String() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "%s %s: ", d.Method, d.Path)
+	switch d.Kind {
+	case DriftUnknownField:
+		fmt.Fprintf(&b, "unknown field %s in %s", d.Field, d.Type)
+	case DriftMissingField:
+		fmt.Fprintf(&b, "missing field %s of %s", d.Field, d.Type)
+	default:
+		fmt.Fprintf(&b, "%s %s in %s", d.Kind, d.Field, d.Type)
+	}
+	if d.Sample != "" {
+		fmt.Fprintf(&b, " (sample: %s)", d.Sample)
+	}
+	return b.String()
+}
+
+// DriftHandler receives every drift found in a response. It is called from the
+// goroutine making the request.
+type DriftHandler func(Drift)
+
+var jsonUnmarshalerType = reflect.TypeOf((*json.Unmarshaler)(nil)).Elem()
+
+// importPathPrefix matches the import path reflect puts before the package
+// name of generic type arguments
+var importPathPrefix = regexp.MustCompile(`[\w.\-]+(/[\w.\-]+)*/`)
+
+// typeName names t the way it is written in code, e.g. resources.Cursor[resources.Chat]
+func typeName(t reflect.Type) string {
+	return importPathPrefix.ReplaceAllString(t.String(), "")
+}
+
+// detectDrift compares a JSON response with the type it was decoded into. Each
+// field path is reported once per response, however many array elements
+// carry it.
+func detectDrift(method, path string, data []byte, result interface{}) []Drift {
+	var raw interface{}
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	if err := decoder.Decode(&raw); err != nil {
+		return nil
+	}
+
+	path, _, _ = strings.Cut(path, "?")
+	w := &driftWalker{method: method, path: "/" + strings.TrimPrefix(path, "/"), seen: map[string]bool{}}
+	w.walk("", raw, reflect.TypeOf(result))
+
+	sort.SliceStable(w.drifts, func(i, j int) bool {
+		return w.drifts[i].Field < w.drifts[j].Field
+	})
+	return w.drifts
+}
+
+type driftWalker struct {
+	method string
+	path   string
+	seen   map[string]bool
+	drifts []Drift
+}
+
+func (w *driftWalker) report(kind DriftKind, field string, t reflect.Type, sample interface{}) {
+	key := string(kind) + " " + field
+	if w.seen[key] {
+		return
+	}
+	w.seen[key] = true
+
+	d := Drift{Method: w.method, Path: w.path, Kind: kind, Field: field, Type: typeName(t)}
+	if sample != nil {
+		encoded, _ := json.Marshal(sample)
+		d.Sample = string(encoded)
+		if runes := []rune(d.Sample); len(runes) > maxDriftSample {
+			d.Sample = string(runes[:maxDriftSample]) + "…"
+		}
+	}
+	w.drifts = append(w.drifts, d)
+}
+
+func (w *driftWalker) walk(field string, value interface{}, t reflect.Type) {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	// Types with their own decoding, such as Timestamp, and untyped values
+	// accept whatever they are given
+	if value == nil || t.Kind() == reflect.Interface || reflect.PointerTo(t).Implements(jsonUnmarshalerType) {
+		return
+	}
+
+	switch t.Kind() {
+	case reflect.Struct:
+		object, ok := value.(map[string]interface{})
+		if !ok {
+			return
+		}
+		fields := jsonFields(t)
+		present := map[string]bool{}
+		for key, member := range object {
+			f, ok := fields.lookup(key)
+			if !ok {
+				w.report(DriftUnknownField, joinField(field, key), t, member)
+				continue
+			}
+			present[f.name] = true
+			w.walk(joinField(field, f.name), member, f.typ)
+		}
+		for _, f := range fields {
+			if !f.optional && !present[f.name] {
+				w.report(DriftMissingField, joinField(field, f.name), t, nil)
+			}
+		}
+	case reflect.Slice, reflect.Array:
+		items, ok := value.([]interface{})
+		if !ok {
+			return
+		}
+		for _, item := range items {
+			w.walk(field+"[]", item, t.Elem())
+		}
+	case reflect.Map:
+		object, ok := value.(map[string]interface{})
+		if !ok {
+			return
+		}
+		for _, member := range object {
+			w.walk(field+"{}", member, t.Elem())
+		}
+	}
+}
+
+func joinField(parent, name string) string {
+	if parent == "" {
+		return name
+	}
+	return parent + "." + name
+}
+
+type jsonField struct {
+	name     string
+	typ      reflect.Type
+	optional bool // omitempty, or a pointer that may be absent
+}
+
+type jsonFieldList []jsonField
+
+// lookup finds a field the way encoding/json does, preferring an exact match
+// over a case-insensitive one
+func (l jsonFieldList) lookup(key string) (jsonField, bool) {
+	for _, f := range l {
+		if f.name == key {
+			return f, true
+		}
+	}
+	for _, f := range l {
+		if strings.EqualFold(f.name, key) {
+			return f, true
+		}
+	}
+	return jsonField{}, false
+}
+
+// jsonFields lists the JSON members of a struct, including those promoted
+// from embedded structs
+func jsonFields(t reflect.Type) jsonFieldList {
+	var fields jsonFieldList
+	for i := 0; i < t.NumField(); i++ {
+		sf := t.Field(i)
+		tag := sf.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		name, opts, _ := strings.Cut(tag, ",")
+
+		if sf.Anonymous && name == "" {
+			embedded := sf.Type
+			if embedded.Kind() == reflect.Ptr {
+				embedded = embedded.Elem()
+			}
+			if embedded.Kind() == reflect.Struct {
+				fields = append(fields, jsonFields(embedded)...)
+				continue
+			}
+		}
+		if !sf.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = sf.Name
+		}
+		fields = append(fields, jsonField{
+			name:     name,
+			typ:      sf.Type,
+			optional: strings.Contains(opts, "omitempty") || sf.Type.Kind() == reflect.Ptr,
+		})
+	}
+	return fields
+}
@@ -0,0 +1,91 @@
+package beeperdesktop
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/cameronaaron/beeper-go-sdk/resources"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const driftingMessages = `{
+	"items": [
+		{"id": "m1", "accountID": "a", "chatID": "c", "messageID": "e1", "senderID": "s", "sortKey": 1,
+		 "timestamp": 1736937000, "isEdited": true,
+		 "reactions": [{"id": "r1", "participantID": "s", "reactionKey": "👍", "count": 2}]},
+		{"id": "m2", "accountID": "a", "chatID": "c", "messageID": "e2", "senderID": "s", "sortKey": "2",
+		 "timestamp": "2025-01-15T10:30:00Z", "isEdited": false}
+	],
+	"pagination": {"has_more": false, "nextCursor": "abc"}
+}`
+
+func TestDetectDrift(t *testing.T) {
+	drifts := detectDrift("GET", "/v0/search-messages?limit=2", []byte(driftingMessages), &resources.MessagesCursor{})
+
+	fields := map[string]Drift{}
+	for _, d := range drifts {
+		assert.Equal(t, "GET", d.Method)
+		assert.Equal(t, "/v0/search-messages", d.Path)
+		fields[d.Field] = d
+	}
+	require.Len(t, fields, 3, "%v", drifts)
+
+	edited := fields["items[].isEdited"]
+	assert.Equal(t, DriftUnknownField, edited.Kind)
+	assert.Equal(t, "resources.Message", edited.Type)
+	assert.Equal(t, "true", edited.Sample)
+	assert.Equal(t, DriftUnknownField, fields["items[].reactions[].count"].Kind)
+	assert.Equal(t, DriftUnknownField, fields["pagination.nextCursor"].Kind)
+
+	t.Run("missing and case-folded fields", func(t *testing.T) {
+		drifts := detectDrift("POST", "/v0/send-message", []byte(`{"messageId": "e1", "success": true}`), &resources.MessageSendResponse{})
+		require.Len(t, drifts, 1)
+		assert.Equal(t, DriftMissingField, drifts[0].Kind)
+		assert.Equal(t, "deeplink", drifts[0].Field)
+	})
+
+	t.Run("matching response", func(t *testing.T) {
+		drifts := detectDrift("GET", "/v0/get-accounts", []byte(`[{"accountID": "a", "network": "n", "user": {"id": "u"}}]`), &resources.AccountListResponse{})
+		assert.Empty(t, drifts)
+	})
+}
+
+func TestStrictDecoding(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(driftingMessages))
+	}))
+	defer server.Close()
+
+	var reported []Drift
+	handler := func(d Drift) { reported = append(reported, d) }
+
+	t.Run("diagnostic", func(t *testing.T) {
+		reported = nil
+		client, err := New(WithAccessToken("token"), WithBaseURL(server.URL), WithDriftHandler(handler))
+		require.NoError(t, err)
+
+		result, err := client.Messages.Search(context.Background(), resources.MessageSearchParams{})
+		require.NoError(t, err)
+		assert.Len(t, result.Items, 2)
+		assert.Len(t, reported, 3)
+	})
+
+	t.Run("strict", func(t *testing.T) {
+		reported = nil
+		client, err := New(WithAccessToken("token"), WithBaseURL(server.URL), WithDriftHandler(handler), WithStrictDecoding())
+		require.NoError(t, err)
+
+		_, err = client.Messages.Search(context.Background(), resources.MessageSearchParams{})
+		var driftErr *SchemaDriftError
+		require.True(t, errors.As(err, &driftErr), "got %v", err)
+		assert.Equal(t, "/v0/search-messages", driftErr.Path)
+		assert.Len(t, driftErr.Drifts, 3)
+		assert.Len(t, reported, 3)
+		assert.Contains(t, err.Error(), "items[].isEdited")
+	})
+}
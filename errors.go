@@ -1,6 +1,12 @@
 package beeperdesktop
 
-import "fmt"
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/cameronaaron/beeper-go-sdk/resources"
+)
 
 // BeeperDesktopError is the base error type for all Beeper Desktop API errors
 type BeeperDesktopError struct {
@@ -26,6 +32,11 @@ func (e *APIError) Error() string {
 	return fmt.Sprintf("API error %d: %s", e.Status, e.Message)
 }
 
+// StatusCode returns the HTTP status code of the error response
+func (e *APIError) StatusCode() int {
+	return e.Status
+}
+
 // APIConnectionError represents a connection error
 type APIConnectionError struct {
 	BeeperDesktopError
@@ -88,6 +99,79 @@ type InternalServerError struct {
 	APIError
 }
 
+// SchemaDriftError is returned in strict decoding mode when a response has
+// fields the SDK's types do not know about. The response was still decoded;
+// the unknown fields were dropped.
+type SchemaDriftError struct {
+	Method string
+	Path   string
+	Drifts []Drift
+}
+
+func (e *SchemaDriftError) Error() string {
+	fields := make([]string, len(e.Drifts))
+	for i, d := range e.Drifts {
+		fields[i] = d.Field
+	}
+	return fmt.Sprintf("schema drift in %s %s: unknown fields %s", e.Method, e.Path, strings.Join(fields, ", "))
+}
+
+// ErrUnsupported is matched by errors.Is for calls to features the connected
+// Beeper Desktop build does not offer
+var ErrUnsupported = errors.New("not supported by this Beeper Desktop version")
+
+// UnsupportedError is returned by methods whose endpoint the server does not
+// have. Cause is the 404 that revealed it, or nil when the client already knew.
+type UnsupportedError struct {
+	Feature string
+	Version string // server version, if known
+	Cause   error
+}
+
+func (e *UnsupportedError) Error() string {
+	if e.Version != "" {
+		return fmt.Sprintf("%s is not supported by Beeper Desktop %s", e.Feature, e.Version)
+	}
+	return fmt.Sprintf("%s is not supported by this Beeper Desktop version", e.Feature)
+}
+
+func (e *UnsupportedError) Unwrap() error {
+	return e.Cause
+}
+
+// Is reports whether the error matches ErrUnsupported
+func (e *UnsupportedError) Is(target error) bool {
+	return target == ErrUnsupported
+}
+
+// StatusCode returns 404, the status of a missing endpoint, so callers that
+// check for one by status keep working
+func (e *UnsupportedError) StatusCode() int {
+	return 404
+}
+
+// SendError is returned by CheckSent when Desktop answered a message send
+// with success set to false. The message was not sent.
+type SendError struct {
+	Message string // the response's error, if any
+}
+
+func (e *SendError) Error() string {
+	if e.Message == "" {
+		return "message not sent"
+	}
+	return "message not sent: " + e.Message
+}
+
+// CheckSent returns a *SendError when a send response reports that the
+// message was not sent, and nil otherwise
+func CheckSent(resp *resources.MessageSendResponse) error {
+	if resp.Success {
+		return nil
+	}
+	return &SendError{Message: resp.Error}
+}
+
 // IsRetryableError returns true if the error is retryable
 func IsRetryableError(err error) bool {
 	switch err.(type) {
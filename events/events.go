@@ -0,0 +1,215 @@
+// Package events delivers real-time updates from Beeper Desktop. It consumes
+// the server-sent events stream when Desktop offers one and otherwise polls
+// message and chat search for changes. Watcher provides a durable tail of new
+// messages that survives restarts.
+package events
+
+import (
+	"context"
+	"errors"
+	"io"
+	"sync"
+	"time"
+
+	"github.com/cameronaaron/beeper-go-sdk/resources"
+)
+
+// Type identifies the kind of an event
+type Type string
+
+const (
+	TypeMessageCreated Type = "message.created"
+	TypeMessageUpdated Type = "message.updated"
+	TypeChatUpdated    Type = "chat.updated"
+	TypeReactionAdded  Type = "reaction.added"
+)
+
+// Event is implemented by every event delivered on a subscription
+type Event interface {
+	EventType() Type
+}
+
+// MessageCreated is emitted when a new message appears in a chat
+type MessageCreated struct {
+	Message resources.Message `json:"message"`
+}
+
+// MessageUpdated is emitted when a known message is edited or otherwise changed
+type MessageUpdated struct {
+	Message resources.Message `json:"message"`
+}
+
+// ChatUpdated is emitted when chat metadata such as unread count or activity changes
+type ChatUpdated struct {
+	Chat resources.Chat `json:"chat"`
+}
+
+// ReactionAdded is emitted when a reaction is added to a message
+type ReactionAdded struct {
+	ChatID    string             `json:"chatID"`
+	MessageID string             `json:"messageID"`
+	Reaction  resources.Reaction `json:"reaction"`
+}
+
+func (MessageCreated) EventType() Type { return TypeMessageCreated }
+func (MessageUpdated) EventType() Type { return TypeMessageUpdated }
+func (ChatUpdated) EventType() Type    { return TypeChatUpdated }
+func (ReactionAdded) EventType() Type  { return TypeReactionAdded }
+
+// Client is the subset of the BeeperDesktop client used for subscriptions
+type Client interface {
+	resources.ClientInterface
+	OpenEventStream(ctx context.Context, path, lastEventID string) (io.ReadCloser, error)
+}
+
+// Mode selects how a subscription receives events
+type Mode int
+
+const (
+	// ModeAuto uses the push stream and falls back to polling when it is unavailable
+	ModeAuto Mode = iota
+	// ModePush only uses the push stream
+	ModePush
+	// ModePoll only polls search endpoints
+	ModePoll
+)
+
+// Options configures a subscription
+type Options struct {
+	Mode         Mode
+	AccountIDs   []string
+	ChatIDs      []string
+	PollInterval time.Duration // Defaults to 5 seconds
+	PageLimit    int           // Messages fetched per poll page, defaults to 50
+	Buffer       int           // Event channel capacity, defaults to 64
+	Cursor       string        // Resume token from a previous Subscription.Cursor
+	MaxBackoff   time.Duration // Upper bound for reconnect delays, defaults to 30 seconds
+}
+
+// Subscription is a running event feed
+type Subscription struct {
+	events chan Event
+	done   chan struct{}
+	cancel context.CancelFunc
+
+	mu     sync.Mutex
+	cursor string
+	err    error
+}
+
+// Subscribe starts delivering events until the context is cancelled or Close is called
+func Subscribe(ctx context.Context, client Client, opts Options) *Subscription {
+	if opts.PollInterval <= 0 {
+		opts.PollInterval = 5 * time.Second
+	}
+	if opts.PageLimit <= 0 {
+		opts.PageLimit = 50
+	}
+	if opts.Buffer <= 0 {
+		opts.Buffer = 64
+	}
+	if opts.MaxBackoff <= 0 {
+		opts.MaxBackoff = 30 * time.Second
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	sub := &Subscription{
+		events: make(chan Event, opts.Buffer),
+		done:   make(chan struct{}),
+		cancel: cancel,
+		cursor: opts.Cursor,
+	}
+
+	go sub.run(ctx, client, opts)
+	return sub
+}
+
+// Events returns the channel events are delivered on. It is closed when the
+// subscription ends; Err then reports why.
+func (s *Subscription) Events() <-chan Event {
+	return s.events
+}
+
+// Cursor returns a resume token that can be passed in Options.Cursor to pick
+// up where this subscription left off
+func (s *Subscription) Cursor() string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.cursor
+}
+
+// Err returns the error that ended the subscription, if any
+func (s *Subscription) Err() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.err
+}
+
+// Close stops the subscription and waits for it to finish
+func (s *Subscription) Close() {
+	s.cancel()
+	<-s.done
+}
+
+func (s *Subscription) run(ctx context.Context, client Client, opts Options) {
+	defer close(s.done)
+	defer close(s.events)
+
+	var err error
+	switch opts.Mode {
+	case ModePoll:
+		err = s.poll(ctx, client, opts)
+	case ModePush:
+		err = s.push(ctx, client, opts)
+	default:
+		err = s.push(ctx, client, opts)
+		if errors.Is(err, errPushUnavailable) {
+			err = s.poll(ctx, client, opts)
+		}
+	}
+
+	if errors.Is(err, context.Canceled) {
+		err = nil
+	}
+
+	s.mu.Lock()
+	s.err = err
+	s.mu.Unlock()
+}
+
+// emit delivers an event and records the resume token that follows it
+func (s *Subscription) emit(ctx context.Context, event Event, cursor string) error {
+	select {
+	case s.events <- event:
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+
+	if cursor != "" {
+		s.mu.Lock()
+		s.cursor = cursor
+		s.mu.Unlock()
+	}
+	return nil
+}
+
+// sleep waits for the given duration or until the context is cancelled
+func sleep(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// statusCode extracts the HTTP status from API errors returned by the client
+func statusCode(err error) int {
+	var statusErr interface{ StatusCode() int }
+	if errors.As(err, &statusErr) {
+		return statusErr.StatusCode()
+	}
+	return 0
+}
@@ -0,0 +1,145 @@
+package events_test
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	beeperdesktop "github.com/cameronaaron/beeper-go-sdk"
+	"github.com/cameronaaron/beeper-go-sdk/events"
+	"github.com/cameronaaron/beeper-go-sdk/resources"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newClient(t *testing.T, url string) *beeperdesktop.BeeperDesktop {
+	client, err := beeperdesktop.New(
+		beeperdesktop.WithAccessToken("token"),
+		beeperdesktop.WithBaseURL(url),
+		beeperdesktop.WithMaxRetries(0),
+	)
+	require.NoError(t, err)
+	return client
+}
+
+func next(t *testing.T, sub *events.Subscription) events.Event {
+	select {
+	case event, ok := <-sub.Events():
+		require.True(t, ok, "subscription ended: %v", sub.Err())
+		return event
+	case <-time.After(2 * time.Second):
+		t.Fatal("timed out waiting for event")
+		return nil
+	}
+}
+
+func TestSubscribePushResumesAfterDisconnect(t *testing.T) {
+	var mu sync.Mutex
+	var lastEventIDs []string
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, "/v0/events", r.URL.Path)
+		assert.Equal(t, "chat-1", r.URL.Query().Get("chatIDs[0]"))
+
+		mu.Lock()
+		lastEventIDs = append(lastEventIDs, r.Header.Get("Last-Event-ID"))
+		connection := len(lastEventIDs)
+		mu.Unlock()
+
+		w.Header().Set("Content-Type", "text/event-stream")
+		if connection == 1 {
+			fmt.Fprint(w, "retry: 10\n\n")
+			fmt.Fprint(w, ": keepalive\n\n")
+			fmt.Fprint(w, "id: 1\nevent: message.created\ndata: {\"message\":{\"id\":\"m1\",\"chatID\":\"chat-1\",\"sortKey\":1}}\n\n")
+			fmt.Fprint(w, "id: 2\nevent: message.created\ndata: {\"message\":{\"id\":\"m-other\",\"chatID\":\"chat-2\",\"sortKey\":2}}\n\n")
+			return
+		}
+		fmt.Fprint(w, "id: 3\nevent: reaction.added\ndata: {\"chatID\":\"chat-1\",\"messageID\":\"m1\",\"reaction\":{\"id\":\"r1\",\"reactionKey\":\"👍\"}}\n\n")
+		w.(http.Flusher).Flush()
+		<-r.Context().Done()
+	}))
+	defer server.Close()
+
+	sub := events.Subscribe(context.Background(), newClient(t, server.URL), events.Options{
+		Mode:    events.ModePush,
+		ChatIDs: []string{"chat-1"},
+	})
+	defer sub.Close()
+
+	created, ok := next(t, sub).(events.MessageCreated)
+	require.True(t, ok)
+	assert.Equal(t, "m1", created.Message.ID)
+
+	// The chat-2 event is filtered out and the stream resumes after event 2
+	reaction, ok := next(t, sub).(events.ReactionAdded)
+	require.True(t, ok)
+	assert.Equal(t, "m1", reaction.MessageID)
+	assert.Equal(t, "👍", reaction.Reaction.ReactionKey)
+	assert.Equal(t, "push:3", sub.Cursor())
+
+	mu.Lock()
+	assert.Equal(t, []string{"", "2"}, lastEventIDs)
+	mu.Unlock()
+}
+
+func TestSubscribeFallsBackToPolling(t *testing.T) {
+	var mu sync.Mutex
+	messages := []resources.Message{
+		{ID: "m1", ChatID: "chat-1", SortKey: resources.NewNumericSortKey(100), Text: beeperdesktop.StringPtr("hi")},
+	}
+	chats := []resources.Chat{{ID: "chat-1", Title: "Team"}}
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Path {
+		case "/v0/events":
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(`{"error": "not found"}`))
+		case "/v0/search-messages":
+			json.NewEncoder(w).Encode(resources.MessagesCursor{Items: messages})
+		case "/v0/search-chats":
+			json.NewEncoder(w).Encode(resources.ChatsCursor{Items: chats})
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	sub := events.Subscribe(context.Background(), newClient(t, server.URL), events.Options{
+		PollInterval: 20 * time.Millisecond,
+	})
+	defer sub.Close()
+
+	// Let the first poll establish the baseline, then change server state
+	time.Sleep(60 * time.Millisecond)
+	mu.Lock()
+	messages = []resources.Message{
+		{ID: "m2", ChatID: "chat-1", SortKey: resources.NewSortKey("101"), Text: beeperdesktop.StringPtr("new")},
+		{ID: "m1", ChatID: "chat-1", SortKey: resources.NewNumericSortKey(100), Text: beeperdesktop.StringPtr("hi"),
+			Reactions: []resources.Reaction{{ID: "r1", ReactionKey: "🎉"}}},
+	}
+	chats = []resources.Chat{{ID: "chat-1", Title: "Team", UnreadCount: 1}}
+	mu.Unlock()
+
+	received := map[events.Type]events.Event{}
+	for len(received) < 3 {
+		event := next(t, sub)
+		received[event.EventType()] = event
+	}
+
+	assert.Equal(t, "m2", received[events.TypeMessageCreated].(events.MessageCreated).Message.ID)
+	assert.Equal(t, "r1", received[events.TypeReactionAdded].(events.ReactionAdded).Reaction.ID)
+	assert.Equal(t, 1, received[events.TypeChatUpdated].(events.ChatUpdated).Chat.UnreadCount)
+	assert.Equal(t, "poll:101", sub.Cursor())
+
+	sub.Close()
+	assert.NoError(t, sub.Err())
+}
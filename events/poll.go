@@ -0,0 +1,244 @@
+package events
+
+import (
+	"context"
+	"crypto/sha1"
+	"encoding/hex"
+	"encoding/json"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/cameronaaron/beeper-go-sdk/resources"
+)
+
+const (
+	// maxPollPages bounds how far back a single poll pages to catch up
+	maxPollPages = 10
+	// maxTrackedMessages bounds the cache used to detect edits and reactions
+	maxTrackedMessages = 2000
+)
+
+// trackedMessage is the last observed state of a recent message
+type trackedMessage struct {
+	fingerprint string
+	reactions   map[string]bool
+}
+
+// poller detects changes by diffing successive search results
+type poller struct {
+	opts     Options
+	messages *resources.Messages
+	chats    *resources.Chats
+
+	highWater    resources.SortKey
+	primed       bool
+	tracked      map[string]*trackedMessage
+	trackedOrder []string
+
+	chatPrints  map[string]string
+	chatsPrimed bool
+}
+
+// poll searches for new and changed messages and chats on every interval
+func (s *Subscription) poll(ctx context.Context, client Client, opts Options) error {
+	p := &poller{
+		opts:       opts,
+		messages:   resources.NewMessages(client),
+		chats:      resources.NewChats(client),
+		tracked:    make(map[string]*trackedMessage),
+		chatPrints: make(map[string]string),
+	}
+
+	if cursor := s.Cursor(); strings.HasPrefix(cursor, pollCursorPrefix) {
+		p.highWater = resources.NewSortKey(strings.TrimPrefix(cursor, pollCursorPrefix))
+		p.primed = !p.highWater.IsZero()
+	}
+
+	ticker := time.NewTicker(opts.PollInterval)
+	defer ticker.Stop()
+
+	for {
+		if err := p.pollMessages(ctx, s); err != nil && fatal(ctx, err) {
+			return err
+		}
+		if err := p.pollChats(ctx, s); err != nil && fatal(ctx, err) {
+			return err
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+// fatal reports whether a poll error should end the subscription rather than
+// being retried on the next tick
+func fatal(ctx context.Context, err error) bool {
+	if ctx.Err() != nil {
+		return true
+	}
+	code := statusCode(err)
+	return code == 401 || code == 403
+}
+
+func (p *poller) pollMessages(ctx context.Context, s *Subscription) error {
+	limit := p.opts.PageLimit
+	params := resources.MessageSearchParams{
+		AccountIDs: p.opts.AccountIDs,
+		ChatIDs:    p.opts.ChatIDs,
+		Limit:      &limit,
+	}
+
+	var batch []resources.Message
+	for page := 0; page < maxPollPages; page++ {
+		result, err := p.messages.Search(ctx, params)
+		if err != nil {
+			return err
+		}
+
+		reachedHighWater := false
+		for _, msg := range result.Items {
+			if !p.highWater.IsZero() && msg.SortKey.Compare(p.highWater) <= 0 {
+				reachedHighWater = true
+			}
+			batch = append(batch, msg)
+		}
+
+		// The first poll only establishes where the tail starts
+		if !p.primed || reachedHighWater {
+			break
+		}
+		if result.Pagination == nil || !result.Pagination.HasMore || result.Pagination.Cursor == nil {
+			break
+		}
+		params.Cursor = result.Pagination.Cursor
+	}
+
+	sort.SliceStable(batch, func(i, j int) bool {
+		return batch[i].SortKey.Less(batch[j].SortKey)
+	})
+
+	for _, msg := range batch {
+		isNew := p.highWater.IsZero() || msg.SortKey.Compare(p.highWater) > 0
+		if isNew {
+			p.highWater = msg.SortKey
+		}
+		cursor := pollCursorPrefix + p.highWater.String()
+
+		for _, event := range p.diffMessage(msg, isNew) {
+			if !matches(p.opts, event) {
+				continue
+			}
+			if err := s.emit(ctx, event, cursor); err != nil {
+				return err
+			}
+		}
+	}
+
+	p.primed = true
+	return nil
+}
+
+// diffMessage records the message and returns the events its change implies
+func (p *poller) diffMessage(msg resources.Message, isNew bool) []Event {
+	fingerprint := messageFingerprint(msg)
+	reactions := make(map[string]bool, len(msg.Reactions))
+	for _, reaction := range msg.Reactions {
+		reactions[reactionKey(reaction)] = true
+	}
+
+	previous, known := p.tracked[msg.ID]
+	p.track(msg.ID, &trackedMessage{fingerprint: fingerprint, reactions: reactions})
+
+	if !known {
+		if p.primed && isNew {
+			return []Event{MessageCreated{Message: msg}}
+		}
+		return nil
+	}
+
+	var events []Event
+	if previous.fingerprint != fingerprint {
+		events = append(events, MessageUpdated{Message: msg})
+	}
+	for _, reaction := range msg.Reactions {
+		if !previous.reactions[reactionKey(reaction)] {
+			events = append(events, ReactionAdded{ChatID: msg.ChatID, MessageID: msg.ID, Reaction: reaction})
+		}
+	}
+	return events
+}
+
+// track stores message state, evicting the oldest entries past the cache limit
+func (p *poller) track(id string, state *trackedMessage) {
+	if _, ok := p.tracked[id]; !ok {
+		p.trackedOrder = append(p.trackedOrder, id)
+	}
+	p.tracked[id] = state
+
+	for len(p.trackedOrder) > maxTrackedMessages {
+		delete(p.tracked, p.trackedOrder[0])
+		p.trackedOrder = p.trackedOrder[1:]
+	}
+}
+
+func (p *poller) pollChats(ctx context.Context, s *Subscription) error {
+	limit := p.opts.PageLimit
+	result, err := p.chats.Search(ctx, resources.ChatSearchParams{
+		AccountIDs: p.opts.AccountIDs,
+		Limit:      &limit,
+	})
+	if err != nil {
+		return err
+	}
+
+	for _, chat := range result.Items {
+		fingerprint := chatFingerprint(chat)
+		previous, known := p.chatPrints[chat.ID]
+		p.chatPrints[chat.ID] = fingerprint
+
+		if !p.chatsPrimed || (known && previous == fingerprint) {
+			continue
+		}
+
+		event := ChatUpdated{Chat: chat}
+		if !matches(p.opts, event) {
+			continue
+		}
+		if err := s.emit(ctx, event, ""); err != nil {
+			return err
+		}
+	}
+
+	p.chatsPrimed = true
+	return nil
+}
+
+// messageFingerprint hashes the parts of a message that an edit can change
+func messageFingerprint(msg resources.Message) string {
+	msg.Reactions = nil
+	msg.IsUnread = nil
+	return hashJSON(msg)
+}
+
+// chatFingerprint hashes the chat state that ChatUpdated reports on
+func chatFingerprint(chat resources.Chat) string {
+	return hashJSON(chat)
+}
+
+func hashJSON(v interface{}) string {
+	data, _ := json.Marshal(v)
+	sum := sha1.Sum(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// reactionKey identifies a reaction even when the server omits its ID
+func reactionKey(reaction resources.Reaction) string {
+	if reaction.ID != "" {
+		return reaction.ID
+	}
+	return reaction.ParticipantID + "|" + reaction.ReactionKey
+}
@@ -0,0 +1,224 @@
+package events
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// streamPath is the Desktop endpoint serving server-sent events
+const streamPath = "/v0/events"
+
+const (
+	pushCursorPrefix = "push:"
+	pollCursorPrefix = "poll:"
+)
+
+// errPushUnavailable reports that the server does not offer an event stream
+var errPushUnavailable = errors.New("event stream is not available on this server")
+
+// sseEvent is a single dispatched server-sent event
+type sseEvent struct {
+	id    string
+	event string
+	data  string
+}
+
+// push consumes the event stream, reconnecting with backoff and resuming from
+// the last received event ID
+func (s *Subscription) push(ctx context.Context, client Client, opts Options) error {
+	lastID := strings.TrimPrefix(s.Cursor(), pushCursorPrefix)
+	if strings.HasPrefix(lastID, pollCursorPrefix) {
+		lastID = ""
+	}
+
+	path := streamPath
+	if query := filterQuery(opts); len(query) > 0 {
+		path += "?" + query.Encode()
+	}
+
+	const initialBackoff = 500 * time.Millisecond
+	backoff := initialBackoff
+	connected := false
+
+	for {
+		body, err := client.OpenEventStream(ctx, path, lastID)
+		if err != nil {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			if !connected {
+				if code := statusCode(err); code == 404 || code == 501 {
+					return errPushUnavailable
+				}
+			}
+			if code := statusCode(err); code == 401 || code == 403 {
+				return err
+			}
+
+			if err := sleep(ctx, backoff); err != nil {
+				return err
+			}
+			backoff *= 2
+			if backoff > opts.MaxBackoff {
+				backoff = opts.MaxBackoff
+			}
+			continue
+		}
+
+		connected = true
+		backoff = initialBackoff
+
+		// readStream returns when the stream ends or drops; reconnect and resume from lastID
+		readStream(body, func(raw sseEvent) error {
+			if raw.id != "" {
+				lastID = raw.id
+			}
+			event, err := decodeEvent(raw)
+			if err != nil || event == nil || !matches(opts, event) {
+				return nil
+			}
+			return s.emit(ctx, event, pushCursorPrefix+lastID)
+		}, &backoff)
+		body.Close()
+
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+
+		if err := sleep(ctx, backoff); err != nil {
+			return err
+		}
+	}
+}
+
+// readStream parses server-sent events from r and dispatches each one. A
+// "retry" field updates the reconnect delay.
+func readStream(r io.Reader, dispatch func(sseEvent) error, retry *time.Duration) error {
+	reader := bufio.NewReader(r)
+	var current sseEvent
+	var data []string
+
+	for {
+		line, err := reader.ReadString('\n')
+		if err != nil {
+			return err
+		}
+		line = strings.TrimRight(line, "\r\n")
+
+		if line == "" {
+			if len(data) > 0 {
+				current.data = strings.Join(data, "\n")
+				if err := dispatch(current); err != nil {
+					return err
+				}
+			}
+			current = sseEvent{}
+			data = nil
+			continue
+		}
+
+		if strings.HasPrefix(line, ":") {
+			continue
+		}
+
+		field, value, _ := strings.Cut(line, ":")
+		value = strings.TrimPrefix(value, " ")
+
+		switch field {
+		case "id":
+			current.id = value
+		case "event":
+			current.event = value
+		case "data":
+			data = append(data, value)
+		case "retry":
+			if ms, err := strconv.Atoi(value); err == nil && ms > 0 {
+				*retry = time.Duration(ms) * time.Millisecond
+			}
+		}
+	}
+}
+
+// decodeEvent converts a raw server-sent event into a typed event. Unknown
+// event types are ignored.
+func decodeEvent(raw sseEvent) (Event, error) {
+	var event Event
+	switch Type(raw.event) {
+	case TypeMessageCreated:
+		event = &MessageCreated{}
+	case TypeMessageUpdated:
+		event = &MessageUpdated{}
+	case TypeChatUpdated:
+		event = &ChatUpdated{}
+	case TypeReactionAdded:
+		event = &ReactionAdded{}
+	default:
+		return nil, nil
+	}
+
+	if err := json.Unmarshal([]byte(raw.data), event); err != nil {
+		return nil, err
+	}
+
+	switch e := event.(type) {
+	case *MessageCreated:
+		return *e, nil
+	case *MessageUpdated:
+		return *e, nil
+	case *ChatUpdated:
+		return *e, nil
+	case *ReactionAdded:
+		return *e, nil
+	}
+	return nil, nil
+}
+
+// filterQuery encodes account and chat filters the same way message search does
+func filterQuery(opts Options) url.Values {
+	query := url.Values{}
+	for idx, id := range opts.AccountIDs {
+		query.Add("accountIDs["+strconv.Itoa(idx)+"]", id)
+	}
+	for idx, id := range opts.ChatIDs {
+		query.Add("chatIDs["+strconv.Itoa(idx)+"]", id)
+	}
+	return query
+}
+
+// matches applies the subscription filters to an event
+func matches(opts Options, event Event) bool {
+	var accountID, chatID string
+	switch e := event.(type) {
+	case MessageCreated:
+		accountID, chatID = e.Message.AccountID, e.Message.ChatID
+	case MessageUpdated:
+		accountID, chatID = e.Message.AccountID, e.Message.ChatID
+	case ChatUpdated:
+		accountID, chatID = e.Chat.AccountID, e.Chat.ID
+	case ReactionAdded:
+		chatID = e.ChatID
+	}
+
+	return contains(opts.AccountIDs, accountID) && contains(opts.ChatIDs, chatID)
+}
+
+// contains reports whether value is in list, treating an empty list or an
+// unknown value as a match
+func contains(list []string, value string) bool {
+	if len(list) == 0 || value == "" {
+		return true
+	}
+	for _, item := range list {
+		if item == value {
+			return true
+		}
+	}
+	return false
+}
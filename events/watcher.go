@@ -0,0 +1,308 @@
+package events
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/cameronaaron/beeper-go-sdk/internal/jsonfile"
+	"github.com/cameronaaron/beeper-go-sdk/resources"
+)
+
+// ChatMark is the high-water mark of delivered messages in one chat
+type ChatMark struct {
+	SortKey   resources.SortKey `json:"sortKey"`
+	Timestamp time.Time         `json:"timestamp"`
+	IDs       []string          `json:"ids,omitempty"` // Delivered message IDs that share SortKey
+}
+
+// WatcherState is the persisted progress of a Watcher
+type WatcherState struct {
+	// Since is the cut-off for chats without a mark; only messages after it are delivered
+	Since time.Time            `json:"since"`
+	Chats map[string]*ChatMark `json:"chats"`
+}
+
+// Store persists watcher state between runs
+type Store interface {
+	Load() (*WatcherState, error)
+	Save(state *WatcherState) error
+}
+
+// FileStore keeps watcher state in a JSON file
+type FileStore = jsonfile.Store[WatcherState]
+
+// NewFileStore creates a store backed by the given file
+func NewFileStore(path string) *FileStore {
+	return jsonfile.NewStore[WatcherState](path, "watcher state")
+}
+
+// WatcherOptions configures a Watcher
+type WatcherOptions struct {
+	AccountIDs []string
+	ChatIDs    []string
+	PageLimit  int           // Messages per search page, defaults to 50
+	MaxPages   int           // Pages fetched per poll while catching up; zero means no limit. See ErrGap.
+	Interval   time.Duration // Delay between polls in Run, defaults to 5 seconds
+}
+
+// ErrGap is returned by Poll when MaxPages pages held only new messages, so
+// older new messages may lie beyond them. Nothing is delivered and the marks
+// do not move; raise MaxPages, or leave it at zero, to catch up.
+var ErrGap = errors.New("more new messages than MaxPages pages hold")
+
+// Watcher tails new messages by polling Messages.Search. It tracks a
+// high-water mark per chat so that overlapping pages and restarts neither
+// re-deliver nor skip messages.
+type Watcher struct {
+	messages *resources.Messages
+	store    Store
+	opts     WatcherOptions
+	now      func() time.Time
+
+	mu    sync.Mutex
+	state *WatcherState
+}
+
+// NewWatcher creates a watcher, resuming from the store when it has saved
+// state. A nil store keeps state in memory only.
+func NewWatcher(client resources.ClientInterface, store Store, opts WatcherOptions) (*Watcher, error) {
+	if opts.PageLimit <= 0 {
+		opts.PageLimit = 50
+	}
+	if opts.Interval <= 0 {
+		opts.Interval = 5 * time.Second
+	}
+
+	w := &Watcher{
+		messages: resources.NewMessages(client),
+		store:    store,
+		opts:     opts,
+		now:      time.Now,
+	}
+
+	if store != nil {
+		state, err := store.Load()
+		if err != nil {
+			return nil, err
+		}
+		w.state = state
+	}
+
+	return w, nil
+}
+
+// State returns a copy of the current high-water marks
+func (w *Watcher) State() WatcherState {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.state == nil {
+		return WatcherState{Chats: map[string]*ChatMark{}}
+	}
+
+	copied := WatcherState{Since: w.state.Since, Chats: make(map[string]*ChatMark, len(w.state.Chats))}
+	for chatID, mark := range w.state.Chats {
+		m := *mark
+		m.IDs = append([]string(nil), mark.IDs...)
+		copied.Chats[chatID] = &m
+	}
+	return copied
+}
+
+// Poll fetches messages newer than the high-water marks and passes them to
+// handler in sort order. Each mark advances and is persisted only after the
+// handler accepts the message, so a failed handler sees it again next poll.
+// The first poll without saved state only records where the tail starts.
+func (w *Watcher) Poll(ctx context.Context, handler func(resources.Message) error) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.state == nil {
+		return w.prime(ctx)
+	}
+
+	batch, err := w.fetchNew(ctx)
+	if err != nil {
+		return err
+	}
+
+	for _, msg := range batch {
+		if err := handler(msg); err != nil {
+			return &handlerError{err: err}
+		}
+		w.advance(msg)
+		if err := w.save(); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Run polls until the context is cancelled or the handler returns an error.
+// Search failures are retried on the next tick; each one is passed to onError
+// when it is non-nil.
+func (w *Watcher) Run(ctx context.Context, handler func(resources.Message) error, onError func(error)) error {
+	ticker := time.NewTicker(w.opts.Interval)
+	defer ticker.Stop()
+
+	for {
+		if err := w.Poll(ctx, handler); err != nil {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			var handlerErr *handlerError
+			if errors.As(err, &handlerErr) {
+				return handlerErr.err
+			}
+			if onError != nil {
+				onError(err)
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+// prime records the newest message per chat without delivering anything
+func (w *Watcher) prime(ctx context.Context) error {
+	result, err := w.messages.Search(ctx, w.searchParams(nil))
+	if err != nil {
+		return err
+	}
+
+	w.state = &WatcherState{Since: w.now(), Chats: make(map[string]*ChatMark)}
+	for _, msg := range sortMessages(result.Items) {
+		if msg.Timestamp.After(w.state.Since) {
+			w.state.Since = msg.Timestamp.Time
+		}
+		w.advance(msg)
+	}
+
+	return w.save()
+}
+
+// fetchNew pages back from the newest messages until a page holds nothing
+// new or there are no more pages. It fails with ErrGap rather than return
+// only the newest messages when MaxPages runs out first, since delivering
+// them would move the marks past the ones left unfetched.
+func (w *Watcher) fetchNew(ctx context.Context) ([]resources.Message, error) {
+	seen := make(map[string]bool)
+	var batch []resources.Message
+	var cursor *string
+
+	for page := 0; ; page++ {
+		if w.opts.MaxPages > 0 && page == w.opts.MaxPages {
+			return nil, fmt.Errorf("%w (%d pages of %d)", ErrGap, w.opts.MaxPages, w.opts.PageLimit)
+		}
+		result, err := w.messages.Search(ctx, w.searchParams(cursor))
+		if err != nil {
+			return nil, err
+		}
+
+		fresh := 0
+		for _, msg := range result.Items {
+			// Pages can overlap when new messages arrive between requests
+			if seen[msg.ID] || !w.isNew(msg) {
+				continue
+			}
+			seen[msg.ID] = true
+			batch = append(batch, msg)
+			fresh++
+		}
+
+		if fresh == 0 || result.Pagination == nil || !result.Pagination.HasMore || result.Pagination.Cursor == nil {
+			break
+		}
+		cursor = result.Pagination.Cursor
+	}
+
+	return sortMessages(batch), nil
+}
+
+// isNew reports whether a message is past its chat's high-water mark
+func (w *Watcher) isNew(msg resources.Message) bool {
+	mark, ok := w.state.Chats[msg.ChatID]
+	if !ok {
+		return msg.Timestamp.After(w.state.Since)
+	}
+
+	if cmp := msg.SortKey.Compare(mark.SortKey); cmp != 0 {
+		return cmp > 0
+	}
+	for _, id := range mark.IDs {
+		if id == msg.ID {
+			return false
+		}
+	}
+	return true
+}
+
+// advance moves the chat's mark to include msg
+func (w *Watcher) advance(msg resources.Message) {
+	mark, ok := w.state.Chats[msg.ChatID]
+	if !ok {
+		w.state.Chats[msg.ChatID] = &ChatMark{SortKey: msg.SortKey, Timestamp: msg.Timestamp.Time, IDs: []string{msg.ID}}
+		return
+	}
+
+	switch cmp := msg.SortKey.Compare(mark.SortKey); {
+	case cmp > 0:
+		mark.SortKey = msg.SortKey
+		mark.Timestamp = msg.Timestamp.Time
+		mark.IDs = []string{msg.ID}
+	case cmp == 0:
+		mark.IDs = append(mark.IDs, msg.ID)
+	}
+}
+
+func (w *Watcher) save() error {
+	if w.store == nil {
+		return nil
+	}
+	if err := w.store.Save(w.state); err != nil {
+		return &handlerError{err: err}
+	}
+	return nil
+}
+
+func (w *Watcher) searchParams(cursor *string) resources.MessageSearchParams {
+	limit := w.opts.PageLimit
+	return resources.MessageSearchParams{
+		AccountIDs: w.opts.AccountIDs,
+		ChatIDs:    w.opts.ChatIDs,
+		Limit:      &limit,
+		Cursor:     cursor,
+	}
+}
+
+// handlerError marks handler and persistence failures, which stop Run rather
+// than being retried
+type handlerError struct {
+	err error
+}
+
+func (e *handlerError) Error() string { return e.err.Error() }
+func (e *handlerError) Unwrap() error { return e.err }
+
+// sortMessages orders messages by timestamp, then by sort key within a chat
+func sortMessages(messages []resources.Message) []resources.Message {
+	sort.SliceStable(messages, func(i, j int) bool {
+		a, b := messages[i], messages[j]
+		if a.ChatID == b.ChatID {
+			if cmp := a.SortKey.Compare(b.SortKey); cmp != 0 {
+				return cmp < 0
+			}
+		}
+		return a.Timestamp.Before(b.Timestamp.Time)
+	})
+	return messages
+}
@@ -0,0 +1,191 @@
+package events_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	beeperdesktop "github.com/cameronaaron/beeper-go-sdk"
+	"github.com/cameronaaron/beeper-go-sdk/events"
+	"github.com/cameronaaron/beeper-go-sdk/resources"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWatcherHighWaterMarks(t *testing.T) {
+	base := time.Now().Add(time.Hour)
+	msg := func(id, chatID string, sortKey resources.SortKey, offset int) resources.Message {
+		return resources.Message{ID: id, ChatID: chatID, SortKey: sortKey, Timestamp: resources.NewTimestamp(base.Add(time.Duration(offset) * time.Second))}
+	}
+
+	var mu sync.Mutex
+	// Newest first, as the search endpoint returns them
+	messages := []resources.Message{
+		msg("b1", "chat-b", resources.NewSortKey("5"), 1),
+		msg("a1", "chat-a", resources.NewNumericSortKey(1), 0),
+	}
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+
+		limit, _ := strconv.Atoi(r.URL.Query().Get("limit"))
+		offset, _ := strconv.Atoi(r.URL.Query().Get("cursor"))
+		end := offset + limit
+		if end > len(messages) {
+			end = len(messages)
+		}
+
+		page := resources.MessagesCursor{Items: messages[offset:end], Pagination: &resources.PaginationInfo{}}
+		if end < len(messages) {
+			// Overlap consecutive pages by one item, as happens when messages arrive mid-scan
+			page.Pagination.HasMore = true
+			page.Pagination.Cursor = beeperdesktop.StringPtr(strconv.Itoa(end - 1))
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(page)
+	}))
+	defer server.Close()
+
+	ctx := context.Background()
+	client := newClient(t, server.URL)
+	store := events.NewFileStore(filepath.Join(t.TempDir(), "watcher.json"))
+
+	var delivered []string
+	handler := func(m resources.Message) error {
+		delivered = append(delivered, m.ID)
+		return nil
+	}
+
+	watcher, err := events.NewWatcher(client, store, events.WatcherOptions{PageLimit: 2})
+	require.NoError(t, err)
+
+	// The first poll only records the starting marks
+	require.NoError(t, watcher.Poll(ctx, handler))
+	assert.Empty(t, delivered)
+	assert.Equal(t, "5", watcher.State().Chats["chat-b"].SortKey.String())
+
+	mu.Lock()
+	messages = append([]resources.Message{
+		msg("a3", "chat-a", resources.NewNumericSortKey(3), 4),
+		msg("b2", "chat-b", resources.NewSortKey("10"), 3),
+		msg("a2", "chat-a", resources.NewNumericSortKey(2), 2),
+	}, messages...)
+	mu.Unlock()
+
+	require.NoError(t, watcher.Poll(ctx, handler))
+	assert.Equal(t, []string{"a2", "b2", "a3"}, delivered)
+	assert.Equal(t, "10", watcher.State().Chats["chat-b"].SortKey.String())
+
+	// A restarted watcher resumes from the saved marks
+	restarted, err := events.NewWatcher(client, store, events.WatcherOptions{PageLimit: 2})
+	require.NoError(t, err)
+	require.NoError(t, restarted.Poll(ctx, handler))
+	assert.Equal(t, []string{"a2", "b2", "a3"}, delivered)
+
+	// A failed handler leaves the mark in place so the message is retried
+	mu.Lock()
+	messages = append([]resources.Message{msg("c1", "chat-c", resources.NewSortKey("1"), 5)}, messages...)
+	mu.Unlock()
+
+	failure := errors.New("handler failed")
+	err = restarted.Poll(ctx, func(resources.Message) error { return failure })
+	assert.ErrorIs(t, err, failure)
+
+	require.NoError(t, restarted.Poll(ctx, handler))
+	assert.Equal(t, []string{"a2", "b2", "a3", "c1"}, delivered)
+}
+
+func TestWatcherCatchesUpOnLargeGaps(t *testing.T) {
+	base := time.Now().Add(time.Hour)
+	var mu sync.Mutex
+	var messages []resources.Message // newest first
+	add := func(n int) {
+		mu.Lock()
+		defer mu.Unlock()
+		for i := 0; i < n; i++ {
+			seq := len(messages) + 1
+			msg := resources.Message{ID: "m" + strconv.Itoa(seq), ChatID: "chat-a", SortKey: resources.NewNumericSortKey(int64(seq)),
+				Timestamp: resources.NewTimestamp(base.Add(time.Duration(seq) * time.Second))}
+			messages = append([]resources.Message{msg}, messages...)
+		}
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		limit, _ := strconv.Atoi(r.URL.Query().Get("limit"))
+		offset, _ := strconv.Atoi(r.URL.Query().Get("cursor"))
+		end := min(offset+limit, len(messages))
+		page := resources.MessagesCursor{Items: messages[offset:end], Pagination: &resources.PaginationInfo{}}
+		if end < len(messages) {
+			page.Pagination.HasMore = true
+			page.Pagination.Cursor = beeperdesktop.StringPtr(strconv.Itoa(end))
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(page)
+	}))
+	defer server.Close()
+
+	ctx := context.Background()
+	client := newClient(t, server.URL)
+	store := events.NewFileStore(filepath.Join(t.TempDir(), "watcher.json"))
+	var delivered []string
+	handler := func(m resources.Message) error {
+		delivered = append(delivered, m.ID)
+		return nil
+	}
+
+	add(1)
+	capped, err := events.NewWatcher(client, store, events.WatcherOptions{PageLimit: 2, MaxPages: 3})
+	require.NoError(t, err)
+	require.NoError(t, capped.Poll(ctx, handler))
+	before := capped.State()
+
+	// More new messages than MaxPages*PageLimit
+	add(7)
+	err = capped.Poll(ctx, handler)
+	assert.ErrorIs(t, err, events.ErrGap)
+	assert.Empty(t, delivered, "nothing is delivered past a gap")
+	assert.Equal(t, before, capped.State(), "the marks do not move")
+
+	// Without a page limit the watcher pages back to its mark
+	watcher, err := events.NewWatcher(client, store, events.WatcherOptions{PageLimit: 2})
+	require.NoError(t, err)
+	require.NoError(t, watcher.Poll(ctx, handler))
+	assert.Equal(t, []string{"m2", "m3", "m4", "m5", "m6", "m7", "m8"}, delivered)
+}
+
+func TestWatcherRunReportsErrors(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusUnauthorized)
+		w.Write([]byte(`{"error":"token expired"}`))
+	}))
+	defer server.Close()
+
+	watcher, err := events.NewWatcher(newClient(t, server.URL), nil, events.WatcherOptions{Interval: time.Millisecond})
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	var errs []error
+	err = watcher.Run(ctx, func(resources.Message) error { return nil }, func(err error) {
+		errs = append(errs, err)
+		if len(errs) == 3 {
+			cancel()
+		}
+	})
+	assert.ErrorIs(t, err, context.Canceled)
+	require.Len(t, errs, 3)
+	var authErr *beeperdesktop.AuthenticationError
+	assert.ErrorAs(t, errs[0], &authErr)
+}
@@ -0,0 +1,351 @@
+// Package inbox keeps a merged, priority-ordered list of the unread chats of
+// every account, for dashboards and digest tools. Refresh fetches only the
+// chats active since the previous refresh and rechecks the rest of the inbox,
+// reporting what changed.
+package inbox
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"sort"
+	"sync"
+
+	beeperdesktop "github.com/cameronaaron/beeper-go-sdk"
+	"github.com/cameronaaron/beeper-go-sdk/resources"
+)
+
+// defaultPageSize is the number of chats fetched per search request
+const defaultPageSize = 50
+
+// Item is one unread chat
+type Item struct {
+	Chat        resources.Chat
+	Account     resources.Account
+	LowPriority bool
+}
+
+// Options configures an Inbox
+type Options struct {
+	// AccountIDs limits the inbox to these accounts. Defaults to all accounts.
+	AccountIDs []string
+	// IncludeMuted keeps unread muted chats in the inbox
+	IncludeMuted bool
+	// ExcludeLowPriority drops low-priority chats instead of listing them last
+	ExcludeLowPriority bool
+	// LowPriority classifies chats. Defaults to treating archived chats as low
+	// priority.
+	LowPriority func(chat resources.Chat) bool
+	// Less orders items. Defaults to ByPriority.
+	Less func(a, b Item) bool
+	// PageSize is the number of chats fetched per request. Defaults to 50.
+	PageSize int
+}
+
+// Changes lists what a refresh changed
+type Changes struct {
+	Added   []Item
+	Updated []Item
+	Removed []Item // chats that were read, muted or deleted
+}
+
+// Empty reports whether nothing changed
+func (c *Changes) Empty() bool {
+	return len(c.Added) == 0 && len(c.Updated) == 0 && len(c.Removed) == 0
+}
+
+// Inbox is the unread inbox across accounts. It is safe for concurrent use.
+type Inbox struct {
+	client beeperdesktop.Client
+	opts   Options
+
+	refreshMu sync.Mutex // serializes refreshes
+
+	mu        sync.Mutex
+	items     map[string]Item // by chat ID
+	watermark *resources.Timestamp
+}
+
+// New creates an empty inbox; call Refresh to fill it
+func New(client beeperdesktop.Client, opts Options) *Inbox {
+	if opts.LowPriority == nil {
+		opts.LowPriority = isArchived
+	}
+	if opts.Less == nil {
+		opts.Less = ByPriority
+	}
+	if opts.PageSize <= 0 {
+		opts.PageSize = defaultPageSize
+	}
+	return &Inbox{
+		client: client,
+		opts:   opts,
+		items:  make(map[string]Item),
+	}
+}
+
+// ByPriority orders pinned chats first and low-priority chats last, then by
+// most recent activity, then by unread count
+func ByPriority(a, b Item) bool {
+	if pa, pb := isPinned(a.Chat), isPinned(b.Chat); pa != pb {
+		return pa
+	}
+	if a.LowPriority != b.LowPriority {
+		return !a.LowPriority
+	}
+	if c := lastActivity(a.Chat).Compare(lastActivity(b.Chat)); c != 0 {
+		return c > 0
+	}
+	if a.Chat.UnreadCount != b.Chat.UnreadCount {
+		return a.Chat.UnreadCount > b.Chat.UnreadCount
+	}
+	return a.Chat.ID < b.Chat.ID
+}
+
+// Items returns the unread chats in priority order
+func (in *Inbox) Items() []Item {
+	in.mu.Lock()
+	items := make([]Item, 0, len(in.items))
+	for _, item := range in.items {
+		items = append(items, item)
+	}
+	in.mu.Unlock()
+
+	sort.SliceStable(items, func(i, j int) bool {
+		return in.opts.Less(items[i], items[j])
+	})
+	return items
+}
+
+// UnreadCount returns the number of unread messages in the inbox
+func (in *Inbox) UnreadCount() int {
+	in.mu.Lock()
+	defer in.mu.Unlock()
+
+	total := 0
+	for _, item := range in.items {
+		total += item.Chat.UnreadCount
+	}
+	return total
+}
+
+// Refresh brings the inbox up to date. The first refresh reads every chat;
+// later ones page through chats until they reach those last active before
+// the previous refresh, then retrieve the remaining inbox chats to catch ones
+// read elsewhere. On error the inbox is left unchanged.
+func (in *Inbox) Refresh(ctx context.Context) (*Changes, error) {
+	in.refreshMu.Lock()
+	defer in.refreshMu.Unlock()
+
+	in.mu.Lock()
+	previous := make(map[string]Item, len(in.items))
+	for id, item := range in.items {
+		previous[id] = item
+	}
+	watermark := in.watermark
+	in.mu.Unlock()
+
+	accounts, err := in.accounts(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	r := &refresh{
+		inbox:    in,
+		full:     watermark == nil,
+		accounts: accounts,
+		previous: previous,
+		next:     make(map[string]Item),
+		seen:     make(map[string]bool),
+	}
+	if err := r.scan(ctx, watermark); err != nil {
+		return nil, err
+	}
+	if !r.full {
+		if err := r.recheck(ctx); err != nil {
+			return nil, err
+		}
+	}
+	changes := r.changes()
+
+	in.mu.Lock()
+	in.items = r.next
+	if r.newest != nil && (watermark == nil || r.newest.After(watermark.Time)) {
+		in.watermark = r.newest
+	}
+	in.mu.Unlock()
+	return changes, nil
+}
+
+// Reload discards the refresh state and reads every chat again
+func (in *Inbox) Reload(ctx context.Context) (*Changes, error) {
+	in.mu.Lock()
+	in.watermark = nil
+	in.mu.Unlock()
+	return in.Refresh(ctx)
+}
+
+// accounts lists the accounts in scope by ID
+func (in *Inbox) accounts(ctx context.Context) (map[string]resources.Account, error) {
+	list, err := in.client.AccountsAPI().List(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("list accounts: %w", err)
+	}
+	accounts := make(map[string]resources.Account, len(*list))
+	for _, account := range *list {
+		accounts[account.AccountID] = account
+	}
+	return accounts, nil
+}
+
+// refresh is the state of one Refresh call
+type refresh struct {
+	inbox    *Inbox
+	full     bool // every chat is scanned, so unseen ones left the inbox
+	accounts map[string]resources.Account
+	previous map[string]Item
+	next     map[string]Item
+	seen     map[string]bool
+	newest   *resources.Timestamp
+}
+
+// scan pages through chats, most recently active first, stopping at chats
+// last active before watermark. A nil watermark reads every chat.
+func (r *refresh) scan(ctx context.Context, watermark *resources.Timestamp) error {
+	opts := r.inbox.opts
+	params := resources.ChatSearchParams{
+		AccountIDs: opts.AccountIDs,
+		Limit:      beeperdesktop.IntPtr(opts.PageSize),
+	}
+	if !opts.IncludeMuted {
+		params.IncludeMuted = beeperdesktop.BoolPtr(false)
+	}
+
+	for {
+		page, err := r.inbox.client.ChatsAPI().Search(ctx, params)
+		if err != nil {
+			return fmt.Errorf("search chats: %w", err)
+		}
+		for _, chat := range page.Items {
+			if watermark != nil && lastActivity(chat).Before(watermark.Time) {
+				return nil
+			}
+			r.apply(chat)
+		}
+		if page.Pagination == nil || !page.Pagination.HasMore || page.Pagination.Cursor == nil {
+			return nil
+		}
+		params.Cursor = page.Pagination.Cursor
+	}
+}
+
+// recheck retrieves the inbox chats the scan did not reach
+func (r *refresh) recheck(ctx context.Context) error {
+	ids := make([]string, 0, len(r.previous))
+	for id := range r.previous {
+		if !r.seen[id] {
+			ids = append(ids, id)
+		}
+	}
+	sort.Strings(ids)
+
+	for _, id := range ids {
+		chat, err := r.inbox.client.ChatsAPI().Retrieve(ctx, resources.ChatRetrieveParams{ChatID: id})
+		var notFound *beeperdesktop.NotFoundError
+		if errors.As(err, &notFound) {
+			r.seen[id] = true
+			continue
+		}
+		if err != nil {
+			return fmt.Errorf("retrieve chat %s: %w", id, err)
+		}
+		r.apply(*chat)
+	}
+	return nil
+}
+
+// apply records a chat, adding it to the next inbox if it belongs there
+func (r *refresh) apply(chat resources.Chat) {
+	r.seen[chat.ID] = true
+	if chat.LastActivity != nil && (r.newest == nil || chat.LastActivity.After(r.newest.Time)) {
+		at := *chat.LastActivity
+		r.newest = &at
+	}
+
+	opts := r.inbox.opts
+	if chat.UnreadCount <= 0 || (!opts.IncludeMuted && isMuted(chat)) {
+		return
+	}
+	if len(opts.AccountIDs) > 0 && !contains(opts.AccountIDs, chat.AccountID) {
+		return
+	}
+	item := Item{Chat: chat, LowPriority: opts.LowPriority(chat)}
+	if item.LowPriority && opts.ExcludeLowPriority {
+		return
+	}
+	item.Account = r.accounts[chat.AccountID]
+	if item.Account.AccountID == "" {
+		item.Account = resources.Account{AccountID: chat.AccountID, Network: chat.Network}
+	}
+	r.next[chat.ID] = item
+}
+
+// changes compares the next inbox with the previous one. After a partial
+// scan, previous items the refresh did not see are kept.
+func (r *refresh) changes() *Changes {
+	changes := &Changes{}
+	for id, item := range r.previous {
+		if !r.seen[id] && !r.full {
+			r.next[id] = item
+			continue
+		}
+		if _, ok := r.next[id]; !ok {
+			changes.Removed = append(changes.Removed, item)
+		}
+	}
+	for id, item := range r.next {
+		old, ok := r.previous[id]
+		switch {
+		case !ok:
+			changes.Added = append(changes.Added, item)
+		case !reflect.DeepEqual(old, item):
+			changes.Updated = append(changes.Updated, item)
+		}
+	}
+
+	less := r.inbox.opts.Less
+	for _, items := range [][]Item{changes.Added, changes.Updated, changes.Removed} {
+		sort.SliceStable(items, func(i, j int) bool { return less(items[i], items[j]) })
+	}
+	return changes
+}
+
+func isPinned(chat resources.Chat) bool {
+	return chat.IsPinned != nil && *chat.IsPinned
+}
+
+func isMuted(chat resources.Chat) bool {
+	return chat.IsMuted != nil && *chat.IsMuted
+}
+
+func isArchived(chat resources.Chat) bool {
+	return chat.IsArchived != nil && *chat.IsArchived
+}
+
+// lastActivity returns when a chat was last active, zero if unknown
+func lastActivity(chat resources.Chat) resources.Timestamp {
+	if chat.LastActivity == nil {
+		return resources.Timestamp{}
+	}
+	return *chat.LastActivity
+}
+
+func contains(list []string, value string) bool {
+	for _, v := range list {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
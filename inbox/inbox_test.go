@@ -0,0 +1,126 @@
+package inbox_test
+
+import (
+	"context"
+	"testing"
+
+	beeperdesktop "github.com/cameronaaron/beeper-go-sdk"
+	"github.com/cameronaaron/beeper-go-sdk/beepertest"
+	"github.com/cameronaaron/beeper-go-sdk/inbox"
+	"github.com/cameronaaron/beeper-go-sdk/resources"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// countingClient counts chat search requests
+type countingClient struct {
+	beeperdesktop.Client
+	searches int
+}
+
+func (c *countingClient) ChatsAPI() resources.ChatsAPI {
+	return countingChats{ChatsAPI: c.Client.ChatsAPI(), client: c}
+}
+
+type countingChats struct {
+	resources.ChatsAPI
+	client *countingClient
+}
+
+func (c countingChats) Search(ctx context.Context, params resources.ChatSearchParams) (*resources.ChatsCursor, error) {
+	c.client.searches++
+	return c.ChatsAPI.Search(ctx, params)
+}
+
+func chatIDs(items []inbox.Item) []string {
+	var ids []string
+	for _, item := range items {
+		ids = append(ids, item.Chat.ID)
+	}
+	return ids
+}
+
+func receive(t *testing.T, server *beepertest.Server, chatID, senderID string) {
+	_, err := server.ReceiveMessage(resources.Message{ChatID: chatID, SenderID: senderID, Text: beeperdesktop.StringPtr("ping")})
+	require.NoError(t, err)
+}
+
+func TestInboxRefresh(t *testing.T) {
+	server := beepertest.NewServer(beepertest.DefaultFixtures())
+	defer server.Close()
+	sdk, err := server.Client()
+	require.NoError(t, err)
+	client := &countingClient{Client: sdk}
+	ctx := context.Background()
+
+	in := inbox.New(client, inbox.Options{PageSize: 1})
+	changes, err := in.Refresh(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"chat-team"}, chatIDs(changes.Added))
+	assert.Equal(t, "Beeper (Matrix)", changes.Added[0].Account.Network)
+	assert.Equal(t, 2, in.UnreadCount())
+
+	// A new message only needs the first page
+	receive(t, server, "chat-alice", "@alice:beeper.com")
+	client.searches = 0
+	changes, err = in.Refresh(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"chat-alice"}, chatIDs(changes.Added))
+	assert.Empty(t, changes.Updated)
+	assert.Equal(t, 2, client.searches, "stops after reaching chats older than the last refresh")
+	assert.Equal(t, []string{"chat-team", "chat-alice"}, chatIDs(in.Items()), "pinned first")
+
+	// Chats read elsewhere are rechecked even though they had no activity
+	require.NoError(t, server.MarkRead("chat-team"))
+	changes, err = in.Refresh(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"chat-team"}, chatIDs(changes.Removed))
+	assert.Equal(t, []string{"chat-alice"}, chatIDs(in.Items()))
+
+	// Muted chats stay out
+	receive(t, server, "chat-family", "+15555550123")
+	changes, err = in.Refresh(ctx)
+	require.NoError(t, err)
+	assert.True(t, changes.Empty())
+
+	// Archived chats are low priority and listed last
+	receive(t, server, "chat-team", "@bob:beeper.com")
+	_, err = sdk.Chats.Archive(ctx, resources.ChatArchiveParams{ChatID: "chat-alice", Archived: true})
+	require.NoError(t, err)
+	changes, err = in.Refresh(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"chat-team"}, chatIDs(changes.Added))
+	require.Equal(t, []string{"chat-alice"}, chatIDs(changes.Updated))
+	assert.True(t, changes.Updated[0].LowPriority)
+	assert.Equal(t, []string{"chat-team", "chat-alice"}, chatIDs(in.Items()))
+
+	changes, err = in.Reload(ctx)
+	require.NoError(t, err)
+	assert.True(t, changes.Empty())
+}
+
+func TestInboxOptions(t *testing.T) {
+	server := beepertest.NewServer(beepertest.DefaultFixtures())
+	defer server.Close()
+	client, err := server.Client()
+	require.NoError(t, err)
+	ctx := context.Background()
+
+	receive(t, server, "chat-family", "+15555550123")
+	receive(t, server, "chat-alice", "@alice:beeper.com")
+
+	in := inbox.New(client, inbox.Options{IncludeMuted: true})
+	_, err = in.Refresh(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"chat-team", "chat-alice", "chat-family"}, chatIDs(in.Items()))
+
+	in = inbox.New(client, inbox.Options{
+		IncludeMuted:       true,
+		AccountIDs:         []string{"whatsapp"},
+		ExcludeLowPriority: true,
+		LowPriority:        func(chat resources.Chat) bool { return chat.Type == "group" },
+	})
+	_, err = in.Refresh(ctx)
+	require.NoError(t, err)
+	assert.Empty(t, in.Items(), "the only WhatsApp chat is a group")
+}
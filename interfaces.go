@@ -0,0 +1,56 @@
+package beeperdesktop
+
+import (
+	"context"
+	"io"
+
+	"github.com/cameronaaron/beeper-go-sdk/resources"
+)
+
+// Client is the interface implemented by BeeperDesktop. Application code that
+// depends on Client instead of *BeeperDesktop can substitute the mocks in the
+// beepermock package in unit tests.
+type Client interface {
+	AccountsAPI() resources.AccountsAPI
+	AppAPI() resources.AppAPI
+	ChatsAPI() resources.ChatsAPI
+	ContactsAPI() resources.ContactsAPI
+	MessagesAPI() resources.MessagesAPI
+	TokenAPI() resources.TokenAPI
+
+	DoRequest(ctx context.Context, method, path string, body interface{}, result interface{}) error
+	DoRequestWithQuery(ctx context.Context, method, path string, query map[string]interface{}, result interface{}) error
+	OpenEventStream(ctx context.Context, path, lastEventID string) (io.ReadCloser, error)
+}
+
+var _ Client = (*BeeperDesktop)(nil)
+
+// AccountsAPI returns the accounts resource as an interface
+func (c *BeeperDesktop) AccountsAPI() resources.AccountsAPI {
+	return c.Accounts
+}
+
+// AppAPI returns the app resource as an interface
+func (c *BeeperDesktop) AppAPI() resources.AppAPI {
+	return c.App
+}
+
+// ChatsAPI returns the chats resource as an interface
+func (c *BeeperDesktop) ChatsAPI() resources.ChatsAPI {
+	return c.Chats
+}
+
+// ContactsAPI returns the contacts resource as an interface
+func (c *BeeperDesktop) ContactsAPI() resources.ContactsAPI {
+	return c.Contacts
+}
+
+// MessagesAPI returns the messages resource as an interface
+func (c *BeeperDesktop) MessagesAPI() resources.MessagesAPI {
+	return c.Messages
+}
+
+// TokenAPI returns the token resource as an interface
+func (c *BeeperDesktop) TokenAPI() resources.TokenAPI {
+	return c.Token
+}
@@ -0,0 +1,48 @@
+// Package cli holds the connection flags shared by the commands in cmd
+package cli
+
+import (
+	"flag"
+	"time"
+
+	beeperdesktop "github.com/cameronaaron/beeper-go-sdk"
+)
+
+// ClientFlags are the -profile, -timeout and -retries flags of a command
+type ClientFlags struct {
+	fs      *flag.FlagSet
+	profile *string
+	timeout *time.Duration
+	retries *int
+}
+
+// RegisterClientFlags adds the connection flags to fs
+func RegisterClientFlags(fs *flag.FlagSet) *ClientFlags {
+	return &ClientFlags{
+		fs:      fs,
+		profile: fs.String("profile", "", "connection profile from the Beeper config file"),
+		timeout: fs.Duration("timeout", 0, "request timeout (default: the profile's, or 30s)"),
+		retries: fs.Int("retries", 0, "retries per request (default: the profile's, or 2)"),
+	}
+}
+
+// Profile returns the -profile flag
+func (f *ClientFlags) Profile() string {
+	return *f.profile
+}
+
+// Options returns the client options for the parsed flags. -timeout and
+// -retries are only applied when given, because options override the
+// profile's timeout and max_retries.
+func (f *ClientFlags) Options() []beeperdesktop.ClientOption {
+	opts := []beeperdesktop.ClientOption{beeperdesktop.WithProfile(*f.profile)}
+	f.fs.Visit(func(fl *flag.Flag) {
+		switch fl.Name {
+		case "timeout":
+			opts = append(opts, beeperdesktop.WithTimeout(*f.timeout))
+		case "retries":
+			opts = append(opts, beeperdesktop.WithMaxRetries(*f.retries))
+		}
+	})
+	return opts
+}
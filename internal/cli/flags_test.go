@@ -0,0 +1,36 @@
+package cli
+
+import (
+	"flag"
+	"testing"
+	"time"
+
+	beeperdesktop "github.com/cameronaaron/beeper-go-sdk"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestClientFlags(t *testing.T) {
+	apply := func(args ...string) *beeperdesktop.ClientConfig {
+		fs := flag.NewFlagSet("test", flag.ContinueOnError)
+		flags := RegisterClientFlags(fs)
+		require.NoError(t, fs.Parse(args))
+
+		// Stand-ins for the profile's values, which options override
+		config := &beeperdesktop.ClientConfig{Timeout: time.Minute, MaxRetries: 5}
+		for _, opt := range flags.Options() {
+			opt(config)
+		}
+		return config
+	}
+
+	config := apply("-profile", "work")
+	assert.True(t, config.UseProfile)
+	assert.Equal(t, "work", config.Profile)
+	assert.Equal(t, time.Minute, config.Timeout, "the profile's timeout is kept")
+	assert.Equal(t, 5, config.MaxRetries, "the profile's retries are kept")
+
+	config = apply("-timeout", "5s", "-retries", "0")
+	assert.Equal(t, 5*time.Second, config.Timeout)
+	assert.Equal(t, 0, config.MaxRetries)
+}
@@ -0,0 +1,449 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"go/format"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+const (
+	typesFile   = "types_gen.go"
+	methodsFile = "methods_gen.go"
+	header      = "// Code generated by internal/cmd/apigen from api/openapi.json; DO NOT EDIT.\n\n"
+)
+
+// initialisms are written in capitals in Go names
+var initialisms = map[string]bool{"ID": true, "IDS": true, "URL": true}
+
+// generate renders the generated files of the resources package
+func generate(doc *document) (map[string][]byte, error) {
+	types := newFile()
+	methods := newFile()
+
+	for _, name := range doc.Components.Schemas.keys {
+		s, _ := doc.Components.Schemas.get(name)
+		if err := types.schemaType(name, s); err != nil {
+			return nil, err
+		}
+	}
+
+	emitted := map[string]bool{}
+	for _, op := range doc.operations() {
+		if op.Params == nil {
+			continue
+		}
+		if emitted[op.Params.Name] {
+			continue
+		}
+		emitted[op.Params.Name] = true
+		if err := types.paramsType(op); err != nil {
+			return nil, err
+		}
+		if len(op.queryParameters()) > 0 {
+			if err := methods.queryEncoder(op); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	for _, op := range doc.operations() {
+		if err := methods.method(doc, op); err != nil {
+			return nil, err
+		}
+	}
+
+	files := map[string][]byte{}
+	for name, f := range map[string]*file{typesFile: types, methodsFile: methods} {
+		code, err := f.render()
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", name, err)
+		}
+		files[name] = code
+	}
+	return files, nil
+}
+
+type file struct {
+	body    bytes.Buffer
+	imports map[string]bool
+}
+
+func newFile() *file {
+	return &file{imports: map[string]bool{}}
+}
+
+func (f *file) printf(format string, args ...interface{}) {
+	fmt.Fprintf(&f.body, format, args...)
+}
+
+func (f *file) render() ([]byte, error) {
+	var buf bytes.Buffer
+	buf.WriteString(header)
+	buf.WriteString("package resources\n\n")
+	if len(f.imports) > 0 {
+		paths := make([]string, 0, len(f.imports))
+		for path := range f.imports {
+			paths = append(paths, path)
+		}
+		sort.Strings(paths)
+		buf.WriteString("import (\n")
+		for _, path := range paths {
+			fmt.Fprintf(&buf, "\t%q\n", path)
+		}
+		buf.WriteString(")\n\n")
+	}
+	buf.Write(f.body.Bytes())
+
+	code, err := format.Source(buf.Bytes())
+	if err != nil {
+		return nil, fmt.Errorf("format generated code: %w\n%s", err, buf.Bytes())
+	}
+	return code, nil
+}
+
+// schemaType emits a component schema as a named Go type
+func (f *file) schemaType(name string, s *schema) error {
+	switch {
+	case s.GoType == "-":
+		// Written by hand
+		return nil
+	case s.GoType != "":
+		f.printf("// %s %s\ntype %s = %s\n\n", name, s.Description, name, s.GoType)
+		return nil
+	case s.Type.is("array"):
+		elem, _, err := f.goType(s.Items)
+		if err != nil {
+			return fmt.Errorf("%s: %w", name, err)
+		}
+		f.printf("// %s %s\ntype %s []%s\n\n", name, s.Description, name, elem)
+		return nil
+	case s.Type.is("object"):
+		f.printf("// %s %s\ntype %s struct {\n", name, s.Description, name)
+		for _, prop := range s.Properties.keys {
+			ps, _ := s.Properties.get(prop)
+			if err := f.field(prop, ps, s.requires(prop)); err != nil {
+				return fmt.Errorf("%s.%s: %w", name, prop, err)
+			}
+		}
+		f.printf("}\n\n")
+		return nil
+	}
+	return fmt.Errorf("%s: unsupported schema type %v", name, s.Type)
+}
+
+// paramsType emits the struct holding an operation's parameters
+func (f *file) paramsType(op *operation) error {
+	if op.Params.Description == "" {
+		return fmt.Errorf("%s: x-go-params %s has no description", op.OperationID, op.Params.Name)
+	}
+	f.printf("// %s %s\ntype %s struct {\n", op.Params.Name, op.Params.Description, op.Params.Name)
+	if body := op.bodySchema(); body != nil {
+		for _, prop := range body.Properties.keys {
+			ps, _ := body.Properties.get(prop)
+			if err := f.field(prop, ps, body.requires(prop)); err != nil {
+				return fmt.Errorf("%s.%s: %w", op.Params.Name, prop, err)
+			}
+		}
+	}
+	for _, p := range op.queryParameters() {
+		s := *p.Schema
+		if p.Description != "" {
+			s.Description = p.Description
+		}
+		if err := f.field(p.Name, &s, p.Required); err != nil {
+			return fmt.Errorf("%s.%s: %w", op.Params.Name, p.Name, err)
+		}
+	}
+	f.printf("}\n\n")
+	return nil
+}
+
+// field emits one struct field. Optional fields are omitted when empty and
+// are pointers unless they are slices or maps, or x-go-pointer is false.
+func (f *file) field(jsonName string, s *schema, required bool) error {
+	typ, pointer, err := f.fieldType(s, required)
+	if err != nil {
+		return err
+	}
+	if pointer {
+		typ = "*" + typ
+	}
+	tag := jsonName
+	if !required {
+		tag += ",omitempty"
+	}
+	f.printf("\t%s %s `json:%q`", goName(jsonName), typ, tag)
+	if s.Description != "" {
+		f.printf(" // %s", s.Description)
+	}
+	f.printf("\n")
+	return nil
+}
+
+func (f *file) fieldType(s *schema, required bool) (string, bool, error) {
+	typ, pointable, err := f.goType(s)
+	if err != nil {
+		return "", false, err
+	}
+	pointer := !required && pointable
+	if s.GoPointer != nil {
+		pointer = pointer && *s.GoPointer
+	}
+	return typ, pointer, nil
+}
+
+// goType maps a schema to a Go type and reports whether an optional value of
+// it should be a pointer
+func (f *file) goType(s *schema) (string, bool, error) {
+	if s.GoType != "" {
+		return s.GoType, true, nil
+	}
+	if s.Ref != "" {
+		return refName(s.Ref), true, nil
+	}
+
+	switch {
+	case s.Type.is("string"):
+		if s.Format == "date-time" {
+			f.imports["time"] = true
+			return "time.Time", true, nil
+		}
+		return "string", true, nil
+	case s.Type.is("integer"):
+		if s.Format == "int64" {
+			return "int64", true, nil
+		}
+		return "int", true, nil
+	case s.Type.is("number"):
+		return "float64", true, nil
+	case s.Type.is("boolean"):
+		return "bool", true, nil
+	case s.Type.is("array"):
+		elem, _, err := f.goType(s.Items)
+		if err != nil {
+			return "", false, err
+		}
+		return "[]" + elem, false, nil
+	case s.Type.is("object") && s.AdditionalProperties != nil:
+		elem, _, err := f.goType(s.AdditionalProperties)
+		if err != nil {
+			return "", false, err
+		}
+		return "map[string]" + elem, false, nil
+	}
+	return "", false, fmt.Errorf("unsupported schema type %v", s.Type)
+}
+
+// queryEncoder emits the method that encodes an operation's query parameters
+func (f *file) queryEncoder(op *operation) error {
+	f.imports["net/url"] = true
+	f.printf("// values returns the query parameters of p\n")
+	f.printf("func (p %s) values() url.Values {\n\tquery := url.Values{}\n", op.Params.Name)
+
+	for _, param := range op.queryParameters() {
+		field := "p." + goName(param.Name)
+		_, pointer, err := f.fieldType(param.Schema, param.Required)
+		if err != nil {
+			return fmt.Errorf("%s.%s: %w", op.Params.Name, param.Name, err)
+		}
+
+		if param.Schema.Type.is("array") {
+			if err := f.encodeArray(param, field); err != nil {
+				return fmt.Errorf("%s.%s: %w", op.Params.Name, param.Name, err)
+			}
+			continue
+		}
+
+		value, err := f.formatValue(param.Schema, field)
+		if err != nil {
+			return fmt.Errorf("%s.%s: %w", op.Params.Name, param.Name, err)
+		}
+		switch {
+		case pointer:
+			// time.Time's Format method is called through the pointer as is
+			if param.Schema.Format != "date-time" {
+				value, _ = f.formatValue(param.Schema, "*"+field)
+			}
+			f.printf("\tif %s != nil {\n\t\tquery.Set(%q, %s)\n\t}\n", field, param.Name, value)
+		case !param.Required:
+			f.printf("\tif %s != %s {\n\t\tquery.Set(%q, %s)\n\t}\n", field, zeroValue(param.Schema), param.Name, value)
+		default:
+			f.printf("\tquery.Set(%q, %s)\n", param.Name, value)
+		}
+	}
+
+	f.printf("\treturn query\n}\n\n")
+	return nil
+}
+
+// encodeArray emits the encoding of a list parameter. OpenAPI's form style
+// repeats the name, or joins the items with commas when explode is false; the
+// x-style extension "indexed" writes name[0]=a&name[1]=b.
+func (f *file) encodeArray(param parameter, field string) error {
+	item, err := f.formatValue(param.Schema.Items, "item")
+	if err != nil {
+		return err
+	}
+
+	switch {
+	case param.XStyle == "indexed":
+		f.imports["strconv"] = true
+		f.printf("\tfor i, item := range %s {\n\t\tquery.Set(%q+strconv.Itoa(i)+\"]\", %s)\n\t}\n", field, param.Name+"[", item)
+	case param.XStyle != "":
+		return fmt.Errorf("unsupported x-style %q", param.XStyle)
+	case param.Style != "" && param.Style != "form":
+		return fmt.Errorf("unsupported style %q", param.Style)
+	case param.Explode != nil && !*param.Explode:
+		if !param.Schema.Items.Type.is("string") {
+			return fmt.Errorf("comma separated lists must hold strings")
+		}
+		f.imports["strings"] = true
+		f.printf("\tif len(%s) > 0 {\n\t\tquery.Set(%q, strings.Join(%s, \",\"))\n\t}\n", field, param.Name, field)
+	default:
+		f.printf("\tfor _, item := range %s {\n\t\tquery.Add(%q, %s)\n\t}\n", field, param.Name, item)
+	}
+	return nil
+}
+
+// formatValue renders the expression converting value to its query string form
+func (f *file) formatValue(s *schema, value string) (string, error) {
+	switch {
+	case s.Type.is("string") && s.Format == "date-time":
+		f.imports["time"] = true
+		return fmt.Sprintf("%s.Format(time.RFC3339Nano)", value), nil
+	case s.Type.is("string"):
+		return value, nil
+	case s.Type.is("integer"):
+		f.imports["strconv"] = true
+		if s.Format == "int64" {
+			return fmt.Sprintf("strconv.FormatInt(%s, 10)", value), nil
+		}
+		return fmt.Sprintf("strconv.Itoa(%s)", value), nil
+	case s.Type.is("number"):
+		f.imports["strconv"] = true
+		return fmt.Sprintf("strconv.FormatFloat(%s, 'f', -1, 64)", value), nil
+	case s.Type.is("boolean"):
+		f.imports["strconv"] = true
+		return fmt.Sprintf("strconv.FormatBool(%s)", value), nil
+	}
+	return "", fmt.Errorf("unsupported query parameter type %v", s.Type)
+}
+
+func zeroValue(s *schema) string {
+	switch {
+	case s.Type.is("string"):
+		return `""`
+	case s.Type.is("boolean"):
+		return "false"
+	}
+	return "0"
+}
+
+// method emits the resource method calling an operation. Operations marked
+// x-go-wrap get an unexported method for a hand-written one to wrap.
+func (f *file) method(doc *document, op *operation) error {
+	resource, name, err := resourceMethod(op.OperationID)
+	if err != nil {
+		return err
+	}
+	if op.Wrap {
+		name = lowerFirst(name)
+	}
+	receiver := strings.ToLower(resource[:1])
+
+	resp, err := doc.responseSchema(op)
+	if err != nil {
+		return err
+	}
+	if resp.Ref == "" {
+		return fmt.Errorf("%s: the 200 response must reference a component schema", op.OperationID)
+	}
+	result := refName(resp.Ref)
+
+	f.imports["context"] = true
+	f.printf("// %s %s\n", name, op.Description)
+	if op.Params != nil {
+		f.printf("func (%s *%s) %s(ctx context.Context, params %s) (*%s, error) {\n", receiver, resource, name, op.Params.Name, result)
+	} else {
+		f.printf("func (%s *%s) %s(ctx context.Context) (*%s, error) {\n", receiver, resource, name, result)
+	}
+	f.printf("\tvar result %s\n", result)
+
+	path := fmt.Sprintf("%q", op.path)
+	body := "nil"
+	switch {
+	case op.Params != nil && len(op.queryParameters()) > 0:
+		path = fmt.Sprintf("withQuery(%s, params.values())", path)
+	case op.Params != nil && op.RequestBody != nil:
+		body = "params"
+	case op.Params != nil:
+		return fmt.Errorf("%s: x-go-params without parameters or a request body", op.OperationID)
+	}
+	f.printf("\terr := %s.client.DoRequest(ctx, %q, %s, %s, &result)\n", receiver, op.method, path, body)
+	f.printf("\tif err != nil {\n\t\treturn nil, err\n\t}\n\treturn &result, nil\n}\n\n")
+	return nil
+}
+
+// resourceMethod splits an operationId such as chats.participants.list into
+// the resource type and method names
+func resourceMethod(operationID string) (string, string, error) {
+	parts := strings.Split(operationID, ".")
+	if len(parts) < 2 {
+		return "", "", fmt.Errorf("operationId %q is not resource.method", operationID)
+	}
+	return upperFirst(parts[len(parts)-2]), upperFirst(parts[len(parts)-1]), nil
+}
+
+// goName converts a JSON name such as participantIDs, assetUrl or token_use to
+// a Go field name
+func goName(jsonName string) string {
+	var b strings.Builder
+	for _, part := range strings.Split(jsonName, "_") {
+		for _, word := range camelWords(part) {
+			if initialisms[strings.ToUpper(word)] {
+				if strings.HasSuffix(word, "s") {
+					word = strings.ToUpper(word[:len(word)-1]) + "s"
+				} else {
+					word = strings.ToUpper(word)
+				}
+			}
+			b.WriteString(upperFirst(word))
+		}
+	}
+	return b.String()
+}
+
+// camelWords splits camelCase where a lower case letter meets an upper case one
+func camelWords(s string) []string {
+	var words []string
+	start := 0
+	runes := []rune(s)
+	for i := 1; i < len(runes); i++ {
+		if unicode.IsLower(runes[i-1]) && unicode.IsUpper(runes[i]) {
+			words = append(words, string(runes[start:i]))
+			start = i
+		}
+	}
+	return append(words, string(runes[start:]))
+}
+
+func upperFirst(s string) string {
+	if s == "" {
+		return s
+	}
+	return strings.ToUpper(s[:1]) + s[1:]
+}
+
+func lowerFirst(s string) string {
+	if s == "" {
+		return s
+	}
+	return strings.ToLower(s[:1]) + s[1:]
+}
+
+func sortOperations(ops []*operation) {
+	sort.Slice(ops, func(i, j int) bool { return ops[i].OperationID < ops[j].OperationID })
+}
@@ -0,0 +1,71 @@
+// Command apigen generates the parameter types, response types, query
+// encoding and methods of the resources package from the Desktop API's
+// OpenAPI document in api/openapi.json. It is run through go generate:
+//
+//	go generate ./resources
+//
+// With -check nothing is written; the exit status is 1 when the committed
+// files differ from what the spec generates, which is how CI catches edits to
+// one without the other.
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+)
+
+func main() {
+	specPath := flag.String("spec", "api/openapi.json", "OpenAPI document to generate from")
+	out := flag.String("out", ".", "directory of the resources package")
+	check := flag.Bool("check", false, "fail if the generated files are out of date instead of writing them")
+	flag.Parse()
+
+	data, err := os.ReadFile(*specPath)
+	if err != nil {
+		log.Fatal(err)
+	}
+	doc, err := loadDocument(data)
+	if err != nil {
+		log.Fatalf("%s: %v", *specPath, err)
+	}
+	files, err := generate(doc)
+	if err != nil {
+		log.Fatalf("%s: %v", *specPath, err)
+	}
+
+	if *check {
+		stale := staleFiles(*out, files)
+		for _, name := range stale {
+			fmt.Fprintf(os.Stderr, "%s is out of date with %s; run go generate ./resources\n", filepath.Join(*out, name), *specPath)
+		}
+		if len(stale) > 0 {
+			os.Exit(1)
+		}
+		return
+	}
+
+	for name, code := range files {
+		if err := os.WriteFile(filepath.Join(*out, name), code, 0o644); err != nil {
+			log.Fatal(err)
+		}
+	}
+}
+
+// staleFiles returns the generated files whose contents in dir differ from
+// files, sorted by name
+func staleFiles(dir string, files map[string][]byte) []string {
+	var stale []string
+	for name, code := range files {
+		current, err := os.ReadFile(filepath.Join(dir, name))
+		if err != nil || !bytes.Equal(current, code) {
+			stale = append(stale, name)
+		}
+	}
+	sort.Strings(stale)
+	return stale
+}
@@ -0,0 +1,93 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestGeneratedFilesUpToDate fails when resources/*_gen.go no longer match
+// api/openapi.json
+func TestGeneratedFilesUpToDate(t *testing.T) {
+	data, err := os.ReadFile(filepath.Join("..", "..", "..", "api", "openapi.json"))
+	require.NoError(t, err)
+	doc, err := loadDocument(data)
+	require.NoError(t, err)
+	files, err := generate(doc)
+	require.NoError(t, err)
+
+	stale := staleFiles(filepath.Join("..", "..", "..", "resources"), files)
+	assert.Empty(t, stale, "run go generate ./resources")
+}
+
+func TestGoName(t *testing.T) {
+	tests := map[string]string{
+		"chatID":         "ChatID",
+		"participantIDs": "ParticipantIDs",
+		"assetUrl":       "AssetURL",
+		"srcURL":         "SrcURL",
+		"token_use":      "TokenUse",
+		"client_id":      "ClientID",
+		"has_more":       "HasMore",
+		"id":             "ID",
+		"isGif":          "IsGif",
+	}
+	for in, want := range tests {
+		assert.Equal(t, want, goName(in), in)
+	}
+}
+
+const querySpec = `{
+  "paths": {
+    "/v0/things": {
+      "get": {
+        "operationId": "things.list",
+        "description": "lists things",
+        "x-go-params": {"name": "ThingListParams", "description": "represents parameters for listing things"},
+        "parameters": [
+          {"name": "ids", "in": "query", "style": "form", "explode": false, "schema": {"type": "array", "items": {"type": "string"}}},
+          {"name": "tags", "in": "query", "schema": {"type": "array", "items": {"type": "string"}}},
+          {"name": "kinds", "in": "query", "x-style": "indexed", "schema": {"type": "array", "items": {"type": "string"}}},
+          {"name": "after", "in": "query", "schema": {"type": "string", "format": "date-time"}},
+          {"name": "limit", "in": "query", "required": true, "schema": {"type": "integer"}}
+        ],
+        "responses": {"200": {"content": {"application/json": {"schema": {"$ref": "#/components/schemas/Things"}}}}}
+      }
+    }
+  },
+  "components": {
+    "schemas": {
+      "Things": {"type": "array", "description": "is a list of things", "items": {"type": "string"}}
+    }
+  }
+}`
+
+func TestGenerateQueryEncoding(t *testing.T) {
+	doc, err := loadDocument([]byte(querySpec))
+	require.NoError(t, err)
+	files, err := generate(doc)
+	require.NoError(t, err)
+
+	types := string(files[typesFile])
+	assert.Contains(t, types, "type Things []string")
+	assert.Contains(t, types, "IDs   []string   `json:\"ids,omitempty\"`")
+	assert.Contains(t, types, "After *time.Time `json:\"after,omitempty\"`")
+	assert.Contains(t, types, "Limit int        `json:\"limit\"`")
+
+	methods := string(files[methodsFile])
+	for _, want := range []string{
+		`query.Set("ids", strings.Join(p.IDs, ","))`,
+		`query.Add("tags", item)`,
+		`query.Set("kinds["+strconv.Itoa(i)+"]", item)`,
+		`query.Set("after", p.After.Format(time.RFC3339Nano))`,
+		`query.Set("limit", strconv.Itoa(p.Limit))`,
+		`func (t *Things) List(ctx context.Context, params ThingListParams) (*Things, error) {`,
+		`t.client.DoRequest(ctx, "GET", withQuery("/v0/things", params.values()), nil, &result)`,
+	} {
+		assert.True(t, strings.Contains(methods, want), "missing %s in\n%s", want, methods)
+	}
+}
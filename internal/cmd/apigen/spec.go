@@ -0,0 +1,227 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// The subset of OpenAPI 3.1 the generator understands, plus the x-go-*
+// extensions described in api/README.md
+
+type document struct {
+	Paths      ordered[map[string]*operation] `json:"paths"`
+	Components components                     `json:"components"`
+}
+
+type components struct {
+	Schemas       ordered[*schema]        `json:"schemas"`
+	RequestBodies map[string]*requestBody `json:"requestBodies"`
+	Responses     map[string]*response    `json:"responses"`
+}
+
+type operation struct {
+	OperationID string       `json:"operationId"`
+	Description string       `json:"description"`
+	Params      *goParams    `json:"x-go-params"`
+	Wrap        bool         `json:"x-go-wrap"`
+	Parameters  []parameter  `json:"parameters"`
+	RequestBody *requestBody `json:"requestBody"`
+	Responses   map[string]*response
+
+	// Set while loading
+	method string
+	path   string
+}
+
+// goParams names the Go struct holding an operation's parameters
+type goParams struct {
+	Name        string `json:"name"`
+	Description string `json:"description"`
+}
+
+type parameter struct {
+	Name        string  `json:"name"`
+	In          string  `json:"in"`
+	Required    bool    `json:"required"`
+	Description string  `json:"description"`
+	Style       string  `json:"style"`
+	Explode     *bool   `json:"explode"`
+	XStyle      string  `json:"x-style"`
+	Schema      *schema `json:"schema"`
+}
+
+type requestBody struct {
+	Ref     string               `json:"$ref"`
+	Content map[string]mediaType `json:"content"`
+}
+
+type response struct {
+	Ref     string               `json:"$ref"`
+	Content map[string]mediaType `json:"content"`
+}
+
+type mediaType struct {
+	Schema *schema `json:"schema"`
+}
+
+type schema struct {
+	Ref                  string           `json:"$ref"`
+	Type                 schemaType       `json:"type"`
+	Format               string           `json:"format"`
+	Description          string           `json:"description"`
+	Enum                 []string         `json:"enum"`
+	Items                *schema          `json:"items"`
+	Properties           ordered[*schema] `json:"properties"`
+	Required             []string         `json:"required"`
+	AdditionalProperties *schema          `json:"additionalProperties"`
+	GoType               string           `json:"x-go-type"`
+	GoPointer            *bool            `json:"x-go-pointer"`
+}
+
+func (s *schema) requires(name string) bool {
+	for _, required := range s.Required {
+		if required == name {
+			return true
+		}
+	}
+	return false
+}
+
+// schemaType is the OpenAPI type keyword, which may be a list in 3.1
+type schemaType []string
+
+func (t *schemaType) UnmarshalJSON(data []byte) error {
+	if bytes.HasPrefix(bytes.TrimSpace(data), []byte("[")) {
+		return json.Unmarshal(data, (*[]string)(t))
+	}
+	var single string
+	if err := json.Unmarshal(data, &single); err != nil {
+		return err
+	}
+	*t = schemaType{single}
+	return nil
+}
+
+func (t schemaType) is(name string) bool {
+	return len(t) == 1 && t[0] == name
+}
+
+// ordered is a JSON object that remembers the order of its members
+type ordered[T any] struct {
+	keys   []string
+	values map[string]T
+}
+
+func (o *ordered[T]) UnmarshalJSON(data []byte) error {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	if token, err := decoder.Token(); err != nil || token != json.Delim('{') {
+		return fmt.Errorf("expected a JSON object")
+	}
+
+	o.keys = nil
+	o.values = map[string]T{}
+	for decoder.More() {
+		token, err := decoder.Token()
+		if err != nil {
+			return err
+		}
+		key := token.(string)
+
+		var value T
+		if err := decoder.Decode(&value); err != nil {
+			return fmt.Errorf("%s: %w", key, err)
+		}
+		o.keys = append(o.keys, key)
+		o.values[key] = value
+	}
+	return nil
+}
+
+func (o ordered[T]) get(key string) (T, bool) {
+	value, ok := o.values[key]
+	return value, ok
+}
+
+// loadDocument parses a spec and resolves component references
+func loadDocument(data []byte) (*document, error) {
+	var doc document
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return nil, err
+	}
+
+	for _, path := range doc.Paths.keys {
+		methods, _ := doc.Paths.get(path)
+		for method, op := range methods {
+			op.method = strings.ToUpper(method)
+			op.path = path
+			if op.OperationID == "" {
+				return nil, fmt.Errorf("%s %s: missing operationId", op.method, path)
+			}
+			if op.RequestBody != nil && op.RequestBody.Ref != "" {
+				body, ok := doc.Components.RequestBodies[refName(op.RequestBody.Ref)]
+				if !ok {
+					return nil, fmt.Errorf("%s: unknown request body %s", op.OperationID, op.RequestBody.Ref)
+				}
+				op.RequestBody = body
+			}
+		}
+	}
+	return &doc, nil
+}
+
+// operations returns every operation sorted by operationId, which groups them
+// by resource
+func (d *document) operations() []*operation {
+	var ops []*operation
+	for _, path := range d.Paths.keys {
+		methods, _ := d.Paths.get(path)
+		for _, op := range methods {
+			ops = append(ops, op)
+		}
+	}
+	sortOperations(ops)
+	return ops
+}
+
+// responseSchema returns the schema of an operation's 200 response
+func (d *document) responseSchema(op *operation) (*schema, error) {
+	resp, ok := op.Responses["200"]
+	if !ok {
+		return nil, fmt.Errorf("%s: no 200 response", op.OperationID)
+	}
+	if resp.Ref != "" {
+		if resp, ok = d.Components.Responses[refName(resp.Ref)]; !ok {
+			return nil, fmt.Errorf("%s: unknown response %s", op.OperationID, op.Responses["200"].Ref)
+		}
+	}
+	media, ok := resp.Content["application/json"]
+	if !ok || media.Schema == nil {
+		return nil, fmt.Errorf("%s: 200 response has no JSON schema", op.OperationID)
+	}
+	return media.Schema, nil
+}
+
+// bodySchema returns the JSON schema of an operation's request body, if any
+func (op *operation) bodySchema() *schema {
+	if op.RequestBody == nil {
+		return nil
+	}
+	return op.RequestBody.Content["application/json"].Schema
+}
+
+// queryParameters returns the operation's query parameters in order
+func (op *operation) queryParameters() []parameter {
+	var params []parameter
+	for _, p := range op.Parameters {
+		if p.In == "query" {
+			params = append(params, p)
+		}
+	}
+	return params
+}
+
+func refName(ref string) string {
+	return ref[strings.LastIndex(ref, "/")+1:]
+}
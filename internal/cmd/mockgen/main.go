@@ -0,0 +1,251 @@
+// Command mockgen generates the call-recording mocks in the beepermock package
+// from the resource interfaces (every exported resources type named *API). It
+// is run through go generate:
+//
+//	go generate ./beepermock
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"go/format"
+	"go/importer"
+	"go/token"
+	"go/types"
+	"log"
+	"os"
+	"sort"
+	"strings"
+)
+
+const resourcesPath = "github.com/cameronaaron/beeper-go-sdk/resources"
+
+func main() {
+	out := flag.String("out", "mocks_gen.go", "output file")
+	pkgName := flag.String("package", "beepermock", "package name of the generated file")
+	flag.Parse()
+
+	fset := token.NewFileSet()
+	imp := importer.ForCompiler(fset, "source", nil)
+
+	pkg, err := imp.Import(resourcesPath)
+	if err != nil {
+		log.Fatalf("load %s: %v", resourcesPath, err)
+	}
+
+	g := newGenerator()
+	for _, name := range pkg.Scope().Names() {
+		obj := pkg.Scope().Lookup(name)
+		iface, ok := obj.Type().Underlying().(*types.Interface)
+		if !ok || !obj.Exported() || !strings.HasSuffix(name, "API") {
+			continue
+		}
+		g.mock(pkg, name, iface)
+	}
+
+	code, err := g.file(*pkgName)
+	if err != nil {
+		log.Fatal(err)
+	}
+	if err := os.WriteFile(*out, code, 0o644); err != nil {
+		log.Fatal(err)
+	}
+}
+
+type generator struct {
+	body    bytes.Buffer
+	imports map[string]string // path -> name
+}
+
+func newGenerator() *generator {
+	return &generator{imports: map[string]string{"fmt": "fmt"}}
+}
+
+// qualifier names packages by their package name and records the import
+func (g *generator) qualifier(pkg *types.Package) string {
+	g.imports[pkg.Path()] = pkg.Name()
+	return pkg.Name()
+}
+
+func (g *generator) typeString(t types.Type) string {
+	return types.TypeString(t, g.qualifier)
+}
+
+func (g *generator) mock(pkg *types.Package, name string, iface *types.Interface) {
+	qualified := g.qualifier(pkg) + "." + name
+
+	methods := make([]*types.Func, 0, iface.NumMethods())
+	for i := 0; i < iface.NumMethods(); i++ {
+		methods = append(methods, iface.Method(i))
+	}
+	sort.Slice(methods, func(i, j int) bool { return methods[i].Name() < methods[j].Name() })
+
+	fmt.Fprintf(&g.body, "// %s is a mock implementation of %s\n", name, qualified)
+	fmt.Fprintf(&g.body, "type %s struct {\n\trecorder\n\n", name)
+	for _, method := range methods {
+		sig := method.Type().(*types.Signature)
+		fmt.Fprintf(&g.body, "\t%sFunc func%s\n", method.Name(), g.signature(sig, false))
+	}
+	fmt.Fprintf(&g.body, "}\n\nvar _ %s = (*%s)(nil)\n\n", qualified, name)
+
+	for _, method := range methods {
+		g.method(name, method)
+	}
+}
+
+func (g *generator) method(mockName string, method *types.Func) {
+	sig := method.Type().(*types.Signature)
+	name := method.Name()
+
+	args := paramNames(sig)
+	var forward []string
+	for i, arg := range args {
+		if sig.Variadic() && i == len(args)-1 {
+			arg += "..."
+		}
+		forward = append(forward, arg)
+	}
+
+	fmt.Fprintf(&g.body, "// %s records the call and invokes %sFunc\n", name, name)
+	fmt.Fprintf(&g.body, "func (m *%s) %s%s {\n", mockName, name, g.signature(sig, true))
+	fmt.Fprintf(&g.body, "\tm.record(%q", name)
+	for _, arg := range args {
+		fmt.Fprintf(&g.body, ", %s", arg)
+	}
+	fmt.Fprintf(&g.body, ")\n")
+
+	fmt.Fprintf(&g.body, "\tif m.%sFunc == nil {\n", name)
+	if results := sig.Results(); results.Len() > 0 {
+		var zeros []string
+		for i := 0; i < results.Len(); i++ {
+			t := results.At(i).Type()
+			if i == results.Len()-1 && isError(t) {
+				zeros = append(zeros, fmt.Sprintf("fmt.Errorf(\"%%w: %s.%s\", ErrNotStubbed)", mockName, name))
+				continue
+			}
+			zeros = append(zeros, g.zero(t))
+		}
+		fmt.Fprintf(&g.body, "\t\treturn %s\n", strings.Join(zeros, ", "))
+	} else {
+		fmt.Fprintf(&g.body, "\t\treturn\n")
+	}
+	fmt.Fprintf(&g.body, "\t}\n")
+
+	call := fmt.Sprintf("m.%sFunc(%s)", name, strings.Join(forward, ", "))
+	if sig.Results().Len() > 0 {
+		fmt.Fprintf(&g.body, "\treturn %s\n}\n\n", call)
+	} else {
+		fmt.Fprintf(&g.body, "\t%s\n}\n\n", call)
+	}
+}
+
+// signature renders "(params) results", naming parameters when named is set
+func (g *generator) signature(sig *types.Signature, named bool) string {
+	names := paramNames(sig)
+	var params []string
+	for i := 0; i < sig.Params().Len(); i++ {
+		t := sig.Params().At(i).Type()
+		typ := g.typeString(t)
+		if sig.Variadic() && i == sig.Params().Len()-1 {
+			typ = "..." + g.typeString(t.(*types.Slice).Elem())
+		}
+		if named {
+			typ = names[i] + " " + typ
+		}
+		params = append(params, typ)
+	}
+
+	var results []string
+	for i := 0; i < sig.Results().Len(); i++ {
+		results = append(results, g.typeString(sig.Results().At(i).Type()))
+	}
+
+	out := "(" + strings.Join(params, ", ") + ")"
+	switch len(results) {
+	case 0:
+	case 1:
+		out += " " + results[0]
+	default:
+		out += " (" + strings.Join(results, ", ") + ")"
+	}
+	return out
+}
+
+// paramNames returns the declared parameter names, inventing any that are
+// missing or blank
+func paramNames(sig *types.Signature) []string {
+	names := make([]string, sig.Params().Len())
+	for i := range names {
+		name := sig.Params().At(i).Name()
+		if name == "" || name == "_" {
+			name = fmt.Sprintf("arg%d", i)
+		}
+		names[i] = name
+	}
+	return names
+}
+
+// zero renders the zero value of t
+func (g *generator) zero(t types.Type) string {
+	switch u := t.Underlying().(type) {
+	case *types.Basic:
+		switch {
+		case u.Info()&types.IsBoolean != 0:
+			return "false"
+		case u.Info()&types.IsString != 0:
+			return `""`
+		case u.Info()&types.IsNumeric != 0:
+			return "0"
+		}
+	case *types.Struct, *types.Array:
+		return g.typeString(t) + "{}"
+	}
+	return "nil"
+}
+
+func isError(t types.Type) bool {
+	return types.Identical(t, types.Universe.Lookup("error").Type())
+}
+
+func (g *generator) file(pkgName string) ([]byte, error) {
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "// Code generated by internal/cmd/mockgen; DO NOT EDIT.\n\n")
+	fmt.Fprintf(&buf, "package %s\n\nimport (\n", pkgName)
+
+	paths := make([]string, 0, len(g.imports))
+	for path := range g.imports {
+		paths = append(paths, path)
+	}
+	// Standard library imports first, then module imports
+	sort.Slice(paths, func(i, j int) bool {
+		if isStd(paths[i]) != isStd(paths[j]) {
+			return isStd(paths[i])
+		}
+		return paths[i] < paths[j]
+	})
+	for i, path := range paths {
+		if i > 0 && isStd(paths[i-1]) && !isStd(path) {
+			buf.WriteString("\n")
+		}
+		name := g.imports[path]
+		if name == path || strings.HasSuffix(path, "/"+name) {
+			fmt.Fprintf(&buf, "\t%q\n", path)
+		} else {
+			fmt.Fprintf(&buf, "\t%s %q\n", name, path)
+		}
+	}
+	fmt.Fprintf(&buf, ")\n\n")
+	buf.Write(g.body.Bytes())
+
+	code, err := format.Source(buf.Bytes())
+	if err != nil {
+		return nil, fmt.Errorf("format generated code: %w\n%s", err, buf.Bytes())
+	}
+	return code, nil
+}
+
+func isStd(path string) bool {
+	first, _, _ := strings.Cut(path, "/")
+	return !strings.Contains(first, ".")
+}
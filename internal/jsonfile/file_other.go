@@ -0,0 +1,16 @@
+//go:build !unix
+
+package jsonfile
+
+import "os"
+
+// syncDir does nothing where directories cannot be synced, as on Windows
+func syncDir(dir string) error {
+	return nil
+}
+
+// lockFile does nothing where advisory locks are not available, so only one
+// process should use a file there
+func lockFile(f *os.File) error {
+	return nil
+}
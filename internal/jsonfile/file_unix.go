@@ -0,0 +1,29 @@
+//go:build unix
+
+package jsonfile
+
+import (
+	"os"
+	"syscall"
+)
+
+// syncDir flushes a directory entry, such as a rename, to disk
+func syncDir(dir string) error {
+	d, err := os.Open(dir)
+	if err != nil {
+		return err
+	}
+	defer d.Close()
+	return d.Sync()
+}
+
+// lockFile takes an exclusive advisory lock on f, waiting for other
+// processes to release theirs
+func lockFile(f *os.File) error {
+	for {
+		err := syscall.Flock(int(f.Fd()), syscall.LOCK_EX)
+		if err != syscall.EINTR {
+			return err
+		}
+	}
+}
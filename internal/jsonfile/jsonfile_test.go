@@ -0,0 +1,50 @@
+package jsonfile
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSaveLoad(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "state", "data.json")
+
+	var got map[string]int
+	ok, err := load(path, "data", &got)
+	require.NoError(t, err)
+	assert.False(t, ok)
+
+	require.NoError(t, save(path, "data", map[string]int{"a": 1}))
+	require.NoError(t, save(path, "data", map[string]int{"b": 2}))
+	ok, err = load(path, "data", &got)
+	require.NoError(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, map[string]int{"b": 2}, got)
+
+	info, err := os.Stat(path)
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0644), info.Mode().Perm())
+
+	// No temporary files are left behind
+	entries, err := os.ReadDir(filepath.Dir(path))
+	require.NoError(t, err)
+	require.Len(t, entries, 1)
+	assert.Equal(t, "data.json", entries[0].Name())
+}
+
+func TestErrors(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "data.json")
+	require.NoError(t, os.WriteFile(path, []byte("{"), 0644))
+	var got map[string]int
+	_, err := load(path, "data", &got)
+	assert.ErrorContains(t, err, "failed to parse data: ")
+
+	err = save(path, "data", func() {})
+	assert.ErrorContains(t, err, "failed to marshal data: ")
+
+	err = save(filepath.Join(path, "nested.json"), "data", 1)
+	assert.ErrorContains(t, err, "failed to create data directory: ")
+}
@@ -0,0 +1,33 @@
+package jsonfile
+
+// Store keeps a value in a JSON file. Packages expose it as their FileStore.
+type Store[T any] struct {
+	Path string
+	name string // what the file holds, for errors
+}
+
+// NewStore creates a store backed by the given file. name describes what it
+// holds in errors, such as "outbox".
+func NewStore[T any](path, name string) *Store[T] {
+	return &Store[T]{Path: path, name: name}
+}
+
+// Load reads the saved value, returning nil when nothing has been saved yet
+func (s *Store[T]) Load() (*T, error) {
+	var v T
+	if ok, err := load(s.Path, s.name, &v); !ok {
+		return nil, err
+	}
+	return &v, nil
+}
+
+// Save writes the value atomically and syncs it to disk
+func (s *Store[T]) Save(v *T) error {
+	return save(s.Path, s.name, v)
+}
+
+// Lock takes the lock that processes sharing the file hold while they
+// reload, change and save it. See lock.
+func (s *Store[T]) Lock() (func() error, error) {
+	return lock(s.Path)
+}
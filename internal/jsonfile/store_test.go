@@ -0,0 +1,30 @@
+package jsonfile
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStore(t *testing.T) {
+	type state struct {
+		Names []string `json:"names"`
+	}
+	store := NewStore[state](filepath.Join(t.TempDir(), "state.json"), "names")
+
+	got, err := store.Load()
+	require.NoError(t, err)
+	assert.Nil(t, got, "nothing saved yet")
+
+	require.NoError(t, store.Save(&state{Names: []string{"alice"}}))
+	got, err = store.Load()
+	require.NoError(t, err)
+	assert.Equal(t, &state{Names: []string{"alice"}}, got)
+
+	require.NoError(t, os.WriteFile(store.Path, []byte("["), 0644))
+	_, err = store.Load()
+	assert.ErrorContains(t, err, "failed to parse names: ")
+}
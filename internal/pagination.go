@@ -19,17 +19,20 @@ type PaginationInfo struct {
 	HasMore   bool    `json:"has_more"`
 }
 
+// maxEmptyPages bounds consecutive empty pages fetched before giving up
+const maxEmptyPages = 5
+
+// PageFunc fetches the page at cursor, or the first page when cursor is empty
+type PageFunc[T any] func(ctx context.Context, cursor string) (*Cursor[T], error)
+
 // Iterator provides iteration over paginated results
 type Iterator[T any] struct {
-	client      RequestClient
-	path        string
-	params      map[string]interface{}
-	cursor      *string
-	limit       *int
-	direction   *string
+	fetch       PageFunc[T]
+	cursor      string
 	hasMore     bool
 	currentIdx  int
 	currentPage []T
+	requested   map[string]bool // Cursors already fetched, "" for the first page
 }
 
 // RequestClient interface for making paginated requests
@@ -43,14 +46,38 @@ func NewIterator[T any](client RequestClient, path string, params map[string]int
 	direction, _ := params["direction"].(string)
 	cursor, _ := params["cursor"].(string)
 
+	return NewPageIterator(cursor, func(ctx context.Context, cursor string) (*Cursor[T], error) {
+		query := make(map[string]interface{})
+		for k, v := range params {
+			query[k] = v
+		}
+
+		if cursor != "" {
+			query["cursor"] = cursor
+		}
+		if limit > 0 {
+			query["limit"] = limit
+		}
+		if direction != "" {
+			query["direction"] = direction
+		}
+
+		var response Cursor[T]
+		if err := client.DoRequestWithQuery(ctx, "GET", path, query, &response); err != nil {
+			return nil, err
+		}
+		return &response, nil
+	})
+}
+
+// NewPageIterator creates an iterator that fetches pages with fetch,
+// starting at cursor
+func NewPageIterator[T any](cursor string, fetch PageFunc[T]) *Iterator[T] {
 	return &Iterator[T]{
-		client:    client,
-		path:      path,
-		params:    params,
-		cursor:    &cursor,
-		limit:     &limit,
-		direction: &direction,
+		fetch:     fetch,
+		cursor:    cursor,
 		hasMore:   true,
+		requested: make(map[string]bool),
 	}
 }
 
@@ -68,9 +95,17 @@ func (it *Iterator[T]) Next(ctx context.Context) (*T, error) {
 		return nil, nil
 	}
 
-	// Fetch next page
-	if err := it.fetchNextPage(ctx); err != nil {
-		return nil, err
+	// Fetch next page, skipping empty pages the server reports more results after
+	for empty := 0; ; empty++ {
+		if err := it.fetchNextPage(ctx); err != nil {
+			return nil, err
+		}
+		if len(it.currentPage) > 0 || !it.hasMore {
+			break
+		}
+		if empty+1 >= maxEmptyPages {
+			return nil, fmt.Errorf("pagination stalled after %d empty pages", maxEmptyPages)
+		}
 	}
 
 	// Return first item from new page
@@ -90,35 +125,28 @@ func (it *Iterator[T]) HasNext() bool {
 
 // fetchNextPage fetches the next page of results
 func (it *Iterator[T]) fetchNextPage(ctx context.Context) error {
-	params := make(map[string]interface{})
-	for k, v := range it.params {
-		params[k] = v
+	requested := it.cursor
+	response, err := it.fetch(ctx, requested)
+	if err != nil {
+		return fmt.Errorf("failed to fetch page: %w", err)
 	}
+	it.requested[requested] = true
 
-	if it.cursor != nil && *it.cursor != "" {
-		params["cursor"] = *it.cursor
+	var next string
+	hasMore := response.Pagination != nil && response.Pagination.HasMore
+	if hasMore && response.Pagination.Cursor != nil {
+		next = *response.Pagination.Cursor
 	}
-	if it.limit != nil && *it.limit > 0 {
-		params["limit"] = *it.limit
-	}
-	if it.direction != nil && *it.direction != "" {
-		params["direction"] = *it.direction
-	}
-
-	var response Cursor[T]
-	if err := it.client.DoRequestWithQuery(ctx, "GET", it.path, params, &response); err != nil {
-		return fmt.Errorf("failed to fetch page: %w", err)
+	// A page that points back at a cursor already fetched would be served
+	// again; only an empty page may be retried that way
+	if hasMore && len(response.Items) > 0 && it.requested[next] {
+		return fmt.Errorf("pagination cursor %q repeated", next)
 	}
 
 	it.currentPage = response.Items
 	it.currentIdx = 0
-
-	if response.Pagination != nil {
-		it.cursor = response.Pagination.Cursor
-		it.hasMore = response.Pagination.HasMore
-	} else {
-		it.hasMore = false
-	}
+	it.cursor = next
+	it.hasMore = hasMore
 
 	return nil
 }
@@ -0,0 +1,44 @@
+// Package phone normalizes phone numbers so that packages matching people by
+// number agree on when two numbers are the same
+package phone
+
+import "strings"
+
+// minDigits keeps short codes and extensions from matching
+const minDigits = 7
+
+// Normalize keeps the digits of a phone number and a leading +, dropping
+// spaces, dashes, dots and parentheses. Numbers with too few digits, or with
+// any other character, normalize to "".
+func Normalize(number string) string {
+	number = strings.TrimSpace(number)
+	var b strings.Builder
+	digits := 0
+	for i, r := range number {
+		switch {
+		case r >= '0' && r <= '9':
+			b.WriteRune(r)
+			digits++
+		case r == '+' && i == 0:
+			b.WriteRune(r)
+		case r == ' ' || r == '-' || r == '.' || r == '(' || r == ')':
+		default:
+			return ""
+		}
+	}
+	if digits < minDigits {
+		return ""
+	}
+	return b.String()
+}
+
+// URI returns the number as an RFC 3966 tel URI, or "" when it has no
+// country code. A local number would need a phone-context this package
+// cannot know.
+func URI(number string) string {
+	normalized := Normalize(number)
+	if !strings.HasPrefix(normalized, "+") {
+		return ""
+	}
+	return "tel:" + normalized
+}
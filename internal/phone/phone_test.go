@@ -0,0 +1,28 @@
+package phone
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNormalize(t *testing.T) {
+	tests := map[string]string{
+		"+1 (555) 123-4567": "+15551234567",
+		" 555.123.4567 ":    "5551234567",
+		"+44 20 7946 0958":  "+442079460958",
+		"911":               "",
+		"+1 555 CALL NOW":   "",
+		"555+1234567":       "",
+		"":                  "",
+	}
+	for input, want := range tests {
+		assert.Equal(t, want, Normalize(input), input)
+	}
+}
+
+func TestURI(t *testing.T) {
+	assert.Equal(t, "tel:+15551234567", URI("+1 (555) 123-4567"))
+	assert.Empty(t, URI("555 123 4567"))
+	assert.Empty(t, URI("+1 555 CALL NOW"))
+}
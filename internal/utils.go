@@ -5,7 +5,6 @@ import (
 	"net/url"
 	"reflect"
 	"strconv"
-	"strings"
 	"time"
 )
 
@@ -38,6 +37,16 @@ func StructToQueryParams(v interface{}) url.Values {
 				}
 			}
 
+			// Values of map[string]interface{} come back wrapped in an interface
+			if value.Kind() == reflect.Interface {
+				value = value.Elem()
+			}
+
+			if value.Kind() == reflect.Slice {
+				addIndexed(params, keyStr, value)
+				continue
+			}
+
 			valueStr := fieldValueToString(value)
 			if valueStr != "" {
 				params.Add(keyStr, valueStr)
@@ -86,26 +95,9 @@ func StructToQueryParams(v interface{}) url.Values {
 			fieldValue = fieldValue.Elem()
 		}
 
-		// Handle slices using comma-separated values
+		// Handle slices using indexed names, as the API expects
 		if fieldValue.Kind() == reflect.Slice {
-			length := fieldValue.Len()
-			if length == 0 {
-				continue
-			}
-
-			var values []string
-			for idx := 0; idx < length; idx++ {
-				elem := fieldValue.Index(idx)
-				elemStr := fieldValueToString(elem)
-				if elemStr == "" {
-					continue
-				}
-				values = append(values, elemStr)
-			}
-
-			if len(values) > 0 {
-				params.Add(name, strings.Join(values, ","))
-			}
+			addIndexed(params, name, fieldValue)
 			continue
 		}
 
@@ -143,6 +135,20 @@ func StructToQueryParams(v interface{}) url.Values {
 	return params
 }
 
+// addIndexed adds the items of a slice as name[0]=a&name[1]=b, skipping
+// empty items
+func addIndexed(params url.Values, name string, slice reflect.Value) {
+	idx := 0
+	for i := 0; i < slice.Len(); i++ {
+		value := fieldValueToString(slice.Index(i))
+		if value == "" {
+			continue
+		}
+		params.Add(name+"["+strconv.Itoa(idx)+"]", value)
+		idx++
+	}
+}
+
 // fieldValueToString converts a reflect.Value to its string representation
 func fieldValueToString(v reflect.Value) string {
 	// Handle nil pointers
@@ -180,15 +186,14 @@ func fieldValueToString(v reflect.Value) string {
 		}
 		return result
 	default:
-		// Try to convert to string using String() method if available
+		// Handle time.Time before its String method, which is not RFC 3339
 		if v.CanInterface() {
+			if t, ok := v.Interface().(time.Time); ok {
+				return t.Format(time.RFC3339Nano)
+			}
 			if stringer, ok := v.Interface().(interface{ String() string }); ok {
 				return stringer.String()
 			}
-			// Handle time.Time specially
-			if t, ok := v.Interface().(time.Time); ok {
-				return t.Format(time.RFC3339)
-			}
 		}
 		return ""
 	}
@@ -0,0 +1,604 @@
+// Package outbox sends messages durably. Each message is saved to a store,
+// with an idempotency key, before it is sent. When a send fails without
+// telling whether the message went out, after a lost connection, a timeout,
+// a server error or a crash, the outbox searches the chat for the message
+// before sending it again, so it is neither lost nor sent twice. Final
+// outcomes are reported through a callback.
+//
+// Several processes can share a store that is a Locker, such as a
+// FileStore: the entries are reloaded before every change. Only one of them
+// should Flush or Run, since an entry another process is still sending
+// looks like one whose send was cut short.
+//
+//	box, err := outbox.Open(client, outbox.Options{Store: outbox.NewFileStore("outbox.json")})
+//	key, err := box.Enqueue(resources.MessageSendParams{ChatID: chatID, Text: "On my way"})
+//	go box.Run(ctx)
+package outbox
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"sync"
+	"time"
+
+	beeperdesktop "github.com/cameronaaron/beeper-go-sdk"
+	"github.com/cameronaaron/beeper-go-sdk/resources"
+)
+
+const (
+	defaultMaxAttempts = 5
+	defaultBackoff     = time.Second
+	maxBackoff         = time.Minute
+	defaultRetention   = 7 * 24 * time.Hour
+
+	// clockSkew widens the search for a message that may have been sent, in
+	// case the server's clock is behind ours
+	clockSkew = time.Minute
+	// searchPageSize and maxSearchPages bound that search
+	searchPageSize = 50
+	maxSearchPages = 10
+)
+
+// Status is where a message is in the outbox
+type Status string
+
+const (
+	// Pending means the message has not been sent yet
+	Pending Status = "pending"
+	// Sending means a send was attempted and its outcome is unknown. The chat
+	// is searched for the message before it is sent again.
+	Sending Status = "sending"
+	// Sent means the message was delivered
+	Sent Status = "sent"
+	// Failed means the message was not delivered and will not be retried
+	Failed Status = "failed"
+)
+
+// Entry is a message in the outbox
+type Entry struct {
+	Key         string                      `json:"key"`
+	Params      resources.MessageSendParams `json:"params"`
+	Status      Status                      `json:"status"`
+	Attempts    int                         `json:"attempts"`
+	CreatedAt   time.Time                   `json:"createdAt"`
+	UpdatedAt   time.Time                   `json:"updatedAt"`
+	NextAttempt time.Time                   `json:"nextAttempt"` // zero when due now
+	MessageID   string                      `json:"messageID,omitempty"`
+	// Reconciled means the message was found in the chat after a send whose
+	// outcome was unknown
+	Reconciled bool   `json:"reconciled,omitempty"`
+	LastError  string `json:"lastError,omitempty"`
+}
+
+// Done reports whether the entry reached a final state
+func (e *Entry) Done() bool {
+	return e.Status == Sent || e.Status == Failed
+}
+
+// Options configures an Outbox
+type Options struct {
+	// Store persists the outbox. Without one, entries live in memory only
+	// and are lost when the process exits.
+	Store Store
+	// MaxAttempts is the number of sends per message, including the first.
+	// Defaults to 5.
+	MaxAttempts int
+	// Backoff is the wait before the first retry; it doubles for each later
+	// retry, up to a minute. Defaults to one second.
+	Backoff time.Duration
+	// Retention is how long sent and failed entries are kept, so that
+	// EnqueueWithKey still recognizes their keys. Defaults to a week.
+	Retention time.Duration
+	// OnResult is called when a message is sent or fails for good. It runs
+	// on the goroutine flushing the outbox. If the process stops before the
+	// outcome is saved, it is reported again on the next run.
+	OnResult func(Entry)
+}
+
+func (o Options) withDefaults() Options {
+	if o.MaxAttempts <= 0 {
+		o.MaxAttempts = defaultMaxAttempts
+	}
+	if o.Backoff <= 0 {
+		o.Backoff = defaultBackoff
+	}
+	if o.Retention <= 0 {
+		o.Retention = defaultRetention
+	}
+	return o
+}
+
+// Outbox is a durable queue of messages to send. It is safe for concurrent
+// use.
+type Outbox struct {
+	client beeperdesktop.Client
+	opts   Options
+	wake   chan struct{}
+
+	flushMu sync.Mutex // serializes flushes
+
+	mu      sync.Mutex
+	entries []*Entry // in enqueue order
+	byKey   map[string]*Entry
+}
+
+// Open loads the outbox from opts.Store. Messages left unfinished by a
+// previous run are sent by the next Flush or Run.
+func Open(client beeperdesktop.Client, opts Options) (*Outbox, error) {
+	o := &Outbox{
+		client: client,
+		opts:   opts.withDefaults(),
+		wake:   make(chan struct{}, 1),
+		byKey:  map[string]*Entry{},
+	}
+	if opts.Store == nil {
+		return o, nil
+	}
+
+	if err := o.reload(); err != nil {
+		return nil, err
+	}
+	return o, nil
+}
+
+// Enqueue adds a message under a new random key and returns the key. The
+// message has been saved when Enqueue returns.
+func (o *Outbox) Enqueue(params resources.MessageSendParams) (string, error) {
+	key, err := newKey()
+	if err != nil {
+		return "", err
+	}
+	return key, o.EnqueueWithKey(key, params)
+}
+
+// EnqueueWithKey adds a message under a key chosen by the caller, such as
+// the ID of the event that prompted it. Enqueueing the same message under a
+// key the outbox already holds does nothing, so it is safe to repeat after a
+// crash.
+func (o *Outbox) EnqueueWithKey(key string, params resources.MessageSendParams) error {
+	switch {
+	case key == "":
+		return errors.New("outbox: empty key")
+	case params.ChatID == "":
+		return errors.New("outbox: message has no chat ID")
+	case params.Text == "" && params.Attachment == nil:
+		return errors.New("outbox: message has no text or attachment")
+	}
+
+	unlock, err := o.lock()
+	if err != nil {
+		unlock()
+		return err
+	}
+	if existing := o.byKey[key]; existing != nil {
+		unlock()
+		if !reflect.DeepEqual(existing.Params, params) {
+			return fmt.Errorf("outbox: key %q is already used by a different message", key)
+		}
+		return nil
+	}
+
+	now := time.Now()
+	entry := &Entry{Key: key, Params: params, Status: Pending, CreatedAt: now, UpdatedAt: now}
+	o.entries = append(o.entries, entry)
+	o.byKey[key] = entry
+	if err := o.saveLocked(); err != nil {
+		o.entries = o.entries[:len(o.entries)-1]
+		delete(o.byKey, key)
+		unlock()
+		return err
+	}
+	unlock()
+
+	select {
+	case o.wake <- struct{}{}:
+	default:
+	}
+	return nil
+}
+
+// Entry returns the entry with the given key
+func (o *Outbox) Entry(key string) (Entry, bool) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	entry, ok := o.byKey[key]
+	if !ok {
+		return Entry{}, false
+	}
+	return *entry, true
+}
+
+// Entries returns a copy of every entry, in enqueue order
+func (o *Outbox) Entries() []Entry {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	entries := make([]Entry, len(o.entries))
+	for i, entry := range o.entries {
+		entries[i] = *entry
+	}
+	return entries
+}
+
+// Flush tries every message that is due once, oldest first. Messages in a
+// chat go out in order: one waiting for a retry holds back those queued
+// after it in the same chat. Send failures are recorded in the entries;
+// Flush returns an error only when ctx is done or the store fails.
+// Finished entries older than the retention period are dropped, and
+// messages other processes enqueued to a shared store are picked up.
+func (o *Outbox) Flush(ctx context.Context) error {
+	o.flushMu.Lock()
+	defer o.flushMu.Unlock()
+
+	// Pick up messages other processes sharing the store enqueued
+	unlock, err := o.lock()
+	unlock()
+	if err != nil {
+		return err
+	}
+
+	blocked := map[string]bool{}
+	now := time.Now()
+	for _, entry := range o.Entries() {
+		chatID := entry.Params.ChatID
+		if entry.Done() || blocked[chatID] {
+			continue
+		}
+		if entry.NextAttempt.After(now) {
+			blocked[chatID] = true
+			continue
+		}
+
+		entry, err := o.process(ctx, entry)
+		if err != nil {
+			return err
+		}
+		if !entry.Done() {
+			blocked[chatID] = true
+		}
+	}
+	return o.prune()
+}
+
+// Run flushes the outbox whenever a message is enqueued through it or a
+// retry falls due, until ctx is done. It returns ctx's error, or the store's if saving
+// fails.
+func (o *Outbox) Run(ctx context.Context) error {
+	for {
+		if err := o.Flush(ctx); err != nil {
+			return err
+		}
+
+		var timer *time.Timer
+		var due <-chan time.Time
+		if next, ok := o.nextAttempt(); ok {
+			timer = time.NewTimer(time.Until(next))
+			due = timer.C
+		}
+		select {
+		case <-ctx.Done():
+		case <-o.wake:
+		case <-due:
+		}
+		if timer != nil {
+			timer.Stop()
+		}
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+	}
+}
+
+// nextAttempt returns when the next message falls due. Only the first
+// unfinished message of each chat counts, since it holds back the rest.
+func (o *Outbox) nextAttempt() (time.Time, bool) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	var next time.Time
+	found := false
+	seen := map[string]bool{}
+	for _, entry := range o.entries {
+		chatID := entry.Params.ChatID
+		if entry.Done() || seen[chatID] {
+			continue
+		}
+		seen[chatID] = true
+		if !found || entry.NextAttempt.Before(next) {
+			next, found = entry.NextAttempt, true
+		}
+	}
+	return next, found
+}
+
+// process makes one attempt at delivering an entry and records the outcome
+func (o *Outbox) process(ctx context.Context, entry Entry) (Entry, error) {
+	if entry.Status == Sending {
+		// The last attempt may have gone through; look before sending again
+		msg, err := o.find(ctx, entry)
+		switch {
+		case ctx.Err() != nil:
+			return entry, ctx.Err()
+		case err != nil:
+			o.retryLater(&entry, fmt.Errorf("failed to check whether the message was sent: %w", err))
+			return entry, o.commit(entry)
+		case msg != nil:
+			entry.MessageID = msg.ID
+			entry.Reconciled = true
+			return o.finish(entry, Sent, nil)
+		case entry.Attempts >= o.opts.MaxAttempts:
+			return o.finish(entry, Failed, fmt.Errorf("not delivered after %d attempts: %s", entry.Attempts, entry.LastError))
+		}
+	}
+
+	// Save the attempt before making it, so that after a crash mid-send the
+	// next run checks the chat instead of sending blindly
+	entry.Status = Sending
+	entry.Attempts++
+	entry.UpdatedAt = time.Now()
+	if err := o.commit(entry); err != nil {
+		return entry, err
+	}
+
+	resp, err := o.client.MessagesAPI().Send(beeperdesktop.WithIdempotencyKey(ctx, entry.Key), entry.Params)
+	if err == nil {
+		if err = beeperdesktop.CheckSent(resp); err == nil {
+			entry.MessageID = resp.MessageID
+			return o.finish(entry, Sent, nil)
+		}
+	}
+	switch {
+	case ctx.Err() != nil:
+		entry.LastError = err.Error()
+		if err := o.commit(entry); err != nil {
+			return entry, err
+		}
+		return entry, ctx.Err()
+	case isPermanent(err):
+		return o.finish(entry, Failed, err)
+	case isRateLimited(err) || isNotSent(err):
+		// A rate-limited send, or one Desktop says it did not send, was not
+		// carried out
+		entry.Status = Pending
+		if entry.Attempts >= o.opts.MaxAttempts {
+			return o.finish(entry, Failed, err)
+		}
+	}
+	o.retryLater(&entry, err)
+	return entry, o.commit(entry)
+}
+
+// find searches the entry's chat for a message that matches it and that no
+// other entry has claimed, returning the oldest such message or nil
+func (o *Outbox) find(ctx context.Context, entry Entry) (*resources.Message, error) {
+	after := entry.CreatedAt.Add(-clockSkew)
+	params := resources.MessageSearchParams{
+		ChatIDs:   []string{entry.Params.ChatID},
+		DateAfter: &after,
+		Limit:     beeperdesktop.IntPtr(searchPageSize),
+	}
+	if text := entry.Params.Text; text != "" {
+		params.Query = &text
+	}
+
+	claimed := o.claimed(entry)
+	var found *resources.Message
+	for page := 0; page < maxSearchPages; page++ {
+		result, err := o.client.MessagesAPI().Search(ctx, params)
+		if err != nil {
+			return nil, err
+		}
+		// Results are newest first, so the last match is the oldest
+		for i := range result.Items {
+			msg := result.Items[i]
+			if matches(msg, entry.Params) && !claimed[msg.ID] {
+				found = &msg
+			}
+		}
+		if result.Pagination == nil || !result.Pagination.HasMore || result.Pagination.Cursor == nil {
+			break
+		}
+		params.Cursor = result.Pagination.Cursor
+	}
+	return found, nil
+}
+
+// claimed returns the IDs of messages other entries in the same chat were
+// delivered as
+func (o *Outbox) claimed(entry Entry) map[string]bool {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	ids := map[string]bool{}
+	for _, other := range o.entries {
+		if other.Key != entry.Key && other.Params.ChatID == entry.Params.ChatID && other.MessageID != "" {
+			ids[other.MessageID] = true
+		}
+	}
+	return ids
+}
+
+// matches reports whether msg is one we sent with params
+func matches(msg resources.Message, params resources.MessageSendParams) bool {
+	if msg.IsSender == nil || !*msg.IsSender {
+		return false
+	}
+	text := ""
+	if msg.Text != nil {
+		text = *msg.Text
+	}
+	if strings.TrimSpace(text) != strings.TrimSpace(params.Text) {
+		return false
+	}
+	if params.Attachment == nil {
+		return true
+	}
+	// Without a file name, the attachment cannot be told from another sent
+	// with the same text
+	name := filepath.Base(*params.Attachment)
+	for _, attachment := range msg.Attachments {
+		if attachment.FileName != nil && *attachment.FileName == name {
+			return true
+		}
+	}
+	return false
+}
+
+// retryLater schedules the next attempt after a failed one
+func (o *Outbox) retryLater(entry *Entry, err error) {
+	backoff := o.opts.Backoff
+	for i := 1; i < entry.Attempts && backoff < maxBackoff; i++ {
+		backoff *= 2
+	}
+	now := time.Now()
+	entry.LastError = err.Error()
+	entry.NextAttempt = now.Add(min(backoff, maxBackoff))
+	entry.UpdatedAt = now
+}
+
+// finish records a final status and reports it
+func (o *Outbox) finish(entry Entry, status Status, err error) (Entry, error) {
+	entry.Status = status
+	entry.NextAttempt = time.Time{}
+	entry.UpdatedAt = time.Now()
+	entry.LastError = ""
+	if err != nil {
+		entry.LastError = err.Error()
+	}
+	saveErr := o.commit(entry)
+	if o.opts.OnResult != nil {
+		o.opts.OnResult(entry)
+	}
+	return entry, saveErr
+}
+
+// commit stores an updated entry and saves the outbox
+func (o *Outbox) commit(entry Entry) error {
+	unlock, err := o.lock()
+	defer unlock()
+	if err != nil {
+		return err
+	}
+
+	if current := o.byKey[entry.Key]; current != nil {
+		*current = entry
+	}
+	return o.saveLocked()
+}
+
+// prune drops finished entries older than the retention period
+func (o *Outbox) prune() error {
+	unlock, err := o.lock()
+	defer unlock()
+	if err != nil {
+		return err
+	}
+
+	cutoff := time.Now().Add(-o.opts.Retention)
+	kept := o.entries[:0]
+	for _, entry := range o.entries {
+		if entry.Done() && entry.UpdatedAt.Before(cutoff) {
+			delete(o.byKey, entry.Key)
+			continue
+		}
+		kept = append(kept, entry)
+	}
+	if len(kept) == len(o.entries) {
+		return nil
+	}
+	for i := len(kept); i < len(o.entries); i++ {
+		o.entries[i] = nil
+	}
+	o.entries = kept
+	return o.saveLocked()
+}
+
+// lock takes o.mu and, when the store is a Locker, the store's lock, then
+// reloads the entries so that changes saved by other processes are kept. The
+// returned func releases both and must be called even on error.
+func (o *Outbox) lock() (func(), error) {
+	o.mu.Lock()
+	unlock := o.mu.Unlock
+	if locker, ok := o.opts.Store.(Locker); ok {
+		release, err := locker.Lock()
+		if err != nil {
+			return unlock, err
+		}
+		unlock = func() {
+			release()
+			o.mu.Unlock()
+		}
+	}
+	return unlock, o.reload()
+}
+
+// reload replaces the entries with those in the store. Callers must hold
+// o.mu.
+func (o *Outbox) reload() error {
+	if o.opts.Store == nil {
+		return nil
+	}
+	state, err := o.opts.Store.Load()
+	if err != nil {
+		return err
+	}
+	o.entries = nil
+	o.byKey = map[string]*Entry{}
+	if state != nil {
+		for i := range state.Entries {
+			entry := state.Entries[i]
+			o.entries = append(o.entries, &entry)
+			o.byKey[entry.Key] = &entry
+		}
+	}
+	return nil
+}
+
+// saveLocked writes every entry to the store. Callers must hold o.mu.
+func (o *Outbox) saveLocked() error {
+	if o.opts.Store == nil {
+		return nil
+	}
+	state := &State{Entries: make([]Entry, len(o.entries))}
+	for i, entry := range o.entries {
+		state.Entries[i] = *entry
+	}
+	return o.opts.Store.Save(state)
+}
+
+// isPermanent reports whether a send failed in a way retrying will not fix:
+// a client error other than a timeout, conflict or rate limit
+func isPermanent(err error) bool {
+	var status interface{ StatusCode() int }
+	if !errors.As(err, &status) {
+		return false
+	}
+	code := status.StatusCode()
+	return code >= 400 && code < 500 && code != 408 && code != 409 && code != 429
+}
+
+func isRateLimited(err error) bool {
+	var rateLimit *beeperdesktop.RateLimitError
+	return errors.As(err, &rateLimit)
+}
+
+func isNotSent(err error) bool {
+	var notSent *beeperdesktop.SendError
+	return errors.As(err, &notSent)
+}
+
+// newKey returns a random idempotency key
+func newKey() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("outbox: failed to generate key: %w", err)
+	}
+	return hex.EncodeToString(b), nil
+}
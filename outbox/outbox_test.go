@@ -0,0 +1,326 @@
+package outbox_test
+
+import (
+	"context"
+	"net/http"
+	"path/filepath"
+	"testing"
+	"time"
+
+	beeperdesktop "github.com/cameronaaron/beeper-go-sdk"
+	"github.com/cameronaaron/beeper-go-sdk/beepermock"
+	"github.com/cameronaaron/beeper-go-sdk/beepertest"
+	"github.com/cameronaaron/beeper-go-sdk/outbox"
+	"github.com/cameronaaron/beeper-go-sdk/resources"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// setup starts a test server and returns a client whose requests go through
+// the given faults
+func setup(t *testing.T, faults ...beepertest.Fault) (*beepertest.Server, beeperdesktop.Client) {
+	server := beepertest.NewServer(beepertest.DefaultFixtures())
+	t.Cleanup(server.Close)
+	injector := beepertest.NewInjector(1, faults...)
+	client, err := server.Client(beeperdesktop.WithHTTPClient(&http.Client{Transport: injector.Transport(nil)}))
+	require.NoError(t, err)
+	return server, client
+}
+
+// withText returns the messages in a chat with the given text
+func withText(server *beepertest.Server, chatID, text string) []resources.Message {
+	var found []resources.Message
+	for _, msg := range server.Messages(chatID) {
+		if msg.Text != nil && *msg.Text == text {
+			found = append(found, msg)
+		}
+	}
+	return found
+}
+
+func TestFlushReconcilesDeliveredSend(t *testing.T) {
+	// The message is delivered but the response is cut off
+	server, client := setup(t, beepertest.Fault{Kind: beepertest.FaultTruncate, Path: "/v0/send-message", Limit: 1})
+	store := outbox.NewFileStore(filepath.Join(t.TempDir(), "outbox.json"))
+	var results []outbox.Entry
+	box, err := outbox.Open(client, outbox.Options{
+		Store:    store,
+		Backoff:  time.Millisecond,
+		OnResult: func(entry outbox.Entry) { results = append(results, entry) },
+	})
+	require.NoError(t, err)
+	ctx := context.Background()
+
+	key, err := box.Enqueue(resources.MessageSendParams{ChatID: "chat-alice", Text: "hello"})
+	require.NoError(t, err)
+	require.NoError(t, box.Flush(ctx))
+	entry, ok := box.Entry(key)
+	require.True(t, ok)
+	assert.Equal(t, outbox.Sending, entry.Status, "outcome unknown")
+	assert.Equal(t, 1, entry.Attempts)
+	assert.NotEmpty(t, entry.LastError)
+	assert.Empty(t, results)
+
+	time.Sleep(5 * time.Millisecond)
+	require.NoError(t, box.Flush(ctx))
+	sent := withText(server, "chat-alice", "hello")
+	require.Len(t, sent, 1, "not sent twice")
+	entry, _ = box.Entry(key)
+	assert.Equal(t, outbox.Sent, entry.Status)
+	assert.True(t, entry.Reconciled)
+	assert.Equal(t, sent[0].ID, entry.MessageID)
+	assert.Equal(t, 1, entry.Attempts)
+	assert.Empty(t, entry.LastError)
+	require.Len(t, results, 1)
+	// The entry is read back from the store, which drops the monotonic clock
+	result := results[0]
+	assert.True(t, entry.UpdatedAt.Equal(result.UpdatedAt))
+	result.UpdatedAt = entry.UpdatedAt
+	assert.Equal(t, entry, result)
+
+	reopened, err := outbox.Open(client, outbox.Options{Store: store})
+	require.NoError(t, err)
+	assert.Len(t, reopened.Entries(), 1)
+	saved, _ := reopened.Entry(key)
+	assert.Equal(t, outbox.Sent, saved.Status)
+}
+
+func TestFlushResendsLostSend(t *testing.T) {
+	// The connection drops before the message reaches the server
+	server, client := setup(t, beepertest.Fault{Kind: beepertest.FaultReset, Path: "/v0/send-message", Limit: 1})
+	box, err := outbox.Open(client, outbox.Options{Backoff: time.Millisecond})
+	require.NoError(t, err)
+	ctx := context.Background()
+
+	key, err := box.Enqueue(resources.MessageSendParams{ChatID: "chat-alice", Text: "again"})
+	require.NoError(t, err)
+	require.NoError(t, box.Flush(ctx))
+	time.Sleep(5 * time.Millisecond)
+	require.NoError(t, box.Flush(ctx))
+
+	entry, _ := box.Entry(key)
+	assert.Equal(t, outbox.Sent, entry.Status)
+	assert.False(t, entry.Reconciled)
+	assert.Equal(t, 2, entry.Attempts)
+	assert.Len(t, withText(server, "chat-alice", "again"), 1)
+}
+
+func TestOpenRecoversInterruptedSends(t *testing.T) {
+	server, client := setup(t)
+	ctx := context.Background()
+
+	// A previous run crashed after sending "on it" once but before saving
+	// the outcome of either of its two sends
+	params := resources.MessageSendParams{ChatID: "chat-alice", Text: "on it"}
+	resp, err := client.MessagesAPI().Send(ctx, params)
+	require.NoError(t, err)
+	created := time.Now().Add(-time.Second)
+	store := outbox.NewFileStore(filepath.Join(t.TempDir(), "outbox.json"))
+	require.NoError(t, store.Save(&outbox.State{Entries: []outbox.Entry{
+		{Key: "first", Params: params, Status: outbox.Sending, Attempts: 1, CreatedAt: created},
+		{Key: "second", Params: params, Status: outbox.Sending, Attempts: 1, CreatedAt: created},
+	}}))
+
+	box, err := outbox.Open(client, outbox.Options{Store: store})
+	require.NoError(t, err)
+	require.NoError(t, box.Flush(ctx))
+
+	first, _ := box.Entry("first")
+	assert.Equal(t, outbox.Sent, first.Status)
+	assert.True(t, first.Reconciled)
+	assert.Equal(t, resp.MessageID, first.MessageID)
+	second, _ := box.Entry("second")
+	assert.Equal(t, outbox.Sent, second.Status)
+	assert.False(t, second.Reconciled, "the sent message is claimed by the first entry")
+	assert.Equal(t, 2, second.Attempts)
+	assert.Len(t, withText(server, "chat-alice", "on it"), 2)
+}
+
+func TestFlushFailures(t *testing.T) {
+	_, client := setup(t, beepertest.Fault{Kind: beepertest.FaultRateLimit, Path: "/v0/send-message", Limit: 1})
+	var results []outbox.Entry
+	box, err := outbox.Open(client, outbox.Options{
+		MaxAttempts: 2,
+		Backoff:     time.Hour,
+		OnResult:    func(entry outbox.Entry) { results = append(results, entry) },
+	})
+	require.NoError(t, err)
+	ctx := context.Background()
+
+	limited, err := box.Enqueue(resources.MessageSendParams{ChatID: "chat-alice", Text: "first"})
+	require.NoError(t, err)
+	queued, err := box.Enqueue(resources.MessageSendParams{ChatID: "chat-alice", Text: "second"})
+	require.NoError(t, err)
+	missing, err := box.Enqueue(resources.MessageSendParams{ChatID: "chat-missing", Text: "hi"})
+	require.NoError(t, err)
+	require.NoError(t, box.Flush(ctx))
+
+	entry, _ := box.Entry(limited)
+	assert.Equal(t, outbox.Pending, entry.Status, "rate-limited sends were not carried out")
+	assert.True(t, entry.NextAttempt.After(time.Now()))
+	entry, _ = box.Entry(queued)
+	assert.Equal(t, 0, entry.Attempts, "held back behind the first message in its chat")
+	entry, _ = box.Entry(missing)
+	assert.Equal(t, outbox.Failed, entry.Status, "missing chats are not retried")
+	assert.Equal(t, 1, entry.Attempts)
+	assert.Contains(t, entry.LastError, "chat not found")
+	require.Len(t, results, 1)
+	assert.Equal(t, missing, results[0].Key)
+
+	// Open a second outbox with no backoff to drive the rate-limited entry
+	// to its last attempt
+	_, client = setup(t, beepertest.Fault{Kind: beepertest.FaultRateLimit, Path: "/v0/send-message", Limit: 2})
+	box, err = outbox.Open(client, outbox.Options{MaxAttempts: 2, Backoff: time.Nanosecond})
+	require.NoError(t, err)
+	key, err := box.Enqueue(resources.MessageSendParams{ChatID: "chat-alice", Text: "late"})
+	require.NoError(t, err)
+	require.NoError(t, box.Flush(ctx))
+	time.Sleep(time.Millisecond)
+	require.NoError(t, box.Flush(ctx))
+	entry, _ = box.Entry(key)
+	assert.Equal(t, outbox.Failed, entry.Status)
+	assert.Equal(t, 2, entry.Attempts)
+}
+
+func TestFlushRetriesUnsuccessfulSend(t *testing.T) {
+	client := beepermock.NewClient()
+	replies := []*resources.MessageSendResponse{{Error: "chat is read-only"}, {MessageID: "m2", Success: true}}
+	client.Messages.SendFunc = func(ctx context.Context, params resources.MessageSendParams) (*resources.MessageSendResponse, error) {
+		reply := replies[0]
+		replies = replies[1:]
+		return reply, nil
+	}
+	var results []outbox.Entry
+	box, err := outbox.Open(client, outbox.Options{
+		Backoff:  time.Nanosecond,
+		OnResult: func(entry outbox.Entry) { results = append(results, entry) },
+	})
+	require.NoError(t, err)
+	ctx := context.Background()
+
+	key, err := box.Enqueue(resources.MessageSendParams{ChatID: "chat-alice", Text: "hello"})
+	require.NoError(t, err)
+	require.NoError(t, box.Flush(ctx))
+	entry, _ := box.Entry(key)
+	assert.Equal(t, outbox.Pending, entry.Status, "Desktop said it did not send the message")
+	assert.Equal(t, "message not sent: chat is read-only", entry.LastError)
+	assert.Empty(t, results)
+
+	time.Sleep(time.Millisecond)
+	require.NoError(t, box.Flush(ctx))
+	entry, _ = box.Entry(key)
+	assert.Equal(t, outbox.Sent, entry.Status)
+	assert.Equal(t, "m2", entry.MessageID)
+	assert.Equal(t, 2, entry.Attempts)
+	assert.Empty(t, client.Messages.CallsTo("Search"), "no need to look for a message that was not sent")
+	require.Len(t, results, 1)
+}
+
+func TestReconcileMatchesAttachmentName(t *testing.T) {
+	ctx := context.Background()
+	attachment := "/tmp/agenda.pdf"
+	params := resources.MessageSendParams{ChatID: "chat-team", Text: "agenda", Attachment: &attachment}
+	for name, fileName := range map[string]*string{"unnamed": nil, "other": beeperdesktop.StringPtr("minutes.pdf"), "same": beeperdesktop.StringPtr("agenda.pdf")} {
+		t.Run(name, func(t *testing.T) {
+			client := beepermock.NewClient()
+			client.Messages.SearchFunc = func(ctx context.Context, params resources.MessageSearchParams) (*resources.MessagesCursor, error) {
+				return &resources.MessagesCursor{Items: []resources.Message{{
+					ID:          "m1",
+					IsSender:    beeperdesktop.BoolPtr(true),
+					Text:        beeperdesktop.StringPtr("agenda"),
+					Attachments: []resources.Attachment{{FileName: fileName}},
+				}}}, nil
+			}
+			client.Messages.SendFunc = func(ctx context.Context, params resources.MessageSendParams) (*resources.MessageSendResponse, error) {
+				return &resources.MessageSendResponse{MessageID: "m2", Success: true}, nil
+			}
+			store := outbox.NewFileStore(filepath.Join(t.TempDir(), "outbox.json"))
+			require.NoError(t, store.Save(&outbox.State{Entries: []outbox.Entry{
+				{Key: "k", Params: params, Status: outbox.Sending, Attempts: 1, CreatedAt: time.Now()},
+			}}))
+			box, err := outbox.Open(client, outbox.Options{Store: store})
+			require.NoError(t, err)
+			require.NoError(t, box.Flush(ctx))
+
+			entry, _ := box.Entry("k")
+			assert.Equal(t, outbox.Sent, entry.Status)
+			if name == "same" {
+				assert.True(t, entry.Reconciled)
+				assert.Equal(t, "m1", entry.MessageID)
+			} else {
+				assert.False(t, entry.Reconciled, "another message with the same text")
+				assert.Equal(t, "m2", entry.MessageID)
+			}
+		})
+	}
+}
+
+func TestEnqueueWithKey(t *testing.T) {
+	_, client := setup(t)
+	box, err := outbox.Open(client, outbox.Options{})
+	require.NoError(t, err)
+
+	params := resources.MessageSendParams{ChatID: "chat-alice", Text: "once"}
+	require.NoError(t, box.EnqueueWithKey("event-1", params))
+	require.NoError(t, box.EnqueueWithKey("event-1", params), "repeats are ignored")
+	assert.Len(t, box.Entries(), 1)
+
+	params.Text = "twice"
+	assert.EqualError(t, box.EnqueueWithKey("event-1", params), `outbox: key "event-1" is already used by a different message`)
+	assert.Error(t, box.EnqueueWithKey("", params))
+	assert.Error(t, box.EnqueueWithKey("event-2", resources.MessageSendParams{ChatID: "chat-alice"}))
+	assert.Error(t, box.EnqueueWithKey("event-3", resources.MessageSendParams{Text: "no chat"}))
+}
+
+func TestSharedStore(t *testing.T) {
+	server, client := setup(t)
+	path := filepath.Join(t.TempDir(), "outbox.json")
+	ctx := context.Background()
+
+	// Two processes open the same file, as send-later's commands do
+	runner, err := outbox.Open(client, outbox.Options{Store: outbox.NewFileStore(path)})
+	require.NoError(t, err)
+	other, err := outbox.Open(client, outbox.Options{Store: outbox.NewFileStore(path)})
+	require.NoError(t, err)
+
+	require.NoError(t, runner.EnqueueWithKey("first", resources.MessageSendParams{ChatID: "chat-alice", Text: "first"}))
+	require.NoError(t, other.EnqueueWithKey("second", resources.MessageSendParams{ChatID: "chat-alice", Text: "second"}))
+	require.NoError(t, other.EnqueueWithKey("first", resources.MessageSendParams{ChatID: "chat-alice", Text: "first"}),
+		"a key the other process enqueued is recognized")
+
+	require.NoError(t, runner.Flush(ctx))
+	assert.Len(t, withText(server, "chat-alice", "first"), 1)
+	assert.Len(t, withText(server, "chat-alice", "second"), 1)
+
+	reopened, err := outbox.Open(client, outbox.Options{Store: outbox.NewFileStore(path)})
+	require.NoError(t, err)
+	entries := reopened.Entries()
+	require.Len(t, entries, 2)
+	for _, entry := range entries {
+		assert.Equal(t, outbox.Sent, entry.Status, entry.Key)
+	}
+}
+
+func TestRun(t *testing.T) {
+	server, client := setup(t)
+	results := make(chan outbox.Entry, 1)
+	box, err := outbox.Open(client, outbox.Options{OnResult: func(entry outbox.Entry) { results <- entry }})
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() { done <- box.Run(ctx) }()
+
+	_, err = box.Enqueue(resources.MessageSendParams{ChatID: "chat-team", Text: "queued"})
+	require.NoError(t, err)
+	select {
+	case entry := <-results:
+		assert.Equal(t, outbox.Sent, entry.Status)
+	case <-time.After(5 * time.Second):
+		t.Fatal("message not sent")
+	}
+	assert.Len(t, withText(server, "chat-team", "queued"), 1)
+
+	cancel()
+	assert.ErrorIs(t, <-done, context.Canceled)
+}
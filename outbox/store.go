@@ -0,0 +1,33 @@
+package outbox
+
+import "github.com/cameronaaron/beeper-go-sdk/internal/jsonfile"
+
+// State is the saved form of an outbox
+type State struct {
+	Entries []Entry `json:"entries"`
+}
+
+// Store persists an outbox between runs. Save is called before every send,
+// so a crash never loses track of a message that may have gone out.
+type Store interface {
+	Load() (*State, error)
+	Save(state *State) error
+}
+
+// Locker is implemented by stores that several processes can share, such
+// as one that enqueues messages and one that sends them. The outbox holds
+// the lock while it reloads, changes and saves the entries, so it never
+// overwrites another process's changes.
+type Locker interface {
+	Lock() (unlock func() error, err error)
+}
+
+// FileStore keeps an outbox in a JSON file. It is a Locker, so processes on
+// one machine can share the file; on systems without file locks, such as
+// Windows, only one process should use it.
+type FileStore = jsonfile.Store[State]
+
+// NewFileStore creates a store backed by the given file
+func NewFileStore(path string) *FileStore {
+	return jsonfile.NewStore[State](path, "outbox")
+}
@@ -34,36 +34,49 @@ func (it *Iterator[T]) ToSlice(ctx context.Context) ([]T, error) {
 	return it.iterator.ToSlice(ctx)
 }
 
+// newPageIterator creates an iterator over a generated list method, which
+// encodes the parameters of each page request
+func newPageIterator[T any](cursor *string, list func(ctx context.Context, cursor *string) (*resources.Cursor[T], error)) *Iterator[T] {
+	start := ""
+	if cursor != nil {
+		start = *cursor
+	}
+
+	return &Iterator[T]{
+		iterator: internal.NewPageIterator(start, func(ctx context.Context, cursor string) (*internal.Cursor[T], error) {
+			var next *string
+			if cursor != "" {
+				next = &cursor
+			}
+			page, err := list(ctx, next)
+			if err != nil {
+				return nil, err
+			}
+			return &internal.Cursor[T]{Items: page.Items, Pagination: (*internal.PaginationInfo)(page.Pagination)}, nil
+		}),
+	}
+}
+
 // NewMessageIterator creates an iterator for message search results
 func (c *BeeperDesktop) NewMessageIterator(params resources.MessageSearchParams) *Iterator[resources.Message] {
-	paramMap := map[string]interface{}{
-		"accountIDs":         params.AccountIDs,
-		"chatIDs":            params.ChatIDs,
-		"chatType":           params.ChatType,
-		"cursor":             params.Cursor,
-		"dateAfter":          params.DateAfter,
-		"dateBefore":         params.DateBefore,
-		"direction":          params.Direction,
-		"excludeLowPriority": params.ExcludeLowPriority,
-		"includeMuted":       params.IncludeMuted,
-		"limit":              params.Limit,
-		"mediaTypes":         params.MediaTypes,
-		"query":              params.Query,
-		"senderIDs":          params.SenderIDs,
-	}
-	return NewIterator[resources.Message](c, "/v0/search-messages", paramMap)
+	return newPageIterator(params.Cursor, func(ctx context.Context, cursor *string) (*resources.MessagesCursor, error) {
+		params.Cursor = cursor
+		return c.Messages.Search(ctx, params)
+	})
 }
 
 // NewChatIterator creates an iterator for chat search results
 func (c *BeeperDesktop) NewChatIterator(params resources.ChatSearchParams) *Iterator[resources.Chat] {
-	paramMap := map[string]interface{}{
-		"accountIDs":   params.AccountIDs,
-		"chatType":     params.ChatType,
-		"includeMuted": params.IncludeMuted,
-		"limit":        params.Limit,
-		"cursor":       params.Cursor,
-		"scope":        params.Scope,
-		"query":        params.Query,
-	}
-	return NewIterator[resources.Chat](c, "/v0/search-chats", paramMap)
+	return newPageIterator(params.Cursor, func(ctx context.Context, cursor *string) (*resources.ChatsCursor, error) {
+		params.Cursor = cursor
+		return c.Chats.Search(ctx, params)
+	})
+}
+
+// NewParticipantIterator creates an iterator over all participants of a chat
+func (c *BeeperDesktop) NewParticipantIterator(params resources.ParticipantListParams) *Iterator[resources.User] {
+	return newPageIterator(params.Cursor, func(ctx context.Context, cursor *string) (*resources.ParticipantsCursor, error) {
+		params.Cursor = cursor
+		return c.Chats.Participants.List(ctx, params)
+	})
 }
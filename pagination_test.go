@@ -0,0 +1,58 @@
+package beeperdesktop
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/cameronaaron/beeper-go-sdk/resources"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestIteratorQueryEncoding(t *testing.T) {
+	var queries []url.Values
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		queries = append(queries, r.URL.Query())
+		page := resources.Cursor[json.RawMessage]{Items: []json.RawMessage{[]byte(`{"id":"1"}`)}, Pagination: &resources.PaginationInfo{}}
+		if len(queries) == 1 {
+			page.Pagination.HasMore = true
+			page.Pagination.Cursor = StringPtr("next")
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(page)
+	}))
+	defer server.Close()
+
+	client, err := New(WithAccessToken("token"), WithBaseURL(server.URL), WithMaxRetries(0))
+	require.NoError(t, err)
+	ctx := context.Background()
+
+	after := time.Date(2024, 6, 5, 9, 0, 0, 0, time.FixedZone("CEST", 2*60*60))
+	messages, err := client.NewMessageIterator(resources.MessageSearchParams{
+		ChatIDs:   []string{"chat-a", "chat-b"},
+		DateAfter: &after,
+		Limit:     IntPtr(1),
+	}).ToSlice(ctx)
+	require.NoError(t, err)
+	assert.Len(t, messages, 2)
+
+	// The iterator sends what Messages.Search sends, plus the cursor
+	require.Len(t, queries, 2)
+	assert.Equal(t, url.Values{
+		"chatIDs[0]": {"chat-a"},
+		"chatIDs[1]": {"chat-b"},
+		"dateAfter":  {"2024-06-05T09:00:00+02:00"},
+		"limit":      {"1"},
+	}, queries[0])
+	assert.Equal(t, "next", queries[1].Get("cursor"))
+
+	queries = nil
+	_, err = client.NewChatIterator(resources.ChatSearchParams{AccountIDs: []string{"matrix", "slack"}}).ToSlice(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, url.Values{"accountIDs[0]": {"matrix"}, "accountIDs[1]": {"slack"}}, queries[0])
+}
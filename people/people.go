@@ -0,0 +1,585 @@
+// Package people merges the users of different accounts and networks into
+// Person records. Users are the same person when they share a phone number or
+// email address, or when they have been linked by hand. The graph remembers
+// which chats each user is in, so every chat with a person can be found
+// whatever the network.
+package people
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	beeperdesktop "github.com/cameronaaron/beeper-go-sdk"
+	"github.com/cameronaaron/beeper-go-sdk/internal/phone"
+	"github.com/cameronaaron/beeper-go-sdk/resources"
+)
+
+// syncPageSize is the number of chats fetched per request by Sync
+const syncPageSize = 100
+
+// Key identifies a user within an account
+type Key struct {
+	AccountID string `json:"accountID"`
+	UserID    string `json:"userID"`
+}
+
+func (k Key) String() string {
+	return k.AccountID + "/" + k.UserID
+}
+
+// Identity is one user as seen from one account
+type Identity struct {
+	AccountID string         `json:"accountID"`
+	Network   string         `json:"network,omitempty"`
+	User      resources.User `json:"user"`
+}
+
+// Key returns the identity's key
+func (i Identity) Key() Key {
+	return Key{AccountID: i.AccountID, UserID: i.User.ID}
+}
+
+// Link joins two identities by hand
+type Link struct {
+	A Key `json:"a"`
+	B Key `json:"b"`
+}
+
+// ChatRef is a chat a person is in
+type ChatRef struct {
+	AccountID string `json:"accountID"`
+	ChatID    string `json:"chatID"`
+	Network   string `json:"network,omitempty"`
+	Title     string `json:"title,omitempty"`
+	Type      string `json:"type,omitempty"`
+}
+
+// Membership is a chat and the user IDs seen in it
+type Membership struct {
+	ChatRef
+	Members []string `json:"members"`
+}
+
+// Person is one human being across accounts
+type Person struct {
+	ID         string // the smallest identity key; changes when people merge, unlike the identity keys
+	Name       string
+	Phones     []string
+	Emails     []string
+	Identities []Identity
+}
+
+// Graph holds identities, links and chat memberships. It is safe for
+// concurrent use.
+type Graph struct {
+	store Store
+
+	mu         sync.Mutex
+	identities map[Key]Identity
+	links      []Link
+	chats      map[string]Membership // by account ID and chat ID
+	people     []Person              // nil when it needs rebuilding
+	personOf   map[Key]int
+}
+
+// Open loads a graph from store, which may be nil for a graph that is not
+// persisted
+func Open(store Store) (*Graph, error) {
+	g := &Graph{
+		store:      store,
+		identities: make(map[Key]Identity),
+		chats:      make(map[string]Membership),
+	}
+	if store == nil {
+		return g, nil
+	}
+
+	state, err := store.Load()
+	if err != nil {
+		return nil, err
+	}
+	if state != nil {
+		for _, identity := range state.Identities {
+			g.identities[identity.Key()] = identity
+		}
+		g.links = state.Links
+		for _, chat := range state.Chats {
+			g.chats[chatKey(chat.AccountID, chat.ChatID)] = chat
+		}
+	}
+	return g, nil
+}
+
+// Save writes the graph to its store
+func (g *Graph) Save() error {
+	if g.store == nil {
+		return nil
+	}
+
+	g.mu.Lock()
+	state := &State{Links: append([]Link(nil), g.links...)}
+	for _, identity := range g.identities {
+		state.Identities = append(state.Identities, identity)
+	}
+	for _, chat := range g.chats {
+		state.Chats = append(state.Chats, chat)
+	}
+	g.mu.Unlock()
+
+	sort.Slice(state.Identities, func(i, j int) bool {
+		return state.Identities[i].Key().String() < state.Identities[j].Key().String()
+	})
+	sort.Slice(state.Chats, func(i, j int) bool {
+		return chatKey(state.Chats[i].AccountID, state.Chats[i].ChatID) < chatKey(state.Chats[j].AccountID, state.Chats[j].ChatID)
+	})
+	return g.store.Save(state)
+}
+
+// AddUser records a user of an account, updating what is known about them.
+// The signed-in user is ignored. Call Save to persist the change.
+func (g *Graph) AddUser(accountID, network string, user resources.User) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.addUser(accountID, network, user)
+}
+
+func (g *Graph) addUser(accountID, network string, user resources.User) {
+	if user.ID == "" || (user.IsSelf != nil && *user.IsSelf) {
+		return
+	}
+	key := Key{AccountID: accountID, UserID: user.ID}
+	if old, ok := g.identities[key]; ok {
+		user = mergeUser(old.User, user)
+	}
+	g.identities[key] = Identity{AccountID: accountID, Network: network, User: user}
+	g.people = nil
+}
+
+// AddChat records a chat and its participants. Call Save to persist the change.
+func (g *Graph) AddChat(chat resources.Chat) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	entry := Membership{ChatRef: ChatRef{
+		AccountID: chat.AccountID,
+		ChatID:    chat.ID,
+		Network:   chat.Network,
+		Title:     chat.Title,
+		Type:      chat.Type,
+	}}
+	for _, user := range chat.Participants.Items {
+		if user.IsSelf != nil && *user.IsSelf {
+			continue
+		}
+		g.addUser(chat.AccountID, chat.Network, user)
+		entry.Members = append(entry.Members, user.ID)
+	}
+	g.chats[chatKey(chat.AccountID, chat.ID)] = entry
+}
+
+// Sync records every chat of every account and their participants, then
+// saves the graph. Users found only through Contacts.Search can be added with
+// AddUser.
+func (g *Graph) Sync(ctx context.Context, client beeperdesktop.Client) error {
+	params := resources.ChatSearchParams{Limit: beeperdesktop.IntPtr(syncPageSize)}
+	for {
+		page, err := client.ChatsAPI().Search(ctx, params)
+		if err != nil {
+			return fmt.Errorf("search chats: %w", err)
+		}
+		for _, chat := range page.Items {
+			if chat.Participants.HasMore {
+				users, err := listParticipants(ctx, client, chat.ID)
+				if err != nil {
+					return err
+				}
+				chat.Participants.Items = users
+			}
+			g.AddChat(chat)
+		}
+		if page.Pagination == nil || !page.Pagination.HasMore || page.Pagination.Cursor == nil {
+			break
+		}
+		params.Cursor = page.Pagination.Cursor
+	}
+	return g.Save()
+}
+
+// listParticipants pages through all participants of a chat
+func listParticipants(ctx context.Context, client beeperdesktop.Client, chatID string) ([]resources.User, error) {
+	params := resources.ParticipantListParams{ChatID: chatID, Limit: beeperdesktop.IntPtr(syncPageSize)}
+	var users []resources.User
+	for {
+		page, err := client.ChatsAPI().ParticipantsAPI().List(ctx, params)
+		if err != nil {
+			return nil, fmt.Errorf("list participants of %s: %w", chatID, err)
+		}
+		users = append(users, page.Items...)
+		if page.Pagination == nil || !page.Pagination.HasMore || page.Pagination.Cursor == nil {
+			return users, nil
+		}
+		params.Cursor = page.Pagination.Cursor
+	}
+}
+
+// Link records that two identities are the same person and saves the graph
+func (g *Graph) Link(a, b Key) error {
+	g.mu.Lock()
+	for _, key := range []Key{a, b} {
+		if _, ok := g.identities[key]; !ok {
+			g.mu.Unlock()
+			return fmt.Errorf("unknown identity %s", key)
+		}
+	}
+	g.links = append(g.links, Link{A: a, B: b})
+	g.people = nil
+	g.mu.Unlock()
+	return g.Save()
+}
+
+// Unlink removes links made with Link between two identities and saves the
+// graph. Identities that share a phone number or email stay merged.
+func (g *Graph) Unlink(a, b Key) error {
+	g.mu.Lock()
+	kept := g.links[:0]
+	for _, link := range g.links {
+		if (link.A == a && link.B == b) || (link.A == b && link.B == a) {
+			continue
+		}
+		kept = append(kept, link)
+	}
+	g.links = kept
+	g.people = nil
+	g.mu.Unlock()
+	return g.Save()
+}
+
+// People returns everyone in the graph, by name
+func (g *Graph) People() []Person {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.build()
+	return append([]Person(nil), g.people...)
+}
+
+// Lookup returns the person an identity belongs to
+func (g *Graph) Lookup(key Key) (Person, bool) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.build()
+	i, ok := g.personOf[key]
+	if !ok {
+		return Person{}, false
+	}
+	return g.people[i], true
+}
+
+// Find returns the people whose name, username, phone number or email
+// contains query, ignoring case. Phone numbers match however they are
+// formatted.
+func (g *Graph) Find(query string) []Person {
+	query = strings.ToLower(strings.TrimSpace(query))
+	digits := phoneDigits(query)
+	var found []Person
+	for _, person := range g.People() {
+		if personMatches(person, query, digits) {
+			found = append(found, person)
+		}
+	}
+	return found
+}
+
+// ErrUnknownPerson is returned for an identity key not in the graph
+var ErrUnknownPerson = errors.New("unknown person")
+
+// Chats returns every recorded chat with the person an identity belongs to,
+// on any network, direct chats first. Any of the person's identity keys
+// will do, and it still finds them after they merge with someone else.
+func (g *Graph) Chats(key Key) ([]ChatRef, error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.build()
+
+	i, ok := g.personOf[key]
+	if !ok {
+		return nil, fmt.Errorf("%w %s", ErrUnknownPerson, key)
+	}
+	person := &g.people[i]
+
+	members := make(map[Key]bool, len(person.Identities))
+	for _, identity := range person.Identities {
+		members[identity.Key()] = true
+	}
+	var chats []ChatRef
+	for _, chat := range g.chats {
+		for _, userID := range chat.Members {
+			if members[Key{AccountID: chat.AccountID, UserID: userID}] {
+				chats = append(chats, chat.ChatRef)
+				break
+			}
+		}
+	}
+	sort.Slice(chats, func(i, j int) bool {
+		if (chats[i].Type == "single") != (chats[j].Type == "single") {
+			return chats[i].Type == "single"
+		}
+		if chats[i].Title != chats[j].Title {
+			return chats[i].Title < chats[j].Title
+		}
+		return chatKey(chats[i].AccountID, chats[i].ChatID) < chatKey(chats[j].AccountID, chats[j].ChatID)
+	})
+	return chats, nil
+}
+
+// build groups identities into people if anything changed. Callers must hold
+// g.mu.
+func (g *Graph) build() {
+	if g.people != nil {
+		return
+	}
+
+	keys := make([]Key, 0, len(g.identities))
+	for key := range g.identities {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool { return keys[i].String() < keys[j].String() })
+
+	sets := newUnionFind(keys)
+	byPhone := make(map[string]Key)
+	byEmail := make(map[string]Key)
+	for _, key := range keys {
+		user := g.identities[key].User
+		for _, phone := range phones(user) {
+			if other, ok := byPhone[phone]; ok {
+				sets.union(key, other)
+			} else {
+				byPhone[phone] = key
+			}
+		}
+		if email := normalizeEmail(user.Email); email != "" {
+			if other, ok := byEmail[email]; ok {
+				sets.union(key, other)
+			} else {
+				byEmail[email] = key
+			}
+		}
+	}
+	for _, link := range g.links {
+		if _, ok := g.identities[link.A]; !ok {
+			continue
+		}
+		if _, ok := g.identities[link.B]; !ok {
+			continue
+		}
+		sets.union(link.A, link.B)
+	}
+
+	groups := make(map[Key][]Identity)
+	var roots []Key
+	for _, key := range keys {
+		root := sets.find(key)
+		if _, ok := groups[root]; !ok {
+			roots = append(roots, root)
+		}
+		groups[root] = append(groups[root], g.identities[key])
+	}
+
+	g.people = make([]Person, 0, len(roots))
+	for _, root := range roots {
+		g.people = append(g.people, newPerson(groups[root]))
+	}
+	sort.SliceStable(g.people, func(i, j int) bool {
+		a, b := strings.ToLower(g.people[i].Name), strings.ToLower(g.people[j].Name)
+		if a != b {
+			return a < b
+		}
+		return g.people[i].ID < g.people[j].ID
+	})
+
+	g.personOf = make(map[Key]int, len(keys))
+	for i, person := range g.people {
+		for _, identity := range person.Identities {
+			g.personOf[identity.Key()] = i
+		}
+	}
+}
+
+// newPerson summarizes a group of identities, sorted by key
+func newPerson(identities []Identity) Person {
+	person := Person{ID: identities[0].Key().String(), Identities: identities}
+
+	names := make(map[string]int)
+	for _, identity := range identities {
+		user := identity.User
+		if user.FullName != nil && *user.FullName != "" {
+			names[*user.FullName]++
+		}
+		for _, phone := range phones(user) {
+			person.Phones = appendUnique(person.Phones, phone)
+		}
+		if email := normalizeEmail(user.Email); email != "" {
+			person.Emails = appendUnique(person.Emails, email)
+		}
+	}
+
+	// The most common full name, then the longest, then the first
+	// alphabetically
+	for name, count := range names {
+		best := names[person.Name]
+		switch {
+		case count != best:
+			if count > best {
+				person.Name = name
+			}
+		case len(name) != len(person.Name):
+			if len(name) > len(person.Name) {
+				person.Name = name
+			}
+		case name < person.Name:
+			person.Name = name
+		}
+	}
+	if person.Name == "" {
+		user := identities[0].User
+		person.Name = user.ID
+		if user.Username != nil && *user.Username != "" {
+			person.Name = *user.Username
+		}
+	}
+	return person
+}
+
+func personMatches(person Person, query, digits string) bool {
+	if strings.Contains(strings.ToLower(person.Name), query) {
+		return true
+	}
+	for _, phone := range person.Phones {
+		if digits != "" && strings.Contains(phone, digits) {
+			return true
+		}
+	}
+	for _, email := range person.Emails {
+		if strings.Contains(email, query) {
+			return true
+		}
+	}
+	for _, identity := range person.Identities {
+		user := identity.User
+		if strings.Contains(strings.ToLower(user.ID), query) {
+			return true
+		}
+		for _, field := range []*string{user.FullName, user.Username} {
+			if field != nil && strings.Contains(strings.ToLower(*field), query) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// phones returns a user's normalized phone numbers, including an ID that is
+// a phone number, as on WhatsApp and SMS
+func phones(user resources.User) []string {
+	var numbers []string
+	if user.PhoneNumber != nil {
+		if number := phone.Normalize(*user.PhoneNumber); number != "" {
+			numbers = append(numbers, number)
+		}
+	}
+	if strings.HasPrefix(user.ID, "+") {
+		if number := phone.Normalize(user.ID); number != "" {
+			numbers = appendUnique(numbers, number)
+		}
+	}
+	return numbers
+}
+
+// phoneDigits returns the digits of a query that looks like part of a phone
+// number, or "" if it does not
+func phoneDigits(query string) string {
+	var b strings.Builder
+	for _, r := range query {
+		switch {
+		case r >= '0' && r <= '9':
+			b.WriteRune(r)
+		case r == '+' || r == ' ' || r == '-' || r == '.' || r == '(' || r == ')':
+		default:
+			return ""
+		}
+	}
+	return b.String()
+}
+
+func normalizeEmail(email *string) string {
+	if email == nil || !strings.Contains(*email, "@") {
+		return ""
+	}
+	return strings.ToLower(strings.TrimSpace(*email))
+}
+
+// mergeUser fills the fields of newer that it lacks from older
+func mergeUser(older, newer resources.User) resources.User {
+	fill := func(dst **string, src *string) {
+		if (*dst == nil || **dst == "") && src != nil {
+			*dst = src
+		}
+	}
+	fill(&newer.FullName, older.FullName)
+	fill(&newer.Username, older.Username)
+	fill(&newer.Email, older.Email)
+	fill(&newer.PhoneNumber, older.PhoneNumber)
+	fill(&newer.ImgURL, older.ImgURL)
+	return newer
+}
+
+func chatKey(accountID, chatID string) string {
+	return accountID + "\x00" + chatID
+}
+
+func appendUnique(list []string, value string) []string {
+	for _, v := range list {
+		if v == value {
+			return list
+		}
+	}
+	return append(list, value)
+}
+
+// unionFind groups keys into disjoint sets, keeping the smallest key (in
+// insertion order) as each set's root
+type unionFind struct {
+	parent map[Key]Key
+	order  map[Key]int
+}
+
+func newUnionFind(keys []Key) *unionFind {
+	u := &unionFind{parent: make(map[Key]Key, len(keys)), order: make(map[Key]int, len(keys))}
+	for i, key := range keys {
+		u.parent[key] = key
+		u.order[key] = i
+	}
+	return u
+}
+
+func (u *unionFind) find(key Key) Key {
+	for u.parent[key] != key {
+		u.parent[key] = u.parent[u.parent[key]]
+		key = u.parent[key]
+	}
+	return key
+}
+
+func (u *unionFind) union(a, b Key) {
+	ra, rb := u.find(a), u.find(b)
+	if ra == rb {
+		return
+	}
+	if u.order[rb] < u.order[ra] {
+		ra, rb = rb, ra
+	}
+	u.parent[rb] = ra
+}
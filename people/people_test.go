@@ -0,0 +1,141 @@
+package people_test
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"testing"
+
+	beeperdesktop "github.com/cameronaaron/beeper-go-sdk"
+	"github.com/cameronaaron/beeper-go-sdk/beepertest"
+	"github.com/cameronaaron/beeper-go-sdk/people"
+	"github.com/cameronaaron/beeper-go-sdk/resources"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fixtures adds a Telegram account where Alice has another identity, and a
+// WhatsApp chat with Bob
+func fixtures() beepertest.Fixtures {
+	f := beepertest.DefaultFixtures()
+	me := resources.User{ID: "tg-me", IsSelf: beeperdesktop.BoolPtr(true)}
+	aliceTG := resources.User{ID: "tg-1001", FullName: beeperdesktop.StringPtr("Alice S."), Email: beeperdesktop.StringPtr(" ALICE@example.com")}
+	bobWA := resources.User{ID: "+15555550100", FullName: beeperdesktop.StringPtr("Bob Jones")}
+	carolMatrix := resources.User{ID: "@carol:beeper.com", Username: beeperdesktop.StringPtr("carolw")}
+	meWA := f.Accounts[1].User
+	meMatrix := f.Accounts[0].User
+
+	f.Accounts = append(f.Accounts, resources.Account{AccountID: "telegram", Network: "Telegram", User: me})
+	f.Chats = append(f.Chats,
+		resources.Chat{ID: "chat-tg-alice", AccountID: "telegram", Network: "Telegram", Title: "Alice S.", Type: "single",
+			Participants: resources.ChatParticipants{Items: []resources.User{me, aliceTG}, Total: 2}},
+		resources.Chat{ID: "chat-wa-bob", AccountID: "whatsapp", Network: "WhatsApp", Title: "Bob Jones", Type: "single",
+			Participants: resources.ChatParticipants{Items: []resources.User{meWA, bobWA}, Total: 2}},
+		resources.Chat{ID: "chat-carol", AccountID: "matrix", Network: "Beeper (Matrix)", Title: "carolw", Type: "single",
+			Participants: resources.ChatParticipants{Items: []resources.User{meMatrix, carolMatrix}, Total: 2}},
+	)
+	return f
+}
+
+func chatIDs(chats []people.ChatRef) []string {
+	var ids []string
+	for _, chat := range chats {
+		ids = append(ids, chat.ChatID)
+	}
+	return ids
+}
+
+func names(persons []people.Person) []string {
+	var list []string
+	for _, person := range persons {
+		list = append(list, person.Name)
+	}
+	return list
+}
+
+func TestSyncMergesAcrossNetworks(t *testing.T) {
+	server := beepertest.NewServer(fixtures())
+	defer server.Close()
+	client, err := server.Client()
+	require.NoError(t, err)
+	ctx := context.Background()
+
+	graph, err := people.Open(nil)
+	require.NoError(t, err)
+	require.NoError(t, graph.Sync(ctx, client))
+
+	assert.Equal(t, []string{"Alice Smith", "Bob Jones", "Carol White", "carolw"}, names(graph.People()), "self users are left out")
+
+	alice, ok := graph.Lookup(people.Key{AccountID: "telegram", UserID: "tg-1001"})
+	require.True(t, ok)
+	assert.Equal(t, "Alice Smith", alice.Name, "ties go to the longest name")
+	assert.Equal(t, []string{"alice@example.com"}, alice.Emails)
+	assert.Len(t, alice.Identities, 2)
+
+	chats, err := graph.Chats(people.Key{AccountID: "telegram", UserID: "tg-1001"})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"chat-tg-alice", "chat-alice", "chat-team"}, chatIDs(chats), "direct chats first")
+
+	bob := graph.Find("555-0100")
+	require.Len(t, bob, 1, "phone numbers match however they are formatted")
+	assert.Equal(t, []string{"+15555550100"}, bob[0].Phones)
+	chats, err = graph.Chats(bob[0].Identities[0].Key())
+	require.NoError(t, err)
+	assert.Equal(t, []string{"chat-wa-bob", "chat-team"}, chatIDs(chats))
+
+	_, err = graph.Chats(people.Key{AccountID: "matrix", UserID: "@nobody:beeper.com"})
+	assert.True(t, errors.Is(err, people.ErrUnknownPerson))
+}
+
+func TestLinkPersists(t *testing.T) {
+	server := beepertest.NewServer(fixtures())
+	defer server.Close()
+	client, err := server.Client()
+	require.NoError(t, err)
+	ctx := context.Background()
+
+	store := people.NewFileStore(filepath.Join(t.TempDir(), "people", "graph.json"))
+	graph, err := people.Open(store)
+	require.NoError(t, err)
+	require.NoError(t, graph.Sync(ctx, client))
+
+	carolMatrix := people.Key{AccountID: "matrix", UserID: "@carol:beeper.com"}
+	carolWA := people.Key{AccountID: "whatsapp", UserID: "+15555550123"}
+	chats, err := graph.Chats(carolWA)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"chat-family"}, chatIDs(chats))
+	require.NoError(t, graph.Link(carolMatrix, carolWA))
+	assert.Error(t, graph.Link(carolMatrix, people.Key{AccountID: "matrix", UserID: "@zed:beeper.com"}))
+
+	// A reopened graph has the link and the chats without syncing
+	graph, err = people.Open(store)
+	require.NoError(t, err)
+	carol, ok := graph.Lookup(carolWA)
+	require.True(t, ok)
+	assert.Equal(t, "Carol White", carol.Name)
+	assert.Equal(t, "matrix/@carol:beeper.com", carol.ID, "the ID changed with the merge")
+	chats, err = graph.Chats(carolWA)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"chat-carol", "chat-family"}, chatIDs(chats))
+
+	require.NoError(t, graph.Unlink(carolWA, carolMatrix))
+	assert.Equal(t, []string{"Alice Smith", "Bob Jones", "Carol White", "carolw"}, names(graph.People()))
+}
+
+func TestAddUser(t *testing.T) {
+	graph, err := people.Open(nil)
+	require.NoError(t, err)
+
+	graph.AddUser("sms", "SMS", resources.User{ID: "+15555550150"})
+	graph.AddUser("signal", "Signal", resources.User{ID: "sig-1", PhoneNumber: beeperdesktop.StringPtr("+1 555.555.0150")})
+	graph.AddUser("signal", "Signal", resources.User{ID: "sig-1", FullName: beeperdesktop.StringPtr("Dana")})
+	graph.AddUser("sms", "SMS", resources.User{ID: "12345", PhoneNumber: beeperdesktop.StringPtr("12345")})
+	graph.AddUser("signal", "Signal", resources.User{ID: "sig-2", PhoneNumber: beeperdesktop.StringPtr("12345")})
+
+	persons := graph.People()
+	assert.Equal(t, []string{"12345", "Dana", "sig-2"}, names(persons), "short codes do not merge")
+	assert.Equal(t, []string{"+15555550150"}, persons[1].Phones, "later updates keep earlier fields")
+	assert.Len(t, persons[1].Identities, 2)
+	assert.Equal(t, "Dana", graph.Find("dana")[0].Name)
+	assert.Empty(t, graph.Find("erin"))
+}
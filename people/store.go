@@ -0,0 +1,24 @@
+package people
+
+import "github.com/cameronaaron/beeper-go-sdk/internal/jsonfile"
+
+// State is the saved form of a graph. People are rebuilt from it on load.
+type State struct {
+	Identities []Identity   `json:"identities"`
+	Links      []Link       `json:"links,omitempty"`
+	Chats      []Membership `json:"chats,omitempty"`
+}
+
+// Store persists a graph between runs
+type Store interface {
+	Load() (*State, error)
+	Save(state *State) error
+}
+
+// FileStore keeps a graph in a JSON file
+type FileStore = jsonfile.Store[State]
+
+// NewFileStore creates a store backed by the given file
+func NewFileStore(path string) *FileStore {
+	return jsonfile.NewStore[State](path, "people graph")
+}
@@ -0,0 +1,531 @@
+// Package pool fronts several Beeper Desktop clients, such as one per machine
+// or config profile, as one. Reads are fanned out to every instance and merged
+// into a single, stably ordered view in which each item is tagged with the
+// instance it came from. Writes to a chat are routed to the instance that
+// owns it.
+package pool
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+
+	beeperdesktop "github.com/cameronaaron/beeper-go-sdk"
+	"github.com/cameronaaron/beeper-go-sdk/resources"
+)
+
+// maxEmptyPages bounds how many empty pages of one instance a merge follows
+const maxEmptyPages = 5
+
+// Instance is one Desktop fronted by a Pool
+type Instance struct {
+	Name   string
+	Client beeperdesktop.Client
+}
+
+// Account is an account tagged with the instance it was listed by
+type Account struct {
+	Instance string `json:"instance"`
+	resources.Account
+}
+
+// Chat is a chat tagged with the instance it was found on
+type Chat struct {
+	Instance string `json:"instance"`
+	resources.Chat
+}
+
+// Message is a message tagged with the instance it was found on
+type Message struct {
+	Instance string `json:"instance"`
+	resources.Message
+}
+
+// Page is one page of merged results. Cursor is empty on the last page.
+type Page[T any] struct {
+	Items  []T
+	Cursor string
+}
+
+// HasMore reports whether another page can be requested with Cursor
+func (p *Page[T]) HasMore() bool {
+	return p.Cursor != ""
+}
+
+// InstanceError is an error returned by one instance
+type InstanceError struct {
+	Instance string
+	Err      error
+}
+
+func (e *InstanceError) Error() string {
+	return fmt.Sprintf("%s: %v", e.Instance, e.Err)
+}
+
+func (e *InstanceError) Unwrap() error {
+	return e.Err
+}
+
+// ErrUnknownChat is returned when no instance has a chat
+var ErrUnknownChat = errors.New("no instance has this chat")
+
+// Pool merges reads across instances and routes writes to the instance that
+// owns a chat. It is safe for concurrent use.
+type Pool struct {
+	instances []Instance
+	index     map[string]int
+
+	mu     sync.Mutex
+	owners map[string]int // chat ID to the index of the instance it was seen on
+}
+
+// New creates a pool. Instance names must be unique and non-empty; their
+// order breaks ties when merging and picks the owner of a chat that more than
+// one instance has.
+func New(instances ...Instance) (*Pool, error) {
+	if len(instances) == 0 {
+		return nil, errors.New("pool needs at least one instance")
+	}
+
+	p := &Pool{
+		instances: append([]Instance(nil), instances...),
+		index:     make(map[string]int, len(instances)),
+		owners:    make(map[string]int),
+	}
+	for i, inst := range instances {
+		if inst.Name == "" {
+			return nil, fmt.Errorf("instance %d has no name", i)
+		}
+		if inst.Client == nil {
+			return nil, fmt.Errorf("instance %q has no client", inst.Name)
+		}
+		if _, dup := p.index[inst.Name]; dup {
+			return nil, fmt.Errorf("duplicate instance name %q", inst.Name)
+		}
+		p.index[inst.Name] = i
+	}
+	return p, nil
+}
+
+// FromProfiles creates a pool with one client per config file profile, named
+// after the profile. opts apply to every client.
+func FromProfiles(profiles []string, opts ...beeperdesktop.ClientOption) (*Pool, error) {
+	instances := make([]Instance, 0, len(profiles))
+	for _, name := range profiles {
+		client, err := beeperdesktop.New(append([]beeperdesktop.ClientOption{beeperdesktop.WithProfile(name)}, opts...)...)
+		if err != nil {
+			return nil, &InstanceError{Instance: name, Err: err}
+		}
+		instances = append(instances, Instance{Name: name, Client: client})
+	}
+	return New(instances...)
+}
+
+// Instances returns the pool's instances in order
+func (p *Pool) Instances() []Instance {
+	return append([]Instance(nil), p.instances...)
+}
+
+// Instance returns the client of the named instance
+func (p *Pool) Instance(name string) (beeperdesktop.Client, bool) {
+	i, ok := p.index[name]
+	if !ok {
+		return nil, false
+	}
+	return p.instances[i].Client, true
+}
+
+// Accounts lists the accounts of every instance, in instance order. An
+// account reachable through several instances is listed once, for the first.
+//
+// Like the other merged reads, Accounts returns what the reachable instances
+// sent along with an error joining an *InstanceError for each one that
+// failed, so callers can choose to show partial results.
+func (p *Pool) Accounts(ctx context.Context) ([]Account, error) {
+	lists := make([]*resources.AccountListResponse, len(p.instances))
+	err := p.fanOut(ctx, p.all(), func(ctx context.Context, i int) error {
+		list, err := p.instances[i].Client.AccountsAPI().List(ctx)
+		lists[i] = list
+		return err
+	})
+
+	var accounts []Account
+	seen := make(map[string]bool)
+	for i, list := range lists {
+		if list == nil {
+			continue
+		}
+		for _, account := range *list {
+			if seen[account.AccountID] {
+				continue
+			}
+			seen[account.AccountID] = true
+			accounts = append(accounts, Account{Instance: p.instances[i].Name, Account: account})
+		}
+	}
+	return accounts, err
+}
+
+// SearchChats searches every instance and merges the results, most recently
+// active first. params.Cursor takes the Cursor of a previous page. The limit
+// is the size of the merged page and is also asked of each instance; a page
+// can be shorter when one instance's page runs out before the others, since
+// the pool cannot order the rest without its next page.
+func (p *Pool) SearchChats(ctx context.Context, params resources.ChatSearchParams) (*Page[Chat], error) {
+	cursors, err := p.decodeCursor(params.Cursor)
+	if err != nil {
+		return nil, err
+	}
+
+	merged, next, err := merge(ctx, p, cursors, deref(params.Limit),
+		func(ctx context.Context, client beeperdesktop.Client, cursor *string) ([]resources.Chat, *resources.PaginationInfo, error) {
+			instParams := params
+			instParams.Cursor = cursor
+			page, err := client.ChatsAPI().Search(ctx, instParams)
+			if err != nil {
+				return nil, nil, err
+			}
+			return page.Items, page.Pagination, nil
+		},
+		func(chat resources.Chat) string { return chat.AccountID + "\x00" + chat.ID },
+		func(a, b resources.Chat) bool { return lastActivity(a).After(lastActivity(b).Time) },
+	)
+
+	chats := make([]Chat, len(merged))
+	for k, item := range merged {
+		p.remember(item.item.ID, item.instance)
+		chats[k] = Chat{Instance: p.instances[item.instance].Name, Chat: item.item}
+	}
+	return &Page[Chat]{Items: chats, Cursor: next.encode()}, err
+}
+
+// SearchMessages searches every instance and merges the results, newest
+// first, or oldest first when params.Direction is "after". Cursors and limits
+// work as for SearchChats.
+func (p *Pool) SearchMessages(ctx context.Context, params resources.MessageSearchParams) (*Page[Message], error) {
+	cursors, err := p.decodeCursor(params.Cursor)
+	if err != nil {
+		return nil, err
+	}
+
+	ascending := params.Direction != nil && *params.Direction == "after"
+	merged, next, err := merge(ctx, p, cursors, deref(params.Limit),
+		func(ctx context.Context, client beeperdesktop.Client, cursor *string) ([]resources.Message, *resources.PaginationInfo, error) {
+			instParams := params
+			instParams.Cursor = cursor
+			page, err := client.MessagesAPI().Search(ctx, instParams)
+			if err != nil {
+				return nil, nil, err
+			}
+			return page.Items, page.Pagination, nil
+		},
+		func(msg resources.Message) string { return msg.AccountID + "\x00" + msg.ChatID + "\x00" + msg.ID },
+		func(a, b resources.Message) bool {
+			if ascending {
+				return a.Timestamp.Before(b.Timestamp.Time)
+			}
+			return a.Timestamp.After(b.Timestamp.Time)
+		},
+	)
+
+	messages := make([]Message, len(merged))
+	for k, item := range merged {
+		p.remember(item.item.ChatID, item.instance)
+		messages[k] = Message{Instance: p.instances[item.instance].Name, Message: item.item}
+	}
+	return &Page[Message]{Items: messages, Cursor: next.encode()}, err
+}
+
+// Send sends a message through the instance that owns params.ChatID. A send
+// the instance answers with success set to false fails with a
+// *beeperdesktop.SendError.
+func (p *Pool) Send(ctx context.Context, params resources.MessageSendParams) (*resources.MessageSendResponse, error) {
+	inst, err := p.Owner(ctx, params.ChatID)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := inst.Client.MessagesAPI().Send(ctx, params)
+	if err == nil {
+		err = beeperdesktop.CheckSent(resp)
+	}
+	if err != nil {
+		return nil, &InstanceError{Instance: inst.Name, Err: err}
+	}
+	return resp, nil
+}
+
+// Archive archives or unarchives a chat on the instance that owns it
+func (p *Pool) Archive(ctx context.Context, params resources.ChatArchiveParams) (*resources.BaseResponse, error) {
+	inst, err := p.Owner(ctx, params.ChatID)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := inst.Client.ChatsAPI().Archive(ctx, params)
+	if err != nil {
+		return nil, &InstanceError{Instance: inst.Name, Err: err}
+	}
+	return resp, nil
+}
+
+// Owner returns the instance that owns a chat. Chats seen in earlier results
+// are looked up directly; others are retrieved from each instance in turn.
+// It fails with ErrUnknownChat when no instance has the chat.
+func (p *Pool) Owner(ctx context.Context, chatID string) (Instance, error) {
+	p.mu.Lock()
+	i, ok := p.owners[chatID]
+	p.mu.Unlock()
+	if ok {
+		return p.instances[i], nil
+	}
+
+	var errs []error
+	for i, inst := range p.instances {
+		_, err := inst.Client.ChatsAPI().Retrieve(ctx, resources.ChatRetrieveParams{ChatID: chatID})
+		if err == nil {
+			p.remember(chatID, i)
+			return inst, nil
+		}
+		var notFound *beeperdesktop.NotFoundError
+		if !errors.As(err, &notFound) {
+			errs = append(errs, &InstanceError{Instance: inst.Name, Err: err})
+		}
+	}
+	if len(errs) > 0 {
+		return Instance{}, fmt.Errorf("find chat %q: %w", chatID, errors.Join(errs...))
+	}
+	return Instance{}, fmt.Errorf("chat %q: %w", chatID, ErrUnknownChat)
+}
+
+// remember records the instance a chat was seen on, keeping the first
+// instance in pool order when several have it
+func (p *Pool) remember(chatID string, i int) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if owner, ok := p.owners[chatID]; !ok || i < owner {
+		p.owners[chatID] = i
+	}
+}
+
+// all returns the indexes of every instance
+func (p *Pool) all() []int {
+	indexes := make([]int, len(p.instances))
+	for i := range indexes {
+		indexes[i] = i
+	}
+	return indexes
+}
+
+// fanOut calls fn for the given instances concurrently and joins their
+// errors, each wrapped in an *InstanceError
+func (p *Pool) fanOut(ctx context.Context, indexes []int, fn func(ctx context.Context, i int) error) error {
+	errs := make([]error, len(p.instances))
+	var wg sync.WaitGroup
+	for _, i := range indexes {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			if err := fn(ctx, i); err != nil {
+				errs[i] = &InstanceError{Instance: p.instances[i].Name, Err: err}
+			}
+		}(i)
+	}
+	wg.Wait()
+	return errors.Join(errs...)
+}
+
+// lastActivity returns when a chat was last active, zero if unknown
+func lastActivity(chat resources.Chat) resources.Timestamp {
+	if chat.LastActivity == nil {
+		return resources.Timestamp{}
+	}
+	return *chat.LastActivity
+}
+
+// source is the page of one instance being merged
+type source[T any] struct {
+	cursor string // the cursor the page was requested with
+	items  []T
+	pos    int     // items consumed
+	next   *string // the cursor of the instance's next page, nil on its last
+}
+
+func (s *source[T]) empty() bool {
+	return s.pos >= len(s.items)
+}
+
+// merged is an item tagged with the index of its instance
+type merged[T any] struct {
+	instance int
+	item     T
+}
+
+// merge fetches the current page of every instance the cursors name and
+// merges them in order. It takes the item that comes first among the
+// instances' next items until limit items are taken or an instance with
+// more pages runs out, since its next page may hold items that come before
+// the others'. The returned cursors record where each instance stopped.
+// Items with the same key, such as a chat reachable through two instances,
+// are taken once.
+func merge[T any](ctx context.Context, p *Pool, cursors pageCursors, limit int,
+	fetch func(ctx context.Context, client beeperdesktop.Client, cursor *string) ([]T, *resources.PaginationInfo, error),
+	key func(T) string, before func(a, b T) bool,
+) ([]merged[T], pageCursors, error) {
+	sources := make([]*source[T], len(p.instances))
+	err := p.fanOut(ctx, cursors.instances(p), func(ctx context.Context, i int) error {
+		at := cursors[p.instances[i].Name]
+		src := &source[T]{cursor: at.Cursor, pos: at.Skip}
+		// A page emptied by the skip, such as after items were deleted, is
+		// followed to the next one
+		for attempt := 0; attempt < maxEmptyPages; attempt++ {
+			items, pagination, err := fetch(ctx, p.instances[i].Client, optional(src.cursor))
+			if err != nil {
+				return err
+			}
+			src.items = items
+			src.next = nil
+			if pagination != nil && pagination.HasMore && pagination.Cursor != nil {
+				src.next = pagination.Cursor
+			}
+			if !src.empty() || src.next == nil {
+				break
+			}
+			src.cursor, src.pos = *src.next, 0
+		}
+		sources[i] = src
+		return nil
+	})
+
+	var out []merged[T]
+	seen := make(map[string]bool)
+	for limit <= 0 || len(out) < limit {
+		best := -1
+		stalled := false
+		for i, src := range sources {
+			switch {
+			case src == nil:
+			case src.empty():
+				stalled = stalled || src.next != nil
+			case best < 0 || before(src.items[src.pos], sources[best].items[sources[best].pos]):
+				best = i
+			}
+		}
+		if stalled || best < 0 {
+			break
+		}
+		src := sources[best]
+		item := src.items[src.pos]
+		src.pos++
+		if k := key(item); !seen[k] {
+			seen[k] = true
+			out = append(out, merged[T]{best, item})
+		}
+	}
+	// Copies of the items taken, left at the front of other instances, are
+	// not carried over to the next page
+	for _, src := range sources {
+		for src != nil && !src.empty() && seen[key(src.items[src.pos])] {
+			src.pos++
+		}
+	}
+
+	next := pageCursors{}
+	for i, src := range sources {
+		name := p.instances[i].Name
+		switch {
+		case src == nil:
+			next.retry(name, cursors)
+		case !src.empty():
+			next[name] = instanceCursor{Cursor: src.cursor, Skip: src.pos}
+		case src.next != nil:
+			next[name] = instanceCursor{Cursor: *src.next}
+		}
+	}
+	return out, next, err
+}
+
+// instanceCursor is how far the pool has read one instance: the cursor of
+// the instance's page being read, empty for its first, and the number of
+// that page's items already returned
+type instanceCursor struct {
+	Cursor string `json:"cursor,omitempty"`
+	Skip   int    `json:"skip,omitempty"`
+}
+
+// pageCursors maps instance names to how far they have been read. An
+// instance missing from a continuation cursor has no more results.
+type pageCursors map[string]instanceCursor
+
+// decodeCursor reads a pool cursor; nil starts at the first page of every
+// instance
+func (p *Pool) decodeCursor(cursor *string) (pageCursors, error) {
+	if cursor == nil || *cursor == "" {
+		return nil, nil
+	}
+	data, err := base64.RawURLEncoding.DecodeString(*cursor)
+	if err != nil {
+		return nil, fmt.Errorf("invalid pool cursor: %w", err)
+	}
+	var cursors pageCursors
+	if err := json.Unmarshal(data, &cursors); err != nil {
+		return nil, fmt.Errorf("invalid pool cursor: %w", err)
+	}
+	for name, at := range cursors {
+		if _, ok := p.index[name]; !ok {
+			return nil, fmt.Errorf("invalid pool cursor: unknown instance %q", name)
+		}
+		if at.Skip < 0 {
+			return nil, fmt.Errorf("invalid pool cursor: negative skip for %q", name)
+		}
+	}
+	return cursors, nil
+}
+
+// instances returns the indexes of the instances to query for this page
+func (c pageCursors) instances(p *Pool) []int {
+	if c == nil {
+		return p.all()
+	}
+	var indexes []int
+	for i, inst := range p.instances {
+		if _, ok := c[inst.Name]; ok {
+			indexes = append(indexes, i)
+		}
+	}
+	return indexes
+}
+
+// retry keeps where a failed instance was, so the next page asks it again.
+// Instances that were not queried stay exhausted.
+func (c pageCursors) retry(name string, queried pageCursors) {
+	if at, ok := queried[name]; ok || queried == nil {
+		c[name] = at
+	}
+}
+
+// encode returns the cursor of the next page, empty when every instance is
+// exhausted
+func (c pageCursors) encode() string {
+	if len(c) == 0 {
+		return ""
+	}
+	data, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+// optional returns nil for an empty cursor, which requests the first page
+func optional(cursor string) *string {
+	if cursor == "" {
+		return nil
+	}
+	return &cursor
+}
+
+func deref(n *int) int {
+	if n == nil {
+		return 0
+	}
+	return *n
+}
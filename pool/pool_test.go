@@ -0,0 +1,245 @@
+package pool_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	beeperdesktop "github.com/cameronaaron/beeper-go-sdk"
+	"github.com/cameronaaron/beeper-go-sdk/beepermock"
+	"github.com/cameronaaron/beeper-go-sdk/beepertest"
+	"github.com/cameronaaron/beeper-go-sdk/pool"
+	"github.com/cameronaaron/beeper-go-sdk/resources"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// laptopFixtures shares the matrix account with the default fixtures and adds
+// a Telegram account only the laptop has
+func laptopFixtures() beepertest.Fixtures {
+	base := time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)
+	me := resources.User{ID: "@me:beeper.com", IsSelf: beeperdesktop.BoolPtr(true)}
+	dave := resources.User{ID: "dave", FullName: beeperdesktop.StringPtr("Dave Green")}
+
+	return beepertest.Fixtures{
+		Accounts: []resources.Account{
+			{AccountID: "matrix", Network: "Beeper (Matrix)", User: me},
+			{AccountID: "telegram", Network: "Telegram", User: resources.User{ID: "tg-me", IsSelf: beeperdesktop.BoolPtr(true)}},
+		},
+		Chats: []resources.Chat{
+			{ID: "chat-dave", AccountID: "telegram", Network: "Telegram", Title: "Dave Green", Type: "single",
+				Participants: resources.ChatParticipants{Items: []resources.User{me, dave}, Total: 2}},
+		},
+		Messages: []resources.Message{
+			{ID: "tg-1", ChatID: "chat-dave", AccountID: "telegram", SenderID: "dave", Text: beeperdesktop.StringPtr("Lunch?"),
+				Timestamp: resources.NewTimestamp(base)},
+		},
+	}
+}
+
+func newPool(t *testing.T) (*pool.Pool, *beepertest.Server, *beepertest.Server) {
+	desktop := beepertest.NewServer(beepertest.DefaultFixtures())
+	t.Cleanup(desktop.Close)
+	laptop := beepertest.NewServer(laptopFixtures())
+	t.Cleanup(laptop.Close)
+
+	desktopClient, err := desktop.Client()
+	require.NoError(t, err)
+	laptopClient, err := laptop.Client()
+	require.NoError(t, err)
+
+	p, err := pool.New(
+		pool.Instance{Name: "desktop", Client: desktopClient},
+		pool.Instance{Name: "laptop", Client: laptopClient},
+	)
+	require.NoError(t, err)
+	return p, desktop, laptop
+}
+
+func TestNewValidatesInstances(t *testing.T) {
+	server := beepertest.NewServer(beepertest.DefaultFixtures())
+	defer server.Close()
+	client, err := server.Client()
+	require.NoError(t, err)
+
+	_, err = pool.New()
+	assert.Error(t, err)
+	_, err = pool.New(pool.Instance{Client: client})
+	assert.Error(t, err)
+	_, err = pool.New(pool.Instance{Name: "a", Client: client}, pool.Instance{Name: "a", Client: client})
+	assert.EqualError(t, err, `duplicate instance name "a"`)
+}
+
+func TestPoolAccounts(t *testing.T) {
+	p, _, _ := newPool(t)
+
+	accounts, err := p.Accounts(context.Background())
+	require.NoError(t, err)
+
+	var got []string
+	for _, account := range accounts {
+		got = append(got, account.Instance+"/"+account.AccountID)
+	}
+	assert.Equal(t, []string{"desktop/matrix", "desktop/whatsapp", "laptop/telegram"}, got)
+}
+
+func TestPoolSearchChatsPages(t *testing.T) {
+	p, _, _ := newPool(t)
+	ctx := context.Background()
+
+	params := resources.ChatSearchParams{Limit: beeperdesktop.IntPtr(2)}
+	first, err := p.SearchChats(ctx, params)
+	require.NoError(t, err)
+	require.Len(t, first.Items, 2, "up to the limit")
+	require.True(t, first.HasMore())
+
+	owners := map[string]string{}
+	pages := []*pool.Page[pool.Chat]{first}
+	for page := first; page.HasMore(); {
+		params.Cursor = &page.Cursor
+		page, err = p.SearchChats(ctx, params)
+		require.NoError(t, err)
+		pages = append(pages, page)
+	}
+	var chats []pool.Chat
+	for _, page := range pages {
+		chats = append(chats, page.Items...)
+	}
+	for i, chat := range chats {
+		assert.NotContains(t, owners, chat.ID)
+		owners[chat.ID] = chat.Instance
+		if i > 0 {
+			prev := chats[i-1]
+			assert.False(t, chat.LastActivity.After(prev.LastActivity.Time), "most recently active first, across pages")
+		}
+	}
+	assert.Equal(t, map[string]string{
+		"chat-alice":  "desktop",
+		"chat-team":   "desktop",
+		"chat-family": "desktop",
+		"chat-dave":   "laptop",
+	}, owners)
+
+	_, err = p.SearchChats(ctx, resources.ChatSearchParams{Cursor: beeperdesktop.StringPtr("not-a-cursor")})
+	assert.Error(t, err)
+}
+
+func TestPoolSearchMessagesMerges(t *testing.T) {
+	p, _, _ := newPool(t)
+
+	page, err := p.SearchMessages(context.Background(), resources.MessageSearchParams{})
+	require.NoError(t, err)
+	require.NotEmpty(t, page.Items)
+
+	var laptop int
+	for i, msg := range page.Items {
+		if msg.Instance == "laptop" {
+			laptop++
+			assert.Equal(t, "tg-1", msg.ID)
+		}
+		if i > 0 {
+			assert.False(t, msg.Timestamp.After(page.Items[i-1].Timestamp.Time), "newest first")
+		}
+	}
+	assert.Equal(t, 1, laptop)
+}
+
+func TestPoolSearchMessagesOrderAcrossPages(t *testing.T) {
+	desktop := beepertest.NewServer(beepertest.DefaultFixtures())
+	t.Cleanup(desktop.Close)
+	// The laptop's newest message is newer than all of the desktop's and the
+	// rest are older, so merging page by page would put them out of order
+	fixtures := laptopFixtures()
+	for i, hour := range []int{8, 7} {
+		fixtures.Messages = append(fixtures.Messages, resources.Message{
+			ID: fmt.Sprintf("tg-%d", i+2), ChatID: "chat-dave", AccountID: "telegram", SenderID: "dave",
+			Text: beeperdesktop.StringPtr("earlier"), Timestamp: resources.NewTimestamp(time.Date(2024, 6, 1, hour, 0, 0, 0, time.UTC)),
+		})
+	}
+	laptop := beepertest.NewServer(fixtures)
+	t.Cleanup(laptop.Close)
+	desktopClient, err := desktop.Client()
+	require.NoError(t, err)
+	laptopClient, err := laptop.Client()
+	require.NoError(t, err)
+	p, err := pool.New(pool.Instance{Name: "desktop", Client: desktopClient}, pool.Instance{Name: "laptop", Client: laptopClient})
+	require.NoError(t, err)
+
+	var ids []string
+	params := resources.MessageSearchParams{Limit: beeperdesktop.IntPtr(2)}
+	for pages := 0; ; pages++ {
+		require.Less(t, pages, 10)
+		page, err := p.SearchMessages(context.Background(), params)
+		require.NoError(t, err)
+		assert.LessOrEqual(t, len(page.Items), 2)
+		for _, msg := range page.Items {
+			ids = append(ids, msg.ID)
+		}
+		if !page.HasMore() {
+			break
+		}
+		params.Cursor = &page.Cursor
+	}
+	assert.Equal(t, []string{"tg-1", "msg-5", "msg-4", "msg-3", "msg-2", "msg-1", "tg-2", "tg-3"}, ids)
+}
+
+func TestPoolSendRoutesToOwner(t *testing.T) {
+	p, desktop, laptop := newPool(t)
+	ctx := context.Background()
+
+	// Never seen in a search, so the owner is found by asking each instance
+	sent, err := p.Send(ctx, resources.MessageSendParams{ChatID: "chat-dave", Text: "Sure"})
+	require.NoError(t, err)
+	assert.True(t, sent.Success)
+
+	owner, err := p.Owner(ctx, "chat-dave")
+	require.NoError(t, err)
+	assert.Equal(t, "laptop", owner.Name)
+	assert.Len(t, laptop.Messages("chat-dave"), 2)
+
+	before := len(desktop.Messages("chat-alice"))
+	_, err = p.Send(ctx, resources.MessageSendParams{ChatID: "chat-alice", Text: "Hi"})
+	require.NoError(t, err)
+	assert.Len(t, desktop.Messages("chat-alice"), before+1)
+
+	_, err = p.Send(ctx, resources.MessageSendParams{ChatID: "chat-nowhere", Text: "?"})
+	assert.ErrorIs(t, err, pool.ErrUnknownChat)
+}
+
+func TestPoolSendChecksSuccess(t *testing.T) {
+	client := beepermock.NewClient()
+	client.Chats.RetrieveFunc = func(ctx context.Context, params resources.ChatRetrieveParams) (*resources.Chat, error) {
+		return &resources.Chat{ID: params.ChatID}, nil
+	}
+	client.Messages.SendFunc = func(ctx context.Context, params resources.MessageSendParams) (*resources.MessageSendResponse, error) {
+		return &resources.MessageSendResponse{Error: "chat is read-only"}, nil
+	}
+	p, err := pool.New(pool.Instance{Name: "desktop", Client: client})
+	require.NoError(t, err)
+
+	_, err = p.Send(context.Background(), resources.MessageSendParams{ChatID: "chat-alice", Text: "Hi"})
+	var sendErr *beeperdesktop.SendError
+	require.ErrorAs(t, err, &sendErr)
+	var instErr *pool.InstanceError
+	require.ErrorAs(t, err, &instErr)
+	assert.Equal(t, "desktop", instErr.Instance)
+}
+
+func TestPoolPartialFailure(t *testing.T) {
+	p, _, laptop := newPool(t)
+	laptop.Close()
+
+	accounts, err := p.Accounts(context.Background())
+	require.Error(t, err)
+	var instErr *pool.InstanceError
+	require.True(t, errors.As(err, &instErr))
+	assert.Equal(t, "laptop", instErr.Instance)
+	assert.Len(t, accounts, 2, "accounts from the instances that answered")
+
+	page, err := p.SearchChats(context.Background(), resources.ChatSearchParams{Limit: beeperdesktop.IntPtr(10)})
+	require.Error(t, err)
+	assert.Len(t, page.Items, 3)
+	assert.True(t, page.HasMore(), "the failed instance is asked again on the next page")
+}
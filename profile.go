@@ -0,0 +1,191 @@
+package beeperdesktop
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// ConfigFile holds named connection profiles, one per Desktop install:
+//
+//	default: work
+//	profiles:
+//	  work:
+//	    base_url: http://localhost:23373
+//	    token_command: op read op://Work/Beeper/token
+//	    timeout: 10s
+//	  personal:
+//	    base_url: unix:///run/user/1000/beeper-personal.sock
+//	    token: bdt_...
+//	    max_retries: 0
+//	    proxy: http://127.0.0.1:8888
+type ConfigFile struct {
+	Default  string             `yaml:"default"`
+	Profiles map[string]Profile `yaml:"profiles"`
+}
+
+// Profile is one named set of connection settings. Empty fields leave the
+// client defaults alone.
+type Profile struct {
+	BaseURL      string        `yaml:"base_url"`
+	Token        string        `yaml:"token"`
+	TokenCommand string        `yaml:"token_command"` // run with the shell; its trimmed output is the token
+	Timeout      time.Duration `yaml:"timeout"`
+	MaxRetries   *int          `yaml:"max_retries"`
+	Proxy        string        `yaml:"proxy"`
+	AutoDiscover bool          `yaml:"auto_discover"`
+}
+
+// tokenCommandTimeout bounds a profile's token command
+const tokenCommandTimeout = 30 * time.Second
+
+// DefaultConfigPath returns BEEPER_CONFIG if set, otherwise
+// $XDG_CONFIG_HOME/beeper/config.yaml, falling back to ~/.config
+func DefaultConfigPath() string {
+	if path := os.Getenv("BEEPER_CONFIG"); path != "" {
+		return path
+	}
+	dir := os.Getenv("XDG_CONFIG_HOME")
+	if dir == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return ""
+		}
+		dir = filepath.Join(home, ".config")
+	}
+	return filepath.Join(dir, "beeper", "config.yaml")
+}
+
+// LoadConfigFile reads a profiles file. Unknown keys are an error so typos do
+// not go unnoticed.
+func LoadConfigFile(path string) (*ConfigFile, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var config ConfigFile
+	decoder := yaml.NewDecoder(f)
+	decoder.KnownFields(true)
+	if err := decoder.Decode(&config); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return &config, nil
+}
+
+// Profile returns a profile by name. An empty name selects the file's
+// default, or its only profile.
+func (f *ConfigFile) Profile(name string) (Profile, string, error) {
+	if name == "" {
+		name = f.Default
+	}
+	if name == "" && len(f.Profiles) == 1 {
+		for only := range f.Profiles {
+			name = only
+		}
+	}
+	if name == "" {
+		return Profile{}, "", errors.New("no profile selected and the config file sets no default")
+	}
+
+	profile, ok := f.Profiles[name]
+	if !ok {
+		names := make([]string, 0, len(f.Profiles))
+		for n := range f.Profiles {
+			names = append(names, n)
+		}
+		sort.Strings(names)
+		return Profile{}, "", fmt.Errorf("no profile %q (have %s)", name, strings.Join(names, ", "))
+	}
+	return profile, name, nil
+}
+
+// apply copies the profile's settings into a client config
+func (p Profile) apply(c *ClientConfig) {
+	if p.BaseURL != "" {
+		c.BaseURL = p.BaseURL
+	}
+	if p.Token != "" {
+		c.AccessToken = p.Token
+	}
+	if p.TokenCommand != "" {
+		// The command runs only if no option sets a token afterwards
+		c.AccessToken = ""
+		c.TokenCommand = p.TokenCommand
+	}
+	if p.Timeout != 0 {
+		c.Timeout = p.Timeout
+	}
+	if p.MaxRetries != nil {
+		c.MaxRetries = *p.MaxRetries
+	}
+	if p.Proxy != "" {
+		c.Proxy = p.Proxy
+	}
+	if p.AutoDiscover {
+		c.AutoDiscover = true
+	}
+}
+
+// applyProfile loads the profile requested by WithProfile or BEEPER_PROFILE
+// into c. Without an explicit name a missing config file is not an error.
+func applyProfile(c *ClientConfig) error {
+	name := c.Profile
+	if name == "" {
+		name = os.Getenv("BEEPER_PROFILE")
+	}
+	path := c.ConfigPath
+	if path == "" {
+		path = DefaultConfigPath()
+	}
+
+	file, err := LoadConfigFile(path)
+	if errors.Is(err, os.ErrNotExist) && name == "" {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("load profile: %w", err)
+	}
+
+	profile, _, err := file.Profile(name)
+	if err != nil {
+		return fmt.Errorf("%s: %w", path, err)
+	}
+	profile.apply(c)
+	return nil
+}
+
+// runTokenCommand runs a profile's token command with the shell and returns
+// its trimmed output
+func runTokenCommand(command string) (string, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), tokenCommandTimeout)
+	defer cancel()
+
+	var cmd *exec.Cmd
+	if runtime.GOOS == "windows" {
+		cmd = exec.CommandContext(ctx, "cmd", "/C", command)
+	} else {
+		cmd = exec.CommandContext(ctx, "sh", "-c", command)
+	}
+	cmd.Stderr = os.Stderr
+
+	out, err := cmd.Output()
+	if err != nil {
+		return "", fmt.Errorf("token command %q: %w", command, err)
+	}
+	token := strings.TrimSpace(string(out))
+	if token == "" {
+		return "", fmt.Errorf("token command %q printed nothing", command)
+	}
+	return token, nil
+}
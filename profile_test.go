@@ -0,0 +1,103 @@
+package beeperdesktop
+
+import (
+	"net/http"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testConfig = `
+default: work
+profiles:
+  work:
+    base_url: http://localhost:23374
+    token: work-token
+    timeout: 5s
+  personal:
+    base_url: unix:///tmp/beeper-personal.sock
+    token_command: echo personal-token
+    max_retries: 0
+    proxy: http://127.0.0.1:8888
+`
+
+func writeConfig(t *testing.T, content string) string {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	require.NoError(t, os.WriteFile(path, []byte(content), 0o600))
+	return path
+}
+
+func TestWithProfile(t *testing.T) {
+	t.Setenv("BEEPER_ACCESS_TOKEN", "env-token")
+	t.Setenv("BEEPER_DESKTOP_BASE_URL", "")
+	t.Setenv("BEEPER_PROFILE", "")
+	path := writeConfig(t, testConfig)
+
+	t.Run("default profile", func(t *testing.T) {
+		client, err := New(WithConfigFile(path), WithProfile(""))
+		require.NoError(t, err)
+		assert.Equal(t, "http://localhost:23374/", client.baseURL)
+		assert.Equal(t, "work-token", client.accessToken)
+		assert.Equal(t, 5*time.Second, client.timeout)
+		assert.Equal(t, 2, client.maxRetries)
+	})
+
+	t.Run("options win whatever their order", func(t *testing.T) {
+		client, err := New(WithTimeout(time.Minute), WithConfigFile(path), WithProfile("work"), WithAccessToken("flag-token"))
+		require.NoError(t, err)
+		assert.Equal(t, time.Minute, client.timeout)
+		assert.Equal(t, "flag-token", client.accessToken)
+	})
+
+	t.Run("token command, retries and proxy", func(t *testing.T) {
+		if runtime.GOOS == "windows" {
+			t.Skip("the token command uses the POSIX shell's echo")
+		}
+		client, err := New(WithConfigFile(path), WithProfile("personal"))
+		require.NoError(t, err)
+		assert.Equal(t, "personal-token", client.accessToken)
+		assert.Equal(t, 0, client.maxRetries)
+		assert.Equal(t, socketBaseURL, client.baseURL)
+
+		req, _ := http.NewRequest("GET", "http://example.test/", nil)
+		proxy, err := client.httpClient.Transport.(*http.Transport).Proxy(req)
+		require.NoError(t, err)
+		assert.Equal(t, "http://127.0.0.1:8888", proxy.String())
+	})
+
+	t.Run("BEEPER_PROFILE", func(t *testing.T) {
+		t.Setenv("BEEPER_PROFILE", "work")
+		t.Setenv("BEEPER_CONFIG", path)
+		client, err := New()
+		require.NoError(t, err)
+		assert.Equal(t, "work-token", client.accessToken)
+	})
+
+	t.Run("unknown profile", func(t *testing.T) {
+		_, err := New(WithConfigFile(path), WithProfile("home"))
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), `no profile "home" (have personal, work)`)
+	})
+
+	t.Run("missing config file", func(t *testing.T) {
+		missing := filepath.Join(t.TempDir(), "config.yaml")
+		client, err := New(WithConfigFile(missing), WithProfile(""))
+		require.NoError(t, err, "an unnamed profile is optional")
+		assert.Equal(t, "env-token", client.accessToken)
+
+		_, err = New(WithConfigFile(missing), WithProfile("work"))
+		assert.ErrorIs(t, err, os.ErrNotExist)
+	})
+
+	t.Run("unknown keys", func(t *testing.T) {
+		bad := writeConfig(t, "profiles:\n  work:\n    baseurl: http://localhost:1\n")
+		_, err := New(WithConfigFile(bad), WithProfile("work"))
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "baseurl")
+	})
+}
@@ -0,0 +1,176 @@
+package reminders
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/cameronaaron/beeper-go-sdk/resources"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseRule(t *testing.T) {
+	rule, err := ParseRule("RRULE:FREQ=weekly;INTERVAL=2;BYDAY=FR,MO;COUNT=4")
+	require.NoError(t, err)
+	assert.Equal(t, Weekly, rule.Freq)
+	assert.Equal(t, 2, rule.Interval)
+	assert.Equal(t, []time.Weekday{time.Monday, time.Friday}, rule.ByDay)
+	assert.Equal(t, "FREQ=WEEKLY;INTERVAL=2;BYDAY=MO,FR;COUNT=4", rule.String())
+
+	for _, input := range []string{"", "INTERVAL=2", "FREQ=SECONDLY", "FREQ=DAILY;BYDAY=MO", "FREQ=DAILY;COUNT=0"} {
+		_, err := ParseRule(input)
+		assert.Error(t, err, input)
+	}
+}
+
+func TestRuleNext(t *testing.T) {
+	// Wednesday 2024-06-05 09:00 UTC
+	start := time.Date(2024, 6, 5, 9, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name  string
+		rule  string
+		start time.Time
+		after time.Time
+		want  time.Time
+	}{
+		{"daily first", "FREQ=DAILY", start, start.Add(-time.Hour), start},
+		{"daily next", "FREQ=DAILY", start, start, start.AddDate(0, 0, 1)},
+		{"every other day", "FREQ=DAILY;INTERVAL=2", start, start.Add(time.Hour), start.AddDate(0, 0, 2)},
+		{"weekly byday same week", "FREQ=WEEKLY;BYDAY=MO,FR", start, start, time.Date(2024, 6, 7, 9, 0, 0, 0, time.UTC)},
+		{"weekly byday next week", "FREQ=WEEKLY;BYDAY=MO,FR", start, time.Date(2024, 6, 7, 9, 0, 0, 0, time.UTC), time.Date(2024, 6, 10, 9, 0, 0, 0, time.UTC)},
+		{"monthly skips short months", "FREQ=MONTHLY", time.Date(2024, 1, 31, 9, 0, 0, 0, time.UTC), time.Date(2024, 1, 31, 9, 0, 0, 0, time.UTC), time.Date(2024, 3, 31, 9, 0, 0, 0, time.UTC)},
+		{"count exhausted", "FREQ=DAILY;COUNT=2", start, start.AddDate(0, 0, 1), time.Time{}},
+		{"until exhausted", "FREQ=DAILY;UNTIL=20240606T090000Z", start, start.AddDate(0, 0, 1), time.Time{}},
+		{"hourly long after start", "FREQ=HOURLY", start, start.AddDate(20, 0, 0).Add(30 * time.Minute), start.AddDate(20, 0, 0).Add(time.Hour)},
+		{"hourly count long after start", "FREQ=HOURLY;COUNT=200000", start, start.Add(199998 * time.Hour), start.Add(199999 * time.Hour)},
+		{"hourly count exhausted long after start", "FREQ=HOURLY;COUNT=200000", start, start.Add(199999 * time.Hour), time.Time{}},
+		{"daily long after start", "FREQ=DAILY;INTERVAL=3", start, start.AddDate(400, 0, 0), start.AddDate(400, 0, 3)},
+		{"weekly byday count long after start", "FREQ=WEEKLY;BYDAY=MO,FR;COUNT=2001", start, time.Date(2043, 8, 6, 9, 0, 0, 0, time.UTC), time.Date(2043, 8, 7, 9, 0, 0, 0, time.UTC)},
+		{"weekly byday count exhausted long after start", "FREQ=WEEKLY;BYDAY=MO,FR;COUNT=2001", start, time.Date(2043, 8, 7, 9, 0, 0, 0, time.UTC), time.Time{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rule, err := ParseRule(tt.rule)
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, rule.Next(tt.start, tt.after))
+		})
+	}
+}
+
+type fakeReminders struct {
+	created   []resources.ReminderCreateParams
+	deleted   []string
+	createErr error
+}
+
+func (f *fakeReminders) Create(ctx context.Context, params resources.ReminderCreateParams) (*resources.BaseResponse, error) {
+	if f.createErr != nil {
+		return nil, f.createErr
+	}
+	f.created = append(f.created, params)
+	return &resources.BaseResponse{Success: true}, nil
+}
+
+func (f *fakeReminders) Delete(ctx context.Context, params resources.ReminderDeleteParams) (*resources.BaseResponse, error) {
+	f.deleted = append(f.deleted, params.ChatID)
+	return &resources.BaseResponse{Success: true}, nil
+}
+
+func TestSchedulerRearmsAndPersists(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "reminders.json")
+	start := time.Date(2024, 6, 5, 9, 0, 0, 0, time.UTC)
+	now := start.Add(-time.Hour)
+
+	client := &fakeReminders{}
+	scheduler, err := NewScheduler(client, path)
+	require.NoError(t, err)
+	scheduler.now = func() time.Time { return now }
+
+	message := "standup"
+	schedule, err := scheduler.Add(ctx, "chat-1", "FREQ=DAILY;COUNT=2", start, &message)
+	require.NoError(t, err)
+	require.NotNil(t, schedule.NextAt)
+	assert.Equal(t, start, *schedule.NextAt)
+	require.Len(t, client.created, 1)
+	assert.Equal(t, "standup", *client.created[0].Message)
+
+	// Nothing to do before the armed occurrence fires
+	require.NoError(t, scheduler.Sync(ctx))
+	assert.Len(t, client.created, 1)
+
+	// A restarted scheduler picks up the saved state and re-arms after the fire
+	restarted, err := NewScheduler(client, path)
+	require.NoError(t, err)
+	now = start.Add(time.Minute)
+	restarted.now = func() time.Time { return now }
+
+	require.NoError(t, restarted.Sync(ctx))
+	require.Len(t, client.created, 2)
+	assert.Equal(t, start.AddDate(0, 0, 1), client.created[1].Timestamp)
+
+	schedules := restarted.List()
+	require.Len(t, schedules, 1)
+	require.NotNil(t, schedules[0].LastFiredAt)
+	assert.Equal(t, start, *schedules[0].LastFiredAt)
+
+	// Once COUNT is reached the schedule is dropped
+	now = start.AddDate(0, 0, 1).Add(time.Minute)
+	require.NoError(t, restarted.Sync(ctx))
+	assert.Len(t, client.created, 2)
+	assert.Empty(t, restarted.List())
+
+	_, err = restarted.Add(ctx, "chat-2", "FREQ=DAILY;COUNT=2", start, nil)
+	assert.EqualError(t, err, "recurrence rule FREQ=DAILY;COUNT=2 has no occurrences left")
+	assert.Len(t, client.created, 2)
+
+	_, err = restarted.Add(ctx, "chat-2", "FREQ=WEEKLY", start, nil)
+	require.NoError(t, err)
+	require.NoError(t, restarted.Remove(ctx, "chat-2"))
+	assert.Equal(t, []string{"chat-2"}, client.deleted)
+	assert.Error(t, restarted.Remove(ctx, "chat-2"))
+}
+
+func TestSchedulerAddKeepsPreviousOnFailure(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "reminders.json")
+	start := time.Date(2024, 6, 5, 9, 0, 0, 0, time.UTC)
+
+	client := &fakeReminders{}
+	scheduler, err := NewScheduler(client, path)
+	require.NoError(t, err)
+	scheduler.now = func() time.Time { return start.Add(-time.Hour) }
+
+	_, err = scheduler.Add(ctx, "chat-1", "FREQ=DAILY", start, nil)
+	require.NoError(t, err)
+	before := scheduler.List()
+
+	// A failed arm leaves the existing schedule in place
+	client.createErr = errors.New("desktop unavailable")
+	_, err = scheduler.Add(ctx, "chat-1", "FREQ=WEEKLY", start, nil)
+	assert.ErrorIs(t, err, client.createErr)
+	assert.Equal(t, before, scheduler.List())
+
+	_, err = scheduler.Add(ctx, "chat-2", "FREQ=WEEKLY", start, nil)
+	assert.Error(t, err)
+	assert.Equal(t, before, scheduler.List())
+	client.createErr = nil
+
+	// So does a failed save, whether the schedule replaces one or is new
+	require.NoError(t, os.Remove(path))
+	require.NoError(t, os.Mkdir(path, 0o755))
+
+	_, err = scheduler.Add(ctx, "chat-1", "FREQ=WEEKLY", start, nil)
+	assert.Error(t, err)
+	assert.Equal(t, before, scheduler.List())
+
+	_, err = scheduler.Add(ctx, "chat-2", "FREQ=WEEKLY", start, nil)
+	assert.Error(t, err)
+	assert.Equal(t, before, scheduler.List())
+}
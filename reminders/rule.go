@@ -0,0 +1,246 @@
+package reminders
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Frequency is the base cadence of a recurrence rule
+type Frequency string
+
+const (
+	Hourly  Frequency = "HOURLY"
+	Daily   Frequency = "DAILY"
+	Weekly  Frequency = "WEEKLY"
+	Monthly Frequency = "MONTHLY"
+	Yearly  Frequency = "YEARLY"
+)
+
+// maxOccurrences bounds the search for the next occurrence of a rule, counted
+// from the period Next starts scanning at
+const maxOccurrences = 100000
+
+var weekdayCodes = map[string]time.Weekday{
+	"SU": time.Sunday,
+	"MO": time.Monday,
+	"TU": time.Tuesday,
+	"WE": time.Wednesday,
+	"TH": time.Thursday,
+	"FR": time.Friday,
+	"SA": time.Saturday,
+}
+
+// Rule is a subset of the iCalendar RRULE format supporting FREQ, INTERVAL,
+// BYDAY (weekly rules only), COUNT and UNTIL
+type Rule struct {
+	Freq     Frequency
+	Interval int
+	ByDay    []time.Weekday
+	Count    int
+	Until    time.Time
+}
+
+// ParseRule parses an RRULE string such as "FREQ=WEEKLY;INTERVAL=2;BYDAY=MO,FR"
+func ParseRule(s string) (*Rule, error) {
+	s = strings.TrimPrefix(strings.TrimSpace(s), "RRULE:")
+	if s == "" {
+		return nil, fmt.Errorf("empty recurrence rule")
+	}
+
+	rule := &Rule{Interval: 1}
+	for _, part := range strings.Split(s, ";") {
+		key, value, ok := strings.Cut(part, "=")
+		if !ok {
+			return nil, fmt.Errorf("invalid rule part %q", part)
+		}
+
+		switch strings.ToUpper(key) {
+		case "FREQ":
+			freq := Frequency(strings.ToUpper(value))
+			switch freq {
+			case Hourly, Daily, Weekly, Monthly, Yearly:
+				rule.Freq = freq
+			default:
+				return nil, fmt.Errorf("unsupported frequency %q", value)
+			}
+		case "INTERVAL":
+			n, err := strconv.Atoi(value)
+			if err != nil || n < 1 {
+				return nil, fmt.Errorf("invalid interval %q", value)
+			}
+			rule.Interval = n
+		case "BYDAY":
+			for _, code := range strings.Split(value, ",") {
+				day, ok := weekdayCodes[strings.ToUpper(code)]
+				if !ok {
+					return nil, fmt.Errorf("invalid weekday %q", code)
+				}
+				rule.ByDay = append(rule.ByDay, day)
+			}
+		case "COUNT":
+			n, err := strconv.Atoi(value)
+			if err != nil || n < 1 {
+				return nil, fmt.Errorf("invalid count %q", value)
+			}
+			rule.Count = n
+		case "UNTIL":
+			until, err := parseUntil(value)
+			if err != nil {
+				return nil, err
+			}
+			rule.Until = until
+		default:
+			return nil, fmt.Errorf("unsupported rule part %q", key)
+		}
+	}
+
+	if rule.Freq == "" {
+		return nil, fmt.Errorf("recurrence rule is missing FREQ")
+	}
+	if len(rule.ByDay) > 0 && rule.Freq != Weekly {
+		return nil, fmt.Errorf("BYDAY is only supported with FREQ=WEEKLY")
+	}
+
+	sort.Slice(rule.ByDay, func(i, j int) bool {
+		return mondayOffset(rule.ByDay[i]) < mondayOffset(rule.ByDay[j])
+	})
+
+	return rule, nil
+}
+
+// String formats the rule back into RRULE syntax
+func (r *Rule) String() string {
+	parts := []string{"FREQ=" + string(r.Freq)}
+	if r.Interval > 1 {
+		parts = append(parts, "INTERVAL="+strconv.Itoa(r.Interval))
+	}
+	if len(r.ByDay) > 0 {
+		codes := make([]string, 0, len(r.ByDay))
+		for _, day := range r.ByDay {
+			codes = append(codes, strings.ToUpper(day.String()[:2]))
+		}
+		parts = append(parts, "BYDAY="+strings.Join(codes, ","))
+	}
+	if r.Count > 0 {
+		parts = append(parts, "COUNT="+strconv.Itoa(r.Count))
+	}
+	if !r.Until.IsZero() {
+		parts = append(parts, "UNTIL="+r.Until.UTC().Format("20060102T150405Z"))
+	}
+	return strings.Join(parts, ";")
+}
+
+// Next returns the first occurrence strictly after the given time for a rule
+// anchored at start, or the zero time when the rule is exhausted
+func (r *Rule) Next(start, after time.Time) time.Time {
+	interval := r.Interval
+	if interval < 1 {
+		interval = 1
+	}
+
+	first, emitted := r.skip(start, after, interval)
+	for period := first; period < first+maxOccurrences; period++ {
+		for _, occurrence := range r.period(start, period*interval) {
+			if occurrence.Before(start) {
+				continue
+			}
+			if !r.Until.IsZero() && occurrence.After(r.Until) {
+				return time.Time{}
+			}
+			emitted++
+			if r.Count > 0 && emitted > r.Count {
+				return time.Time{}
+			}
+			if occurrence.After(after) {
+				return occurrence
+			}
+		}
+	}
+
+	return time.Time{}
+}
+
+// skip returns the period Next can start scanning at so that it does not
+// walk every period since start, along with the number of occurrences the
+// skipped periods emitted. Monthly and yearly rules are scanned from start.
+func (r *Rule) skip(start, after time.Time, interval int) (int, int) {
+	var unit time.Duration
+	switch r.Freq {
+	case Hourly:
+		unit = time.Hour
+	case Daily:
+		unit = 24 * time.Hour
+	case Weekly:
+		unit = 7 * 24 * time.Hour
+	default:
+		return 0, 0
+	}
+
+	// Step back one period to absorb daylight saving shifts in calendar units
+	period := int(after.Sub(start)/(unit*time.Duration(interval))) - 1
+	if period <= 0 {
+		return 0, 0
+	}
+
+	if len(r.ByDay) == 0 {
+		return period, period
+	}
+	emitted := period * len(r.ByDay)
+	for _, occurrence := range r.period(start, 0) {
+		if occurrence.Before(start) {
+			emitted--
+		}
+	}
+	return period, emitted
+}
+
+// period returns the occurrences in the n-th frequency unit after start
+func (r *Rule) period(start time.Time, n int) []time.Time {
+	switch r.Freq {
+	case Hourly:
+		return []time.Time{start.Add(time.Duration(n) * time.Hour)}
+	case Daily:
+		return []time.Time{start.AddDate(0, 0, n)}
+	case Weekly:
+		if len(r.ByDay) == 0 {
+			return []time.Time{start.AddDate(0, 0, 7*n)}
+		}
+		weekStart := start.AddDate(0, 0, -mondayOffset(start.Weekday())+7*n)
+		occurrences := make([]time.Time, 0, len(r.ByDay))
+		for _, day := range r.ByDay {
+			occurrences = append(occurrences, weekStart.AddDate(0, 0, mondayOffset(day)))
+		}
+		return occurrences
+	case Monthly:
+		// Skip months that do not contain the start day, as RRULE does
+		if next := start.AddDate(0, n, 0); next.Day() == start.Day() {
+			return []time.Time{next}
+		}
+		return nil
+	case Yearly:
+		if next := start.AddDate(n, 0, 0); next.Day() == start.Day() {
+			return []time.Time{next}
+		}
+		return nil
+	default:
+		return nil
+	}
+}
+
+// mondayOffset returns the number of days since Monday for the given weekday
+func mondayOffset(day time.Weekday) int {
+	return (int(day) + 6) % 7
+}
+
+// parseUntil accepts both the iCalendar basic format and RFC3339
+func parseUntil(value string) (time.Time, error) {
+	for _, layout := range []string{"20060102T150405Z", "20060102", time.RFC3339} {
+		if t, err := time.Parse(layout, value); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("invalid until %q", value)
+}
@@ -0,0 +1,248 @@
+// Package reminders provides a client-side scheduler for recurring chat
+// reminders on top of the single-shot Reminders API
+package reminders
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/cameronaaron/beeper-go-sdk/internal/jsonfile"
+	"github.com/cameronaaron/beeper-go-sdk/resources"
+)
+
+// ReminderClient is the subset of resources.Reminders used by the scheduler
+type ReminderClient interface {
+	Create(ctx context.Context, params resources.ReminderCreateParams) (*resources.BaseResponse, error)
+	Delete(ctx context.Context, params resources.ReminderDeleteParams) (*resources.BaseResponse, error)
+}
+
+// Schedule is a recurring reminder for a single chat
+type Schedule struct {
+	ChatID      string     `json:"chatID"`
+	Rule        string     `json:"rule"`
+	Start       time.Time  `json:"start"`
+	Message     *string    `json:"message,omitempty"`
+	NextAt      *time.Time `json:"nextAt,omitempty"`      // Occurrence currently armed on Desktop
+	LastFiredAt *time.Time `json:"lastFiredAt,omitempty"` // Most recent occurrence that has passed
+}
+
+// Scheduler keeps one reminder armed per schedule and re-arms it after each fire.
+// Desktop holds at most one reminder per chat, so schedules are keyed by chat ID.
+type Scheduler struct {
+	client   ReminderClient
+	store    *jsonfile.Store[[]*Schedule]
+	interval time.Duration
+	now      func() time.Time
+
+	mu        sync.Mutex
+	schedules map[string]*Schedule
+}
+
+// NewScheduler creates a scheduler that persists its schedules to the given
+// file, loading any previously saved state
+func NewScheduler(client ReminderClient, path string) (*Scheduler, error) {
+	s := &Scheduler{
+		client:    client,
+		store:     jsonfile.NewStore[[]*Schedule](path, "schedules"),
+		interval:  time.Minute,
+		now:       time.Now,
+		schedules: make(map[string]*Schedule),
+	}
+
+	if err := s.load(); err != nil {
+		return nil, err
+	}
+
+	return s, nil
+}
+
+// SetInterval changes how often Run checks for fired reminders
+func (s *Scheduler) SetInterval(interval time.Duration) {
+	s.mu.Lock()
+	s.interval = interval
+	s.mu.Unlock()
+}
+
+// Add registers a recurring reminder for a chat, replacing any existing
+// schedule for that chat, and arms its first occurrence
+func (s *Scheduler) Add(ctx context.Context, chatID, rule string, start time.Time, message *string) (*Schedule, error) {
+	parsed, err := ParseRule(rule)
+	if err != nil {
+		return nil, err
+	}
+	if parsed.Next(start, s.now()).IsZero() {
+		return nil, fmt.Errorf("recurrence rule %s has no occurrences left", rule)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	schedule := &Schedule{
+		ChatID:  chatID,
+		Rule:    rule,
+		Start:   start,
+		Message: message,
+	}
+	if err := s.arm(ctx, schedule); err != nil {
+		return nil, err
+	}
+
+	// Keep the schedule being replaced until the new one is saved
+	previous, replaced := s.schedules[chatID]
+	s.schedules[chatID] = schedule
+	if err := s.save(); err != nil {
+		if replaced {
+			s.schedules[chatID] = previous
+		} else {
+			delete(s.schedules, chatID)
+		}
+		return nil, err
+	}
+
+	copied := *schedule
+	return &copied, nil
+}
+
+// Remove deletes the schedule for a chat and clears its armed reminder
+func (s *Scheduler) Remove(ctx context.Context, chatID string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	schedule, ok := s.schedules[chatID]
+	if !ok {
+		return fmt.Errorf("no recurring reminder for chat %s", chatID)
+	}
+
+	if schedule.NextAt != nil {
+		if _, err := s.client.Delete(ctx, resources.ReminderDeleteParams{ChatID: chatID}); err != nil {
+			return fmt.Errorf("failed to clear reminder: %w", err)
+		}
+	}
+
+	delete(s.schedules, chatID)
+	return s.save()
+}
+
+// List returns all schedules ordered by chat ID
+func (s *Scheduler) List() []Schedule {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	schedules := make([]Schedule, 0, len(s.schedules))
+	for _, schedule := range s.schedules {
+		schedules = append(schedules, *schedule)
+	}
+	sort.Slice(schedules, func(i, j int) bool {
+		return schedules[i].ChatID < schedules[j].ChatID
+	})
+	return schedules
+}
+
+// Sync re-arms every schedule whose armed occurrence has passed. Schedules
+// whose rule is exhausted are dropped.
+func (s *Scheduler) Sync(ctx context.Context) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.now()
+	var errs []error
+	for _, schedule := range s.schedules {
+		if schedule.NextAt != nil && now.Before(*schedule.NextAt) {
+			continue
+		}
+		if schedule.NextAt != nil {
+			fired := *schedule.NextAt
+			schedule.LastFiredAt = &fired
+		}
+		if err := s.arm(ctx, schedule); err != nil {
+			errs = append(errs, fmt.Errorf("chat %s: %w", schedule.ChatID, err))
+		}
+	}
+
+	if err := s.save(); err != nil {
+		errs = append(errs, err)
+	}
+
+	return errors.Join(errs...)
+}
+
+// Run calls Sync periodically until the context is cancelled. Failed
+// schedules keep their state and are retried on the next tick; each failure
+// is passed to onError when it is non-nil.
+func (s *Scheduler) Run(ctx context.Context, onError func(error)) error {
+	s.mu.Lock()
+	interval := s.interval
+	s.mu.Unlock()
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		if err := s.Sync(ctx); err != nil && ctx.Err() == nil && onError != nil {
+			onError(err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+// arm creates the reminder for the next occurrence after now. Callers must hold s.mu.
+func (s *Scheduler) arm(ctx context.Context, schedule *Schedule) error {
+	rule, err := ParseRule(schedule.Rule)
+	if err != nil {
+		return err
+	}
+
+	next := rule.Next(schedule.Start, s.now())
+	if next.IsZero() {
+		delete(s.schedules, schedule.ChatID)
+		return nil
+	}
+
+	_, err = s.client.Create(ctx, resources.ReminderCreateParams{
+		ChatID:    schedule.ChatID,
+		Timestamp: next,
+		Message:   schedule.Message,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to set reminder: %w", err)
+	}
+
+	schedule.NextAt = &next
+	return nil
+}
+
+// load reads saved schedules from disk, treating a missing file as empty
+func (s *Scheduler) load() error {
+	schedules, err := s.store.Load()
+	if err != nil || schedules == nil {
+		return err
+	}
+
+	for _, schedule := range *schedules {
+		s.schedules[schedule.ChatID] = schedule
+	}
+	return nil
+}
+
+// save writes schedules to disk atomically and syncs them. Callers must
+// hold s.mu.
+func (s *Scheduler) save() error {
+	schedules := make([]*Schedule, 0, len(s.schedules))
+	for _, schedule := range s.schedules {
+		schedules = append(schedules, schedule)
+	}
+	sort.Slice(schedules, func(i, j int) bool {
+		return schedules[i].ChatID < schedules[j].ChatID
+	})
+
+	return s.store.Save(&schedules)
+}
@@ -17,23 +17,3 @@ type ClientInterface interface {
 func NewAccounts(client ClientInterface) *Accounts {
 	return &Accounts{client: client}
 }
-
-// Account represents a chat account added to Beeper
-type Account struct {
-	AccountID string `json:"accountID"`
-	Network   string `json:"network"`
-	User      User   `json:"user"`
-}
-
-// AccountListResponse represents the response from listing accounts
-type AccountListResponse []Account
-
-// List retrieves all connected Beeper accounts available on this device
-func (a *Accounts) List(ctx context.Context) (*AccountListResponse, error) {
-	var result AccountListResponse
-	err := a.client.DoRequest(ctx, "GET", "/v0/get-accounts", nil, &result)
-	if err != nil {
-		return nil, err
-	}
-	return &result, nil
-}
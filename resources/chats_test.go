@@ -3,6 +3,7 @@ package resources_test
 import (
 	"context"
 	"encoding/json"
+	"io"
 	"net/http"
 	"net/http/httptest"
 	"net/url"
@@ -16,12 +17,12 @@ import (
 
 func TestChatsSearchPayload(t *testing.T) {
 	var capturedURL *url.URL
-	var capturedBody map[string]interface{}
+	var capturedBody []byte
 
 	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		capturedURL = r.URL
 		defer r.Body.Close()
-		_ = json.NewDecoder(r.Body).Decode(&capturedBody)
+		capturedBody, _ = io.ReadAll(r.Body)
 		w.Header().Set("Content-Type", "application/json")
 		json.NewEncoder(w).Encode(resources.ChatsCursor{})
 	}))
@@ -35,7 +36,7 @@ func TestChatsSearchPayload(t *testing.T) {
 	require.NoError(t, err)
 
 	_, err = client.Chats.Search(context.Background(), resources.ChatSearchParams{
-		AccountIDs:   []string{"account-1"},
+		AccountIDs:   []string{"account-1", "account-2"},
 		IncludeMuted: beeperdesktop.BoolPtr(true),
 		Limit:        beeperdesktop.IntPtr(10),
 		Scope:        beeperdesktop.StringPtr("titles"),
@@ -45,18 +46,17 @@ func TestChatsSearchPayload(t *testing.T) {
 
 	require.NotNil(t, capturedURL)
 	assert.Equal(t, "/v0/search-chats", capturedURL.Path)
-
-	require.NotNil(t, capturedBody)
-
-	accountIDs, ok := capturedBody["accountIDs"].([]interface{})
-	require.True(t, ok)
-	require.Len(t, accountIDs, 1)
-	assert.Equal(t, "account-1", accountIDs[0])
-
-	assert.Equal(t, true, capturedBody["includeMuted"])
-	assert.Equal(t, float64(10), capturedBody["limit"])
-	assert.Equal(t, "titles", capturedBody["scope"])
-	assert.Equal(t, "updates", capturedBody["query"])
+	assert.Empty(t, capturedBody)
+
+	query := capturedURL.Query()
+	assert.Equal(t, "account-1", query.Get("accountIDs[0]"))
+	assert.Equal(t, "account-2", query.Get("accountIDs[1]"))
+	assert.Equal(t, "true", query.Get("includeMuted"))
+	assert.Equal(t, "10", query.Get("limit"))
+	assert.Equal(t, "titles", query.Get("scope"))
+	assert.Equal(t, "updates", query.Get("query"))
+	assert.NotContains(t, query, "chatType")
+	assert.NotContains(t, query, "cursor")
 }
 
 func TestChatsCreatePayload(t *testing.T) {
@@ -101,3 +101,54 @@ func TestChatsCreatePayload(t *testing.T) {
 	require.NotNil(t, captured.Title)
 	assert.Equal(t, "Project Updates", *captured.Title)
 }
+
+func TestChatParticipantsIteratorAndForbidden(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Path {
+		case "/v0/get-chat-participants":
+			assert.Equal(t, "chat-1", r.URL.Query().Get("chatID"))
+			if r.URL.Query().Get("cursor") == "" {
+				json.NewEncoder(w).Encode(resources.ParticipantsCursor{
+					Items:      []resources.User{{ID: "user-1"}, {ID: "user-2"}},
+					Pagination: &resources.PaginationInfo{Cursor: beeperdesktop.StringPtr("page-2"), HasMore: true},
+				})
+				return
+			}
+			assert.Equal(t, "page-2", r.URL.Query().Get("cursor"))
+			json.NewEncoder(w).Encode(resources.ParticipantsCursor{
+				Items:      []resources.User{{ID: "user-3"}},
+				Pagination: &resources.PaginationInfo{HasMore: false},
+			})
+		case "/v0/add-chat-participants":
+			w.WriteHeader(http.StatusForbidden)
+			w.Write([]byte(`{"error": "network does not support adding participants"}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	client, err := beeperdesktop.New(
+		beeperdesktop.WithAccessToken("token"),
+		beeperdesktop.WithBaseURL(server.URL),
+		beeperdesktop.WithMaxRetries(0),
+	)
+	require.NoError(t, err)
+
+	users, err := client.NewParticipantIterator(resources.ParticipantListParams{ChatID: "chat-1"}).ToSlice(context.Background())
+	require.NoError(t, err)
+	require.Len(t, users, 3)
+	assert.Equal(t, "user-3", users[2].ID)
+
+	_, err = client.Chats.Participants.Add(context.Background(), resources.ParticipantUpdateParams{
+		ChatID:         "chat-1",
+		ParticipantIDs: []string{"user-4"},
+	})
+	require.Error(t, err)
+	assert.ErrorIs(t, err, resources.ErrParticipantsForbidden)
+
+	var denied *beeperdesktop.PermissionDeniedError
+	require.ErrorAs(t, err, &denied)
+	assert.Equal(t, 403, denied.Status)
+}
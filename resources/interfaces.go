@@ -0,0 +1,77 @@
+package resources
+
+import "context"
+
+// AccountsAPI is the interface implemented by Accounts
+type AccountsAPI interface {
+	List(ctx context.Context) (*AccountListResponse, error)
+}
+
+// AppAPI is the interface implemented by App
+type AppAPI interface {
+	DownloadAsset(ctx context.Context, params AppDownloadAssetParams) (*AppDownloadAssetResponse, error)
+	Open(ctx context.Context, params AppOpenParams) (*AppOpenResponse, error)
+	Search(ctx context.Context, params AppSearchParams) (*AppSearchResponse, error)
+}
+
+// ChatsAPI is the interface implemented by Chats
+type ChatsAPI interface {
+	Create(ctx context.Context, params ChatCreateParams) (*ChatCreateResponse, error)
+	Retrieve(ctx context.Context, params ChatRetrieveParams) (*Chat, error)
+	Archive(ctx context.Context, params ChatArchiveParams) (*BaseResponse, error)
+	Search(ctx context.Context, params ChatSearchParams) (*ChatsCursor, error)
+	ParticipantsAPI() ParticipantsAPI
+	RemindersAPI() RemindersAPI
+}
+
+// ParticipantsAPI is the interface implemented by Participants
+type ParticipantsAPI interface {
+	List(ctx context.Context, params ParticipantListParams) (*ParticipantsCursor, error)
+	Add(ctx context.Context, params ParticipantUpdateParams) (*BaseResponse, error)
+	Remove(ctx context.Context, params ParticipantUpdateParams) (*BaseResponse, error)
+}
+
+// RemindersAPI is the interface implemented by Reminders
+type RemindersAPI interface {
+	Create(ctx context.Context, params ReminderCreateParams) (*BaseResponse, error)
+	Delete(ctx context.Context, params ReminderDeleteParams) (*BaseResponse, error)
+	List(ctx context.Context, params ReminderListParams) (*ReminderListResponse, error)
+	Get(ctx context.Context, params ReminderGetParams) (*Reminder, error)
+}
+
+// ContactsAPI is the interface implemented by Contacts
+type ContactsAPI interface {
+	Search(ctx context.Context, params ContactSearchParams) (*ContactSearchResponse, error)
+}
+
+// MessagesAPI is the interface implemented by Messages
+type MessagesAPI interface {
+	Search(ctx context.Context, params MessageSearchParams) (*MessagesCursor, error)
+	Send(ctx context.Context, params MessageSendParams) (*MessageSendResponse, error)
+}
+
+// TokenAPI is the interface implemented by Token
+type TokenAPI interface {
+	Info(ctx context.Context) (*UserInfo, error)
+}
+
+var (
+	_ AccountsAPI     = (*Accounts)(nil)
+	_ AppAPI          = (*App)(nil)
+	_ ChatsAPI        = (*Chats)(nil)
+	_ ParticipantsAPI = (*Participants)(nil)
+	_ RemindersAPI    = (*Reminders)(nil)
+	_ ContactsAPI     = (*Contacts)(nil)
+	_ MessagesAPI     = (*Messages)(nil)
+	_ TokenAPI        = (*Token)(nil)
+)
+
+// ParticipantsAPI returns the participants sub-resource as an interface
+func (c *Chats) ParticipantsAPI() ParticipantsAPI {
+	return c.Participants
+}
+
+// RemindersAPI returns the reminders sub-resource as an interface
+func (c *Chats) RemindersAPI() RemindersAPI {
+	return c.Reminders
+}
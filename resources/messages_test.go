@@ -55,7 +55,7 @@ func TestMessagesSendPayload(t *testing.T) {
 	type sendPayload struct {
 		ChatID    string  `json:"chatID"`
 		Text      string  `json:"text"`
-		ReplyToID *string `json:"replyToId"`
+		ReplyToID *string `json:"replyToID"`
 	}
 
 	var captured sendPayload
@@ -0,0 +1,341 @@
+// Code generated by internal/cmd/apigen from api/openapi.json; DO NOT EDIT.
+
+package resources
+
+import (
+	"context"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+// values returns the query parameters of p
+func (p AppSearchParams) values() url.Values {
+	query := url.Values{}
+	query.Set("query", p.Query)
+	for i, item := range p.AccountIDs {
+		query.Set("accountIDs["+strconv.Itoa(i)+"]", item)
+	}
+	if p.ChatType != nil {
+		query.Set("chatType", *p.ChatType)
+	}
+	if p.IncludeMuted != nil {
+		query.Set("includeMuted", strconv.FormatBool(*p.IncludeMuted))
+	}
+	if p.Limit != nil {
+		query.Set("limit", strconv.Itoa(*p.Limit))
+	}
+	if p.MessageLimit != nil {
+		query.Set("messageLimit", strconv.Itoa(*p.MessageLimit))
+	}
+	if p.ParticipantLimit != nil {
+		query.Set("participantLimit", strconv.Itoa(*p.ParticipantLimit))
+	}
+	return query
+}
+
+// values returns the query parameters of p
+func (p ParticipantListParams) values() url.Values {
+	query := url.Values{}
+	query.Set("chatID", p.ChatID)
+	if p.Cursor != nil {
+		query.Set("cursor", *p.Cursor)
+	}
+	if p.Limit != nil {
+		query.Set("limit", strconv.Itoa(*p.Limit))
+	}
+	return query
+}
+
+// values returns the query parameters of p
+func (p ReminderGetParams) values() url.Values {
+	query := url.Values{}
+	query.Set("chatID", p.ChatID)
+	return query
+}
+
+// values returns the query parameters of p
+func (p ReminderListParams) values() url.Values {
+	query := url.Values{}
+	for i, item := range p.AccountIDs {
+		query.Set("accountIDs["+strconv.Itoa(i)+"]", item)
+	}
+	return query
+}
+
+// values returns the query parameters of p
+func (p ChatRetrieveParams) values() url.Values {
+	query := url.Values{}
+	query.Set("chatID", p.ChatID)
+	return query
+}
+
+// values returns the query parameters of p
+func (p ChatSearchParams) values() url.Values {
+	query := url.Values{}
+	for i, item := range p.AccountIDs {
+		query.Set("accountIDs["+strconv.Itoa(i)+"]", item)
+	}
+	if p.ChatType != nil {
+		query.Set("chatType", *p.ChatType)
+	}
+	if p.IncludeMuted != nil {
+		query.Set("includeMuted", strconv.FormatBool(*p.IncludeMuted))
+	}
+	if p.Limit != nil {
+		query.Set("limit", strconv.Itoa(*p.Limit))
+	}
+	if p.Cursor != nil {
+		query.Set("cursor", *p.Cursor)
+	}
+	if p.Scope != nil {
+		query.Set("scope", *p.Scope)
+	}
+	if p.Query != nil {
+		query.Set("query", *p.Query)
+	}
+	return query
+}
+
+// values returns the query parameters of p
+func (p ContactSearchParams) values() url.Values {
+	query := url.Values{}
+	query.Set("accountID", p.AccountID)
+	query.Set("query", p.Query)
+	return query
+}
+
+// values returns the query parameters of p
+func (p MessageSearchParams) values() url.Values {
+	query := url.Values{}
+	for i, item := range p.AccountIDs {
+		query.Set("accountIDs["+strconv.Itoa(i)+"]", item)
+	}
+	for i, item := range p.ChatIDs {
+		query.Set("chatIDs["+strconv.Itoa(i)+"]", item)
+	}
+	if p.ChatType != nil {
+		query.Set("chatType", *p.ChatType)
+	}
+	if p.Cursor != nil {
+		query.Set("cursor", *p.Cursor)
+	}
+	if p.DateAfter != nil {
+		query.Set("dateAfter", p.DateAfter.Format(time.RFC3339Nano))
+	}
+	if p.DateBefore != nil {
+		query.Set("dateBefore", p.DateBefore.Format(time.RFC3339Nano))
+	}
+	if p.Direction != nil {
+		query.Set("direction", *p.Direction)
+	}
+	if p.ExcludeLowPriority != nil {
+		query.Set("excludeLowPriority", strconv.FormatBool(*p.ExcludeLowPriority))
+	}
+	if p.IncludeMuted != nil {
+		query.Set("includeMuted", strconv.FormatBool(*p.IncludeMuted))
+	}
+	if p.Limit != nil {
+		query.Set("limit", strconv.Itoa(*p.Limit))
+	}
+	for i, item := range p.MediaTypes {
+		query.Set("mediaTypes["+strconv.Itoa(i)+"]", item)
+	}
+	if p.Query != nil {
+		query.Set("query", *p.Query)
+	}
+	for i, item := range p.SenderIDs {
+		query.Set("senderIDs["+strconv.Itoa(i)+"]", item)
+	}
+	return query
+}
+
+// List retrieves all connected Beeper accounts available on this device
+func (a *Accounts) List(ctx context.Context) (*AccountListResponse, error) {
+	var result AccountListResponse
+	err := a.client.DoRequest(ctx, "GET", "/v0/get-accounts", nil, &result)
+	if err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+// DownloadAsset downloads an asset from a URL
+func (a *App) DownloadAsset(ctx context.Context, params AppDownloadAssetParams) (*AppDownloadAssetResponse, error) {
+	var result AppDownloadAssetResponse
+	err := a.client.DoRequest(ctx, "POST", "/v0/download-asset", params, &result)
+	if err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+// Open opens Beeper Desktop and optionally navigates to a specific chat
+func (a *App) Open(ctx context.Context, params AppOpenParams) (*AppOpenResponse, error) {
+	var result AppOpenResponse
+	err := a.client.DoRequest(ctx, "POST", "/v0/open-app", params, &result)
+	if err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+// Search searches for chats and messages in one call
+func (a *App) Search(ctx context.Context, params AppSearchParams) (*AppSearchResponse, error) {
+	var result AppSearchResponse
+	err := a.client.DoRequest(ctx, "GET", withQuery("/v0/search", params.values()), nil, &result)
+	if err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+// Archive archives or unarchives a chat
+func (c *Chats) Archive(ctx context.Context, params ChatArchiveParams) (*BaseResponse, error) {
+	var result BaseResponse
+	err := c.client.DoRequest(ctx, "POST", "/v0/archive-chat", params, &result)
+	if err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+// Create creates a single or group chat on a specific account
+func (c *Chats) Create(ctx context.Context, params ChatCreateParams) (*ChatCreateResponse, error) {
+	var result ChatCreateResponse
+	err := c.client.DoRequest(ctx, "POST", "/v0/create-chat", params, &result)
+	if err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+// add adds users to a group chat
+func (p *Participants) add(ctx context.Context, params ParticipantUpdateParams) (*BaseResponse, error) {
+	var result BaseResponse
+	err := p.client.DoRequest(ctx, "POST", "/v0/add-chat-participants", params, &result)
+	if err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+// List retrieves a single page of participants for a chat
+func (p *Participants) List(ctx context.Context, params ParticipantListParams) (*ParticipantsCursor, error) {
+	var result ParticipantsCursor
+	err := p.client.DoRequest(ctx, "GET", withQuery("/v0/get-chat-participants", params.values()), nil, &result)
+	if err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+// remove removes users from a group chat
+func (p *Participants) remove(ctx context.Context, params ParticipantUpdateParams) (*BaseResponse, error) {
+	var result BaseResponse
+	err := p.client.DoRequest(ctx, "POST", "/v0/remove-chat-participants", params, &result)
+	if err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+// Create sets a reminder for a chat at a specific time
+func (r *Reminders) Create(ctx context.Context, params ReminderCreateParams) (*BaseResponse, error) {
+	var result BaseResponse
+	err := r.client.DoRequest(ctx, "POST", "/v0/set-chat-reminder", params, &result)
+	if err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+// Delete clears a chat reminder
+func (r *Reminders) Delete(ctx context.Context, params ReminderDeleteParams) (*BaseResponse, error) {
+	var result BaseResponse
+	err := r.client.DoRequest(ctx, "POST", "/v0/clear-chat-reminder", params, &result)
+	if err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+// Get retrieves the reminder set on a chat
+func (r *Reminders) Get(ctx context.Context, params ReminderGetParams) (*Reminder, error) {
+	var result Reminder
+	err := r.client.DoRequest(ctx, "GET", withQuery("/v0/get-chat-reminder", params.values()), nil, &result)
+	if err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+// List retrieves all reminders, optionally limited to specific accounts
+func (r *Reminders) List(ctx context.Context, params ReminderListParams) (*ReminderListResponse, error) {
+	var result ReminderListResponse
+	err := r.client.DoRequest(ctx, "GET", withQuery("/v0/get-chat-reminders", params.values()), nil, &result)
+	if err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+// Retrieve gets chat details including metadata, participants, and latest message
+func (c *Chats) Retrieve(ctx context.Context, params ChatRetrieveParams) (*Chat, error) {
+	var result Chat
+	err := c.client.DoRequest(ctx, "GET", withQuery("/v0/get-chat", params.values()), nil, &result)
+	if err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+// Search searches chats by title/network or participants
+func (c *Chats) Search(ctx context.Context, params ChatSearchParams) (*ChatsCursor, error) {
+	var result ChatsCursor
+	err := c.client.DoRequest(ctx, "GET", withQuery("/v0/search-chats", params.values()), nil, &result)
+	if err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+// Search searches for contacts/users
+func (c *Contacts) Search(ctx context.Context, params ContactSearchParams) (*ContactSearchResponse, error) {
+	var result ContactSearchResponse
+	err := c.client.DoRequest(ctx, "GET", withQuery("/v0/search-users", params.values()), nil, &result)
+	if err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+// Search searches messages across chats using Beeper's message index
+func (m *Messages) Search(ctx context.Context, params MessageSearchParams) (*MessagesCursor, error) {
+	var result MessagesCursor
+	err := m.client.DoRequest(ctx, "GET", withQuery("/v0/search-messages", params.values()), nil, &result)
+	if err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+// Send sends a text message to a specific chat
+func (m *Messages) Send(ctx context.Context, params MessageSendParams) (*MessageSendResponse, error) {
+	var result MessageSendResponse
+	err := m.client.DoRequest(ctx, "POST", "/v0/send-message", params, &result)
+	if err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+// Info returns information about the authenticated user/token
+func (t *Token) Info(ctx context.Context) (*UserInfo, error) {
+	var result UserInfo
+	err := t.client.DoRequest(ctx, "GET", "/oauth/userinfo", nil, &result)
+	if err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
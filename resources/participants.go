@@ -0,0 +1,67 @@
+package resources
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+// ErrParticipantsForbidden is reported when the chat's network does not allow
+// this account to change the participant list
+var ErrParticipantsForbidden = errors.New("network does not allow participant changes")
+
+// Participants handles chat participant operations
+type Participants struct {
+	client ClientInterface
+}
+
+// NewParticipants creates a new Participants resource client
+func NewParticipants(client ClientInterface) *Participants {
+	return &Participants{client: client}
+}
+
+// ParticipantError describes a participant change rejected by the server
+type ParticipantError struct {
+	ChatID string
+	Op     string // add, remove
+	Cause  error
+}
+
+func (e *ParticipantError) Error() string {
+	return fmt.Sprintf("cannot %s participants in chat %s: %v", e.Op, e.ChatID, e.Cause)
+}
+
+func (e *ParticipantError) Unwrap() error {
+	return e.Cause
+}
+
+// Is reports whether the error matches ErrParticipantsForbidden
+func (e *ParticipantError) Is(target error) bool {
+	return target == ErrParticipantsForbidden
+}
+
+// Add adds users to a group chat
+func (p *Participants) Add(ctx context.Context, params ParticipantUpdateParams) (*BaseResponse, error) {
+	result, err := p.add(ctx, params)
+	return result, participantError(err, "add", params.ChatID)
+}
+
+// Remove removes users from a group chat
+func (p *Participants) Remove(ctx context.Context, params ParticipantUpdateParams) (*BaseResponse, error) {
+	result, err := p.remove(ctx, params)
+	return result, participantError(err, "remove", params.ChatID)
+}
+
+// participantError wraps permission failures of a participant change
+func participantError(err error, op, chatID string) error {
+	if isForbidden(err) {
+		return &ParticipantError{ChatID: chatID, Op: op, Cause: err}
+	}
+	return err
+}
+
+// isForbidden reports whether err carries a 403 status from the API
+func isForbidden(err error) bool {
+	var statusErr interface{ StatusCode() int }
+	return errors.As(err, &statusErr) && statusErr.StatusCode() == 403
+}
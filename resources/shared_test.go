@@ -0,0 +1,100 @@
+package resources_test
+
+import (
+	"encoding/json"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/cameronaaron/beeper-go-sdk/resources"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSortKeyJSONRoundTrip(t *testing.T) {
+	var msg resources.Message
+	require.NoError(t, json.Unmarshal([]byte(`{"id":"m1","sortKey":12345678901234567890}`), &msg))
+	assert.Equal(t, "12345678901234567890", msg.SortKey.String())
+
+	data, err := json.Marshal(msg.SortKey)
+	require.NoError(t, err)
+	assert.Equal(t, `12345678901234567890`, string(data))
+
+	require.NoError(t, json.Unmarshal([]byte(`{"id":"m2","sortKey":"00042"}`), &msg))
+	data, err = json.Marshal(msg.SortKey)
+	require.NoError(t, err)
+	assert.Equal(t, `"00042"`, string(data))
+
+	var chat resources.Chat
+	require.NoError(t, json.Unmarshal([]byte(`{"id":"c1","lastReadMessageSortKey":null}`), &chat))
+	assert.Nil(t, chat.LastReadMessageSortKey)
+}
+
+func TestSortKeyCompare(t *testing.T) {
+	tests := []struct {
+		a, b resources.SortKey
+		want int
+	}{
+		{resources.NewSortKey("9"), resources.NewSortKey("10"), -1},
+		{resources.NewNumericSortKey(42), resources.NewSortKey("00042"), 0},
+		{resources.NewSortKey("1.5"), resources.NewSortKey("1.25"), 1},
+		{resources.NewSortKey("abc"), resources.NewSortKey("abd"), -1},
+		{resources.NewSortKey("999"), resources.NewSortKey("a"), -1},
+		{resources.SortKey{}, resources.NewSortKey("0"), -1},
+		{resources.SortKey{}, resources.SortKey{}, 0},
+	}
+
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, tt.a.Compare(tt.b), "%q vs %q", tt.a, tt.b)
+		assert.Equal(t, -tt.want, tt.b.Compare(tt.a), "%q vs %q", tt.b, tt.a)
+	}
+
+	keys := []resources.SortKey{
+		resources.NewSortKey("b"),
+		resources.NewNumericSortKey(100),
+		resources.NewSortKey("20"),
+		resources.NewSortKey("a"),
+		resources.NewNumericSortKey(3),
+	}
+	sort.Slice(keys, func(i, j int) bool { return keys[i].Less(keys[j]) })
+
+	var ordered []string
+	for _, key := range keys {
+		ordered = append(ordered, key.String())
+	}
+	assert.Equal(t, []string{"3", "20", "100", "a", "b"}, ordered)
+}
+
+func TestTimestampDecoding(t *testing.T) {
+	want := time.Date(2024, 6, 1, 12, 30, 0, 0, time.UTC)
+
+	inputs := []string{
+		`"2024-06-01T12:30:00Z"`,
+		`"2024-06-01T14:30:00+02:00"`,
+		`"2024-06-01T12:30:00.000Z"`,
+		`"2024-06-01T12:30:00+0000"`,
+		`"2024-06-01 12:30:00Z"`,
+		`"2024-06-01T12:30:00"`,
+		`1717245000000`,
+		`"1717245000000"`,
+		`1717245000`,
+	}
+
+	for _, input := range inputs {
+		var ts resources.Timestamp
+		require.NoError(t, json.Unmarshal([]byte(input), &ts), input)
+		assert.True(t, want.Equal(ts.Time), "%s decoded as %s", input, ts.Time)
+	}
+
+	var ts resources.Timestamp
+	assert.Error(t, json.Unmarshal([]byte(`"yesterday"`), &ts))
+
+	var chat resources.Chat
+	require.NoError(t, json.Unmarshal([]byte(`{"id":"c1","lastActivity":1717245000000}`), &chat))
+	require.NotNil(t, chat.LastActivity)
+	assert.True(t, want.Equal(chat.LastActivity.Time))
+
+	data, err := json.Marshal(resources.NewTimestamp(want))
+	require.NoError(t, err)
+	assert.Equal(t, `"2024-06-01T12:30:00Z"`, string(data))
+}
@@ -0,0 +1,175 @@
+package resources
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// SortKey orders messages within a chat. The API sends it as either a JSON
+// string or a JSON number; both forms are kept exactly as received.
+//
+// Keys compare numerically when both are decimal numbers and
+// lexicographically otherwise, with numeric keys ordered before non-numeric
+// ones so that Compare is a total order.
+type SortKey struct {
+	value   string
+	numeric bool // Received as a JSON number
+}
+
+// NewSortKey creates a sort key from its string form
+func NewSortKey(value string) SortKey {
+	return SortKey{value: value}
+}
+
+// NewNumericSortKey creates a sort key that is sent as a JSON number
+func NewNumericSortKey(value int64) SortKey {
+	return SortKey{value: strconv.FormatInt(value, 10), numeric: true}
+}
+
+// ParseSortKey reads a sort key from an untyped value such as a decoded JSON
+// field, accepting strings, numbers and nil
+func ParseSortKey(v interface{}) (SortKey, error) {
+	switch key := v.(type) {
+	case nil:
+		return SortKey{}, nil
+	case SortKey:
+		return key, nil
+	case string:
+		return NewSortKey(key), nil
+	case json.Number:
+		return SortKey{value: key.String(), numeric: true}, nil
+	case float64:
+		return SortKey{value: strconv.FormatFloat(key, 'f', -1, 64), numeric: true}, nil
+	case int:
+		return NewNumericSortKey(int64(key)), nil
+	case int64:
+		return NewNumericSortKey(key), nil
+	default:
+		return SortKey{}, fmt.Errorf("unsupported sort key type %T", v)
+	}
+}
+
+// String returns the key as received from the API
+func (k SortKey) String() string {
+	return k.value
+}
+
+// IsZero reports whether the key is empty
+func (k SortKey) IsZero() bool {
+	return k.value == ""
+}
+
+// Compare returns -1, 0 or 1 depending on whether k sorts before, equal to or
+// after other. The empty key sorts before every other key.
+func (k SortKey) Compare(other SortKey) int {
+	if k.IsZero() || other.IsZero() {
+		return compareBool(!k.IsZero(), !other.IsZero())
+	}
+
+	aInt, aFrac, aOK := splitDecimal(k.value)
+	bInt, bFrac, bOK := splitDecimal(other.value)
+
+	switch {
+	case aOK && bOK:
+		if len(aInt) != len(bInt) {
+			return compareInt(len(aInt), len(bInt))
+		}
+		if cmp := strings.Compare(aInt, bInt); cmp != 0 {
+			return cmp
+		}
+		return strings.Compare(aFrac, bFrac)
+	case aOK != bOK:
+		// Numeric keys sort before non-numeric keys
+		return compareBool(!aOK, !bOK)
+	default:
+		return strings.Compare(k.value, other.value)
+	}
+}
+
+// Less reports whether k sorts before other
+func (k SortKey) Less(other SortKey) bool {
+	return k.Compare(other) < 0
+}
+
+// MarshalJSON encodes the key in the form it was received
+func (k SortKey) MarshalJSON() ([]byte, error) {
+	if k.numeric {
+		return []byte(k.value), nil
+	}
+	return json.Marshal(k.value)
+}
+
+// UnmarshalJSON accepts a JSON string, number or null
+func (k *SortKey) UnmarshalJSON(data []byte) error {
+	data = bytes.TrimSpace(data)
+	if bytes.Equal(data, []byte("null")) {
+		*k = SortKey{}
+		return nil
+	}
+
+	if len(data) > 0 && data[0] == '"' {
+		var value string
+		if err := json.Unmarshal(data, &value); err != nil {
+			return err
+		}
+		*k = NewSortKey(value)
+		return nil
+	}
+
+	var number json.Number
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	if err := decoder.Decode(&number); err != nil {
+		return fmt.Errorf("invalid sort key %s: %w", data, err)
+	}
+	*k = SortKey{value: number.String(), numeric: true}
+	return nil
+}
+
+// splitDecimal splits a non-negative decimal into its integer part without
+// leading zeros and its fraction without trailing zeros
+func splitDecimal(s string) (string, string, bool) {
+	intPart, fracPart, _ := strings.Cut(s, ".")
+	if !isDigits(intPart) || (fracPart != "" && !isDigits(fracPart)) {
+		return "", "", false
+	}
+	return strings.TrimLeft(intPart, "0"), strings.TrimRight(fracPart, "0"), true
+}
+
+func isDigits(s string) bool {
+	if s == "" {
+		return false
+	}
+	for _, r := range s {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+func compareInt(a, b int) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	default:
+		return 0
+	}
+}
+
+// compareBool orders false before true
+func compareBool(a, b bool) int {
+	switch {
+	case a == b:
+		return 0
+	case b:
+		return -1
+	default:
+		return 1
+	}
+}
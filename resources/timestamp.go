@@ -0,0 +1,114 @@
+package resources
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Timestamp is a point in time decoded tolerantly from the API. It accepts
+// RFC3339 strings with or without a zone or "T" separator, plain dates, and
+// Unix epochs in seconds or milliseconds as numbers or numeric strings.
+// Timestamps are always encoded as RFC3339 with nanoseconds.
+type Timestamp struct {
+	time.Time
+}
+
+// epochMillisThreshold separates epoch seconds from epoch milliseconds;
+// 1e11 seconds is in the year 5138 while 1e11 milliseconds is in 1973
+const epochMillisThreshold = 1e11
+
+// timestampLayouts are tried in order for non-numeric strings. Layouts without
+// a zone are interpreted as UTC.
+var timestampLayouts = []string{
+	time.RFC3339Nano,
+	"2006-01-02T15:04:05.999999999Z0700",
+	"2006-01-02 15:04:05.999999999Z07:00",
+	"2006-01-02T15:04:05.999999999",
+	"2006-01-02 15:04:05.999999999",
+	"2006-01-02",
+}
+
+// NewTimestamp wraps a time.Time
+func NewTimestamp(t time.Time) Timestamp {
+	return Timestamp{Time: t}
+}
+
+// ParseTimestamp parses any of the formats accepted by UnmarshalJSON
+func ParseTimestamp(value string) (Timestamp, error) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return Timestamp{}, nil
+	}
+
+	if number, err := strconv.ParseFloat(value, 64); err == nil {
+		return fromEpoch(number), nil
+	}
+
+	for _, layout := range timestampLayouts {
+		if t, err := time.Parse(layout, value); err == nil {
+			return Timestamp{Time: t}, nil
+		}
+	}
+
+	return Timestamp{}, fmt.Errorf("unrecognized timestamp %q", value)
+}
+
+// Compare returns -1, 0 or 1 depending on whether t is before, equal to or after other
+func (t Timestamp) Compare(other Timestamp) int {
+	switch {
+	case t.Time.Before(other.Time):
+		return -1
+	case t.Time.After(other.Time):
+		return 1
+	default:
+		return 0
+	}
+}
+
+// MarshalJSON encodes the timestamp as an RFC3339 string
+func (t Timestamp) MarshalJSON() ([]byte, error) {
+	return t.Time.MarshalJSON()
+}
+
+// UnmarshalJSON accepts strings, numbers and null
+func (t *Timestamp) UnmarshalJSON(data []byte) error {
+	data = bytes.TrimSpace(data)
+	if bytes.Equal(data, []byte("null")) {
+		*t = Timestamp{}
+		return nil
+	}
+
+	if len(data) > 0 && data[0] == '"' {
+		var value string
+		if err := json.Unmarshal(data, &value); err != nil {
+			return err
+		}
+		parsed, err := ParseTimestamp(value)
+		if err != nil {
+			return err
+		}
+		*t = parsed
+		return nil
+	}
+
+	number, err := strconv.ParseFloat(string(data), 64)
+	if err != nil {
+		return fmt.Errorf("invalid timestamp %s", data)
+	}
+	*t = fromEpoch(number)
+	return nil
+}
+
+// fromEpoch converts Unix seconds or milliseconds to a UTC timestamp
+func fromEpoch(value float64) Timestamp {
+	if math.Abs(value) >= epochMillisThreshold {
+		return Timestamp{Time: time.UnixMilli(int64(value)).UTC()}
+	}
+	seconds, fraction := math.Modf(value)
+	return Timestamp{Time: time.Unix(int64(seconds), int64(fraction*1e9)).UTC()}
+}
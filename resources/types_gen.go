@@ -0,0 +1,326 @@
+// Code generated by internal/cmd/apigen from api/openapi.json; DO NOT EDIT.
+
+package resources
+
+import (
+	"time"
+)
+
+// Account represents a chat account added to Beeper
+type Account struct {
+	AccountID string `json:"accountID"`
+	Network   string `json:"network"`
+	User      User   `json:"user"`
+}
+
+// AccountListResponse represents the response from listing accounts
+type AccountListResponse []Account
+
+// User represents a person on or reachable through Beeper
+type User struct {
+	ID            string  `json:"id"`
+	CannotMessage *bool   `json:"cannotMessage,omitempty"`
+	Email         *string `json:"email,omitempty"`
+	FullName      *string `json:"fullName,omitempty"`
+	ImgURL        *string `json:"imgURL,omitempty"`
+	IsSelf        *bool   `json:"isSelf,omitempty"`
+	PhoneNumber   *string `json:"phoneNumber,omitempty"`
+	Username      *string `json:"username,omitempty"`
+}
+
+// Chat represents a chat/conversation
+type Chat struct {
+	ID                     string           `json:"id"`
+	AccountID              string           `json:"accountID"`
+	Network                string           `json:"network"`
+	Title                  string           `json:"title"`
+	Type                   string           `json:"type"` // single, group
+	UnreadCount            int              `json:"unreadCount"`
+	Participants           ChatParticipants `json:"participants"`
+	IsArchived             *bool            `json:"isArchived,omitempty"`
+	IsMuted                *bool            `json:"isMuted,omitempty"`
+	IsPinned               *bool            `json:"isPinned,omitempty"`
+	LastActivity           *Timestamp       `json:"lastActivity,omitempty"`
+	LastReadMessageSortKey *SortKey         `json:"lastReadMessageSortKey,omitempty"`
+	LocalChatID            *string          `json:"localChatID,omitempty"`
+}
+
+// ChatParticipants represents chat participants information
+type ChatParticipants struct {
+	HasMore bool   `json:"hasMore"`
+	Items   []User `json:"items"`
+	Total   int    `json:"total"`
+}
+
+// ChatCreateResponse represents the response from creating a chat
+type ChatCreateResponse struct {
+	Chat    Chat   `json:"chat"`
+	Success bool   `json:"success"`
+	Error   string `json:"error,omitempty"`
+}
+
+// ChatsCursor represents paginated chat results
+type ChatsCursor = Cursor[Chat]
+
+// ParticipantsCursor represents paginated participant results
+type ParticipantsCursor = Cursor[User]
+
+// MessagesCursor is a type alias for message pagination
+type MessagesCursor = Cursor[Message]
+
+// PaginationInfo contains pagination metadata
+type PaginationInfo struct {
+	Cursor    *string `json:"cursor,omitempty"`
+	Limit     *int    `json:"limit,omitempty"`
+	Direction *string `json:"direction,omitempty"`
+	HasMore   bool    `json:"has_more"`
+}
+
+// Reminder represents a reminder set on a chat
+type Reminder struct {
+	ChatID    string    `json:"chatID"`
+	AccountID string    `json:"accountID"`
+	Timestamp Timestamp `json:"timestamp"`
+	Message   *string   `json:"message,omitempty"`
+}
+
+// ReminderListResponse represents the response from listing reminders
+type ReminderListResponse struct {
+	Items []Reminder `json:"items"`
+}
+
+// Message represents a chat message
+type Message struct {
+	ID          string       `json:"id"`
+	AccountID   string       `json:"accountID"`
+	ChatID      string       `json:"chatID"`
+	MessageID   string       `json:"messageID"`
+	SenderID    string       `json:"senderID"`
+	SortKey     SortKey      `json:"sortKey"`
+	Timestamp   Timestamp    `json:"timestamp"`
+	Attachments []Attachment `json:"attachments,omitempty"`
+	IsSender    *bool        `json:"isSender,omitempty"`
+	IsUnread    *bool        `json:"isUnread,omitempty"`
+	Reactions   []Reaction   `json:"reactions,omitempty"`
+	SenderName  *string      `json:"senderName,omitempty"`
+	Text        *string      `json:"text,omitempty"`
+}
+
+// Attachment represents a file attachment in a message
+type Attachment struct {
+	Type        string          `json:"type"` // unknown, img, video, audio
+	Duration    *int            `json:"duration,omitempty"`
+	FileName    *string         `json:"fileName,omitempty"`
+	FileSize    *int64          `json:"fileSize,omitempty"`
+	IsGif       *bool           `json:"isGif,omitempty"`
+	IsSticker   *bool           `json:"isSticker,omitempty"`
+	IsVoiceNote *bool           `json:"isVoiceNote,omitempty"`
+	MimeType    *string         `json:"mimeType,omitempty"`
+	PosterImg   *string         `json:"posterImg,omitempty"`
+	Size        *AttachmentSize `json:"size,omitempty"`
+	SrcURL      *string         `json:"srcURL,omitempty"`
+}
+
+// AttachmentSize represents pixel dimensions of an attachment
+type AttachmentSize struct {
+	Height *int `json:"height,omitempty"`
+	Width  *int `json:"width,omitempty"`
+}
+
+// Reaction represents a message reaction
+type Reaction struct {
+	ID            string  `json:"id"`
+	ParticipantID string  `json:"participantID"`
+	ReactionKey   string  `json:"reactionKey"`
+	Emoji         *bool   `json:"emoji,omitempty"`
+	ImgURL        *string `json:"imgURL,omitempty"`
+}
+
+// MessageSendResponse represents the response from sending a message
+type MessageSendResponse struct {
+	MessageID string `json:"messageID"`
+	Deeplink  string `json:"deeplink"`
+	Success   bool   `json:"success"`
+	Error     string `json:"error,omitempty"`
+}
+
+// ContactSearchResponse represents the response from searching contacts
+type ContactSearchResponse struct {
+	Items []User `json:"items"`
+}
+
+// AppDownloadAssetResponse represents the response from downloading an asset
+type AppDownloadAssetResponse struct {
+	LocalPath string `json:"localPath"`
+	Success   bool   `json:"success"`
+	Error     string `json:"error,omitempty"`
+}
+
+// AppOpenResponse represents the response from opening the app
+type AppOpenResponse struct {
+	Success bool   `json:"success"`
+	Error   string `json:"error,omitempty"`
+}
+
+// AppSearchResponse represents the response from searching
+type AppSearchResponse struct {
+	Chats    []ChatSearchResult    `json:"chats"`
+	Messages []MessageSearchResult `json:"messages"`
+}
+
+// ChatSearchResult represents a chat in search results
+type ChatSearchResult struct {
+	Chat         Chat      `json:"chat"`
+	Participants []User    `json:"participants"`
+	Messages     []Message `json:"messages"`
+}
+
+// MessageSearchResult represents a message in search results
+type MessageSearchResult struct {
+	Message Message `json:"message"`
+	Chat    Chat    `json:"chat"`
+}
+
+// BaseResponse represents a basic API response
+type BaseResponse struct {
+	Success bool    `json:"success"`
+	Error   *string `json:"error,omitempty"`
+}
+
+// ErrorResponse represents an API error response
+type ErrorResponse struct {
+	Error   string            `json:"error"`
+	Code    *string           `json:"code,omitempty"`
+	Details map[string]string `json:"details,omitempty"`
+}
+
+// UserInfo represents information about the authenticated user/token
+type UserInfo struct {
+	Iat      int64   `json:"iat"`                 // Issued at timestamp (Unix epoch seconds)
+	Scope    string  `json:"scope"`               // Granted scopes
+	Sub      string  `json:"sub"`                 // Subject identifier (token ID)
+	TokenUse string  `json:"token_use"`           // Token type
+	Aud      *string `json:"aud,omitempty"`       // Audience (client ID)
+	ClientID *string `json:"client_id,omitempty"` // Client identifier
+	Exp      *int64  `json:"exp,omitempty"`       // Expiration timestamp (Unix epoch seconds)
+}
+
+// AppDownloadAssetParams represents parameters for downloading an asset
+type AppDownloadAssetParams struct {
+	AssetURL string `json:"assetUrl"` // mxc:// or file URL of the asset
+}
+
+// AppOpenParams represents parameters for opening the app
+type AppOpenParams struct {
+	ChatID          *string `json:"chatID,omitempty"`
+	MessageID       *string `json:"messageID,omitempty"`
+	DraftText       *string `json:"draftText,omitempty"`
+	DraftAttachment *string `json:"draftAttachment,omitempty"` // Local path of a file to attach to the draft
+}
+
+// AppSearchParams represents parameters for searching
+type AppSearchParams struct {
+	Query            string   `json:"query"`
+	AccountIDs       []string `json:"accountIDs,omitempty"`
+	ChatType         *string  `json:"chatType,omitempty"`
+	IncludeMuted     *bool    `json:"includeMuted,omitempty"`
+	Limit            *int     `json:"limit,omitempty"`
+	MessageLimit     *int     `json:"messageLimit,omitempty"`
+	ParticipantLimit *int     `json:"participantLimit,omitempty"`
+}
+
+// ChatArchiveParams represents parameters for archiving a chat
+type ChatArchiveParams struct {
+	ChatID   string `json:"chatID"`
+	Archived bool   `json:"archived"`
+}
+
+// ChatCreateParams represents parameters for creating a chat
+type ChatCreateParams struct {
+	AccountID      string   `json:"accountID"`
+	ParticipantIDs []string `json:"participantIDs"`
+	Type           string   `json:"type"` // single, group
+	Title          *string  `json:"title,omitempty"`
+}
+
+// ParticipantUpdateParams represents parameters for adding or removing participants
+type ParticipantUpdateParams struct {
+	ChatID         string   `json:"chatID"`
+	ParticipantIDs []string `json:"participantIDs"`
+}
+
+// ParticipantListParams represents parameters for listing chat participants
+type ParticipantListParams struct {
+	ChatID string  `json:"chatID"`
+	Cursor *string `json:"cursor,omitempty"`
+	Limit  *int    `json:"limit,omitempty"`
+}
+
+// ReminderCreateParams represents parameters for creating a reminder
+type ReminderCreateParams struct {
+	ChatID    string    `json:"chatID"`
+	Timestamp time.Time `json:"timestamp"`
+	Message   *string   `json:"message,omitempty"`
+}
+
+// ReminderDeleteParams represents parameters for deleting a reminder
+type ReminderDeleteParams struct {
+	ChatID string `json:"chatID"`
+}
+
+// ReminderGetParams represents parameters for retrieving a chat's reminder
+type ReminderGetParams struct {
+	ChatID string `json:"chatID"`
+}
+
+// ReminderListParams represents parameters for listing reminders
+type ReminderListParams struct {
+	AccountIDs []string `json:"accountIDs,omitempty"`
+}
+
+// ChatRetrieveParams represents parameters for retrieving a chat
+type ChatRetrieveParams struct {
+	ChatID string `json:"chatID"`
+}
+
+// ChatSearchParams represents parameters for searching chats
+type ChatSearchParams struct {
+	AccountIDs   []string `json:"accountIDs,omitempty"`
+	ChatType     *string  `json:"chatType,omitempty"`
+	IncludeMuted *bool    `json:"includeMuted,omitempty"`
+	Limit        *int     `json:"limit,omitempty"`
+	Cursor       *string  `json:"cursor,omitempty"`
+	Scope        *string  `json:"scope,omitempty"`
+	Query        *string  `json:"query,omitempty"`
+}
+
+// ContactSearchParams represents parameters for searching contacts
+type ContactSearchParams struct {
+	AccountID string `json:"accountID"`
+	Query     string `json:"query"`
+}
+
+// MessageSearchParams represents parameters for searching messages
+type MessageSearchParams struct {
+	AccountIDs         []string   `json:"accountIDs,omitempty"`
+	ChatIDs            []string   `json:"chatIDs,omitempty"`
+	ChatType           *string    `json:"chatType,omitempty"`
+	Cursor             *string    `json:"cursor,omitempty"`
+	DateAfter          *time.Time `json:"dateAfter,omitempty"`
+	DateBefore         *time.Time `json:"dateBefore,omitempty"`
+	Direction          *string    `json:"direction,omitempty"`
+	ExcludeLowPriority *bool      `json:"excludeLowPriority,omitempty"`
+	IncludeMuted       *bool      `json:"includeMuted,omitempty"`
+	Limit              *int       `json:"limit,omitempty"`
+	MediaTypes         []string   `json:"mediaTypes,omitempty"`
+	Query              *string    `json:"query,omitempty"`
+	SenderIDs          []string   `json:"senderIDs,omitempty"`
+}
+
+// MessageSendParams represents parameters for sending a message
+type MessageSendParams struct {
+	ChatID     string  `json:"chatID"`
+	Text       string  `json:"text"`
+	ReplyToID  *string `json:"replyToID,omitempty"`
+	Attachment *string `json:"attachment,omitempty"`
+}
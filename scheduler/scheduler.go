@@ -0,0 +1,729 @@
+// Package scheduler sends messages later: once at a given time, or on a
+// recurring cron schedule. Jobs are kept in a local store. When one falls
+// due, its message is handed to a durable outbox under a key made from the
+// job and the scheduled time, so a run is neither lost nor sent twice when
+// the process restarts. The outcome of every run is recorded on its job.
+// Several processes can share stores that are Lockers, such as the
+// FileStores of this package and the outbox: the jobs and outbox entries
+// are reloaded before every change and tick. Only one of them should Tick
+// or Run.
+//
+//	s, err := scheduler.Open(client, scheduler.Options{
+//		Store:  scheduler.NewFileStore("jobs.json"),
+//		Outbox: outbox.Options{Store: outbox.NewFileStore("outbox.json")},
+//	})
+//	job, err := s.Add(scheduler.Job{Params: params, Cron: "0 9 * * MON"})
+//	err = s.Run(ctx, func(err error) { log.Print(err) })
+package scheduler
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	beeperdesktop "github.com/cameronaaron/beeper-go-sdk"
+	"github.com/cameronaaron/beeper-go-sdk/outbox"
+	"github.com/cameronaaron/beeper-go-sdk/resources"
+)
+
+const (
+	defaultInterval = 30 * time.Second
+	// maxRuns is the number of results kept per job
+	maxRuns = 20
+)
+
+// ErrUnknownJob is returned for a job ID the scheduler does not hold
+var ErrUnknownJob = errors.New("unknown job")
+
+// Status is where a job is in its life
+type Status string
+
+const (
+	// Scheduled means the job has a run ahead of it
+	Scheduled Status = "scheduled"
+	// Completed means a one-shot job ran, or a recurring job's schedule
+	// ended
+	Completed Status = "completed"
+	// Canceled means the job was canceled before it completed
+	Canceled Status = "canceled"
+)
+
+// Job is a message to send later
+type Job struct {
+	ID     string                      `json:"id"`
+	Params resources.MessageSendParams `json:"params"`
+	// At is when a one-shot job runs. For a recurring job it is optional and
+	// holds off the first run until then.
+	At time.Time `json:"at"`
+	// Cron makes the job recurring, such as "30 9 * * MON-FRI". See ParseCron.
+	Cron string `json:"cron,omitempty"`
+	// TimeZone is the IANA time zone Cron is read in. Defaults to
+	// Options.Location.
+	TimeZone  string    `json:"timeZone,omitempty"`
+	Status    Status    `json:"status"`
+	Next      time.Time `json:"next"` // zero once the job is completed or canceled
+	CreatedAt time.Time `json:"createdAt"`
+	UpdatedAt time.Time `json:"updatedAt"`
+	// Revision counts the job's edits. It is part of the outbox key of its
+	// runs, so an edited job is sent again even at a time it already ran.
+	Revision int   `json:"revision,omitempty"`
+	Runs     []Run `json:"runs,omitempty"` // oldest first, the last 20
+}
+
+// Recurring reports whether the job runs on a cron schedule
+func (j *Job) Recurring() bool {
+	return j.Cron != ""
+}
+
+// Run is one occurrence of a job
+type Run struct {
+	At        time.Time     `json:"at"`  // when it was scheduled for
+	Key       string        `json:"key"` // the outbox key of its message
+	Status    outbox.Status `json:"status"`
+	MessageID string        `json:"messageID,omitempty"`
+	Error     string        `json:"error,omitempty"`
+}
+
+// Done reports whether the run's message was sent or failed for good
+func (r *Run) Done() bool {
+	return r.Status == outbox.Sent || r.Status == outbox.Failed
+}
+
+// Options configures a Scheduler
+type Options struct {
+	// Store persists jobs. Without one, jobs live in memory only. Jobs are
+	// reloaded from it before every change, holding its lock if it is a
+	// Locker.
+	Store Store
+	// Outbox configures the outbox messages are sent through. Set its Store
+	// too, or a run in flight when the process stops may be lost. Its
+	// OnResult is still called.
+	Outbox outbox.Options
+	// Location is the time zone of cron schedules without a TimeZone.
+	// Defaults to the local time zone.
+	Location *time.Location
+	// Interval is how often Run checks on messages waiting to be retried,
+	// and on jobs changed by other processes sharing the store. Defaults to
+	// 30 seconds.
+	Interval time.Duration
+	// MaxDelay skips runs that come due more than this late, such as after
+	// the scheduler was stopped for a while; they are recorded as failed.
+	// Zero sends late runs whenever the scheduler gets to them. Either way a
+	// recurring job runs once for all the occurrences it missed.
+	MaxDelay time.Duration
+	// OnResult is called when a run is sent or fails
+	OnResult func(Job, Run)
+}
+
+func (o Options) withDefaults() Options {
+	if o.Location == nil {
+		o.Location = time.Local
+	}
+	if o.Interval <= 0 {
+		o.Interval = defaultInterval
+	}
+	return o
+}
+
+// Scheduler runs jobs when they come due. It is safe for concurrent use.
+type Scheduler struct {
+	opts Options
+	box  *outbox.Outbox
+	now  func() time.Time
+	wake chan struct{}
+
+	tickMu sync.Mutex // serializes ticks
+
+	mu        sync.Mutex
+	jobs      map[string]*Job
+	recordErr error // a failure to save a result, returned by the next Tick
+}
+
+// Open loads the saved jobs and the outbox. Results the outbox recorded
+// while the scheduler was not tracking them are applied to their jobs. The
+// client is only used by Tick and Run, so it may be nil for a scheduler that
+// only manages jobs.
+func Open(client beeperdesktop.Client, opts Options) (*Scheduler, error) {
+	s := &Scheduler{
+		opts: opts.withDefaults(),
+		now:  time.Now,
+		wake: make(chan struct{}, 1),
+		jobs: map[string]*Job{},
+	}
+
+	if err := s.reload(); err != nil {
+		return nil, err
+	}
+
+	boxOpts := opts.Outbox
+	onResult := boxOpts.OnResult
+	boxOpts.OnResult = func(entry outbox.Entry) {
+		s.record(entry)
+		if onResult != nil {
+			onResult(entry)
+		}
+	}
+	box, err := outbox.Open(client, boxOpts)
+	if err != nil {
+		return nil, err
+	}
+	s.box = box
+
+	if err := s.syncRuns(); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// Add schedules a job from its ID, Params, At, Cron and TimeZone; the other
+// fields are filled in. A one-shot job whose time has passed runs on the
+// next tick. An empty ID is replaced with a random one.
+func (s *Scheduler) Add(job Job) (Job, error) {
+	unlock, err := s.lock()
+	defer unlock()
+	if err != nil {
+		return Job{}, err
+	}
+
+	if job.ID == "" {
+		id, err := newID()
+		if err != nil {
+			return Job{}, err
+		}
+		job.ID = id
+	}
+	if strings.Contains(job.ID, "@") {
+		return Job{}, fmt.Errorf("invalid job ID %q: must not contain @", job.ID)
+	}
+	if _, ok := s.jobs[job.ID]; ok {
+		return Job{}, fmt.Errorf("job %q already exists", job.ID)
+	}
+
+	now := s.now()
+	scheduled := &Job{
+		ID:        job.ID,
+		Params:    job.Params,
+		At:        job.At,
+		Cron:      job.Cron,
+		TimeZone:  job.TimeZone,
+		CreatedAt: now,
+	}
+	if err := s.schedule(scheduled, now); err != nil {
+		return Job{}, err
+	}
+	s.jobs[job.ID] = scheduled
+	if err := s.saveLocked(); err != nil {
+		delete(s.jobs, job.ID)
+		return Job{}, err
+	}
+	s.signal()
+	return scheduled.copy(), nil
+}
+
+// Edit replaces a job's message and schedule with those of job, keeping its
+// ID and results, and schedules it again even if it had completed or been
+// canceled. A run already handed to the outbox is not affected.
+func (s *Scheduler) Edit(id string, job Job) (Job, error) {
+	unlock, err := s.lock()
+	defer unlock()
+	if err != nil {
+		return Job{}, err
+	}
+
+	current, ok := s.jobs[id]
+	if !ok {
+		return Job{}, fmt.Errorf("%w %q", ErrUnknownJob, id)
+	}
+
+	edited := current.copy()
+	edited.Params = job.Params
+	edited.At = job.At
+	edited.Cron = job.Cron
+	edited.TimeZone = job.TimeZone
+	edited.Revision++
+	now := s.now()
+	if err := s.schedule(&edited, now); err != nil {
+		return Job{}, err
+	}
+
+	previous := *current
+	*current = edited
+	if err := s.saveLocked(); err != nil {
+		*current = previous
+		return Job{}, err
+	}
+	s.signal()
+	return edited.copy(), nil
+}
+
+// Cancel stops a job from running again. Canceling a canceled job does
+// nothing. A run already handed to the outbox is still sent.
+func (s *Scheduler) Cancel(id string) error {
+	unlock, err := s.lock()
+	defer unlock()
+	if err != nil {
+		return err
+	}
+
+	job, ok := s.jobs[id]
+	if !ok {
+		return fmt.Errorf("%w %q", ErrUnknownJob, id)
+	}
+	switch job.Status {
+	case Canceled:
+		return nil
+	case Completed:
+		return fmt.Errorf("job %q already completed", id)
+	}
+
+	previous := *job
+	job.Status = Canceled
+	job.Next = time.Time{}
+	job.UpdatedAt = s.now()
+	if err := s.saveLocked(); err != nil {
+		*job = previous
+		return err
+	}
+	return nil
+}
+
+// Remove deletes a job and its results
+func (s *Scheduler) Remove(id string) error {
+	unlock, err := s.lock()
+	defer unlock()
+	if err != nil {
+		return err
+	}
+
+	job, ok := s.jobs[id]
+	if !ok {
+		return fmt.Errorf("%w %q", ErrUnknownJob, id)
+	}
+	delete(s.jobs, id)
+	if err := s.saveLocked(); err != nil {
+		s.jobs[id] = job
+		return err
+	}
+	return nil
+}
+
+// Job returns the job with the given ID
+func (s *Scheduler) Job(id string) (Job, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	job, ok := s.jobs[id]
+	if !ok {
+		return Job{}, false
+	}
+	return job.copy(), true
+}
+
+// List returns every job: scheduled ones by their next run, then the rest
+// by when they last changed, most recent first
+func (s *Scheduler) List() []Job {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	jobs := make([]Job, 0, len(s.jobs))
+	for _, job := range s.jobs {
+		jobs = append(jobs, job.copy())
+	}
+	sortJobs(jobs)
+	return jobs
+}
+
+// Tick hands the jobs that are due to the outbox, then flushes the outbox
+// and records the results
+func (s *Scheduler) Tick(ctx context.Context) error {
+	s.tickMu.Lock()
+	defer s.tickMu.Unlock()
+
+	var errs []error
+	if err := s.dispatch(); err != nil {
+		errs = append(errs, err)
+	}
+	if err := s.box.Flush(ctx); err != nil {
+		errs = append(errs, err)
+	}
+	if err := s.syncRuns(); err != nil {
+		errs = append(errs, err)
+	}
+
+	s.mu.Lock()
+	if s.recordErr != nil {
+		errs = append(errs, s.recordErr)
+		s.recordErr = nil
+	}
+	s.mu.Unlock()
+	return errors.Join(errs...)
+}
+
+// Run calls Tick whenever a job comes due or is added, and at least every
+// Interval to retry messages, until the context is cancelled. Each failure
+// is passed to onError when it is non-nil.
+func (s *Scheduler) Run(ctx context.Context, onError func(error)) error {
+	for {
+		if err := s.Tick(ctx); err != nil && ctx.Err() == nil && onError != nil {
+			onError(err)
+		}
+
+		wait := s.opts.Interval
+		if next, ok := s.nextRun(); ok {
+			wait = min(wait, next.Sub(s.now()))
+		}
+		timer := time.NewTimer(max(wait, 0))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		case <-s.wake:
+		case <-timer.C:
+		}
+		timer.Stop()
+	}
+}
+
+// Outbox returns the outbox runs are sent through
+func (s *Scheduler) Outbox() *outbox.Outbox {
+	return s.box
+}
+
+// dispatch enqueues the message of every job that is due and moves the job
+// on to its next run
+func (s *Scheduler) dispatch() error {
+	unlock, err := s.lock()
+	if err != nil {
+		unlock()
+		return err
+	}
+	now := s.now()
+	var due []*Job
+	for _, job := range s.jobs {
+		if job.Status == Scheduled && !job.Next.After(now) {
+			due = append(due, job)
+		}
+	}
+	sort.Slice(due, func(i, j int) bool {
+		if !due[i].Next.Equal(due[j].Next) {
+			return due[i].Next.Before(due[j].Next)
+		}
+		return due[i].ID < due[j].ID
+	})
+
+	var errs []error
+	var missed []Job
+	for _, job := range due {
+		run := Run{At: job.Next, Key: runKey(job)}
+		if late := now.Sub(job.Next); s.opts.MaxDelay > 0 && late > s.opts.MaxDelay {
+			run.Status = outbox.Failed
+			run.Error = fmt.Sprintf("missed by %s", late.Round(time.Second))
+		} else {
+			// A key the outbox already holds, from a tick cut short before
+			// the job was saved, is not enqueued twice
+			if err := s.box.EnqueueWithKey(run.Key, job.Params); err != nil {
+				errs = append(errs, fmt.Errorf("job %s: %w", job.ID, err))
+				continue
+			}
+			run.Status = outbox.Pending
+		}
+
+		job.Runs = append(job.Runs, run)
+		if len(job.Runs) > maxRuns {
+			job.Runs = append([]Run(nil), job.Runs[len(job.Runs)-maxRuns:]...)
+		}
+		job.UpdatedAt = now
+		job.Next = time.Time{}
+		job.Status = Completed
+		if job.Recurring() {
+			if cron, loc, err := s.cron(job); err == nil {
+				if next := cron.Next(now.In(loc)); !next.IsZero() {
+					job.Next = next
+					job.Status = Scheduled
+				}
+			}
+		}
+		if run.Done() {
+			missed = append(missed, job.copy())
+		}
+	}
+
+	if len(due) > 0 {
+		if err := s.saveLocked(); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	unlock()
+
+	if s.opts.OnResult != nil {
+		for _, job := range missed {
+			s.opts.OnResult(job, job.Runs[len(job.Runs)-1])
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// record applies an outbox result to the run it belongs to
+func (s *Scheduler) record(entry outbox.Entry) {
+	unlock, err := s.lock()
+	var job *Job
+	var run Run
+	if err == nil {
+		if job, run = s.update(entry); job != nil {
+			err = s.saveLocked()
+		}
+	}
+	if err != nil && s.recordErr == nil {
+		s.recordErr = err
+	}
+	unlock()
+
+	if job != nil && s.opts.OnResult != nil {
+		s.opts.OnResult(*job, run)
+	}
+}
+
+// syncRuns copies the status of unfinished runs from the outbox, reporting
+// those that finished
+func (s *Scheduler) syncRuns() error {
+	type result struct {
+		job Job
+		run Run
+	}
+
+	unlock, err := s.lock()
+	if err != nil {
+		unlock()
+		return err
+	}
+	var finished []result
+	changed := false
+	for _, job := range s.jobs {
+		for _, run := range job.Runs {
+			if run.Done() {
+				continue
+			}
+			entry, ok := s.box.Entry(run.Key)
+			if !ok || entry.Status == run.Status {
+				continue
+			}
+			updated, run := s.update(entry)
+			changed = true
+			if run.Done() {
+				finished = append(finished, result{*updated, run})
+			}
+		}
+	}
+	if changed {
+		err = s.saveLocked()
+	}
+	unlock()
+
+	if s.opts.OnResult != nil {
+		for _, r := range finished {
+			s.opts.OnResult(r.job, r.run)
+		}
+	}
+	return err
+}
+
+// update copies an outbox entry's outcome into its run, returning a copy of
+// the job and run, or a nil job when the entry has no run. Callers must
+// hold s.mu.
+func (s *Scheduler) update(entry outbox.Entry) (*Job, Run) {
+	id, _, ok := strings.Cut(entry.Key, "@")
+	if !ok {
+		return nil, Run{}
+	}
+	job := s.jobs[id]
+	if job == nil {
+		return nil, Run{}
+	}
+	for i := range job.Runs {
+		run := &job.Runs[i]
+		if run.Key != entry.Key {
+			continue
+		}
+		run.Status = entry.Status
+		run.MessageID = entry.MessageID
+		run.Error = entry.LastError
+		copied := job.copy()
+		return &copied, *run
+	}
+	return nil, Run{}
+}
+
+// runKey is the outbox key of a job's next run: its ID and time, and its
+// revision once it has been edited
+func runKey(job *Job) string {
+	key := job.ID + "@" + job.Next.UTC().Format(time.RFC3339)
+	if job.Revision > 0 {
+		key += fmt.Sprintf("#%d", job.Revision)
+	}
+	return key
+}
+
+// schedule validates a job and sets its first run. Callers must hold s.mu.
+func (s *Scheduler) schedule(job *Job, now time.Time) error {
+	switch {
+	case job.Params.ChatID == "":
+		return errors.New("job has no chat ID")
+	case job.Params.Text == "" && job.Params.Attachment == nil:
+		return errors.New("job has no text or attachment")
+	case job.Cron == "" && job.At.IsZero():
+		return errors.New("job needs a time or a cron schedule")
+	}
+
+	job.Status = Scheduled
+	job.UpdatedAt = now
+	if !job.Recurring() {
+		job.Next = job.At
+		return nil
+	}
+
+	cron, loc, err := s.cron(job)
+	if err != nil {
+		return err
+	}
+	// The first run is at or after At, if that is still ahead
+	from := now
+	if job.At.After(now) {
+		from = job.At.Add(-time.Nanosecond)
+	}
+	job.Next = cron.Next(from.In(loc))
+	if job.Next.IsZero() {
+		return fmt.Errorf("cron schedule %q never fires", job.Cron)
+	}
+	return nil
+}
+
+// cron parses a job's schedule and time zone
+func (s *Scheduler) cron(job *Job) (*Cron, *time.Location, error) {
+	cron, err := ParseCron(job.Cron)
+	if err != nil {
+		return nil, nil, err
+	}
+	loc := s.opts.Location
+	if job.TimeZone != "" {
+		if loc, err = time.LoadLocation(job.TimeZone); err != nil {
+			return nil, nil, fmt.Errorf("invalid time zone %q: %w", job.TimeZone, err)
+		}
+	}
+	return cron, loc, nil
+}
+
+// nextRun returns the earliest next run of a scheduled job
+func (s *Scheduler) nextRun() (time.Time, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var next time.Time
+	found := false
+	for _, job := range s.jobs {
+		if job.Status == Scheduled && (!found || job.Next.Before(next)) {
+			next, found = job.Next, true
+		}
+	}
+	return next, found
+}
+
+// signal wakes Run to pick up a changed schedule
+func (s *Scheduler) signal() {
+	select {
+	case s.wake <- struct{}{}:
+	default:
+	}
+}
+
+// lock takes s.mu and, when the store is a Locker, the store's lock, then
+// reloads the jobs so that changes saved by other processes are kept. The
+// returned func releases both and must be called even on error.
+func (s *Scheduler) lock() (func(), error) {
+	s.mu.Lock()
+	unlock := s.mu.Unlock
+	if locker, ok := s.opts.Store.(Locker); ok {
+		release, err := locker.Lock()
+		if err != nil {
+			return unlock, err
+		}
+		unlock = func() {
+			release()
+			s.mu.Unlock()
+		}
+	}
+	return unlock, s.reload()
+}
+
+// reload replaces the jobs with those in the store. Callers must hold s.mu.
+func (s *Scheduler) reload() error {
+	if s.opts.Store == nil {
+		return nil
+	}
+	state, err := s.opts.Store.Load()
+	if err != nil {
+		return err
+	}
+	s.jobs = map[string]*Job{}
+	if state != nil {
+		for i := range state.Jobs {
+			job := state.Jobs[i]
+			s.jobs[job.ID] = &job
+		}
+	}
+	return nil
+}
+
+// saveLocked writes every job to the store. Callers must hold s.mu.
+func (s *Scheduler) saveLocked() error {
+	if s.opts.Store == nil {
+		return nil
+	}
+	state := &State{Jobs: make([]Job, 0, len(s.jobs))}
+	for _, job := range s.jobs {
+		state.Jobs = append(state.Jobs, job.copy())
+	}
+	sort.Slice(state.Jobs, func(i, j int) bool {
+		return state.Jobs[i].ID < state.Jobs[j].ID
+	})
+	return s.opts.Store.Save(state)
+}
+
+// copy returns a copy of the job that shares no slices with it
+func (j *Job) copy() Job {
+	copied := *j
+	copied.Runs = append([]Run(nil), j.Runs...)
+	return copied
+}
+
+// sortJobs orders scheduled jobs by their next run, then the rest by when
+// they last changed, most recent first
+func sortJobs(jobs []Job) {
+	sort.Slice(jobs, func(i, j int) bool {
+		a, b := jobs[i], jobs[j]
+		if (a.Status == Scheduled) != (b.Status == Scheduled) {
+			return a.Status == Scheduled
+		}
+		if a.Status == Scheduled && !a.Next.Equal(b.Next) {
+			return a.Next.Before(b.Next)
+		}
+		if a.Status != Scheduled && !a.UpdatedAt.Equal(b.UpdatedAt) {
+			return a.UpdatedAt.After(b.UpdatedAt)
+		}
+		return a.ID < b.ID
+	})
+}
+
+// newID returns a random job ID
+func newID() (string, error) {
+	b := make([]byte, 6)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("failed to generate job ID: %w", err)
+	}
+	return hex.EncodeToString(b), nil
+}
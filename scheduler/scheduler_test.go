@@ -0,0 +1,333 @@
+package scheduler
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/cameronaaron/beeper-go-sdk/beepertest"
+	"github.com/cameronaaron/beeper-go-sdk/outbox"
+	"github.com/cameronaaron/beeper-go-sdk/resources"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// monday is 2024-06-03 08:00 UTC
+var monday = time.Date(2024, 6, 3, 8, 0, 0, 0, time.UTC)
+
+func TestParseCron(t *testing.T) {
+	tests := []struct {
+		name  string
+		spec  string
+		after time.Time
+		want  time.Time
+	}{
+		{"weekdays", "30 9 * * MON-FRI", monday, time.Date(2024, 6, 3, 9, 30, 0, 0, time.UTC)},
+		{"skips the weekend", "30 9 * * 1-5", time.Date(2024, 6, 7, 10, 0, 0, 0, time.UTC), time.Date(2024, 6, 10, 9, 30, 0, 0, time.UTC)},
+		{"strictly after", "0 8 * * *", monday, monday.AddDate(0, 0, 1)},
+		{"steps", "*/20 * * * *", monday.Add(25 * time.Minute), monday.Add(40 * time.Minute)},
+		{"step from a value", "5/20 8 * * *", monday.Add(30 * time.Minute), monday.Add(45 * time.Minute)},
+		{"day of month or weekday", "0 0 15 * FRI", monday, time.Date(2024, 6, 7, 0, 0, 0, 0, time.UTC)},
+		{"sunday as 7", "0 12 * * 7", monday, time.Date(2024, 6, 9, 12, 0, 0, 0, time.UTC)},
+		{"months by name", "0 0 1 jan,jul *", monday, time.Date(2024, 7, 1, 0, 0, 0, 0, time.UTC)},
+		{"shorthand", "@monthly", monday, time.Date(2024, 7, 1, 0, 0, 0, 0, time.UTC)},
+		{"leap day", "0 0 29 2 *", monday, time.Date(2028, 2, 29, 0, 0, 0, 0, time.UTC)},
+		{"never", "0 0 30 2 *", monday, time.Time{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cron, err := ParseCron(tt.spec)
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, cron.Next(tt.after))
+			assert.Equal(t, tt.spec, cron.String())
+		})
+	}
+
+	tokyo := time.FixedZone("JST", 9*3600)
+	cron, err := ParseCron("@daily")
+	require.NoError(t, err)
+	assert.Equal(t, time.Date(2024, 6, 4, 0, 0, 0, 0, tokyo), cron.Next(monday.In(tokyo)), "in after's location")
+
+	for _, spec := range []string{"", "* * * *", "60 * * * *", "* * 0 * *", "5-1 * * * *", "*/0 * * * *", "* * * * FUNDAY", "@often"} {
+		_, err := ParseCron(spec)
+		assert.Error(t, err, spec)
+	}
+}
+
+// setup opens a scheduler on a test server with its clock at monday
+func setup(t *testing.T, opts Options) (*Scheduler, *beepertest.Server, *time.Time) {
+	server := beepertest.NewServer(beepertest.DefaultFixtures())
+	t.Cleanup(server.Close)
+	client, err := server.Client()
+	require.NoError(t, err)
+
+	if opts.Location == nil {
+		opts.Location = time.UTC
+	}
+	s, err := Open(client, opts)
+	require.NoError(t, err)
+	now := monday
+	s.now = func() time.Time { return now }
+	return s, server, &now
+}
+
+func withText(server *beepertest.Server, chatID, text string) []resources.Message {
+	var found []resources.Message
+	for _, msg := range server.Messages(chatID) {
+		if msg.Text != nil && *msg.Text == text {
+			found = append(found, msg)
+		}
+	}
+	return found
+}
+
+func TestSchedulerRunsJobs(t *testing.T) {
+	var results []Run
+	s, server, now := setup(t, Options{OnResult: func(job Job, run Run) { results = append(results, run) }})
+	ctx := context.Background()
+
+	once, err := s.Add(Job{ID: "once", Params: resources.MessageSendParams{ChatID: "chat-alice", Text: "later"}, At: monday.Add(time.Hour)})
+	require.NoError(t, err)
+	assert.Equal(t, Scheduled, once.Status)
+	assert.Equal(t, monday.Add(time.Hour), once.Next)
+	daily, err := s.Add(Job{ID: "daily", Params: resources.MessageSendParams{ChatID: "chat-team", Text: "standup"}, Cron: "0 9 * * *"})
+	require.NoError(t, err)
+	assert.Equal(t, monday.Add(time.Hour), daily.Next)
+
+	require.NoError(t, s.Tick(ctx))
+	assert.Empty(t, withText(server, "chat-alice", "later"), "not due yet")
+
+	*now = monday.Add(time.Hour + 30*time.Second)
+	require.NoError(t, s.Tick(ctx))
+	sent := withText(server, "chat-alice", "later")
+	require.Len(t, sent, 1)
+	assert.Len(t, withText(server, "chat-team", "standup"), 1)
+
+	once, _ = s.Job("once")
+	assert.Equal(t, Completed, once.Status)
+	assert.True(t, once.Next.IsZero())
+	require.Len(t, once.Runs, 1)
+	assert.Equal(t, Run{At: monday.Add(time.Hour), Key: "once@2024-06-03T09:00:00Z", Status: outbox.Sent, MessageID: sent[0].ID}, once.Runs[0])
+	daily, _ = s.Job("daily")
+	assert.Equal(t, Scheduled, daily.Status)
+	assert.Equal(t, monday.Add(25*time.Hour), daily.Next)
+	assert.Len(t, results, 2)
+
+	// Three missed days run once
+	*now = monday.AddDate(0, 0, 3).Add(3 * time.Hour)
+	require.NoError(t, s.Tick(ctx))
+	assert.Len(t, withText(server, "chat-team", "standup"), 2)
+	daily, _ = s.Job("daily")
+	assert.Equal(t, time.Date(2024, 6, 7, 9, 0, 0, 0, time.UTC), daily.Next)
+	assert.Equal(t, monday.Add(25*time.Hour), daily.Runs[1].At)
+}
+
+func TestSchedulerMaxDelay(t *testing.T) {
+	var results []Run
+	s, server, now := setup(t, Options{MaxDelay: time.Hour, OnResult: func(job Job, run Run) { results = append(results, run) }})
+	_, err := s.Add(Job{ID: "weekly", Params: resources.MessageSendParams{ChatID: "chat-team", Text: "report"}, Cron: "@weekly", TimeZone: "UTC"})
+	require.NoError(t, err)
+
+	*now = time.Date(2024, 6, 9, 2, 0, 0, 0, time.UTC)
+	require.NoError(t, s.Tick(context.Background()))
+	assert.Empty(t, withText(server, "chat-team", "report"))
+	job, _ := s.Job("weekly")
+	require.Len(t, job.Runs, 1)
+	assert.Equal(t, outbox.Failed, job.Runs[0].Status)
+	assert.Equal(t, "missed by 2h0m0s", job.Runs[0].Error)
+	assert.Equal(t, time.Date(2024, 6, 16, 0, 0, 0, 0, time.UTC), job.Next)
+	assert.Equal(t, job.Runs, results)
+}
+
+func TestSchedulerResumesAfterRestart(t *testing.T) {
+	server := beepertest.NewServer(beepertest.DefaultFixtures())
+	defer server.Close()
+	client, err := server.Client()
+	require.NoError(t, err)
+	ctx := context.Background()
+	dir := t.TempDir()
+	opts := Options{
+		Store:  NewFileStore(filepath.Join(dir, "jobs.json")),
+		Outbox: outbox.Options{Store: outbox.NewFileStore(filepath.Join(dir, "outbox.json"))},
+	}
+
+	params := resources.MessageSendParams{ChatID: "chat-alice", Text: "exactly once"}
+	at := time.Now().Add(-time.Minute).Truncate(time.Second)
+	s, err := Open(client, opts)
+	require.NoError(t, err)
+	_, err = s.Add(Job{ID: "job", Params: params, At: at})
+	require.NoError(t, err)
+
+	// The previous process sent the run through the outbox, then stopped
+	// before saving the job
+	box, err := outbox.Open(client, opts.Outbox)
+	require.NoError(t, err)
+	require.NoError(t, box.EnqueueWithKey("job@"+at.UTC().Format(time.RFC3339), params))
+	require.NoError(t, box.Flush(ctx))
+
+	var results []Run
+	opts.OnResult = func(job Job, run Run) { results = append(results, run) }
+	s, err = Open(client, opts)
+	require.NoError(t, err)
+	require.NoError(t, s.Tick(ctx))
+
+	assert.Len(t, withText(server, "chat-alice", "exactly once"), 1)
+	job, _ := s.Job("job")
+	assert.Equal(t, Completed, job.Status)
+	require.Len(t, job.Runs, 1)
+	assert.Equal(t, outbox.Sent, job.Runs[0].Status)
+	require.Len(t, results, 1)
+
+	s, err = Open(client, opts)
+	require.NoError(t, err)
+	job, _ = s.Job("job")
+	assert.Equal(t, outbox.Sent, job.Runs[0].Status, "saved")
+}
+
+func TestSchedulerEditCompletedJob(t *testing.T) {
+	s, server, now := setup(t, Options{})
+	ctx := context.Background()
+	at := monday.Add(time.Hour)
+	_, err := s.Add(Job{ID: "once", Params: resources.MessageSendParams{ChatID: "chat-alice", Text: "first"}, At: at})
+	require.NoError(t, err)
+	*now = at
+	require.NoError(t, s.Tick(ctx))
+	require.Len(t, withText(server, "chat-alice", "first"), 1)
+
+	// Editing the text alone schedules a new run at the same time
+	edited, err := s.Edit("once", Job{Params: resources.MessageSendParams{ChatID: "chat-alice", Text: "second"}, At: at})
+	require.NoError(t, err)
+	assert.Equal(t, 1, edited.Revision)
+	require.NoError(t, s.Tick(ctx))
+	assert.Len(t, withText(server, "chat-alice", "second"), 1)
+	assert.Len(t, withText(server, "chat-alice", "first"), 1)
+
+	job, _ := s.Job("once")
+	require.Len(t, job.Runs, 2)
+	assert.Equal(t, "once@2024-06-03T09:00:00Z", job.Runs[0].Key)
+	assert.Equal(t, "once@2024-06-03T09:00:00Z#1", job.Runs[1].Key)
+	assert.Equal(t, outbox.Sent, job.Runs[1].Status)
+}
+
+func TestSchedulerEditCancelList(t *testing.T) {
+	s, _, now := setup(t, Options{})
+	hello := resources.MessageSendParams{ChatID: "chat-alice", Text: "hello"}
+
+	a, err := s.Add(Job{Params: hello, At: monday.Add(2 * time.Hour)})
+	require.NoError(t, err)
+	assert.NotEmpty(t, a.ID)
+	b, err := s.Add(Job{ID: "b", Params: hello, At: monday.Add(time.Hour)})
+	require.NoError(t, err)
+	c, err := s.Add(Job{ID: "c", Params: hello, Cron: "0 12 * * *", At: monday.AddDate(0, 0, 2)})
+	require.NoError(t, err)
+	assert.Equal(t, time.Date(2024, 6, 5, 12, 0, 0, 0, time.UTC), c.Next, "not before At")
+
+	edited, err := s.Edit("b", Job{Params: resources.MessageSendParams{ChatID: "chat-team", Text: "bye"}, Cron: "0 7 * * *", TimeZone: "America/New_York"})
+	require.NoError(t, err)
+	assert.Equal(t, "bye", edited.Params.Text)
+	assert.Equal(t, time.Date(2024, 6, 3, 11, 0, 0, 0, time.UTC), edited.Next.UTC())
+	assert.Equal(t, b.CreatedAt, edited.CreatedAt)
+
+	*now = monday.Add(time.Minute)
+	require.NoError(t, s.Cancel(a.ID))
+	require.NoError(t, s.Cancel(a.ID), "already canceled")
+	canceled, _ := s.Job(a.ID)
+	assert.Equal(t, Canceled, canceled.Status)
+	assert.True(t, canceled.Next.IsZero())
+
+	var ids []string
+	for _, job := range s.List() {
+		ids = append(ids, job.ID)
+	}
+	assert.Equal(t, []string{"b", "c", a.ID}, ids)
+
+	require.NoError(t, s.Remove("c"))
+	assert.Len(t, s.List(), 2)
+	assert.True(t, errors.Is(s.Cancel("c"), ErrUnknownJob))
+	_, err = s.Edit("c", Job{Params: hello, At: monday})
+	assert.True(t, errors.Is(err, ErrUnknownJob))
+
+	for _, job := range []Job{
+		{ID: "b", Params: hello, At: monday},
+		{ID: "x@y", Params: hello, At: monday},
+		{Params: resources.MessageSendParams{Text: "hi"}, At: monday},
+		{Params: resources.MessageSendParams{ChatID: "chat-alice"}, At: monday},
+		{Params: hello},
+		{Params: hello, Cron: "every day"},
+		{Params: hello, Cron: "0 0 30 2 *"},
+		{Params: hello, Cron: "@daily", TimeZone: "Mars/Olympus"},
+	} {
+		_, err := s.Add(job)
+		assert.Error(t, err, "%+v", job)
+	}
+}
+
+func TestSchedulerRun(t *testing.T) {
+	server := beepertest.NewServer(beepertest.DefaultFixtures())
+	defer server.Close()
+	client, err := server.Client()
+	require.NoError(t, err)
+
+	results := make(chan Run, 1)
+	s, err := Open(client, Options{OnResult: func(job Job, run Run) { results <- run }})
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() { done <- s.Run(ctx, nil) }()
+
+	_, err = s.Add(Job{Params: resources.MessageSendParams{ChatID: "chat-team", Text: "soon"}, At: time.Now().Add(50 * time.Millisecond)})
+	require.NoError(t, err)
+	select {
+	case run := <-results:
+		assert.Equal(t, outbox.Sent, run.Status)
+	case <-time.After(5 * time.Second):
+		t.Fatal("job did not run")
+	}
+	assert.Len(t, withText(server, "chat-team", "soon"), 1)
+
+	cancel()
+	assert.ErrorIs(t, <-done, context.Canceled)
+}
+
+func TestSchedulerSharedStore(t *testing.T) {
+	server := beepertest.NewServer(beepertest.DefaultFixtures())
+	defer server.Close()
+	client, err := server.Client()
+	require.NoError(t, err)
+	dir := t.TempDir()
+	store := NewFileStore(filepath.Join(dir, "jobs.json"))
+	hello := resources.MessageSendParams{ChatID: "chat-alice", Text: "hello"}
+	clock := func() time.Time { return monday }
+
+	// A process running the jobs, and a command adding and canceling them
+	daemon, err := Open(client, Options{Store: store, Outbox: outbox.Options{Store: outbox.NewFileStore(filepath.Join(dir, "outbox.json"))}})
+	require.NoError(t, err)
+	daemon.now = clock
+	command, err := Open(nil, Options{Store: store})
+	require.NoError(t, err)
+	command.now = clock
+
+	_, err = command.Add(Job{ID: "due", Params: hello, At: monday})
+	require.NoError(t, err)
+	_, err = command.Add(Job{ID: "later", Params: hello, At: monday.Add(time.Hour)})
+	require.NoError(t, err)
+	_, err = daemon.Add(Job{ID: "daemon", Params: hello, At: monday.Add(2 * time.Hour)})
+	require.NoError(t, err)
+	require.NoError(t, command.Cancel("later"))
+	require.NoError(t, daemon.Tick(context.Background()))
+	assert.Len(t, withText(server, "chat-alice", "hello"), 1)
+
+	reopened, err := Open(nil, Options{Store: store})
+	require.NoError(t, err)
+	statuses := map[string]Status{}
+	for _, job := range reopened.List() {
+		statuses[job.ID] = job.Status
+	}
+	assert.Equal(t, map[string]Status{"due": Completed, "later": Canceled, "daemon": Scheduled}, statuses)
+	due, _ := reopened.Job("due")
+	require.Len(t, due.Runs, 1)
+	assert.Equal(t, outbox.Sent, due.Runs[0].Status)
+}
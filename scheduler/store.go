@@ -0,0 +1,32 @@
+package scheduler
+
+import "github.com/cameronaaron/beeper-go-sdk/internal/jsonfile"
+
+// State is the saved form of a scheduler
+type State struct {
+	Jobs []Job `json:"jobs"`
+}
+
+// Store persists scheduled jobs between runs
+type Store interface {
+	Load() (*State, error)
+	Save(state *State) error
+}
+
+// Locker is implemented by stores that several processes can share, such
+// as a command that adds jobs and the one that runs them. The scheduler
+// holds the lock while it reloads, changes and saves the jobs, so it never
+// overwrites another process's changes.
+type Locker interface {
+	Lock() (unlock func() error, err error)
+}
+
+// FileStore keeps scheduled jobs in a JSON file. It is a Locker, so
+// processes on one machine can share the file; on systems without file
+// locks, such as Windows, only one process should use it.
+type FileStore = jsonfile.Store[State]
+
+// NewFileStore creates a store backed by the given file
+func NewFileStore(path string) *FileStore {
+	return jsonfile.NewStore[State](path, "scheduled jobs")
+}
@@ -0,0 +1,375 @@
+// Package search parses the query language of search boxes into message and
+// chat search parameters:
+//
+//	from:alice in:"Project Updates" has:image before:2024-06-01 -muted hello
+//
+// Filters are written key:value, with values quoted when they contain spaces.
+// Everything else is search text. Parse checks the syntax; a Resolver then
+// looks up the people, chats and accounts named in the query and builds the
+// API parameters. Query.String formats a query back into the language.
+package search
+
+import (
+	"fmt"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Query is a parsed search query. Names in From, In and Accounts are resolved
+// to IDs by a Resolver.
+type Query struct {
+	Terms    []string // search text; quoted phrases are one term
+	From     []string // from: senders, by name, username or ID, or "me"
+	In       []string // in: chats, by title or ID
+	Accounts []string // account: accounts, by ID or network name
+	Has      []string // has: attachment kinds, see MediaTypes
+	ChatType string   // is:single or is:group
+
+	Before *time.Time // before: messages sent before this time
+	After  *time.Time // after: messages sent after this time
+
+	// Muted is set by is:muted (include muted chats) and -muted (exclude them)
+	Muted *bool
+	// LowPriority is set by is:lowpriority and -lowpriority
+	LowPriority *bool
+}
+
+// MediaTypes maps has: values to the API's attachment types
+var MediaTypes = map[string]string{
+	"image": "img",
+	"video": "video",
+	"audio": "audio",
+	"file":  "unknown",
+	"any":   "any",
+}
+
+// Filter keys
+const (
+	keyFrom    = "from"
+	keyIn      = "in"
+	keyAccount = "account"
+	keyHas     = "has"
+	keyIs      = "is"
+	keyBefore  = "before"
+	keyAfter   = "after"
+)
+
+var keys = map[string]bool{
+	keyFrom: true, keyIn: true, keyAccount: true, keyHas: true,
+	keyIs: true, keyBefore: true, keyAfter: true,
+}
+
+// dateLayout is how dates are written in queries; times use RFC 3339
+const dateLayout = "2006-01-02"
+
+// SyntaxError reports a malformed query and where the problem is
+type SyntaxError struct {
+	Input  string
+	Column int // 1-based, in characters
+	Msg    string
+}
+
+func (e *SyntaxError) Error() string {
+	return fmt.Sprintf("column %d: %s", e.Column, e.Msg)
+}
+
+// Parse parses a query. A filter may be repeated to match any of its values,
+// except before:, after: and the is: flags, which may appear once.
+func Parse(input string) (*Query, error) {
+	p := &parser{input: input}
+	q := &Query{}
+	for {
+		p.skipSpace()
+		if p.done() {
+			return q, nil
+		}
+		if err := p.term(q); err != nil {
+			return nil, err
+		}
+	}
+}
+
+type parser struct {
+	input string
+	pos   int // byte offset
+}
+
+func (p *parser) done() bool {
+	return p.pos >= len(p.input)
+}
+
+func (p *parser) peek() rune {
+	r, _ := utf8.DecodeRuneInString(p.input[p.pos:])
+	return r
+}
+
+func (p *parser) skipSpace() {
+	for !p.done() && unicode.IsSpace(p.peek()) {
+		p.pos++
+	}
+}
+
+// errorAt returns a syntax error at a byte offset
+func (p *parser) errorAt(pos int, format string, args ...interface{}) error {
+	return &SyntaxError{
+		Input:  p.input,
+		Column: utf8.RuneCountInString(p.input[:pos]) + 1,
+		Msg:    fmt.Sprintf(format, args...),
+	}
+}
+
+// term parses one filter, flag or piece of search text
+func (p *parser) term(q *Query) error {
+	start := p.pos
+	negated := false
+	if p.peek() == '-' && p.pos+1 < len(p.input) && !unicode.IsSpace(rune(p.input[p.pos+1])) {
+		negated = true
+		p.pos++
+	}
+
+	if p.peek() == '"' {
+		phrase, err := p.quoted()
+		if err != nil {
+			return err
+		}
+		if negated {
+			return p.errorAt(start, "cannot exclude %q: the API has no exclusion search; quote the whole term to search for it", phrase)
+		}
+		q.Terms = append(q.Terms, phrase)
+		return nil
+	}
+
+	wordStart := p.pos
+	word := p.bare()
+	rawKey, _, isFilter := strings.Cut(word, ":")
+	key := strings.ToLower(rawKey)
+	if isFilter && keys[key] {
+		p.pos = wordStart + len(rawKey) + 1
+		var value string
+		if !p.done() && p.peek() == '"' {
+			var err error
+			if value, err = p.quoted(); err != nil {
+				return err
+			}
+		} else {
+			value = p.bare()
+		}
+		if value == "" {
+			return p.errorAt(wordStart, "%s: needs a value", key)
+		}
+		return p.filter(q, start, key, value, negated)
+	}
+
+	if negated {
+		lower := strings.ToLower(word)
+		if lower == "muted" || lower == "lowpriority" {
+			return p.filter(q, start, keyIs, lower, true)
+		}
+		return p.errorAt(start, "cannot exclude %q: only -muted and -lowpriority are supported; quote the term to search for %q", word, "-"+word)
+	}
+	q.Terms = append(q.Terms, word)
+	return nil
+}
+
+// bare reads up to the next space
+func (p *parser) bare() string {
+	start := p.pos
+	for !p.done() && !unicode.IsSpace(p.peek()) {
+		_, size := utf8.DecodeRuneInString(p.input[p.pos:])
+		p.pos += size
+	}
+	return p.input[start:p.pos]
+}
+
+// quoted reads a double-quoted string in which \" and \\ are escapes
+func (p *parser) quoted() (string, error) {
+	start := p.pos
+	p.pos++ // opening quote
+
+	var b strings.Builder
+	for !p.done() {
+		c := p.input[p.pos]
+		switch {
+		case c == '"':
+			p.pos++
+			return b.String(), nil
+		case c == '\\' && p.pos+1 < len(p.input) && (p.input[p.pos+1] == '"' || p.input[p.pos+1] == '\\'):
+			b.WriteByte(p.input[p.pos+1])
+			p.pos += 2
+		default:
+			b.WriteByte(c)
+			p.pos++
+		}
+	}
+	return "", p.errorAt(start, "unterminated quote")
+}
+
+// filter applies one key:value filter to q
+func (p *parser) filter(q *Query, start int, key, value string, negated bool) error {
+	if negated && key != keyIs {
+		return p.errorAt(start, "cannot exclude %s: filters; only -muted and -lowpriority are supported", key)
+	}
+
+	switch key {
+	case keyFrom:
+		q.From = append(q.From, value)
+	case keyIn:
+		q.In = append(q.In, value)
+	case keyAccount:
+		q.Accounts = append(q.Accounts, value)
+	case keyHas:
+		kind := strings.ToLower(value)
+		if _, ok := MediaTypes[kind]; !ok {
+			return p.errorAt(start, "has:%s: want image, video, audio, file or any", value)
+		}
+		q.Has = append(q.Has, kind)
+	case keyBefore, keyAfter:
+		t, err := parseTime(value)
+		if err != nil {
+			return p.errorAt(start, "%s:%s: want a date like 2024-06-01 or an RFC 3339 time", key, value)
+		}
+		target := &q.Before
+		if key == keyAfter {
+			target = &q.After
+		}
+		if *target != nil {
+			return p.errorAt(start, "%s: given more than once", key)
+		}
+		*target = &t
+	case keyIs:
+		return p.flag(q, start, strings.ToLower(value), negated)
+	}
+	return nil
+}
+
+// flag applies an is: filter
+func (p *parser) flag(q *Query, start int, value string, negated bool) error {
+	set := func(target **bool) error {
+		if *target != nil {
+			return p.errorAt(start, "is:%s given more than once", value)
+		}
+		include := !negated
+		*target = &include
+		return nil
+	}
+
+	switch value {
+	case "muted":
+		return set(&q.Muted)
+	case "lowpriority":
+		return set(&q.LowPriority)
+	case "single", "dm", "group":
+		if negated {
+			return p.errorAt(start, "cannot exclude is:%s; use is:%s instead", value, otherChatType(value))
+		}
+		chatType := value
+		if chatType == "dm" {
+			chatType = "single"
+		}
+		if q.ChatType != "" && q.ChatType != chatType {
+			return p.errorAt(start, "is:%s conflicts with is:%s", value, q.ChatType)
+		}
+		q.ChatType = chatType
+		return nil
+	}
+	return p.errorAt(start, "is:%s: want single, dm, group, muted or lowpriority", value)
+}
+
+func otherChatType(chatType string) string {
+	if chatType == "group" {
+		return "single"
+	}
+	return "group"
+}
+
+// parseTime reads a date, taken as midnight local time, or an RFC 3339 time
+func parseTime(value string) (time.Time, error) {
+	if t, err := time.ParseInLocation(dateLayout, value, time.Local); err == nil {
+		return t, nil
+	}
+	return time.Parse(time.RFC3339, value)
+}
+
+// formatTime writes a time as a date when it is midnight in its location
+func formatTime(t time.Time) string {
+	if t.Location() == time.Local && t.Equal(time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.Local)) {
+		return t.Format(dateLayout)
+	}
+	return t.Format(time.RFC3339)
+}
+
+// String formats the query so that Parse returns an equal query. Filters
+// come first in a fixed order, followed by the search text.
+func (q *Query) String() string {
+	var parts []string
+	add := func(key string, values ...string) {
+		for _, v := range values {
+			parts = append(parts, key+":"+quoteValue(v))
+		}
+	}
+
+	add(keyFrom, q.From...)
+	add(keyIn, q.In...)
+	add(keyAccount, q.Accounts...)
+	add(keyHas, q.Has...)
+	if q.ChatType != "" {
+		add(keyIs, q.ChatType)
+	}
+	if q.Muted != nil {
+		parts = append(parts, flagString("muted", *q.Muted))
+	}
+	if q.LowPriority != nil {
+		parts = append(parts, flagString("lowpriority", *q.LowPriority))
+	}
+	if q.After != nil {
+		add(keyAfter, formatTime(*q.After))
+	}
+	if q.Before != nil {
+		add(keyBefore, formatTime(*q.Before))
+	}
+	for _, term := range q.Terms {
+		parts = append(parts, quoteTerm(term))
+	}
+	return strings.Join(parts, " ")
+}
+
+// Text returns the search text, or nil when there is none
+func (q *Query) Text() *string {
+	if len(q.Terms) == 0 {
+		return nil
+	}
+	text := strings.Join(q.Terms, " ")
+	return &text
+}
+
+func flagString(name string, include bool) string {
+	if include {
+		return keyIs + ":" + name
+	}
+	return "-" + name
+}
+
+// quoteValue quotes a filter value that would not survive as a bare word
+func quoteValue(v string) string {
+	if v == "" || strings.ContainsAny(v, "\"\\") || strings.IndexFunc(v, unicode.IsSpace) >= 0 || strings.HasPrefix(v, "\"") {
+		return quote(v)
+	}
+	return v
+}
+
+// quoteTerm quotes search text that would otherwise parse as a filter, a
+// negation or several terms
+func quoteTerm(term string) string {
+	key, _, isFilter := strings.Cut(term, ":")
+	if isFilter && keys[strings.ToLower(key)] || strings.HasPrefix(term, "-") || strings.HasPrefix(term, "\"") {
+		return quote(term)
+	}
+	return quoteValue(term)
+}
+
+func quote(s string) string {
+	s = strings.ReplaceAll(s, `\`, `\\`)
+	return `"` + strings.ReplaceAll(s, `"`, `\"`) + `"`
+}
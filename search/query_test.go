@@ -0,0 +1,94 @@
+package search
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func boolPtr(b bool) *bool {
+	return &b
+}
+
+func TestParse(t *testing.T) {
+	june := time.Date(2024, 6, 1, 0, 0, 0, 0, time.Local)
+
+	q, err := Parse(`from:alice in:"Project Updates" has:image before:2024-06-01 -muted hello`)
+	require.NoError(t, err)
+	assert.Equal(t, &Query{
+		Terms:  []string{"hello"},
+		From:   []string{"alice"},
+		In:     []string{"Project Updates"},
+		Has:    []string{"image"},
+		Before: &june,
+		Muted:  boolPtr(false),
+	}, q)
+
+	q, err = Parse(`FROM:me from:bob is:dm account:WhatsApp -is:lowpriority after:2024-05-01T08:00:00Z "see you" http://example.com -`)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"me", "bob"}, q.From)
+	assert.Equal(t, "single", q.ChatType)
+	assert.Equal(t, []string{"WhatsApp"}, q.Accounts)
+	assert.Equal(t, boolPtr(false), q.LowPriority)
+	assert.True(t, q.After.Equal(time.Date(2024, 5, 1, 8, 0, 0, 0, time.UTC)))
+	assert.Equal(t, []string{"see you", "http://example.com", "-"}, q.Terms)
+	assert.Equal(t, "see you http://example.com -", *q.Text())
+
+	q, err = Parse("   ")
+	require.NoError(t, err)
+	assert.Equal(t, &Query{}, q)
+	assert.Nil(t, q.Text())
+}
+
+func TestParseErrors(t *testing.T) {
+	tests := []struct {
+		input  string
+		column int
+		msg    string
+	}{
+		{`in:"Project`, 4, "unterminated quote"},
+		{`hello from:`, 7, "from: needs a value"},
+		{`has:pdf`, 1, "has:pdf: want image, video, audio, file or any"},
+		{`before:yesterday`, 1, "before:yesterday: want a date like 2024-06-01 or an RFC 3339 time"},
+		{`after:2024-01-01 after:2024-02-01`, 18, "after: given more than once"},
+		{`is:group is:dm`, 10, "is:dm conflicts with is:group"},
+		{`-is:group`, 1, "cannot exclude is:group; use is:single instead"},
+		{`is:starred`, 1, "is:starred: want single, dm, group, muted or lowpriority"},
+		{`-from:bob`, 1, "cannot exclude from: filters; only -muted and -lowpriority are supported"},
+		{`ünïcode -spam`, 9, `cannot exclude "spam": only -muted and -lowpriority are supported; quote the term to search for "-spam"`},
+		{`-muted is:muted`, 8, "is:muted given more than once"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			_, err := Parse(tt.input)
+			var syntaxErr *SyntaxError
+			require.True(t, errors.As(err, &syntaxErr), "got %v", err)
+			assert.Equal(t, tt.column, syntaxErr.Column)
+			assert.Equal(t, tt.msg, syntaxErr.Msg)
+			assert.Equal(t, tt.input, syntaxErr.Input)
+		})
+	}
+}
+
+func TestStringRoundTrips(t *testing.T) {
+	inputs := map[string]string{
+		`hello from:alice -muted in:"Project Updates" has:image before:2024-06-01`: `from:alice in:"Project Updates" has:image -muted before:2024-06-01 hello`,
+		`is:dm is:muted is:lowpriority after:2024-05-01T08:00:00Z`:                 `is:single is:muted is:lowpriority after:2024-05-01T08:00:00Z`,
+		`"from:alice" "-spam" "say \"hi\"" back\slash in:"a \\ b"`:                 `in:"a \\ b" "from:alice" "-spam" "say \"hi\"" "back\\slash"`,
+		`account:"Beeper (Matrix)" has:FILE`:                                       `account:"Beeper (Matrix)" has:file`,
+	}
+
+	for input, want := range inputs {
+		q, err := Parse(input)
+		require.NoError(t, err, input)
+		assert.Equal(t, want, q.String())
+
+		again, err := Parse(q.String())
+		require.NoError(t, err)
+		assert.Equal(t, q, again, input)
+	}
+}
@@ -0,0 +1,302 @@
+package search
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	beeperdesktop "github.com/cameronaaron/beeper-go-sdk"
+	"github.com/cameronaaron/beeper-go-sdk/resources"
+)
+
+// maxCandidates bounds the matches listed in a ResolveError
+const maxCandidates = 5
+
+// ResolveError reports a from:, in: or account: value that matched nothing,
+// or more than one thing without an exact match
+type ResolveError struct {
+	Filter     string
+	Value      string
+	Candidates []string // the ambiguous matches; empty when nothing matched
+}
+
+func (e *ResolveError) Error() string {
+	if len(e.Candidates) == 0 {
+		return fmt.Sprintf("%s:%s matches nothing", e.Filter, e.Value)
+	}
+	return fmt.Sprintf("%s:%s is ambiguous; it matches %s", e.Filter, e.Value, strings.Join(e.Candidates, ", "))
+}
+
+// Resolver turns the names in a query into IDs using the Desktop API
+type Resolver struct {
+	Accounts resources.AccountsAPI
+	Contacts resources.ContactsAPI
+	Chats    resources.ChatsAPI
+}
+
+// NewResolver creates a resolver backed by a client
+func NewResolver(client beeperdesktop.Client) *Resolver {
+	return &Resolver{
+		Accounts: client.AccountsAPI(),
+		Contacts: client.ContactsAPI(),
+		Chats:    client.ChatsAPI(),
+	}
+}
+
+// MessageParams builds message search parameters for q. Senders are looked
+// up with Contacts.Search in each account in scope, and chats with
+// Chats.Search by title, falling back to Chats.Retrieve for chat IDs.
+func (r *Resolver) MessageParams(ctx context.Context, q *Query) (resources.MessageSearchParams, error) {
+	params := resources.MessageSearchParams{
+		Query:      q.Text(),
+		DateBefore: q.Before,
+		DateAfter:  q.After,
+	}
+	if q.ChatType != "" {
+		params.ChatType = beeperdesktop.StringPtr(q.ChatType)
+	}
+	if q.Muted != nil {
+		params.IncludeMuted = beeperdesktop.BoolPtr(*q.Muted)
+	}
+	if q.LowPriority != nil {
+		params.ExcludeLowPriority = beeperdesktop.BoolPtr(!*q.LowPriority)
+	}
+	for _, kind := range q.Has {
+		params.MediaTypes = appendUnique(params.MediaTypes, MediaTypes[kind])
+	}
+
+	accounts, err := r.accounts(ctx, q)
+	if err != nil {
+		return params, err
+	}
+	if len(q.Accounts) > 0 {
+		params.AccountIDs = accountIDs(accounts)
+	}
+	for _, name := range q.From {
+		ids, err := r.sender(ctx, accounts, name)
+		if err != nil {
+			return params, err
+		}
+		params.SenderIDs = appendUnique(params.SenderIDs, ids...)
+	}
+	for _, name := range q.In {
+		id, err := r.chat(ctx, params.AccountIDs, name)
+		if err != nil {
+			return params, err
+		}
+		params.ChatIDs = appendUnique(params.ChatIDs, id)
+	}
+	return params, nil
+}
+
+// ChatParams builds chat search parameters for q. Filters that only apply to
+// messages are an error.
+func (r *Resolver) ChatParams(ctx context.Context, q *Query) (resources.ChatSearchParams, error) {
+	params := resources.ChatSearchParams{Query: q.Text()}
+	if q.Muted != nil {
+		params.IncludeMuted = beeperdesktop.BoolPtr(*q.Muted)
+	}
+	if q.ChatType != "" {
+		params.ChatType = beeperdesktop.StringPtr(q.ChatType)
+	}
+
+	for _, filter := range []struct {
+		name string
+		used bool
+	}{
+		{"from:", len(q.From) > 0},
+		{"in:", len(q.In) > 0},
+		{"has:", len(q.Has) > 0},
+		{"before:", q.Before != nil},
+		{"after:", q.After != nil},
+		{"lowpriority", q.LowPriority != nil},
+	} {
+		if filter.used {
+			return params, fmt.Errorf("%s only applies to message searches", filter.name)
+		}
+	}
+
+	if len(q.Accounts) > 0 {
+		accounts, err := r.accounts(ctx, q)
+		if err != nil {
+			return params, err
+		}
+		params.AccountIDs = accountIDs(accounts)
+	}
+	return params, nil
+}
+
+// accounts returns the accounts named by account: filters, or every account
+// when there are none
+func (r *Resolver) accounts(ctx context.Context, q *Query) ([]resources.Account, error) {
+	if len(q.Accounts) == 0 && len(q.From) == 0 {
+		return nil, nil
+	}
+	list, err := r.Accounts.List(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("list accounts: %w", err)
+	}
+	if len(q.Accounts) == 0 {
+		return *list, nil
+	}
+
+	var matched []resources.Account
+	for _, name := range q.Accounts {
+		found := false
+		for _, account := range *list {
+			if strings.EqualFold(account.AccountID, name) || strings.EqualFold(account.Network, name) {
+				matched = append(matched, account)
+				found = true
+			}
+		}
+		if !found {
+			return nil, &ResolveError{Filter: keyAccount, Value: name}
+		}
+	}
+	return matched, nil
+}
+
+// sender returns the user IDs a from: value names. "me" is the signed-in user
+// of each account. Exact matches on name, username, ID, email or phone number
+// win; otherwise the search must find exactly one person.
+func (r *Resolver) sender(ctx context.Context, accounts []resources.Account, name string) ([]string, error) {
+	if strings.EqualFold(name, "me") {
+		var ids []string
+		for _, account := range accounts {
+			ids = appendUnique(ids, account.User.ID)
+		}
+		return ids, nil
+	}
+
+	var exact, found []resources.User
+	for _, account := range accounts {
+		resp, err := r.Contacts.Search(ctx, resources.ContactSearchParams{AccountID: account.AccountID, Query: name})
+		if err != nil {
+			return nil, fmt.Errorf("search contacts in %s: %w", account.AccountID, err)
+		}
+		for _, user := range resp.Items {
+			found = append(found, user)
+			if userMatches(user, name) {
+				exact = append(exact, user)
+			}
+		}
+	}
+
+	switch {
+	case len(exact) > 0:
+		return userIDs(exact), nil
+	case len(found) == 1:
+		return userIDs(found), nil
+	}
+	err := &ResolveError{Filter: keyFrom, Value: name}
+	for _, user := range found {
+		if len(err.Candidates) == maxCandidates {
+			break
+		}
+		err.Candidates = append(err.Candidates, describeUser(user))
+	}
+	return nil, err
+}
+
+// chat returns the ID of the chat an in: value names. An exact title or ID
+// match wins; otherwise the search must find exactly one chat.
+func (r *Resolver) chat(ctx context.Context, accountIDs []string, name string) (string, error) {
+	resp, err := r.Chats.Search(ctx, resources.ChatSearchParams{
+		AccountIDs: accountIDs,
+		Query:      &name,
+		Scope:      beeperdesktop.StringPtr("titles"),
+	})
+	if err != nil {
+		return "", fmt.Errorf("search chats: %w", err)
+	}
+
+	var exact []resources.Chat
+	for _, chat := range resp.Items {
+		if chat.ID == name || strings.EqualFold(chat.Title, name) {
+			exact = append(exact, chat)
+		}
+	}
+	switch {
+	case len(exact) == 1:
+		return exact[0].ID, nil
+	case len(exact) == 0 && len(resp.Items) == 1:
+		return resp.Items[0].ID, nil
+	case len(resp.Items) == 0:
+		// Not a title; perhaps an ID
+		chat, err := r.Chats.Retrieve(ctx, resources.ChatRetrieveParams{ChatID: name})
+		var notFound *beeperdesktop.NotFoundError
+		if errors.As(err, &notFound) {
+			return "", &ResolveError{Filter: keyIn, Value: name}
+		}
+		if err != nil {
+			return "", fmt.Errorf("retrieve chat: %w", err)
+		}
+		return chat.ID, nil
+	}
+
+	candidates := exact
+	if len(candidates) == 0 {
+		candidates = resp.Items
+	}
+	resolveErr := &ResolveError{Filter: keyIn, Value: name}
+	for _, chat := range candidates {
+		if len(resolveErr.Candidates) == maxCandidates {
+			break
+		}
+		resolveErr.Candidates = append(resolveErr.Candidates, fmt.Sprintf("%q (%s, %s)", chat.Title, chat.Network, chat.ID))
+	}
+	return "", resolveErr
+}
+
+func userMatches(user resources.User, name string) bool {
+	if strings.EqualFold(user.ID, name) {
+		return true
+	}
+	for _, field := range []*string{user.FullName, user.Username, user.Email, user.PhoneNumber} {
+		if field != nil && strings.EqualFold(*field, name) {
+			return true
+		}
+	}
+	return false
+}
+
+func describeUser(user resources.User) string {
+	if user.FullName != nil && *user.FullName != "" {
+		return fmt.Sprintf("%s (%s)", *user.FullName, user.ID)
+	}
+	return user.ID
+}
+
+func userIDs(users []resources.User) []string {
+	var ids []string
+	for _, user := range users {
+		ids = appendUnique(ids, user.ID)
+	}
+	return ids
+}
+
+func accountIDs(accounts []resources.Account) []string {
+	var ids []string
+	for _, account := range accounts {
+		ids = appendUnique(ids, account.AccountID)
+	}
+	return ids
+}
+
+// appendUnique appends the values not already in list
+func appendUnique(list []string, values ...string) []string {
+	for _, v := range values {
+		found := false
+		for _, existing := range list {
+			if existing == v {
+				found = true
+				break
+			}
+		}
+		if !found {
+			list = append(list, v)
+		}
+	}
+	return list
+}
@@ -0,0 +1,102 @@
+package search
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/cameronaaron/beeper-go-sdk/beepertest"
+	"github.com/cameronaaron/beeper-go-sdk/resources"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newResolver(t *testing.T) *Resolver {
+	server := beepertest.NewServer(beepertest.DefaultFixtures())
+	t.Cleanup(server.Close)
+	client, err := server.Client()
+	require.NoError(t, err)
+	return NewResolver(client)
+}
+
+func mustParse(t *testing.T, input string) *Query {
+	q, err := Parse(input)
+	require.NoError(t, err)
+	return q
+}
+
+func TestMessageParams(t *testing.T) {
+	r := newResolver(t)
+	ctx := context.Background()
+
+	params, err := r.MessageParams(ctx, mustParse(t, `from:alice in:"project updates" has:image -muted -lowpriority launch`))
+	require.NoError(t, err)
+	assert.Equal(t, []string{"@alice:beeper.com"}, params.SenderIDs)
+	assert.Equal(t, []string{"chat-team"}, params.ChatIDs)
+	assert.Equal(t, []string{"img"}, params.MediaTypes)
+	assert.Equal(t, boolPtr(false), params.IncludeMuted)
+	assert.Equal(t, boolPtr(true), params.ExcludeLowPriority)
+	assert.Equal(t, "launch", *params.Query)
+	assert.Nil(t, params.AccountIDs)
+
+	params, err = r.MessageParams(ctx, mustParse(t, `from:me account:whatsapp in:chat-family from:Carol`))
+	require.NoError(t, err)
+	assert.Equal(t, []string{"whatsapp"}, params.AccountIDs)
+	assert.Equal(t, []string{"+15555550199", "+15555550123"}, params.SenderIDs)
+	assert.Equal(t, []string{"chat-family"}, params.ChatIDs, "chat IDs resolve too")
+}
+
+func TestMessageParamsFindsMessages(t *testing.T) {
+	server := beepertest.NewServer(beepertest.DefaultFixtures())
+	defer server.Close()
+	client, err := server.Client()
+	require.NoError(t, err)
+	ctx := context.Background()
+
+	params, err := NewResolver(client).MessageParams(ctx, mustParse(t, `from:bob is:group release`))
+	require.NoError(t, err)
+	page, err := client.Messages.Search(ctx, params)
+	require.NoError(t, err)
+	require.Len(t, page.Items, 1)
+	assert.Equal(t, "msg-3", page.Items[0].ID)
+}
+
+func TestResolveErrors(t *testing.T) {
+	r := newResolver(t)
+	ctx := context.Background()
+
+	tests := []struct {
+		input string
+		msg   string
+	}{
+		{`from:zed`, "from:zed matches nothing"},
+		{`from:beeper.com`, "from:beeper.com is ambiguous; it matches Alice Smith (@alice:beeper.com), Bob Jones (@bob:beeper.com)"},
+		{`in:nowhere`, "in:nowhere matches nothing"},
+		{`account:telegram`, "account:telegram matches nothing"},
+	}
+	for _, tt := range tests {
+		_, err := r.MessageParams(ctx, mustParse(t, tt.input))
+		var resolveErr *ResolveError
+		require.True(t, errors.As(err, &resolveErr), "%s: got %v", tt.input, err)
+		assert.Equal(t, tt.msg, err.Error())
+	}
+}
+
+func TestChatParams(t *testing.T) {
+	r := newResolver(t)
+	ctx := context.Background()
+
+	params, err := r.ChatParams(ctx, mustParse(t, `is:group account:"beeper (matrix)" is:muted team`))
+	require.NoError(t, err)
+	assert.Equal(t, resources.ChatSearchParams{
+		AccountIDs:   []string{"matrix"},
+		ChatType:     params.ChatType,
+		IncludeMuted: boolPtr(true),
+		Query:        params.Query,
+	}, params)
+	assert.Equal(t, "group", *params.ChatType)
+	assert.Equal(t, "team", *params.Query)
+
+	_, err = r.ChatParams(ctx, mustParse(t, `from:alice`))
+	assert.EqualError(t, err, "from: only applies to message searches")
+}
@@ -0,0 +1,79 @@
+package beeperdesktop
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// DialFunc opens a connection to the Desktop API. It has the signature of
+// net.Dialer.DialContext, so it can reach the API through an SSH tunnel, a
+// named pipe or any other transport.
+type DialFunc func(ctx context.Context, network, addr string) (net.Conn, error)
+
+// socketBaseURL is the base URL requests use when connections go to a unix
+// socket; the host is ignored
+const socketBaseURL = "http://localhost/"
+
+// unixSocketPath returns the socket path of a unix:// base URL, such as
+// unix:///run/user/1000/beeper.sock
+func unixSocketPath(baseURL string) (string, bool) {
+	u, err := url.Parse(baseURL)
+	if err != nil || u.Scheme != "unix" {
+		return "", false
+	}
+	if u.Opaque != "" {
+		return u.Opaque, true
+	}
+	return u.Path, u.Path != ""
+}
+
+// resolveTransport returns the HTTP base URL and client for a base URL. For
+// unix:// URLs, and whenever dial or proxy is set, the client's transport is
+// cloned with its dialer or proxy replaced.
+func resolveTransport(baseURL string, client *http.Client, dial DialFunc, proxy *url.URL) (string, *http.Client, error) {
+	if socket, ok := unixSocketPath(baseURL); ok {
+		baseURL = socketBaseURL
+		dial = socketDialer(socket, dial)
+	} else if strings.HasPrefix(baseURL, "unix:") {
+		return "", nil, fmt.Errorf("invalid unix socket URL %q: want unix:///path/to/socket", baseURL)
+	}
+	if dial == nil && proxy == nil {
+		return baseURL, client, nil
+	}
+
+	var transport *http.Transport
+	switch t := client.Transport.(type) {
+	case nil:
+		transport = http.DefaultTransport.(*http.Transport).Clone()
+	case *http.Transport:
+		transport = t.Clone()
+	default:
+		return "", nil, errors.New("a custom dialer, proxy or unix socket needs an *http.Transport, but the HTTP client has a different transport")
+	}
+	if dial != nil {
+		transport.DialContext = dial
+	}
+	if proxy != nil {
+		transport.Proxy = http.ProxyURL(proxy)
+	}
+
+	dialing := *client
+	dialing.Transport = transport
+	return baseURL, &dialing, nil
+}
+
+// socketDialer connects to a unix socket whatever address is requested, using
+// dial if set
+func socketDialer(socket string, dial DialFunc) DialFunc {
+	if dial == nil {
+		dial = (&net.Dialer{}).DialContext
+	}
+	return func(ctx context.Context, _, _ string) (net.Conn, error) {
+		return dial(ctx, "unix", socket)
+	}
+}
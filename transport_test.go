@@ -0,0 +1,111 @@
+package beeperdesktop
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// socketServer serves the token endpoint on a unix socket and returns its path
+func socketServer(t *testing.T) string {
+	// Socket paths are limited to about 100 bytes, which t.TempDir can exceed
+	dir, err := os.MkdirTemp("", "beeper")
+	require.NoError(t, err)
+	t.Cleanup(func() { os.RemoveAll(dir) })
+	socket := filepath.Join(dir, "api.sock")
+
+	listener, err := net.Listen("unix", socket)
+	require.NoError(t, err)
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"iat": 1, "scope": "read", "sub": "socket", "token_use": "access"}`))
+	}))
+	server.Listener = listener
+	server.Start()
+	t.Cleanup(server.Close)
+	return socket
+}
+
+func TestUnixSocketBaseURL(t *testing.T) {
+	socket := socketServer(t)
+
+	client, err := New(WithAccessToken("token"), WithBaseURL("unix://"+socket))
+	require.NoError(t, err)
+	info, err := client.Token.Info(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, "socket", info.Sub)
+
+	t.Run("through a custom dialer", func(t *testing.T) {
+		var dialed int32
+		dial := func(ctx context.Context, network, addr string) (net.Conn, error) {
+			atomic.AddInt32(&dialed, 1)
+			assert.Equal(t, "unix", network)
+			assert.Equal(t, socket, addr)
+			return (&net.Dialer{}).DialContext(ctx, network, addr)
+		}
+		client, err := New(WithAccessToken("token"), WithBaseURL("unix://"+socket), WithDialer(dial))
+		require.NoError(t, err)
+		_, err = client.Token.Info(context.Background())
+		require.NoError(t, err)
+		assert.Equal(t, int32(1), atomic.LoadInt32(&dialed))
+	})
+
+	t.Run("discovered", func(t *testing.T) {
+		found, err := Discover(context.Background(), DiscoverOptions{Sockets: []string{socket}, Ports: []int{}})
+		require.NoError(t, err)
+		assert.Equal(t, "unix://"+socket, found.BaseURL)
+		assert.Equal(t, socket, found.Source)
+	})
+}
+
+func TestWithDialer(t *testing.T) {
+	server := desktopServer(t)
+	target := server.Listener.Addr().String()
+
+	// The dialer decides where connections go, as an SSH tunnel would
+	var addrs []string
+	dial := func(ctx context.Context, network, addr string) (net.Conn, error) {
+		addrs = append(addrs, addr)
+		return (&net.Dialer{}).DialContext(ctx, "tcp", target)
+	}
+	client, err := New(WithAccessToken("token"), WithBaseURL("http://desktop.internal:23373"), WithDialer(dial))
+	require.NoError(t, err)
+	_, err = client.Token.Info(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, []string{"desktop.internal:23373"}, addrs)
+
+	t.Run("keeps the HTTP client's transport settings", func(t *testing.T) {
+		transport := &http.Transport{MaxIdleConns: 7}
+		client, err := New(WithAccessToken("token"), WithHTTPClient(&http.Client{Transport: transport}), WithDialer(dial))
+		require.NoError(t, err)
+		cloned := client.httpClient.Transport.(*http.Transport)
+		assert.NotSame(t, transport, cloned)
+		assert.Equal(t, 7, cloned.MaxIdleConns)
+		assert.Nil(t, transport.DialContext, "the caller's transport is not modified")
+	})
+
+	t.Run("needs an http.Transport", func(t *testing.T) {
+		custom := &http.Client{Transport: roundTripperFunc(http.DefaultTransport.RoundTrip)}
+		_, err := New(WithAccessToken("token"), WithHTTPClient(custom), WithDialer(dial))
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "*http.Transport")
+	})
+
+	t.Run("rejects malformed socket URLs", func(t *testing.T) {
+		_, err := New(WithAccessToken("token"), WithBaseURL("unix://"))
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "unix:///path/to/socket")
+	})
+}
+
+type roundTripperFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripperFunc) RoundTrip(r *http.Request) (*http.Response, error) { return f(r) }
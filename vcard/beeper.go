@@ -0,0 +1,223 @@
+package vcard
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	beeperdesktop "github.com/cameronaaron/beeper-go-sdk"
+	"github.com/cameronaaron/beeper-go-sdk/internal/phone"
+	"github.com/cameronaaron/beeper-go-sdk/resources"
+)
+
+// participantPageSize is the number of participants fetched per request
+const participantPageSize = 100
+
+// FromUser builds a card for a user of an account
+func FromUser(accountID string, user resources.User) Card {
+	card := Card{
+		UID:       userUID(accountID, user.ID),
+		FullName:  deref(user.FullName),
+		Nickname:  deref(user.Username),
+		PhotoURL:  deref(user.ImgURL),
+		AccountID: accountID,
+		UserID:    user.ID,
+	}
+	if phone := deref(user.PhoneNumber); phone != "" {
+		card.Phones = append(card.Phones, phone)
+	} else if isPhone(user.ID) {
+		card.Phones = append(card.Phones, user.ID)
+	}
+	if email := deref(user.Email); email != "" {
+		card.Emails = append(card.Emails, email)
+	}
+	return card
+}
+
+// ContactCards returns cards for the contacts of an account that match query
+func ContactCards(ctx context.Context, client beeperdesktop.Client, accountID, query string) ([]Card, error) {
+	resp, err := client.ContactsAPI().Search(ctx, resources.ContactSearchParams{AccountID: accountID, Query: query})
+	if err != nil {
+		return nil, fmt.Errorf("search contacts in %s: %w", accountID, err)
+	}
+	return cards(accountID, resp.Items), nil
+}
+
+// ParticipantCards returns cards for the participants of a chat, leaving out
+// the signed-in user
+func ParticipantCards(ctx context.Context, client beeperdesktop.Client, chatID string) ([]Card, error) {
+	chat, err := client.ChatsAPI().Retrieve(ctx, resources.ChatRetrieveParams{ChatID: chatID})
+	if err != nil {
+		return nil, fmt.Errorf("retrieve chat %s: %w", chatID, err)
+	}
+	if !chat.Participants.HasMore {
+		return cards(chat.AccountID, chat.Participants.Items), nil
+	}
+
+	params := resources.ParticipantListParams{ChatID: chatID, Limit: beeperdesktop.IntPtr(participantPageSize)}
+	var users []resources.User
+	for {
+		page, err := client.ChatsAPI().ParticipantsAPI().List(ctx, params)
+		if err != nil {
+			return nil, fmt.Errorf("list participants of %s: %w", chatID, err)
+		}
+		users = append(users, page.Items...)
+		if page.Pagination == nil || !page.Pagination.HasMore || page.Pagination.Cursor == nil {
+			return cards(chat.AccountID, users), nil
+		}
+		params.Cursor = page.Pagination.Cursor
+	}
+}
+
+func cards(accountID string, users []resources.User) []Card {
+	var list []Card
+	for _, user := range users {
+		if !isSelf(user) {
+			list = append(list, FromUser(accountID, user))
+		}
+	}
+	return list
+}
+
+// Match is a Beeper user a card resolved to
+type Match struct {
+	AccountID string
+	User      resources.User
+	By        string // "id", "phone", "email", "username" or "name"
+}
+
+// Resolution is a card and the users it matched, in account order
+type Resolution struct {
+	Card    Card
+	Matches []Match
+}
+
+// Resolve matches cards to Beeper users with Contacts.Search in each account,
+// or in accountIDs when given. In each account the strongest kind of match
+// wins: the card's X-BEEPER-USER for its own account, then a phone number,
+// an email address, the nickname as a username, and finally the full name,
+// which only counts when exactly one contact has it.
+func Resolve(ctx context.Context, client beeperdesktop.Client, cards []Card, accountIDs ...string) ([]Resolution, error) {
+	list, err := client.AccountsAPI().List(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("list accounts: %w", err)
+	}
+	var accounts []string
+	for _, account := range *list {
+		if len(accountIDs) == 0 || contains(accountIDs, account.AccountID) {
+			accounts = append(accounts, account.AccountID)
+		}
+	}
+
+	resolutions := make([]Resolution, 0, len(cards))
+	for _, card := range cards {
+		resolution := Resolution{Card: card}
+		for _, accountID := range accounts {
+			matches, err := resolve(ctx, client, accountID, card)
+			if err != nil {
+				return nil, err
+			}
+			resolution.Matches = append(resolution.Matches, matches...)
+		}
+		resolutions = append(resolutions, resolution)
+	}
+	return resolutions, nil
+}
+
+// candidate is one way to find a card's user: a search query and a test of
+// the results
+type candidate struct {
+	by    string
+	query string
+	match func(user resources.User) bool
+}
+
+// resolve returns the users a card matches in one account
+func resolve(ctx context.Context, client beeperdesktop.Client, accountID string, card Card) ([]Match, error) {
+	var candidates []candidate
+	if card.UserID != "" && card.AccountID == accountID {
+		candidates = append(candidates, candidate{"id", card.UserID, func(user resources.User) bool {
+			return user.ID == card.UserID
+		}})
+	}
+	for _, number := range card.Phones {
+		want := phone.Normalize(number)
+		if want == "" {
+			continue
+		}
+		candidates = append(candidates, candidate{"phone", want, func(user resources.User) bool {
+			return phone.Normalize(deref(user.PhoneNumber)) == want || (isPhone(user.ID) && phone.Normalize(user.ID) == want)
+		}})
+	}
+	for _, email := range card.Emails {
+		want := strings.TrimSpace(email)
+		candidates = append(candidates, candidate{"email", want, func(user resources.User) bool {
+			return strings.EqualFold(strings.TrimSpace(deref(user.Email)), want)
+		}})
+	}
+	if card.Nickname != "" {
+		candidates = append(candidates, candidate{"username", card.Nickname, func(user resources.User) bool {
+			return strings.EqualFold(deref(user.Username), card.Nickname)
+		}})
+	}
+
+	for _, c := range candidates {
+		matches, err := search(ctx, client, accountID, c)
+		if err != nil {
+			return nil, err
+		}
+		if len(matches) > 0 {
+			return matches, nil
+		}
+	}
+
+	if card.FullName == "" {
+		return nil, nil
+	}
+	matches, err := search(ctx, client, accountID, candidate{"name", card.FullName, func(user resources.User) bool {
+		return strings.EqualFold(deref(user.FullName), card.FullName)
+	}})
+	if err != nil || len(matches) != 1 {
+		return nil, err
+	}
+	return matches, nil
+}
+
+func search(ctx context.Context, client beeperdesktop.Client, accountID string, c candidate) ([]Match, error) {
+	resp, err := client.ContactsAPI().Search(ctx, resources.ContactSearchParams{AccountID: accountID, Query: c.query})
+	if err != nil {
+		return nil, fmt.Errorf("search contacts in %s: %w", accountID, err)
+	}
+	var matches []Match
+	for _, user := range resp.Items {
+		if !isSelf(user) && c.match(user) {
+			matches = append(matches, Match{AccountID: accountID, User: user, By: c.by})
+		}
+	}
+	return matches, nil
+}
+
+// isPhone reports whether a user ID is a phone number, as on WhatsApp
+func isPhone(id string) bool {
+	return strings.HasPrefix(id, "+") && phone.Normalize(id) != ""
+}
+
+func isSelf(user resources.User) bool {
+	return user.IsSelf != nil && *user.IsSelf
+}
+
+func deref(s *string) string {
+	if s == nil {
+		return ""
+	}
+	return *s
+}
+
+func contains(list []string, value string) bool {
+	for _, v := range list {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
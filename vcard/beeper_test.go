@@ -0,0 +1,91 @@
+package vcard_test
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/cameronaaron/beeper-go-sdk/beepertest"
+	"github.com/cameronaaron/beeper-go-sdk/vcard"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExport(t *testing.T) {
+	server := beepertest.NewServer(beepertest.DefaultFixtures())
+	defer server.Close()
+	client, err := server.Client()
+	require.NoError(t, err)
+	ctx := context.Background()
+
+	cards, err := vcard.ContactCards(ctx, client, "matrix", "")
+	require.NoError(t, err)
+	require.Len(t, cards, 2)
+	assert.Equal(t, "Alice Smith", cards[0].FullName)
+	assert.Equal(t, "alice", cards[0].Nickname)
+	assert.Equal(t, []string{"alice@example.com"}, cards[0].Emails)
+	assert.Equal(t, []string{"+15555550100"}, cards[1].Phones)
+	assert.Regexp(t, `^urn:uuid:[0-9a-f]{8}-[0-9a-f]{4}-5[0-9a-f]{3}-[89ab][0-9a-f]{3}-[0-9a-f]{12}$`, cards[0].UID)
+	assert.NotEqual(t, cards[0].UID, cards[1].UID)
+
+	again, err := vcard.ContactCards(ctx, client, "matrix", "alice")
+	require.NoError(t, err)
+	assert.Equal(t, cards[:1], again, "UIDs are stable")
+
+	cards, err = vcard.ParticipantCards(ctx, client, "chat-family")
+	require.NoError(t, err)
+	require.Len(t, cards, 1, "the signed-in user is left out")
+	assert.Equal(t, "whatsapp", cards[0].AccountID)
+	assert.Equal(t, "+15555550123", cards[0].UserID)
+
+	// Exported cards survive a round trip
+	var buf bytes.Buffer
+	require.NoError(t, vcard.Encode(&buf, cards...))
+	decoded, err := vcard.Decode(&buf)
+	require.NoError(t, err)
+	assert.Equal(t, cards, decoded)
+}
+
+func TestResolve(t *testing.T) {
+	server := beepertest.NewServer(beepertest.DefaultFixtures())
+	defer server.Close()
+	client, err := server.Client()
+	require.NoError(t, err)
+	ctx := context.Background()
+
+	cards := []vcard.Card{
+		{FullName: "Carol", Phones: []string{"+1 (555) 555-0123"}},
+		{FullName: "A. Smith", Emails: []string{"ALICE@example.com"}},
+		{FullName: "bob jones"},
+		{FullName: "Someone", Nickname: "bob"},
+		{FullName: "Renamed", AccountID: "matrix", UserID: "@alice:beeper.com"},
+		{FullName: "Test User"},
+		{FullName: "Zed", Phones: []string{"not a number"}},
+	}
+	resolutions, err := vcard.Resolve(ctx, client, cards)
+	require.NoError(t, err)
+	require.Len(t, resolutions, len(cards))
+
+	type found struct{ account, user, by string }
+	var got [][]found
+	for _, resolution := range resolutions {
+		var matches []found
+		for _, match := range resolution.Matches {
+			matches = append(matches, found{match.AccountID, match.User.ID, match.By})
+		}
+		got = append(got, matches)
+	}
+	assert.Equal(t, [][]found{
+		{{"whatsapp", "+15555550123", "phone"}},
+		{{"matrix", "@alice:beeper.com", "email"}},
+		{{"matrix", "@bob:beeper.com", "name"}},
+		{{"matrix", "@bob:beeper.com", "username"}},
+		{{"matrix", "@alice:beeper.com", "id"}},
+		nil,
+		nil,
+	}, got)
+
+	resolutions, err = vcard.Resolve(ctx, client, cards[:1], "matrix")
+	require.NoError(t, err)
+	assert.Empty(t, resolutions[0].Matches, "only the given accounts are searched")
+}
@@ -0,0 +1,292 @@
+// Package vcard converts Beeper users to and from vCard 4.0 (RFC 6350), so
+// contacts can be exchanged with address book tools. Encode and Decode handle
+// the format; FromUser, ContactCards and ParticipantCards build cards from
+// Beeper users, and Resolve matches imported cards back to them.
+package vcard
+
+import (
+	"bufio"
+	"crypto/sha1"
+	"fmt"
+	"io"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/cameronaaron/beeper-go-sdk/internal/phone"
+)
+
+// maxLineOctets is where lines are folded, per RFC 6350 section 3.2
+const maxLineOctets = 75
+
+// Card is the part of a vCard that maps to a Beeper user
+type Card struct {
+	UID      string
+	FullName string
+	Nickname string // the Beeper username on export
+	Phones   []string
+	Emails   []string
+	PhotoURL string
+
+	// AccountID and UserID identify the Beeper user a card was exported
+	// from. They are written as X-BEEPER-ACCOUNT and X-BEEPER-USER.
+	AccountID string
+	UserID    string
+}
+
+// ParseError reports malformed vCard input
+type ParseError struct {
+	Line int
+	Msg  string
+}
+
+func (e *ParseError) Error() string {
+	return fmt.Sprintf("vcard: line %d: %s", e.Line, e.Msg)
+}
+
+// Encode writes cards as vCard 4.0. Cards without a full name get their
+// nickname, phone, email or user ID as FN, which vCard 4.0 requires. Phones
+// with a country code are written as tel URIs and others as text.
+func Encode(w io.Writer, cards ...Card) error {
+	bw := bufio.NewWriter(w)
+	for _, card := range cards {
+		writeLine(bw, "BEGIN:VCARD")
+		writeLine(bw, "VERSION:4.0")
+		writeLine(bw, "KIND:individual")
+		if card.UID != "" {
+			writeLine(bw, "UID:"+card.UID)
+		}
+		writeLine(bw, "FN:"+escape(card.displayName()))
+		if card.Nickname != "" {
+			writeLine(bw, "NICKNAME:"+escape(card.Nickname))
+		}
+		for _, number := range card.Phones {
+			if uri := phone.URI(number); uri != "" {
+				writeLine(bw, "TEL;VALUE=uri:"+uri)
+			} else {
+				writeLine(bw, "TEL;VALUE=text:"+escape(number))
+			}
+		}
+		for _, email := range card.Emails {
+			writeLine(bw, "EMAIL:"+escape(email))
+		}
+		if card.PhotoURL != "" {
+			writeLine(bw, "PHOTO:"+card.PhotoURL)
+		}
+		if card.AccountID != "" {
+			writeLine(bw, "X-BEEPER-ACCOUNT:"+escape(card.AccountID))
+		}
+		if card.UserID != "" {
+			writeLine(bw, "X-BEEPER-USER:"+escape(card.UserID))
+		}
+		writeLine(bw, "END:VCARD")
+	}
+	return bw.Flush()
+}
+
+func (c Card) displayName() string {
+	for _, name := range []string{c.FullName, c.Nickname, first(c.Phones), first(c.Emails), c.UserID} {
+		if name != "" {
+			return name
+		}
+	}
+	return ""
+}
+
+// writeLine writes a content line, folding it at 75 octets without splitting
+// a UTF-8 sequence. Errors surface from the final Flush.
+func writeLine(w *bufio.Writer, line string) {
+	limit := maxLineOctets
+	for len(line) > limit {
+		cut := limit
+		for cut > 0 && !utf8.RuneStart(line[cut]) {
+			cut--
+		}
+		w.WriteString(line[:cut])
+		w.WriteString("\r\n ")
+		line = line[cut:]
+		limit = maxLineOctets - 1 // the leading space counts
+	}
+	w.WriteString(line)
+	w.WriteString("\r\n")
+}
+
+// Decode reads every vCard in r. Versions 3.0 and 4.0 are accepted; unknown
+// properties and parameters are ignored, as are inline (base64) photos.
+func Decode(r io.Reader) ([]Card, error) {
+	lines, err := unfold(r)
+	if err != nil {
+		return nil, err
+	}
+
+	var cards []Card
+	var card *Card
+	for _, l := range lines {
+		if strings.TrimSpace(l.text) == "" {
+			continue
+		}
+		name, params, value, ok := splitLine(l.text)
+		if !ok {
+			return nil, &ParseError{Line: l.number, Msg: "missing ':'"}
+		}
+
+		switch {
+		case name == "BEGIN" && strings.EqualFold(value, "VCARD"):
+			if card != nil {
+				return nil, &ParseError{Line: l.number, Msg: "BEGIN:VCARD inside a card"}
+			}
+			card = &Card{}
+			continue
+		case card == nil:
+			return nil, &ParseError{Line: l.number, Msg: fmt.Sprintf("%s outside a card", name)}
+		case name == "END" && strings.EqualFold(value, "VCARD"):
+			cards = append(cards, *card)
+			card = nil
+			continue
+		}
+
+		switch name {
+		case "VERSION":
+			if value != "3.0" && value != "4.0" {
+				return nil, &ParseError{Line: l.number, Msg: fmt.Sprintf("unsupported version %q", value)}
+			}
+		case "UID":
+			card.UID = value
+		case "FN":
+			card.FullName = unescape(value)
+		case "NICKNAME":
+			if card.Nickname == "" {
+				card.Nickname = unescape(splitList(value)[0])
+			}
+		case "TEL":
+			number := unescape(value)
+			if uri, ok := strings.CutPrefix(number, "tel:"); ok {
+				number, _, _ = strings.Cut(uri, ";")
+			}
+			if number != "" {
+				card.Phones = append(card.Phones, number)
+			}
+		case "EMAIL":
+			if email := unescape(value); email != "" {
+				card.Emails = append(card.Emails, email)
+			}
+		case "PHOTO":
+			if !strings.Contains(params, "ENCODING=") && card.PhotoURL == "" {
+				card.PhotoURL = value
+			}
+		case "X-BEEPER-ACCOUNT":
+			card.AccountID = unescape(value)
+		case "X-BEEPER-USER":
+			card.UserID = unescape(value)
+		}
+	}
+	if card != nil {
+		return nil, &ParseError{Line: len(lines), Msg: "missing END:VCARD"}
+	}
+	return cards, nil
+}
+
+type line struct {
+	number int // of the first physical line
+	text   string
+}
+
+// unfold joins continuation lines, which start with a space or tab
+func unfold(r io.Reader) ([]line, error) {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+
+	var lines []line
+	number := 0
+	for scanner.Scan() {
+		number++
+		text := strings.TrimSuffix(scanner.Text(), "\r")
+		if len(lines) > 0 && (strings.HasPrefix(text, " ") || strings.HasPrefix(text, "\t")) {
+			lines[len(lines)-1].text += text[1:]
+			continue
+		}
+		lines = append(lines, line{number: number, text: text})
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("vcard: %w", err)
+	}
+	return lines, nil
+}
+
+// splitLine splits a content line into its upper-cased name (without group),
+// its upper-cased parameters and its value. Colons inside quoted parameter
+// values do not end the name.
+func splitLine(text string) (name, params, value string, ok bool) {
+	quoted := false
+	for i, r := range text {
+		switch {
+		case r == '"':
+			quoted = !quoted
+		case r == ':' && !quoted:
+			name, params, _ = strings.Cut(strings.ToUpper(text[:i]), ";")
+			if dot := strings.LastIndexByte(name, '.'); dot >= 0 {
+				name = name[dot+1:]
+			}
+			return name, params, text[i+1:], true
+		}
+	}
+	return "", "", "", false
+}
+
+// splitList splits a value on unescaped commas
+func splitList(value string) []string {
+	var parts []string
+	start := 0
+	for i := 0; i < len(value); i++ {
+		switch value[i] {
+		case '\\':
+			i++
+		case ',':
+			parts = append(parts, value[start:i])
+			start = i + 1
+		}
+	}
+	return append(parts, value[start:])
+}
+
+var escaper = strings.NewReplacer(`\`, `\\`, ",", `\,`, ";", `\;`, "\r\n", `\n`, "\n", `\n`)
+
+func escape(s string) string {
+	return escaper.Replace(s)
+}
+
+func unescape(s string) string {
+	if !strings.Contains(s, `\`) {
+		return s
+	}
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		if s[i] != '\\' || i == len(s)-1 {
+			b.WriteByte(s[i])
+			continue
+		}
+		i++
+		switch s[i] {
+		case 'n', 'N':
+			b.WriteByte('\n')
+		default:
+			b.WriteByte(s[i])
+		}
+	}
+	return b.String()
+}
+
+// userUID derives a stable UID for a Beeper user, so address books update
+// the same contact on every export
+func userUID(accountID, userID string) string {
+	sum := sha1.Sum([]byte(accountID + "\x00" + userID))
+	sum[6] = sum[6]&0x0f | 0x50 // version 5, name-based
+	sum[8] = sum[8]&0x3f | 0x80 // RFC 4122 variant
+	return fmt.Sprintf("urn:uuid:%x-%x-%x-%x-%x", sum[0:4], sum[4:6], sum[6:8], sum[8:10], sum[10:16])
+}
+
+func first(list []string) string {
+	if len(list) == 0 {
+		return ""
+	}
+	return list[0]
+}
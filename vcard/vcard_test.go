@@ -0,0 +1,129 @@
+package vcard_test
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/cameronaaron/beeper-go-sdk/vcard"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEncode(t *testing.T) {
+	var buf bytes.Buffer
+	require.NoError(t, vcard.Encode(&buf, vcard.Card{
+		UID:       "urn:uuid:0d2d3c1e-0000-5000-8000-000000000000",
+		FullName:  "Smith, Alice; Ph.D.",
+		Nickname:  "alice",
+		Phones:    []string{"+15555550100"},
+		Emails:    []string{"alice@example.com"},
+		PhotoURL:  "https://example.com/a.png",
+		AccountID: "matrix",
+		UserID:    "@alice:beeper.com",
+	}, vcard.Card{Phones: []string{"+15555550123"}}))
+
+	assert.Equal(t, strings.Join([]string{
+		"BEGIN:VCARD",
+		"VERSION:4.0",
+		"KIND:individual",
+		"UID:urn:uuid:0d2d3c1e-0000-5000-8000-000000000000",
+		`FN:Smith\, Alice\; Ph.D.`,
+		"NICKNAME:alice",
+		"TEL;VALUE=uri:tel:+15555550100",
+		"EMAIL:alice@example.com",
+		"PHOTO:https://example.com/a.png",
+		"X-BEEPER-ACCOUNT:matrix",
+		"X-BEEPER-USER:@alice:beeper.com",
+		"END:VCARD",
+		"BEGIN:VCARD",
+		"VERSION:4.0",
+		"KIND:individual",
+		"FN:+15555550123",
+		"TEL;VALUE=uri:tel:+15555550123",
+		"END:VCARD",
+		"",
+	}, "\r\n"), buf.String())
+}
+
+func TestEncodePhones(t *testing.T) {
+	card := vcard.Card{FullName: "Bob", Phones: []string{"+1 (555) 123-4567", "555 123 4567", "ext; 12"}}
+	var buf bytes.Buffer
+	require.NoError(t, vcard.Encode(&buf, card))
+
+	// Only numbers with a country code make valid RFC 3966 URIs
+	assert.Contains(t, buf.String(), "\r\nTEL;VALUE=uri:tel:+15551234567\r\n")
+	assert.Contains(t, buf.String(), "\r\nTEL;VALUE=text:555 123 4567\r\n")
+	assert.Contains(t, buf.String(), "\r\nTEL;VALUE=text:ext\\; 12\r\n")
+
+	cards, err := vcard.Decode(&buf)
+	require.NoError(t, err)
+	require.Len(t, cards, 1)
+	assert.Equal(t, []string{"+15551234567", "555 123 4567", "ext; 12"}, cards[0].Phones)
+}
+
+func TestEncodeFoldsLongLines(t *testing.T) {
+	card := vcard.Card{FullName: strings.Repeat("Zoë ", 30)}
+	var buf bytes.Buffer
+	require.NoError(t, vcard.Encode(&buf, card))
+
+	for _, line := range strings.Split(strings.TrimSuffix(buf.String(), "\r\n"), "\r\n") {
+		assert.LessOrEqual(t, len(line), 75)
+		assert.True(t, strings.ToValidUTF8(line, "?") == line, "folds between characters: %q", line)
+	}
+
+	cards, err := vcard.Decode(&buf)
+	require.NoError(t, err)
+	require.Len(t, cards, 1)
+	assert.Equal(t, card.FullName, cards[0].FullName)
+}
+
+func TestDecode(t *testing.T) {
+	input := "BEGIN:VCARD\n" +
+		"VERSION:3.0\n" +
+		"N:White;Carol;;;\n" +
+		"FN:Carol\n" +
+		"  White\n" +
+		"item1.TEL;TYPE=\"cell:main\";TYPE=VOICE:+1 (555) 555-0123\n" +
+		"email;type=INTERNET:carol@example.com\n" +
+		"NICKNAME:cw,caz\n" +
+		"PHOTO;ENCODING=b;TYPE=JPEG:/9j/4AAQSkZJRg==\n" +
+		"NOTE:line one\\nline two\n" +
+		"END:VCARD\n" +
+		"\n" +
+		"BEGIN:VCARD\r\n" +
+		"VERSION:4.0\r\n" +
+		"FN:Dana\\, Esq.\r\n" +
+		"TEL;VALUE=uri;TYPE=cell:tel:+1-555-555-0150;ext=12\r\n" +
+		"PHOTO:https://example.com/d.png\r\n" +
+		"END:VCARD\r\n"
+
+	cards, err := vcard.Decode(strings.NewReader(input))
+	require.NoError(t, err)
+	assert.Equal(t, []vcard.Card{
+		{FullName: "Carol White", Nickname: "cw", Phones: []string{"+1 (555) 555-0123"}, Emails: []string{"carol@example.com"}},
+		{FullName: "Dana, Esq.", Phones: []string{"+1-555-555-0150"}, PhotoURL: "https://example.com/d.png"},
+	}, cards)
+}
+
+func TestDecodeErrors(t *testing.T) {
+	tests := []struct {
+		input string
+		line  int
+		msg   string
+	}{
+		{"FN:Alice\n", 1, "FN outside a card"},
+		{"BEGIN:VCARD\nVERSION:2.1\nEND:VCARD\n", 2, `unsupported version "2.1"`},
+		{"BEGIN:VCARD\nFN Alice\nEND:VCARD\n", 2, "missing ':'"},
+		{"BEGIN:VCARD\nBEGIN:VCARD\n", 2, "BEGIN:VCARD inside a card"},
+		{"BEGIN:VCARD\nFN:Alice\n", 2, "missing END:VCARD"},
+	}
+	for _, tt := range tests {
+		_, err := vcard.Decode(strings.NewReader(tt.input))
+		var parseErr *vcard.ParseError
+		require.True(t, errors.As(err, &parseErr), "%q: got %v", tt.input, err)
+		assert.Equal(t, tt.line, parseErr.Line, tt.input)
+		assert.Equal(t, tt.msg, parseErr.Msg, tt.input)
+	}
+}